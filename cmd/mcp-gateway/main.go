@@ -17,16 +17,33 @@ import (
 
 	cfgpkg "github.com/ChiragChiranjib/mcp-proxy/internal/config"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/encryptor"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/events"
 	logpkg "github.com/ChiragChiranjib/mcp-proxy/internal/log"
+	mcpclient "github.com/ChiragChiranjib/mcp-proxy/internal/mcp/client"
 	mrepo "github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/apikey"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/approval"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/audit"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/catalog"
 	catalogOrchestrator "github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/catalog_orchestrator"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/identity"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/mcphub"
 	mcphubOrchestrator "github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/mcphub_orchestrator"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/oauth"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/org"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/prober"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/prompt"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/ratelimit"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/resource"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/scheduler"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/session"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/tool"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/user"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/virtualmcp"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/metrics"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/oidc"
 	mcpserver "github.com/ChiragChiranjib/mcp-proxy/internal/server"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
@@ -57,9 +74,23 @@ func main() {
 		logger.Error("gorm init", "error", err)
 		os.Exit(1)
 	}
+	// Change events feed tools/list_changed notifications to sessions
+	bus := events.NewBus()
+
 	toolSvc := tool.NewService(
 		tool.WithLogger(logger),
 		tool.WithRepo(grepo),
+		tool.WithEvents(bus),
+	)
+
+	resourceSvc := resource.NewService(
+		resource.WithLogger(logger),
+		resource.WithRepo(grepo),
+	)
+
+	promptSvc := prompt.NewService(
+		prompt.WithLogger(logger),
+		prompt.WithRepo(grepo),
 	)
 
 	hubSvc := mcphub.NewService(
@@ -70,6 +101,92 @@ func main() {
 	virtualSvc := virtualmcp.NewService(
 		virtualmcp.WithLogger(logger),
 		virtualmcp.WithRepo(grepo),
+		virtualmcp.WithEvents(bus),
+		// Relayed requests wait on the replica that sent them, which a
+		// client answering through shared sessions may not reach.
+		virtualmcp.WithClientRequests(cfg.Sessions.Store != "db"),
+	)
+
+	keySvc := apikey.NewService(
+		apikey.WithLogger(logger),
+		apikey.WithRepo(grepo),
+	)
+
+	limitSvc := ratelimit.NewService(
+		ratelimit.WithLogger(logger),
+		ratelimit.WithRepo(grepo),
+		ratelimit.WithCacheTTL(
+			time.Duration(cfg.RateLimits.CacheSeconds)*time.Second),
+	)
+
+	approvalSvc := approval.NewService(
+		approval.WithLogger(logger),
+		approval.WithRepo(grepo),
+		approval.WithApprovalTimeout(
+			time.Duration(cfg.Approvals.TimeoutSeconds)*time.Second),
+		approval.WithPollInterval(
+			time.Duration(cfg.Approvals.PollSeconds)*time.Second),
+	)
+
+	orgSvc := org.NewService(
+		org.WithLogger(logger),
+		org.WithRepo(grepo),
+	)
+
+	var oauthSvc *oauth.Service
+	if cfg.OAuth.Enabled {
+		oauthSvc = oauth.NewService(
+			oauth.WithLogger(logger),
+			oauth.WithRepo(grepo),
+			oauth.WithAccessTokenTTL(
+				time.Duration(cfg.OAuth.AccessTokenTTLSeconds)*time.Second),
+			oauth.WithRefreshTokenTTL(
+				time.Duration(cfg.OAuth.RefreshTokenTTLSeconds)*time.Second),
+			oauth.WithCodeTTL(
+				time.Duration(cfg.OAuth.CodeTTLSeconds)*time.Second),
+		)
+	}
+
+	oidcProviders := make([]*oidc.Provider, 0, len(cfg.OIDC))
+	for _, pc := range cfg.OIDC {
+		p, err := oidc.NewProvider(pc, logger)
+		if err != nil {
+			logger.Error("oidc provider", "error", err)
+			os.Exit(1)
+		}
+		oidcProviders = append(oidcProviders, p)
+	}
+
+	var identitySvc *identity.Service
+	if cfg.Identity.Enabled {
+		opts := []identity.Option{
+			identity.WithLogger(logger),
+			identity.WithTokenTTL(
+				time.Duration(cfg.Identity.TokenTTLSeconds) * time.Second),
+		}
+		if cfg.Identity.PrivateKeyFile != "" {
+			key, err := identity.LoadSigningKey(cfg.Identity.PrivateKeyFile)
+			if err != nil {
+				logger.Error("identity key", "error", err)
+				os.Exit(1)
+			}
+			opts = append(opts, identity.WithSigningKey(key))
+		}
+		identitySvc, err = identity.NewService(opts...)
+		if err != nil {
+			logger.Error("identity init", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	auditSvc := audit.NewService(
+		audit.WithLogger(logger),
+		audit.WithRepo(grepo),
+		audit.WithBatchSize(cfg.Audit.BatchSize),
+		audit.WithFlushInterval(
+			time.Duration(cfg.Audit.FlushIntervalMS)*time.Millisecond),
+		audit.WithQueueSize(cfg.Audit.QueueSize),
+		audit.WithRedactKeys(cfg.Audit.RedactKeys),
 	)
 
 	catalogSvc := catalog.NewService(
@@ -87,19 +204,106 @@ func main() {
 			encr = e
 		}
 	}
+	// Collectors served by the internal server's /metrics endpoint
+	mt := metrics.New(prometheus.DefaultRegisterer)
+
+	// Long-lived upstream sessions shared by proxied calls
+	pool := mcpclient.NewPool(
+		mcpclient.WithPoolLogger(logger),
+		mcpclient.WithPoolMetrics(mt),
+		mcpclient.WithMaxSessions(cfg.Upstream.MaxSessions),
+		mcpclient.WithIdleTimeout(
+			time.Duration(cfg.Upstream.IdleTimeoutSeconds)*time.Second),
+		mcpclient.WithHealthInterval(
+			time.Duration(cfg.Upstream.HealthIntervalSeconds)*time.Second),
+	)
+
 	// Wire orchestrators: use concrete MCP client via adapter
-	orch := mcphubOrchestrator.New(hubSvc, toolSvc, grepo, logger, encr)
-	catalogOrch := catalogOrchestrator.New(catalogSvc, toolSvc, grepo, logger, encr)
+	orch := mcphubOrchestrator.New(hubSvc, toolSvc, grepo, logger, encr, bus)
+	catalogOrch := catalogOrchestrator.New(
+		catalogSvc, toolSvc, grepo, logger, encr, bus)
+
+	// Periodic tool refresh; leases keep replicas from duplicating work
+	var refresher *scheduler.Scheduler
+	if cfg.Refresh.Enabled {
+		refresher = scheduler.New(
+			scheduler.WithLogger(logger),
+			scheduler.WithRepo(grepo),
+			scheduler.WithCatalogOrchestrator(catalogOrch),
+			scheduler.WithMcphubOrchestrator(orch),
+			scheduler.WithMetrics(mt),
+			scheduler.WithCatalogInterval(
+				time.Duration(cfg.Refresh.CatalogIntervalSeconds)*time.Second),
+			scheduler.WithHubInterval(
+				time.Duration(cfg.Refresh.HubIntervalSeconds)*time.Second),
+			scheduler.WithPollInterval(
+				time.Duration(cfg.Refresh.PollIntervalSeconds)*time.Second),
+			scheduler.WithJitter(
+				time.Duration(cfg.Refresh.JitterSeconds)*time.Second),
+			scheduler.WithConcurrency(cfg.Refresh.Concurrency),
+			scheduler.WithRefreshTimeout(
+				time.Duration(cfg.Refresh.TimeoutSeconds)*time.Second),
+		)
+		refresher.Start()
+	}
+
+	// Health probes move hubs between ACTIVE and UNREACHABLE
+	var healthProber *prober.Prober
+	if cfg.Health.Enabled {
+		healthProber = prober.New(
+			prober.WithLogger(logger),
+			prober.WithRepo(grepo),
+			prober.WithEncrypter(encr),
+			prober.WithMetrics(mt),
+			prober.WithInterval(
+				time.Duration(cfg.Health.IntervalSeconds)*time.Second),
+			prober.WithJitter(
+				time.Duration(cfg.Health.JitterSeconds)*time.Second),
+			prober.WithProbeTimeout(
+				time.Duration(cfg.Health.TimeoutSeconds)*time.Second),
+			prober.WithFailureThreshold(cfg.Health.FailureThreshold),
+			prober.WithSuccessThreshold(cfg.Health.SuccessThreshold),
+			prober.WithConcurrency(cfg.Health.Concurrency),
+		)
+		healthProber.Start()
+	}
+
+	// Stateful MCP sessions; the DB store shares them between replicas
+	sessionStore := session.NewMemoryStore()
+	if cfg.Sessions.Store == "db" {
+		sessionStore = session.NewDBStore(grepo)
+	}
+	sessionSvc := session.NewService(
+		session.WithLogger(logger),
+		session.WithStore(sessionStore),
+		session.WithTTL(time.Duration(cfg.Sessions.TTLSeconds)*time.Second),
+		session.WithSweepInterval(
+			time.Duration(cfg.Sessions.SweepIntervalSeconds)*time.Second),
+	)
 
 	server := mcpserver.New(
 		mcpserver.DefaultConfig(),
 		mcpserver.WithLogger(logger),
 		mcpserver.WithTools(toolSvc),
+		mcpserver.WithResources(resourceSvc),
+		mcpserver.WithPrompts(promptSvc),
 		mcpserver.WithHubs(hubSvc),
 		mcpserver.WithVirtual(virtualSvc),
+		mcpserver.WithKeys(keySvc),
+		mcpserver.WithAudit(auditSvc),
+		mcpserver.WithLimits(limitSvc),
+		mcpserver.WithApprovals(approvalSvc),
+		mcpserver.WithOAuth(oauthSvc),
+		mcpserver.WithIdentity(identitySvc),
+		mcpserver.WithOIDCProviders(oidcProviders),
+		mcpserver.WithOrgs(orgSvc),
 		mcpserver.WithCatalog(catalogSvc),
 		mcpserver.WithUserService(userSvc),
 		mcpserver.WithEncrypter(encr),
+		mcpserver.WithSessionPool(pool),
+		mcpserver.WithSessions(sessionSvc),
+		mcpserver.WithEvents(bus),
+		mcpserver.WithMetrics(mt),
 		mcpserver.WithAppConfig(cfg),
 		mcpserver.WithMcphubOrchestrator(orch),
 		mcpserver.WithCatalogOrchestrator(catalogOrch),
@@ -109,6 +313,8 @@ func main() {
 		Addr:    fmt.Sprintf(":%d", cfg.Server.Port),
 		Handler: server.Handler,
 	}
+	// Session GET streams never go idle; end them when shutdown begins
+	srv.RegisterOnShutdown(func() { _ = sessionSvc.Close() })
 
 	// Internal server for metrics and pprof
 	internalSrv, err := newInternalServer()
@@ -139,6 +345,17 @@ func main() {
 	defer cancel()
 	_ = srv.Shutdown(ctx)
 	_ = internalSrv.Shutdown(ctx)
+	if refresher != nil {
+		_ = refresher.Close()
+	}
+	if healthProber != nil {
+		_ = healthProber.Close()
+	}
+	if oauthSvc != nil {
+		_ = oauthSvc.Close()
+	}
+	_ = pool.Close()
+	_ = auditSvc.Close()
 }
 
 // newInternalServer builds an internal server for metrics and pprof.
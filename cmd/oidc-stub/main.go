@@ -0,0 +1,57 @@
+// Package main runs a Keycloak-like OpenID Connect provider for signing in
+// to the gateway locally. Each -user is email[:role,role...]; its roles are
+// issued as realm_access.roles and groups.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/oidc/oidcstub"
+)
+
+type users []oidcstub.User
+
+func (u *users) String() string { return fmt.Sprint(len(*u)) }
+
+func (u *users) Set(v string) error {
+	email, roles, _ := strings.Cut(v, ":")
+	user := oidcstub.User{Email: email, Name: email}
+	if roles != "" {
+		user.Roles = strings.Split(roles, ",")
+	}
+	*u = append(*u, user)
+	return nil
+}
+
+func main() {
+	addr := flag.String("addr", "localhost:9090", "listen address")
+	realm := flag.String("realm", "dev", "realm name")
+	clientID := flag.String("client-id", "mcp-proxy", "client id")
+	clientSecret := flag.String("client-secret", "secret", "client secret")
+	autoLogin := flag.String("auto-login", "", "sign this user in without asking")
+	var us users
+	flag.Var(&us, "user", "user as email[:role,role...] (repeatable)")
+	flag.Parse()
+	if len(us) == 0 {
+		_ = us.Set("admin@example.com:mcp-admins")
+		_ = us.Set("user@example.com")
+	}
+
+	stub, err := oidcstub.New(oidcstub.Config{
+		BaseURL:      "http://" + *addr,
+		Realm:        *realm,
+		ClientID:     *clientID,
+		ClientSecret: *clientSecret,
+		Users:        us,
+		AutoLogin:    *autoLogin,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("issuer %s", stub.Issuer())
+	log.Fatal(http.ListenAndServe(*addr, stub))
+}
@@ -2,6 +2,7 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -29,6 +30,29 @@ type GoogleConfig struct {
 	ClientID string `mapstructure:"client_id"`
 }
 
+// OIDCProviderConfig is an OpenID Connect login provider such as Okta,
+// Keycloak or Azure AD. Its endpoints are discovered from Issuer. Name is
+// the provider's id in login URLs. When AllowedDomains is set, only emails
+// in those domains may sign in. When RoleClaim is set (a dotted path such
+// as "realm_access.roles"), its values are looked up in RoleMapping on every
+// login: any value mapped to ADMIN makes the user an admin, else they are a
+// USER. Mapping keys match case-insensitively. ID tokens must carry
+// email_verified: true unless TrustEmail says the provider only issues
+// verified emails.
+type OIDCProviderConfig struct {
+	Name           string            `mapstructure:"name"`
+	DisplayName    string            `mapstructure:"display_name"`
+	Issuer         string            `mapstructure:"issuer"`
+	ClientID       string            `mapstructure:"client_id"`
+	ClientSecret   string            `mapstructure:"client_secret"`
+	Scopes         []string          `mapstructure:"scopes"`
+	EmailClaim     string            `mapstructure:"email_claim"`
+	TrustEmail     bool              `mapstructure:"trust_email"`
+	AllowedDomains []string          `mapstructure:"allowed_domains"`
+	RoleClaim      string            `mapstructure:"role_claim"`
+	RoleMapping    map[string]string `mapstructure:"role_mapping"`
+}
+
 // DatabaseConfig holds MySQL connection and pool settings.
 type DatabaseConfig struct {
 	DSN                    string `mapstructure:"dsn"`
@@ -43,14 +67,114 @@ type DatabaseConfig struct {
 	ConnMaxLifetimeSeconds int    `mapstructure:"conn_max_lifetime_seconds"`
 }
 
+// UpstreamPoolConfig tunes the pool of upstream MCP client sessions.
+// Zero values fall back to the pool defaults.
+type UpstreamPoolConfig struct {
+	MaxSessions           int `mapstructure:"max_sessions"`
+	IdleTimeoutSeconds    int `mapstructure:"idle_timeout_seconds"`
+	HealthIntervalSeconds int `mapstructure:"health_interval_seconds"`
+}
+
+// AuditConfig tunes the tool call audit writer. Zero values fall back to
+// the audit defaults; an empty redact_keys list uses the built-in names.
+type AuditConfig struct {
+	BatchSize       int      `mapstructure:"batch_size"`
+	FlushIntervalMS int      `mapstructure:"flush_interval_ms"`
+	QueueSize       int      `mapstructure:"queue_size"`
+	RedactKeys      []string `mapstructure:"redact_keys"`
+}
+
+// RefreshConfig tunes the background refresh of catalog servers and hubs.
+// Zero values fall back to the scheduler defaults.
+type RefreshConfig struct {
+	Enabled                bool `mapstructure:"enabled"`
+	CatalogIntervalSeconds int  `mapstructure:"catalog_interval_seconds"`
+	HubIntervalSeconds     int  `mapstructure:"hub_interval_seconds"`
+	PollIntervalSeconds    int  `mapstructure:"poll_interval_seconds"`
+	JitterSeconds          int  `mapstructure:"jitter_seconds"`
+	Concurrency            int  `mapstructure:"concurrency"`
+	TimeoutSeconds         int  `mapstructure:"timeout_seconds"`
+}
+
+// HealthConfig tunes upstream health probing. Zero values fall back to the
+// prober defaults. UnreachableTools is "hide" (default) to drop tools of
+// UNREACHABLE hubs from tools/list, or "flag" to list them marked as such.
+type HealthConfig struct {
+	Enabled          bool   `mapstructure:"enabled"`
+	IntervalSeconds  int    `mapstructure:"interval_seconds"`
+	JitterSeconds    int    `mapstructure:"jitter_seconds"`
+	TimeoutSeconds   int    `mapstructure:"timeout_seconds"`
+	FailureThreshold int    `mapstructure:"failure_threshold"`
+	SuccessThreshold int    `mapstructure:"success_threshold"`
+	Concurrency      int    `mapstructure:"concurrency"`
+	UnreachableTools string `mapstructure:"unreachable_tools"`
+}
+
+// SessionsConfig tunes stateful MCP sessions. Store is "memory" (default,
+// single replica) or "db" to share sessions between replicas; the db store
+// turns off sampling and elicitation relay and refuses to enable it. Zero
+// values fall back to the session defaults.
+type SessionsConfig struct {
+	Store                string `mapstructure:"store"`
+	TTLSeconds           int    `mapstructure:"ttl_seconds"`
+	HeartbeatSeconds     int    `mapstructure:"heartbeat_seconds"`
+	SweepIntervalSeconds int    `mapstructure:"sweep_interval_seconds"`
+}
+
+// RateLimitsConfig tunes rate limit enforcement. Zero values fall back to
+// the rate limit defaults.
+type RateLimitsConfig struct {
+	CacheSeconds int `mapstructure:"cache_seconds"`
+}
+
+// ApprovalsConfig tunes how held tool calls wait for a decision. Zero
+// values fall back to the approval defaults.
+type ApprovalsConfig struct {
+	TimeoutSeconds int `mapstructure:"timeout_seconds"`
+	PollSeconds    int `mapstructure:"poll_seconds"`
+}
+
+// OAuthConfig configures the OAuth 2.1 authorization server for the MCP
+// endpoints. Issuer is the public base URL of the gateway, also used for
+// identity tokens, OIDC login and oauth2 hub redirects; it is required by
+// each of them. Zero values fall back to the OAuth defaults.
+type OAuthConfig struct {
+	Enabled                bool   `mapstructure:"enabled"`
+	Issuer                 string `mapstructure:"issuer"`
+	AccessTokenTTLSeconds  int    `mapstructure:"access_token_ttl_seconds"`
+	RefreshTokenTTLSeconds int    `mapstructure:"refresh_token_ttl_seconds"`
+	CodeTTLSeconds         int    `mapstructure:"code_ttl_seconds"`
+}
+
+// IdentityConfig configures the tokens identifying callers to upstream
+// servers that ask for them. PrivateKeyFile is a PEM encoded EC P-256 or
+// RSA key, separate from the session secret; when empty a key is generated
+// at startup, which suits a single replica only. Zero values fall back to
+// the identity defaults.
+type IdentityConfig struct {
+	Enabled         bool   `mapstructure:"enabled"`
+	PrivateKeyFile  string `mapstructure:"private_key_file"`
+	TokenTTLSeconds int    `mapstructure:"token_ttl_seconds"`
+}
+
 // Config is the root application configuration.
 type Config struct {
-	AppEnv   string
-	MCPMode  string
-	Server   ServerConfig   `mapstructure:"server"`
-	DB       DatabaseConfig `mapstructure:"database"`
-	Security SecurityConfig `mapstructure:"security"`
-	Google   GoogleConfig   `mapstructure:"google"`
+	AppEnv     string
+	MCPMode    string
+	Server     ServerConfig         `mapstructure:"server"`
+	DB         DatabaseConfig       `mapstructure:"database"`
+	Security   SecurityConfig       `mapstructure:"security"`
+	Google     GoogleConfig         `mapstructure:"google"`
+	OIDC       []OIDCProviderConfig `mapstructure:"oidc"`
+	Upstream   UpstreamPoolConfig   `mapstructure:"upstream_pool"`
+	Audit      AuditConfig          `mapstructure:"audit"`
+	Refresh    RefreshConfig        `mapstructure:"refresh"`
+	Health     HealthConfig         `mapstructure:"health"`
+	Sessions   SessionsConfig       `mapstructure:"sessions"`
+	RateLimits RateLimitsConfig     `mapstructure:"rate_limits"`
+	Approvals  ApprovalsConfig      `mapstructure:"approvals"`
+	OAuth      OAuthConfig          `mapstructure:"oauth"`
+	Identity   IdentityConfig       `mapstructure:"identity"`
 }
 
 // Load reads the TOML config for the current APP_ENV and MCP_MODE.
@@ -73,9 +197,22 @@ func Load() (*Config, error) {
 	}
 	cfg.AppEnv = appEnv
 	cfg.MCPMode = mcpMode
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("config %s: %w", file, err)
+	}
 	return &cfg, nil
 }
 
+// validate rejects settings that cannot work together.
+func (c *Config) validate() error {
+	if c.OAuth.Issuer == "" &&
+		(c.OAuth.Enabled || c.Identity.Enabled || len(c.OIDC) > 0) {
+		return errors.New(
+			"oauth.issuer is required with oauth, identity or oidc enabled")
+	}
+	return nil
+}
+
 func getenvDefault(k, def string) string {
 	if v := os.Getenv(k); v != "" {
 		return v
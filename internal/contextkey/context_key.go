@@ -23,6 +23,14 @@ const (
 	// RequestIDKey is the tracing request id key used by the
 	// request id middleware.
 	RequestIDKey ContextKey = "request_id"
+
+	// APIKeyIDKey holds the id of the API key that authenticated an MCP
+	// endpoint request.
+	APIKeyIDKey ContextKey = "api_key_id"
+
+	// OAuthGrantIDKey holds the id of the OAuth grant whose access token
+	// authenticated an MCP endpoint request.
+	OAuthGrantIDKey ContextKey = "oauth_grant_id"
 )
 
 // fromContext returns the string value for the given key if present.
@@ -54,3 +62,13 @@ func GetUserRoleFromContext(ctx context.Context) string {
 func GetRequestIDFromContext(ctx context.Context) string {
 	return fromContext(ctx, RequestIDKey)
 }
+
+// GetAPIKeyIDFromContext returns the API key id from context.
+func GetAPIKeyIDFromContext(ctx context.Context) string {
+	return fromContext(ctx, APIKeyIDKey)
+}
+
+// GetOAuthGrantIDFromContext returns the OAuth grant id from context.
+func GetOAuthGrantIDFromContext(ctx context.Context) string {
+	return fromContext(ctx, OAuthGrantIDKey)
+}
@@ -0,0 +1,56 @@
+// Package events is an in-process bus for changes that other components
+// react to, such as telling connected MCP clients their tool list changed.
+package events
+
+import "sync"
+
+// Kind names what changed.
+type Kind string
+
+const (
+	// KindToolsChanged means the tools some virtual servers expose changed.
+	KindToolsChanged Kind = "tools_changed"
+)
+
+// Event describes one change and the virtual servers it affects.
+type Event struct {
+	Kind             Kind
+	VirtualServerIDs []string
+}
+
+// Bus delivers events to subscribers synchronously, in the publisher's
+// goroutine; subscribers must not block. A nil *Bus drops every event, so
+// services work without one.
+type Bus struct {
+	mu   sync.RWMutex
+	subs []func(Event)
+}
+
+// NewBus creates an empty Bus.
+func NewBus() *Bus { return &Bus{} }
+
+// Subscribe registers fn for every published event.
+func (b *Bus) Subscribe(fn func(Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs = append(b.subs, fn)
+}
+
+// Publish delivers ev to the subscribers. Events without virtual servers
+// are dropped.
+func (b *Bus) Publish(ev Event) {
+	if b == nil || len(ev.VirtualServerIDs) == 0 {
+		return
+	}
+	b.mu.RLock()
+	subs := b.subs
+	b.mu.RUnlock()
+	for _, fn := range subs {
+		fn(ev)
+	}
+}
+
+// ToolsChanged publishes a KindToolsChanged event for the virtual servers.
+func (b *Bus) ToolsChanged(vsIDs ...string) {
+	b.Publish(Event{Kind: KindToolsChanged, VirtualServerIDs: vsIDs})
+}
@@ -0,0 +1,165 @@
+// Package argschema validates tool call arguments against the input schema
+// a tool declared upstream.
+package argschema
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// schemaURL is the location compiled schemas are registered under.
+const schemaURL = "mcp-proxy://tool/input-schema.json"
+
+var printer = message.NewPrinter(language.English)
+
+// Violation is one way arguments fail their schema. Path is a JSON Pointer
+// into the arguments; the empty path is the arguments object itself.
+type Violation struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Validator validates arguments with compiled schemas cached per tool ID.
+// Entries remember the schema they were compiled from and are recompiled
+// when a refresh stores a different one, on every replica.
+type Validator struct {
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+type entry struct {
+	sum    [sha256.Size]byte
+	schema *jsonschema.Schema
+	err    error
+}
+
+// NewValidator creates an empty Validator.
+func NewValidator() *Validator {
+	return &Validator{entries: map[string]entry{}}
+}
+
+// Validate checks args against the input schema of tool toolID and returns
+// the violations, ordered by path. names renames top-level arguments in
+// the reported paths, for clients that know them under other names. It
+// returns an error when the schema itself cannot be compiled; such tools
+// cannot be validated.
+func (v *Validator) Validate(
+	toolID string,
+	schema json.RawMessage,
+	args map[string]any,
+	names map[string]string,
+) ([]Violation, error) {
+	if len(schema) == 0 {
+		return nil, nil
+	}
+	sch, err := v.compiled(toolID, schema)
+	if err != nil {
+		return nil, err
+	}
+	// Round-trip through JSON so numbers decode the way the validator
+	// expects.
+	raw, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+	inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	err = sch.Validate(inst)
+	var ve *jsonschema.ValidationError
+	if !errors.As(err, &ve) {
+		return nil, err
+	}
+	var out []Violation
+	collect(ve, names, &out)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out, nil
+}
+
+// compiled returns the cached schema of a tool, compiling it when the
+// tool is new or its schema changed.
+func (v *Validator) compiled(
+	toolID string, schema json.RawMessage,
+) (*jsonschema.Schema, error) {
+	sum := sha256.Sum256(schema)
+	v.mu.Lock()
+	e, ok := v.entries[toolID]
+	v.mu.Unlock()
+	if ok && e.sum == sum {
+		return e.schema, e.err
+	}
+	e = entry{sum: sum}
+	e.schema, e.err = compile(schema)
+	v.mu.Lock()
+	v.entries[toolID] = e
+	v.mu.Unlock()
+	return e.schema, e.err
+}
+
+func compile(schema json.RawMessage) (*jsonschema.Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(schema))
+	if err != nil {
+		return nil, fmt.Errorf("invalid input schema: %w", err)
+	}
+	c := jsonschema.NewCompiler()
+	// Schemas come from upstreams; never let them make the proxy read
+	// files or fetch URLs.
+	c.UseLoader(noLoader{})
+	if err := c.AddResource(schemaURL, doc); err != nil {
+		return nil, fmt.Errorf("invalid input schema: %w", err)
+	}
+	sch, err := c.Compile(schemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid input schema: %w", err)
+	}
+	return sch, nil
+}
+
+// collect appends the leaf errors of ve, which name the actual violations.
+func collect(
+	ve *jsonschema.ValidationError, names map[string]string, out *[]Violation,
+) {
+	if len(ve.Causes) == 0 {
+		loc := ve.InstanceLocation
+		if len(loc) > 0 && names[loc[0]] != "" {
+			loc = append([]string{names[loc[0]]}, loc[1:]...)
+		}
+		*out = append(*out, Violation{
+			Path:    pointer(loc),
+			Message: ve.ErrorKind.LocalizedString(printer),
+		})
+		return
+	}
+	for _, c := range ve.Causes {
+		collect(c, names, out)
+	}
+}
+
+// pointer formats tokens as a JSON Pointer.
+func pointer(tokens []string) string {
+	var sb strings.Builder
+	for _, t := range tokens {
+		t = strings.ReplaceAll(t, "~", "~0")
+		t = strings.ReplaceAll(t, "/", "~1")
+		sb.WriteString("/" + t)
+	}
+	return sb.String()
+}
+
+// noLoader refuses every external schema reference.
+type noLoader struct{}
+
+func (noLoader) Load(url string) (any, error) {
+	return nil, fmt.Errorf("external schema %s not allowed", url)
+}
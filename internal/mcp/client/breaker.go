@@ -0,0 +1,140 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+// halfOpenRetryAfter is suggested to callers turned away while a trial
+// call is deciding whether a breaker closes.
+const halfOpenRetryAfter = time.Second
+
+// CircuitOpenError is returned for calls to an upstream server whose
+// circuit breaker is open.
+type CircuitOpenError struct {
+	ServerID   string
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker open for upstream server %s",
+		e.ServerID)
+}
+
+// breaker is the circuit breaker of one catalog server. It opens after
+// BreakerThreshold consecutive upstream failures, fails calls for
+// BreakerCooldown, then lets a single trial call through: success closes
+// it, failure opens it again.
+type breaker struct {
+	state    m.BreakerState
+	failures int
+	openedAt time.Time
+	cooldown time.Duration
+	trial    bool
+}
+
+// Breaker returns this replica's circuit breaker state for a catalog
+// server. Servers that have not failed report closed.
+func (p *Pool) Breaker(serverID string) m.BreakerStatus {
+	p.bmu.Lock()
+	defer p.bmu.Unlock()
+	b, ok := p.breakers[serverID]
+	if !ok {
+		return m.BreakerStatus{State: m.BreakerClosed}
+	}
+	st := m.BreakerStatus{State: b.state, ConsecutiveFailures: b.failures}
+	if b.state != m.BreakerClosed {
+		opened := b.openedAt
+		st.OpenedAt = &opened
+	}
+	if b.state == m.BreakerOpen {
+		retry := b.openedAt.Add(b.cooldown)
+		st.RetryAt = &retry
+	}
+	return st
+}
+
+// admit checks a call against the upstream's breaker. An open breaker
+// past its cooldown turns half-open and admits the call as its trial.
+func (p *Pool) admit(up Upstream) error {
+	if up.ServerID == "" {
+		return nil
+	}
+	p.bmu.Lock()
+	defer p.bmu.Unlock()
+	b, ok := p.breakers[up.ServerID]
+	if !ok {
+		return nil
+	}
+	switch b.state {
+	case m.BreakerOpen:
+		if wait := time.Until(b.openedAt.Add(b.cooldown)); wait > 0 {
+			return &CircuitOpenError{ServerID: up.ServerID, RetryAfter: wait}
+		}
+		p.transitionLocked(up, b, m.BreakerHalfOpen)
+		b.trial = true
+	case m.BreakerHalfOpen:
+		if b.trial {
+			return &CircuitOpenError{
+				ServerID: up.ServerID, RetryAfter: halfOpenRetryAfter,
+			}
+		}
+		b.trial = true
+	}
+	return nil
+}
+
+// record feeds the outcome of an admitted call to the upstream's breaker.
+// Calls the caller cancelled, or that never reached the upstream because
+// the pool was full or closed, count neither way.
+func (p *Pool) record(ctx context.Context, up Upstream, err error) {
+	if up.ServerID == "" {
+		return
+	}
+	neutral := err != nil && (ctx.Err() != nil ||
+		errors.Is(err, ErrPoolExhausted) || errors.Is(err, ErrPoolClosed))
+	failed := err != nil && !neutral && isUpstreamFailure(err)
+
+	p.bmu.Lock()
+	defer p.bmu.Unlock()
+	b, ok := p.breakers[up.ServerID]
+	if !ok {
+		if !failed {
+			return
+		}
+		b = &breaker{state: m.BreakerClosed}
+		p.breakers[up.ServerID] = b
+	}
+	b.trial = false
+	switch {
+	case neutral:
+	case failed:
+		b.failures++
+		if b.state == m.BreakerHalfOpen ||
+			(b.state == m.BreakerClosed && b.failures >= up.breakerThreshold()) {
+			b.openedAt = time.Now()
+			b.cooldown = up.breakerCooldown()
+			p.transitionLocked(up, b, m.BreakerOpen)
+		}
+	default:
+		b.failures = 0
+		if b.state != m.BreakerClosed {
+			p.transitionLocked(up, b, m.BreakerClosed)
+		}
+	}
+}
+
+// transitionLocked moves a breaker to state. Caller must hold p.bmu.
+func (p *Pool) transitionLocked(
+	up Upstream, b *breaker, state m.BreakerState,
+) {
+	b.state = state
+	p.metrics.ObserveBreakerTransition(up.ServerID, string(state))
+	p.logger.Warn("UPSTREAM_BREAKER_TRANSITION",
+		"server_id", up.ServerID, "upstream", up.String(),
+		"state", state, "failures", b.failures)
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	mclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/idgen"
+)
+
+const (
+	methodCancelled = "notifications/cancelled"
+	cancelTimeout   = 5 * time.Second
+)
+
+// callTool sends tools/call under a request id chosen by the proxy, so the
+// call can be cancelled upstream. If ctx ends before the upstream answers,
+// the upstream is sent notifications/cancelled for that id and ctx's error
+// is returned unwrapped; it is not a connection error and does not drop
+// the pooled session.
+func callTool(
+	ctx context.Context,
+	c *mclient.Client,
+	params mcp.CallToolParams,
+) (*mcp.CallToolResult, error) {
+	id := mcp.NewRequestId("mcp-proxy-" + idgen.NewID())
+	resp, err := c.GetTransport().SendRequest(ctx, transport.JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      id,
+		Method:  string(mcp.MethodToolsCall),
+		Params:  params,
+	})
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			cancelRequest(ctx, c, id, ctxErr)
+			return nil, ctxErr
+		}
+		return nil, transport.NewError(err)
+	}
+	if resp.Error != nil {
+		return nil, errors.New(resp.Error.Message)
+	}
+	return mcp.ParseCallToolResult(&resp.Result)
+}
+
+// cancelRequest tells the upstream to stop working on request id.
+func cancelRequest(
+	ctx context.Context, c *mclient.Client, id mcp.RequestId, cause error,
+) {
+	reason := "request cancelled by client"
+	if errors.Is(cause, context.DeadlineExceeded) {
+		reason = "request timed out"
+	}
+	nctx, cancel := context.WithTimeout(
+		context.WithoutCancel(ctx), cancelTimeout)
+	defer cancel()
+	_ = c.GetTransport().SendNotification(nctx, mcp.JSONRPCNotification{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		Notification: mcp.Notification{
+			Method: methodCancelled,
+			Params: mcp.NotificationParams{
+				AdditionalFields: map[string]any{
+					"requestId": id,
+					"reason":    reason,
+				},
+			},
+		},
+	})
+}
@@ -3,7 +3,6 @@ package client
 import (
 	"context"
 	"encoding/json"
-	"time"
 
 	ic "github.com/ChiragChiranjib/mcp-proxy/internal/httpclient"
 	mclient "github.com/mark3labs/mcp-go/client"
@@ -18,42 +17,76 @@ func ConnectStreamable(
 	url string,
 	headers map[string]string,
 ) (*mclient.Client, error) {
+	return connectStreamable(ctx, url, headers, mcp.ClientCapabilities{})
+}
 
+func connectStreamable(
+	ctx context.Context,
+	url string,
+	headers map[string]string,
+	caps mcp.ClientCapabilities,
+) (*mclient.Client, error) {
 	httpClient := ic.NewHTTPClient(ic.WithHeaders(headers))
-	trans, err := transport.NewStreamableHTTP(url,
-		transport.WithHTTPBasicClient(httpClient))
+	opts := []transport.StreamableHTTPCOption{
+		transport.WithHTTPBasicClient(httpClient),
+		transport.WithHTTPHeaderFunc(callHeaders),
+	}
+	startCtx := ctx
+	if caps.Sampling != nil || caps.Elicitation != nil {
+		// Servers may send their requests on the GET stream rather than
+		// the stream of the call they belong to. It must outlive the
+		// connect context.
+		opts = append(opts, transport.WithContinuousListening())
+		startCtx = context.WithoutCancel(ctx)
+	}
+	trans, err := transport.NewStreamableHTTP(url, opts...)
 	if err != nil {
 		return nil, err
 	}
 	c := mclient.NewClient(trans)
-	if err := c.Start(ctx); err != nil {
+	if err := c.Start(startCtx); err != nil {
 		return nil, err
 	}
-	// Initialize
-	_, err = c.Initialize(ctx, mcp.InitializeRequest{
-		Request: mcp.Request{Method: string(mcp.MethodInitialize)},
-		Params: mcp.InitializeParams{
-			ProtocolVersion: mcp.LATEST_PROTOCOL_VERSION,
-			ClientInfo: mcp.Implementation{
-				Name:    "mcp-proxy-client",
-				Version: "1.0.0",
-			},
-			Capabilities: mcp.ClientCapabilities{},
-		},
-	})
-	if err != nil {
+	if err := initialize(ctx, c, caps); err != nil {
 		_ = c.Close()
 		return nil, err
 	}
 	return c, nil
 }
 
+type callHeadersKey struct{}
+
+// withCallHeaders returns a context whose upstream HTTP requests carry h.
+func withCallHeaders(
+	ctx context.Context, h map[string]string) context.Context {
+	if len(h) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, callHeadersKey{}, h)
+}
+
+// callHeaders returns the headers of the call ctx belongs to.
+func callHeaders(ctx context.Context) map[string]string {
+	h, _ := ctx.Value(callHeadersKey{}).(map[string]string)
+	return h
+}
+
+// Probe connects to the upstream, which runs the initialize handshake,
+// pings it and closes the connection.
+func Probe(ctx context.Context, up Upstream) error {
+	c, err := Connect(ctx, up)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = c.Close() }()
+	return c.Ping(ctx)
+}
+
 // ListTools connects and lists tools using mcp-go client.
 func ListTools(
 	ctx context.Context,
-	url string,
-	headers map[string]string) (*mcp.ListToolsResult, error) {
-	c, err := ConnectStreamable(ctx, url, headers)
+	up Upstream) (*mcp.ListToolsResult, error) {
+	c, err := Connect(ctx, up)
 	if err != nil {
 		return nil, err
 	}
@@ -64,30 +97,28 @@ func ListTools(
 // CallTool connects and calls a tool with the provided name and arguments.
 func CallTool(
 	ctx context.Context,
-	url string,
+	up Upstream,
 	toolName string,
-	args map[string]any,
-	headers map[string]string) (*mcp.CallToolResult, error) {
-	c, err := ConnectStreamable(ctx, url, headers)
+	args map[string]any) (*mcp.CallToolResult, error) {
+	c, err := Connect(ctx, up)
 	if err != nil {
 		return nil, err
 	}
 	defer func() { _ = c.Close() }()
-	cctx, cancel := context.WithTimeout(ctx, 120*time.Second)
+	cctx, cancel := context.WithTimeout(ctx, up.callTimeout())
 	defer cancel()
-	return c.CallTool(cctx, mcp.CallToolRequest{Params: mcp.CallToolParams{
+	return callTool(cctx, c, mcp.CallToolParams{
 		Name:      toolName,
 		Arguments: args,
-	}})
+	})
 }
 
 // InitCapabilities initializes and returns the negotiated capabilities.
 func InitCapabilities(
 	ctx context.Context,
-	url string,
-	headers map[string]string,
+	up Upstream,
 ) ([]byte, error) {
-	c, err := ConnectStreamable(ctx, url, headers)
+	c, err := Connect(ctx, up)
 	if err != nil {
 		return nil, err
 	}
@@ -1,19 +1,39 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/ChiragChiranjib/mcp-proxy/internal/encryptor"
 	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
 )
 
+// HubTokenStore persists the OAuth credentials of oauth2 hubs as their
+// tokens are refreshed.
+type HubTokenStore interface {
+	GetHubAuthValue(ctx context.Context, id string) ([]byte, error)
+	UpdateHubAuthValue(ctx context.Context, id string, value []byte) error
+	MarkHubNeedsAuth(ctx context.Context, id, reason string) error
+}
+
+// hubRefreshLocks serializes token refreshes per hub, so concurrent calls
+// do not each spend the same refresh token.
+var hubRefreshLocks sync.Map
+
 // BuildUpstreamHeaders prepares Authorization/custom headers,
 // with AES decryption when bearer token is stored encrypted as JSON.
+// The access token of an oauth2 hub is refreshed, and saved to store,
+// when it is about to expire.
 func BuildUpstreamHeaders(
+	ctx context.Context,
 	logger *slog.Logger,
 	encypt *encryptor.AESEncrypter,
+	store HubTokenStore,
 	hub *m.MCPHubServer,
 ) map[string]string {
 	logger.Info(
@@ -61,8 +81,79 @@ func BuildUpstreamHeaders(
 			}
 			logger.Info("CUSTOM_HEADERS_APPLIED", "count", len(headers))
 		}
+	case m.AuthTypeOAuth2:
+		creds, err := DecodeOAuthCredentials(encypt, hub.AuthValue)
+		if err != nil {
+			logger.Error("DECRYPT_OAUTH_CREDENTIALS_ERROR", "error", err)
+			break
+		}
+		if creds.needsRefresh(time.Now()) && store != nil {
+			creds = refreshHubToken(ctx, logger, encypt, store, hub, creds)
+		}
+		if creds.AccessToken != "" {
+			headers["Authorization"] = "Bearer " + creds.AccessToken
+		}
 	default:
 		logger.Info("NO_AUTH_HEADERS_APPLIED")
 	}
 	return headers
 }
+
+// refreshHubToken refreshes the access token of an oauth2 hub and stores
+// the new credentials, unless another caller already did. When the
+// authorization server refuses, the hub is marked NEEDS_AUTH; when it
+// cannot be reached the current credentials are returned.
+func refreshHubToken(
+	ctx context.Context,
+	logger *slog.Logger,
+	encr *encryptor.AESEncrypter,
+	store HubTokenStore,
+	hub *m.MCPHubServer,
+	creds OAuthCredentials,
+) OAuthCredentials {
+	mu, _ := hubRefreshLocks.LoadOrStore(hub.ID, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	defer mu.(*sync.Mutex).Unlock()
+
+	if raw, err := store.GetHubAuthValue(ctx, hub.ID); err == nil {
+		if cur, err := DecodeOAuthCredentials(encr, raw); err == nil {
+			creds = cur
+			hub.AuthValue = raw
+		}
+	}
+	if !creds.needsRefresh(time.Now()) {
+		return creds
+	}
+
+	logger.Info("OAUTH_REFRESH_INIT", "hub_id", hub.ID)
+	err := RefreshOAuthToken(ctx, &creds)
+	var refused *OAuthError
+	switch {
+	case errors.As(err, &refused):
+		logger.Error("OAUTH_REFRESH_REFUSED", "hub_id", hub.ID, "error", err)
+		if merr := store.MarkHubNeedsAuth(context.WithoutCancel(ctx), hub.ID,
+			"token refresh failed: "+err.Error()); merr != nil {
+			logger.Error("OAUTH_MARK_NEEDS_AUTH_ERROR", "error", merr)
+		} else {
+			hub.Status = m.StatusNeedsAuth
+		}
+		return creds
+	case err != nil:
+		logger.Error("OAUTH_REFRESH_ERROR", "hub_id", hub.ID, "error", err)
+		return creds
+	}
+
+	enc, err := EncodeOAuthCredentials(encr, creds)
+	if err == nil {
+		err = store.UpdateHubAuthValue(context.WithoutCancel(ctx), hub.ID, enc)
+	}
+	if err != nil {
+		// The refresh token may have rotated; without it the next
+		// refresh fails and the hub is marked for re-authorization.
+		logger.Error("OAUTH_REFRESH_SAVE_ERROR", "hub_id", hub.ID, "error", err)
+		return creds
+	}
+	hub.AuthValue = enc
+	logger.Info("OAUTH_REFRESH_SUCCESS", "hub_id", hub.ID)
+	return creds
+}
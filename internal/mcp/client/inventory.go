@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/idgen"
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+// Inventory holds the resources, resource templates and prompts an upstream
+// server exposes.
+type Inventory struct {
+	Resources []mcp.Resource
+	Templates []mcp.ResourceTemplate
+	Prompts   []mcp.Prompt
+}
+
+// ListInventory connects once and lists resources, resource templates and
+// prompts. Capabilities the upstream does not advertise are skipped.
+func ListInventory(
+	ctx context.Context,
+	up Upstream,
+) (*Inventory, error) {
+	c, err := Connect(ctx, up)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = c.Close() }()
+
+	inv := &Inventory{}
+	caps := c.GetServerCapabilities()
+	if caps.Resources != nil {
+		res, err := c.ListResources(ctx, mcp.ListResourcesRequest{})
+		if err != nil {
+			return nil, err
+		}
+		inv.Resources = res.Resources
+		tpl, err := c.ListResourceTemplates(
+			ctx, mcp.ListResourceTemplatesRequest{})
+		if err != nil {
+			return nil, err
+		}
+		inv.Templates = tpl.ResourceTemplates
+	}
+	if caps.Prompts != nil {
+		res, err := c.ListPrompts(ctx, mcp.ListPromptsRequest{})
+		if err != nil {
+			return nil, err
+		}
+		inv.Prompts = res.Prompts
+	}
+	return inv, nil
+}
+
+// Models converts the inventory into ACTIVE records owned by the given
+// server, and owner and hub for private servers.
+func (inv *Inventory) Models(
+	serverID string, owner m.Owner, hubID *string,
+) ([]m.MCPResource, []m.MCPPrompt) {
+	userID, teamID := owner.Columns()
+	resources := make([]m.MCPResource, 0,
+		len(inv.Resources)+len(inv.Templates))
+	for _, r := range inv.Resources {
+		ann, _ := json.Marshal(r.Annotations)
+		resources = append(resources, m.MCPResource{
+			ID:             idgen.NewID(),
+			UserID:         userID,
+			TeamID:         teamID,
+			MCPServerID:    serverID,
+			MCPHubServerID: hubID,
+			URI:            r.URI,
+			Name:           r.Name,
+			Description:    r.Description,
+			MIMEType:       r.MIMEType,
+			Annotations:    ann,
+			Status:         m.StatusActive,
+		})
+	}
+	for _, t := range inv.Templates {
+		if t.URITemplate == nil || t.URITemplate.Template == nil {
+			continue
+		}
+		ann, _ := json.Marshal(t.Annotations)
+		resources = append(resources, m.MCPResource{
+			ID:             idgen.NewID(),
+			UserID:         userID,
+			TeamID:         teamID,
+			MCPServerID:    serverID,
+			MCPHubServerID: hubID,
+			URI:            t.URITemplate.Raw(),
+			IsTemplate:     true,
+			Name:           t.Name,
+			Description:    t.Description,
+			MIMEType:       t.MIMEType,
+			Annotations:    ann,
+			Status:         m.StatusActive,
+		})
+	}
+	prompts := make([]m.MCPPrompt, 0, len(inv.Prompts))
+	for _, p := range inv.Prompts {
+		args, _ := json.Marshal(p.Arguments)
+		prompts = append(prompts, m.MCPPrompt{
+			ID:             idgen.NewID(),
+			UserID:         userID,
+			TeamID:         teamID,
+			MCPServerID:    serverID,
+			MCPHubServerID: hubID,
+			Name:           p.Name,
+			Description:    p.Description,
+			Arguments:      args,
+			Status:         m.StatusActive,
+		})
+	}
+	return resources, prompts
+}
@@ -0,0 +1,163 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/idgen"
+)
+
+// methodProgress is the MCP progress notification method.
+const methodProgress = "notifications/progress"
+
+// NotificationFunc receives upstream notifications that belong to one
+// proxied request.
+type NotificationFunc func(mcp.JSONRPCNotification)
+
+// RequestFunc relays a request the upstream sends its client, such as
+// sampling/createMessage or elicitation/create, to the downstream client
+// of one proxied request and returns the client's response.
+type RequestFunc func(
+	ctx context.Context, req transport.JSONRPCRequest,
+) (*transport.JSONRPCResponse, error)
+
+// callTokenKey carries the token of the proxied call a request to the
+// upstream is sent for, so requests the upstream makes while answering it
+// can be routed back.
+type callTokenKey struct{}
+
+// subscription is one call waiting on a pooled session.
+type subscription struct {
+	notify  NotificationFunc
+	request RequestFunc
+}
+
+// notifier routes the notifications and requests of one pooled session to
+// the calls waiting on it. Sessions are shared, so only messages that can
+// be attributed to a call are delivered: progress carrying a token the
+// proxy issued for that call, requests arriving on the stream of that
+// call's POST, and other request-scoped messages (such as log messages)
+// while that call is the only request on the session.
+type notifier struct {
+	mu   sync.Mutex
+	subs map[string]subscription // by progress token
+}
+
+func newNotifier() *notifier {
+	return &notifier{subs: map[string]subscription{}}
+}
+
+// subscribe registers a call and returns the progress token to send
+// upstream. onRequest may be nil when the call cannot answer requests.
+func (n *notifier) subscribe(
+	onNotify NotificationFunc, onRequest RequestFunc,
+) string {
+	token := "mcp-proxy-" + idgen.NewID()
+	n.mu.Lock()
+	n.subs[token] = subscription{notify: onNotify, request: onRequest}
+	n.mu.Unlock()
+	return token
+}
+
+func (n *notifier) unsubscribe(token string) {
+	n.mu.Lock()
+	delete(n.subs, token)
+	n.mu.Unlock()
+}
+
+// dispatch delivers an upstream notification. sole reports whether a single
+// request is in flight on the session.
+func (n *notifier) dispatch(note mcp.JSONRPCNotification, sole bool) {
+	if note.Method == methodProgress {
+		token := fmt.Sprint(note.Params.AdditionalFields["progressToken"])
+		n.mu.Lock()
+		fn := n.subs[token].notify
+		n.mu.Unlock()
+		if fn != nil {
+			fn(note)
+		}
+		return
+	}
+	// Session-wide changes are not about any one call.
+	if strings.HasSuffix(note.Method, "/list_changed") || !sole {
+		return
+	}
+	if sub, ok := n.only(); ok && sub.notify != nil {
+		sub.notify(note)
+	}
+}
+
+// request answers a request from the upstream. Pings are answered by the
+// proxy; anything else goes to the call it belongs to: the one whose POST
+// stream carried it, or for transports without per-request streams the
+// only call in flight.
+func (n *notifier) request(
+	ctx context.Context, req transport.JSONRPCRequest, sole bool,
+) (*transport.JSONRPCResponse, error) {
+	if req.Method == string(mcp.MethodPing) {
+		return &transport.JSONRPCResponse{
+			JSONRPC: mcp.JSONRPC_VERSION,
+			ID:      req.ID,
+			Result:  json.RawMessage(`{}`),
+		}, nil
+	}
+	var fn RequestFunc
+	if token, ok := ctx.Value(callTokenKey{}).(string); ok {
+		n.mu.Lock()
+		fn = n.subs[token].request
+		n.mu.Unlock()
+	} else if sub, ok := n.only(); ok && sole {
+		fn = sub.request
+	}
+	if fn == nil {
+		return ErrorResponse(req.ID, mcp.METHOD_NOT_FOUND,
+			"no client to handle "+req.Method), nil
+	}
+	return fn(ctx, req)
+}
+
+// only returns the subscription when exactly one call is registered.
+func (n *notifier) only() (subscription, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.subs) != 1 {
+		return subscription{}, false
+	}
+	for _, sub := range n.subs {
+		return sub, true
+	}
+	return subscription{}, false
+}
+
+// ErrorResponse builds a JSON-RPC error response to request id.
+func ErrorResponse(
+	id mcp.RequestId, code int, msg string,
+) *transport.JSONRPCResponse {
+	resp := &transport.JSONRPCResponse{JSONRPC: mcp.JSONRPC_VERSION, ID: id}
+	resp.Error = &struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}{Code: code, Message: msg}
+	return resp
+}
+
+// withProgressToken returns a copy of a progress notification carrying the
+// given token.
+func withProgressToken(
+	note mcp.JSONRPCNotification, token mcp.ProgressToken,
+) mcp.JSONRPCNotification {
+	fields := make(map[string]any, len(note.Params.AdditionalFields))
+	for k, v := range note.Params.AdditionalFields {
+		fields[k] = v
+	}
+	fields["progressToken"] = token
+	note.Params.AdditionalFields = fields
+	return note
+}
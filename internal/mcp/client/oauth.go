@@ -0,0 +1,342 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/encryptor"
+)
+
+const (
+	// oauthRefreshSkew is how long before expiry an access token is
+	// refreshed.
+	oauthRefreshSkew = time.Minute
+	oauthHTTPTimeout = 15 * time.Second
+	maxOAuthBodySize = 1 << 20
+)
+
+var oauthHTTPClient = &http.Client{Timeout: oauthHTTPTimeout}
+
+// OAuthCredentials is the auth value of an oauth2 hub, stored encrypted.
+// The client and endpoints are configured when the hub is added or
+// discovered from the upstream; the tokens come from the authorization
+// code flow; the pending fields hold a flow that has not completed yet.
+type OAuthCredentials struct {
+	ClientID              string `json:"client_id,omitempty"`
+	ClientSecret          string `json:"client_secret,omitempty"`
+	AuthorizationEndpoint string `json:"authorization_endpoint,omitempty"`
+	TokenEndpoint         string `json:"token_endpoint,omitempty"`
+	Scope                 string `json:"scope,omitempty"`
+	// Registered is set when the client was registered dynamically for
+	// RedirectURI, and is registered again if that changes.
+	Registered  bool   `json:"registered,omitempty"`
+	RedirectURI string `json:"redirect_uri,omitempty"`
+	// Resource is the upstream MCP URL tokens are requested for (RFC 8707).
+	Resource string `json:"resource,omitempty"`
+
+	AccessToken  string     `json:"access_token,omitempty"`
+	RefreshToken string     `json:"refresh_token,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+
+	State         string     `json:"state,omitempty"`
+	Verifier      string     `json:"verifier,omitempty"`
+	FlowExpiresAt *time.Time `json:"flow_expires_at,omitempty"`
+}
+
+// needsRefresh reports whether the access token is missing or expires
+// within oauthRefreshSkew.
+func (c OAuthCredentials) needsRefresh(now time.Time) bool {
+	if c.AccessToken == "" {
+		return true
+	}
+	return c.ExpiresAt != nil && now.Add(oauthRefreshSkew).After(*c.ExpiresAt)
+}
+
+// DecodeOAuthCredentials decrypts the auth value of an oauth2 hub.
+func DecodeOAuthCredentials(
+	encr *encryptor.AESEncrypter, raw []byte,
+) (OAuthCredentials, error) {
+	var c OAuthCredentials
+	if len(raw) == 0 {
+		return c, nil
+	}
+	if encr == nil {
+		return c, errors.New("no encrypter configured")
+	}
+	plain, err := encr.DecryptFromJSON(raw)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(plain, &c)
+	return c, err
+}
+
+// EncodeOAuthCredentials encrypts credentials as a hub auth value.
+func EncodeOAuthCredentials(
+	encr *encryptor.AESEncrypter, c OAuthCredentials,
+) ([]byte, error) {
+	if encr == nil {
+		return nil, errors.New("no encrypter configured")
+	}
+	plain, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return encr.EncryptToJSON(plain)
+}
+
+// OAuthError is an error response from an authorization server. It means
+// the request was refused, not that the server could not be reached.
+type OAuthError struct {
+	Status      int
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *OAuthError) Error() string {
+	msg := fmt.Sprintf("authorization server answered %d", e.Status)
+	if e.Code != "" {
+		msg += ": " + e.Code
+	}
+	if e.Description != "" {
+		msg += ": " + e.Description
+	}
+	return msg
+}
+
+// OAuthMetadata is the part of an upstream's authorization server metadata
+// (RFC 8414) the gateway uses, with the scopes its protected resource
+// metadata (RFC 9728) advertises.
+type OAuthMetadata struct {
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	RegistrationEndpoint  string   `json:"registration_endpoint"`
+	Scopes                []string `json:"-"`
+}
+
+// DiscoverOAuth finds the authorization server of an MCP server as the MCP
+// authorization spec describes: from its protected resource metadata when
+// it publishes any, else at the server's origin.
+func DiscoverOAuth(
+	ctx context.Context, serverURL string) (OAuthMetadata, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil || u.Host == "" {
+		return OAuthMetadata{}, fmt.Errorf("invalid server url %q", serverURL)
+	}
+	origin := u.Scheme + "://" + u.Host
+
+	var resource struct {
+		AuthorizationServers []string `json:"authorization_servers"`
+		ScopesSupported      []string `json:"scopes_supported"`
+	}
+	for _, p := range []string{
+		"/.well-known/oauth-protected-resource" + strings.TrimRight(u.Path, "/"),
+		"/.well-known/oauth-protected-resource",
+	} {
+		if getJSON(ctx, origin+p, &resource) == nil {
+			break
+		}
+	}
+	issuer := origin
+	if len(resource.AuthorizationServers) > 0 {
+		issuer = strings.TrimRight(resource.AuthorizationServers[0], "/")
+	}
+
+	iu, err := url.Parse(issuer)
+	if err != nil || iu.Host == "" {
+		return OAuthMetadata{}, fmt.Errorf("invalid authorization server %q",
+			issuer)
+	}
+	base := iu.Scheme + "://" + iu.Host
+	path := strings.TrimRight(iu.Path, "/")
+	for _, doc := range []string{
+		base + "/.well-known/oauth-authorization-server" + path,
+		base + "/.well-known/openid-configuration" + path,
+		issuer + "/.well-known/openid-configuration",
+	} {
+		var md OAuthMetadata
+		if getJSON(ctx, doc, &md) == nil &&
+			md.AuthorizationEndpoint != "" && md.TokenEndpoint != "" {
+			md.Scopes = resource.ScopesSupported
+			return md, nil
+		}
+	}
+	return OAuthMetadata{}, fmt.Errorf(
+		"no authorization server metadata found for %s", serverURL)
+}
+
+// RegisterOAuthClient registers the gateway as a client of an
+// authorization server (RFC 7591).
+func RegisterOAuthClient(
+	ctx context.Context, endpoint, name, redirectURI string,
+) (clientID, clientSecret string, err error) {
+	body, err := json.Marshal(map[string]any{
+		"client_name":                name,
+		"redirect_uris":              []string{redirectURI},
+		"grant_types":                []string{"authorization_code", "refresh_token"},
+		"response_types":             []string{"code"},
+		"token_endpoint_auth_method": "none",
+	})
+	if err != nil {
+		return "", "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint,
+		strings.NewReader(string(body)))
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	var out struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+	}
+	if err := doOAuth(req, &out); err != nil {
+		return "", "", err
+	}
+	if out.ClientID == "" {
+		return "", "", errors.New("registration returned no client_id")
+	}
+	return out.ClientID, out.ClientSecret, nil
+}
+
+// NewPKCE returns a PKCE verifier and its S256 challenge.
+func NewPKCE() (verifier, challenge string, err error) {
+	verifier, err = randomString()
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// NewOAuthState returns a random state parameter prefixed with id.
+func NewOAuthState(id string) (string, error) {
+	s, err := randomString()
+	if err != nil {
+		return "", err
+	}
+	return id + "." + s, nil
+}
+
+// ExchangeOAuthCode redeems an authorization code with the verifier of the
+// pending flow and stores the tokens in c.
+func ExchangeOAuthCode(
+	ctx context.Context, c *OAuthCredentials, code string) error {
+	return requestToken(ctx, c, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.RedirectURI},
+		"code_verifier": {c.Verifier},
+	})
+}
+
+// RefreshOAuthToken exchanges the refresh token in c for new tokens.
+func RefreshOAuthToken(ctx context.Context, c *OAuthCredentials) error {
+	if c.RefreshToken == "" {
+		return &OAuthError{Code: "invalid_grant",
+			Description: "no refresh token was issued"}
+	}
+	return requestToken(ctx, c, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {c.RefreshToken},
+	})
+}
+
+// requestToken makes a token request, authenticating with the client
+// secret when there is one, and stores the tokens in c. Refresh tokens are
+// kept when the server does not rotate them.
+func requestToken(
+	ctx context.Context, c *OAuthCredentials, form url.Values,
+) error {
+	if c.Resource != "" {
+		form.Set("resource", c.Resource)
+	}
+	if c.ClientSecret == "" {
+		form.Set("client_id", c.ClientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		c.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.ClientID),
+			url.QueryEscape(c.ClientSecret))
+	}
+	var out struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+	}
+	if err := doOAuth(req, &out); err != nil {
+		return err
+	}
+	if out.AccessToken == "" {
+		return errors.New("token response has no access_token")
+	}
+	c.AccessToken = out.AccessToken
+	if out.RefreshToken != "" {
+		c.RefreshToken = out.RefreshToken
+	}
+	c.ExpiresAt = nil
+	if out.ExpiresIn > 0 {
+		at := time.Now().Add(time.Duration(out.ExpiresIn) * time.Second).UTC()
+		c.ExpiresAt = &at
+	}
+	return nil
+}
+
+// doOAuth sends a request to an authorization server and decodes its JSON
+// answer into out, or returns an *OAuthError for an error status.
+func doOAuth(req *http.Request, out any) error {
+	res, err := oauthHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxOAuthBodySize))
+	if err != nil {
+		return err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		oe := &OAuthError{Status: res.StatusCode}
+		_ = json.Unmarshal(body, oe)
+		if res.StatusCode >= 500 {
+			// Not a refusal: the server may answer next time
+			return fmt.Errorf("authorization server error: %v", oe)
+		}
+		return oe
+	}
+	return json.Unmarshal(body, out)
+}
+
+// getJSON fetches a metadata document.
+func getJSON(ctx context.Context, u string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	return doOAuth(req, out)
+}
+
+func randomString() (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b[:]), nil
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Call policy defaults, used where a catalog server leaves a value at zero.
+const (
+	defaultCallTimeout      = 120 * time.Second
+	defaultConnectTimeout   = 30 * time.Second
+	defaultRetryBackoff     = 500 * time.Millisecond
+	maxRetryBackoff         = 30 * time.Second
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+)
+
+// callTimeout bounds one attempt of a tool call.
+func (u Upstream) callTimeout() time.Duration {
+	return seconds(u.Policy.CallTimeoutSeconds, defaultCallTimeout)
+}
+
+// connectTimeout bounds connecting and initializing a session.
+func (u Upstream) connectTimeout() time.Duration {
+	return seconds(u.Policy.ConnectTimeoutSeconds, defaultConnectTimeout)
+}
+
+// retryBackoff is the wait before the first retry; it doubles after each.
+func (u Upstream) retryBackoff() time.Duration {
+	if u.Policy.RetryBackoffMS <= 0 {
+		return defaultRetryBackoff
+	}
+	return time.Duration(u.Policy.RetryBackoffMS) * time.Millisecond
+}
+
+// breakerThreshold is the number of consecutive failures that opens the
+// circuit breaker.
+func (u Upstream) breakerThreshold() int {
+	if u.Policy.BreakerThreshold <= 0 {
+		return defaultBreakerThreshold
+	}
+	return u.Policy.BreakerThreshold
+}
+
+// breakerCooldown is how long an open breaker fails calls before it lets a
+// trial call through.
+func (u Upstream) breakerCooldown() time.Duration {
+	return seconds(u.Policy.BreakerCooldownSeconds, defaultBreakerCooldown)
+}
+
+func seconds(n int, def time.Duration) time.Duration {
+	if n <= 0 {
+		return def
+	}
+	return time.Duration(n) * time.Second
+}
+
+// IsIdempotent reports whether tool annotations mark a tool safe to retry:
+// read-only, or idempotent by its idempotentHint.
+func IsIdempotent(annotations json.RawMessage) bool {
+	if len(annotations) == 0 {
+		return false
+	}
+	var a struct {
+		ReadOnlyHint   *bool `json:"readOnlyHint"`
+		IdempotentHint *bool `json:"idempotentHint"`
+	}
+	if err := json.Unmarshal(annotations, &a); err != nil {
+		return false
+	}
+	return (a.ReadOnlyHint != nil && *a.ReadOnlyHint) ||
+		(a.IdempotentHint != nil && *a.IdempotentHint)
+}
+
+// isUpstreamFailure reports whether err means the upstream could not be
+// reached or did not answer in time. These count against the circuit
+// breaker and may be retried; JSON-RPC errors and tool errors are answers
+// and do not. Callers must check their own context first: the call
+// timeout is a failure, a cancelled caller is not.
+func isUpstreamFailure(err error) bool {
+	var ce *connectError
+	return isConnectionError(err) ||
+		errors.As(err, &ce) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+// connectError marks a failure to connect to or initialize an upstream.
+type connectError struct{ err error }
+
+func (e *connectError) Error() string { return e.err.Error() }
+
+func (e *connectError) Unwrap() error { return e.err }
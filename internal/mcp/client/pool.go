@@ -0,0 +1,708 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	mclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/metrics"
+)
+
+const (
+	defaultIdleTimeout    = 5 * time.Minute
+	defaultHealthInterval = 30 * time.Second
+	defaultMaxSessions    = 256
+	pingTimeout           = 5 * time.Second
+	pingConcurrency       = 16
+	restartBackoffMin     = time.Second
+	restartBackoffMax     = time.Minute
+)
+
+// ErrPoolExhausted is returned when the pool is at its session limit and
+// every session is in use.
+var ErrPoolExhausted = errors.New("upstream session pool exhausted")
+
+// ErrPoolClosed is returned when the pool has been closed.
+var ErrPoolClosed = errors.New("upstream session pool closed")
+
+// PoolOption configures a Pool (functional options).
+type PoolOption func(*Pool)
+
+// WithPoolLogger sets a logger.
+func WithPoolLogger(l *slog.Logger) PoolOption {
+	return func(p *Pool) { p.logger = l }
+}
+
+// WithPoolMetrics records upstream connect latency and failures.
+func WithPoolMetrics(mt *metrics.Metrics) PoolOption {
+	return func(p *Pool) { p.metrics = mt }
+}
+
+// WithIdleTimeout sets how long an unused session is kept open.
+// Non-positive values keep the default.
+func WithIdleTimeout(d time.Duration) PoolOption {
+	return func(p *Pool) {
+		if d > 0 {
+			p.idleTimeout = d
+		}
+	}
+}
+
+// WithMaxSessions caps the number of open upstream sessions.
+// Non-positive values keep the default.
+func WithMaxSessions(n int) PoolOption {
+	return func(p *Pool) {
+		if n > 0 {
+			p.maxSessions = n
+		}
+	}
+}
+
+// WithHealthInterval sets how often idle sessions are pinged and evicted.
+// Non-positive values keep the default.
+func WithHealthInterval(d time.Duration) PoolOption {
+	return func(p *Pool) {
+		if d > 0 {
+			p.healthInterval = d
+		}
+	}
+}
+
+// Pool keeps initialized upstream MCP sessions alive across calls. Sessions
+// are keyed by upstream URL and a hash of the resolved headers (or the
+// command line and environment for stdio), so two hubs pointing at the same
+// server with different credentials never share one. Stdio processes that
+// exit are restarted with exponential backoff while they are still in use.
+//
+// Tool calls follow the call policy of their catalog server: each attempt
+// is bounded by its call timeout, idempotent calls are retried after
+// upstream failures, and a circuit breaker per server fails calls fast
+// once the server keeps failing. Breakers are kept per replica.
+type Pool struct {
+	mu       sync.Mutex
+	sessions map[string]*session
+	closed   bool
+
+	bmu      sync.Mutex
+	breakers map[string]*breaker
+
+	logger         *slog.Logger
+	metrics        *metrics.Metrics
+	idleTimeout    time.Duration
+	maxSessions    int
+	healthInterval time.Duration
+
+	connect func(
+		ctx context.Context, up Upstream,
+	) (*mclient.Client, <-chan struct{}, error)
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// session is one pooled upstream client. ready is closed once the connect
+// attempt finishes; err holds its outcome.
+type session struct {
+	key      string
+	up       Upstream
+	client   *mclient.Client
+	exited   <-chan struct{}
+	notify   *notifier
+	err      error
+	ready    chan struct{}
+	inUse    int
+	lastUsed time.Time
+	broken   bool
+}
+
+// NewPool creates a Pool and starts its background janitor.
+func NewPool(opts ...PoolOption) *Pool {
+	p := &Pool{
+		sessions:       map[string]*session{},
+		breakers:       map[string]*breaker{},
+		logger:         slog.Default(),
+		idleTimeout:    defaultIdleTimeout,
+		maxSessions:    defaultMaxSessions,
+		healthInterval: defaultHealthInterval,
+		connect:        dial,
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(p)
+	}
+	go p.janitor()
+	return p
+}
+
+// Do runs fn with a pooled client for the upstream. If fn fails with a
+// transport-level error the session is dropped, a new one is established
+// and fn is retried once. The requests fn makes carry up.CallHeaders.
+func (p *Pool) Do(
+	ctx context.Context,
+	up Upstream,
+	fn func(ctx context.Context, c *mclient.Client) error,
+) error {
+	return p.do(ctx, up, true, func(ctx context.Context, s *session) error {
+		return fn(ctx, s.client)
+	})
+}
+
+// do is Do with access to the pooled session. Without resend fn is run
+// again only when the failed request provably never reached the upstream:
+// the session's process had already exited, the upstream no longer knew
+// the session or the connection could not be opened. Tool calls use this
+// since a call that failed after it was sent may have run.
+func (p *Pool) do(
+	ctx context.Context,
+	up Upstream,
+	resend bool,
+	fn func(ctx context.Context, s *session) error,
+) error {
+	for attempt := 0; ; attempt++ {
+		s, err := p.acquire(ctx, up)
+		if err != nil {
+			return err
+		}
+		sent := !s.dead()
+		if sent {
+			err = fn(withCallHeaders(ctx, up.CallHeaders), s)
+		} else {
+			err = errProcessExited
+		}
+		if err == nil || !isConnectionError(err) {
+			p.release(s)
+			return err
+		}
+		p.invalidate(s)
+		p.release(s)
+		if attempt > 0 || ctx.Err() != nil ||
+			(!resend && sent && !isUnsentError(err)) {
+			return err
+		}
+		p.logger.Warn("UPSTREAM_SESSION_RETRY",
+			"upstream", up.String(), "error", err)
+	}
+}
+
+// ToolCall is a tool call made through CallToolStream.
+type ToolCall struct {
+	Name      string
+	Arguments map[string]any
+	// ProgressToken is the client's token; progress is relayed only when
+	// it is set.
+	ProgressToken mcp.ProgressToken
+	OnNotify      NotificationFunc
+	OnRequest     RequestFunc
+	// Idempotent calls are retried after upstream failures, up to the
+	// server's MaxRetries (see IsIdempotent).
+	Idempotent bool
+}
+
+// CallTool calls a tool through a pooled session. Cancelling ctx cancels
+// the call upstream and returns context.Canceled.
+func (p *Pool) CallTool(
+	ctx context.Context,
+	up Upstream,
+	toolName string,
+	args map[string]any,
+) (*mcp.CallToolResult, error) {
+	var res *mcp.CallToolResult
+	err := p.call(ctx, up, false,
+		func(ctx context.Context, s *session) error {
+			r, err := callTool(ctx, s.client,
+				mcp.CallToolParams{Name: toolName, Arguments: args})
+			res = r
+			return err
+		})
+	return res, err
+}
+
+// CallToolStream calls a tool through a pooled session and passes the
+// upstream notifications that belong to the call to call.OnNotify while it
+// runs. Progress notifications carry the client's token instead of the one
+// sent upstream. Requests the upstream makes of its client while answering
+// go to call.OnRequest; a nil OnRequest declines them. Cancelling ctx
+// cancels the call upstream as for CallTool.
+func (p *Pool) CallToolStream(
+	ctx context.Context,
+	up Upstream,
+	call ToolCall,
+) (*mcp.CallToolResult, error) {
+	var res *mcp.CallToolResult
+	err := p.call(ctx, up, call.Idempotent,
+		func(ctx context.Context, s *session) error {
+			token := s.notify.subscribe(func(note mcp.JSONRPCNotification) {
+				if note.Method == methodProgress {
+					if call.ProgressToken == nil {
+						return
+					}
+					note = withProgressToken(note, call.ProgressToken)
+				}
+				call.OnNotify(note)
+			}, call.OnRequest)
+			defer s.notify.unsubscribe(token)
+
+			cctx := context.WithValue(ctx, callTokenKey{}, token)
+			r, err := callTool(cctx, s.client, mcp.CallToolParams{
+				Name:      call.Name,
+				Arguments: call.Arguments,
+				Meta:      &mcp.Meta{ProgressToken: token},
+			})
+			res = r
+			return err
+		})
+	return res, err
+}
+
+// call runs a tool call through the upstream's circuit breaker with each
+// attempt bounded by the call timeout. A stale pooled session is replaced
+// and the call resent once when it cannot have reached the upstream, and
+// only the outcome on the replacement counts for the breaker. Beyond that
+// only idempotent calls that fail to reach the upstream or time out are
+// retried, with exponential backoff.
+func (p *Pool) call(
+	ctx context.Context,
+	up Upstream,
+	idempotent bool,
+	fn func(ctx context.Context, s *session) error,
+) error {
+	backoff := up.retryBackoff()
+	for attempt := 0; ; attempt++ {
+		if err := p.admit(up); err != nil {
+			return err
+		}
+		err := p.do(ctx, up, idempotent,
+			func(ctx context.Context, s *session) error {
+				cctx, cancel := context.WithTimeout(ctx, up.callTimeout())
+				defer cancel()
+				return fn(cctx, s)
+			})
+		p.record(ctx, up, err)
+		if err == nil || !idempotent || attempt >= up.Policy.MaxRetries ||
+			ctx.Err() != nil || !isUpstreamFailure(err) {
+			return err
+		}
+		p.metrics.ObserveUpstreamRetry(up.ServerID)
+		p.logger.Warn("UPSTREAM_CALL_RETRY", "upstream", up.String(),
+			"attempt", attempt+1, "backoff", backoff, "error", err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+}
+
+// ListTools lists tools through a pooled session.
+func (p *Pool) ListTools(
+	ctx context.Context,
+	up Upstream,
+) (*mcp.ListToolsResult, error) {
+	var res *mcp.ListToolsResult
+	err := p.Do(ctx, up,
+		func(ctx context.Context, c *mclient.Client) error {
+			r, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+			res = r
+			return err
+		})
+	return res, err
+}
+
+// ReadResource reads a resource through a pooled session.
+func (p *Pool) ReadResource(
+	ctx context.Context,
+	up Upstream,
+	uri string,
+) (*mcp.ReadResourceResult, error) {
+	var res *mcp.ReadResourceResult
+	err := p.Do(ctx, up,
+		func(ctx context.Context, c *mclient.Client) error {
+			r, err := c.ReadResource(ctx, mcp.ReadResourceRequest{
+				Params: mcp.ReadResourceParams{URI: uri},
+			})
+			res = r
+			return err
+		})
+	return res, err
+}
+
+// GetPrompt renders a prompt through a pooled session.
+func (p *Pool) GetPrompt(
+	ctx context.Context,
+	up Upstream,
+	name string,
+	args map[string]string,
+) (*mcp.GetPromptResult, error) {
+	var res *mcp.GetPromptResult
+	err := p.Do(ctx, up,
+		func(ctx context.Context, c *mclient.Client) error {
+			r, err := c.GetPrompt(ctx, mcp.GetPromptRequest{
+				Params: mcp.GetPromptParams{Name: name, Arguments: args},
+			})
+			res = r
+			return err
+		})
+	return res, err
+}
+
+// Len returns the number of open sessions.
+func (p *Pool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.sessions)
+}
+
+// Close stops the janitor and closes every session.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	all := make([]*session, 0, len(p.sessions))
+	for k, s := range p.sessions {
+		all = append(all, s)
+		delete(p.sessions, k)
+	}
+	p.mu.Unlock()
+
+	close(p.stop)
+	<-p.done
+	for _, s := range all {
+		<-s.ready
+		if s.client != nil {
+			_ = s.client.Close()
+		}
+	}
+	return nil
+}
+
+// acquire returns a ready session for the upstream, connecting if needed.
+// Concurrent callers for the same key share a single connect attempt.
+func (p *Pool) acquire(ctx context.Context, up Upstream) (*session, error) {
+	key := up.key()
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, ErrPoolClosed
+	}
+	s, ok := p.sessions[key]
+	if ok {
+		s.inUse++
+		p.mu.Unlock()
+		select {
+		case <-s.ready:
+		case <-ctx.Done():
+			p.release(s)
+			return nil, ctx.Err()
+		}
+		if s.err != nil {
+			p.release(s)
+			return nil, s.err
+		}
+		return s, nil
+	}
+	if len(p.sessions) >= p.maxSessions &&
+		!p.evictOldestIdleLocked() {
+		p.mu.Unlock()
+		return nil, ErrPoolExhausted
+	}
+	s = &session{key: key, up: up, ready: make(chan struct{}), inUse: 1}
+	p.sessions[key] = s
+	p.mu.Unlock()
+
+	// Detach from the caller so a cancelled request does not fail other
+	// callers waiting on the same connect.
+	if err := p.open(context.WithoutCancel(ctx), s); err != nil {
+		p.release(s)
+		return nil, err
+	}
+	if s.exited != nil {
+		go p.supervise(s, restartBackoffMin)
+	}
+	return s, nil
+}
+
+// open connects the session and marks it ready. On failure the session is
+// removed from the pool.
+func (p *Pool) open(ctx context.Context, s *session) error {
+	s.notify = newNotifier()
+	cctx, cancel := context.WithTimeout(ctx, s.up.connectTimeout())
+	started := time.Now()
+	c, exited, err := p.connect(cctx, s.up)
+	cancel()
+	p.metrics.ObserveUpstreamConnect(s.up.transportLabel(),
+		connectStage(err), err, time.Since(started))
+	if err != nil {
+		err = &connectError{err: err}
+	}
+
+	p.mu.Lock()
+	s.client, s.exited, s.err = c, exited, err
+	if err != nil && p.sessions[s.key] == s {
+		delete(p.sessions, s.key)
+	}
+	close(s.ready)
+	p.mu.Unlock()
+
+	if err != nil {
+		p.logger.Error("UPSTREAM_SESSION_CONNECT_ERROR",
+			"upstream", s.up.String(), "error", err)
+		return err
+	}
+	c.OnNotification(func(note mcp.JSONRPCNotification) {
+		p.mu.Lock()
+		sole := s.inUse == 1
+		p.mu.Unlock()
+		s.notify.dispatch(note, sole)
+	})
+	// Answer the upstream's own requests through the calls that caused
+	// them rather than mcp-go's handlers.
+	if bt, ok := c.GetTransport().(transport.BidirectionalInterface); ok {
+		bt.SetRequestHandler(func(
+			ctx context.Context, req transport.JSONRPCRequest,
+		) (*transport.JSONRPCResponse, error) {
+			p.mu.Lock()
+			sole := s.inUse == 1
+			p.mu.Unlock()
+			return s.notify.request(ctx, req, sole)
+		})
+	}
+	p.logger.Info("UPSTREAM_SESSION_CONNECTED", "upstream", s.up.String())
+	return nil
+}
+
+// supervise waits for a stdio session's process to exit and restarts it
+// with exponential backoff while the session is still wanted. A process
+// that stayed up for longer than the maximum backoff resets the delay.
+func (p *Pool) supervise(s *session, backoff time.Duration) {
+	for {
+		started := time.Now()
+		select {
+		case <-p.stop:
+			return
+		case <-s.exited:
+		}
+
+		p.mu.Lock()
+		current := p.sessions[s.key] == s && !s.broken
+		recent := s.inUse > 0 || time.Since(s.lastUsed) < p.idleTimeout
+		p.mu.Unlock()
+		if !current {
+			// Closed or replaced on purpose; nothing to restart.
+			return
+		}
+		p.logger.Warn("UPSTREAM_STDIO_EXITED", "upstream", s.up.String())
+		p.invalidate(s)
+		if !recent {
+			return
+		}
+		if time.Since(started) > restartBackoffMax {
+			backoff = restartBackoffMin
+		}
+		if s = p.restart(s, &backoff); s == nil {
+			return
+		}
+		p.logger.Info("UPSTREAM_STDIO_RESTARTED", "upstream", s.up.String())
+	}
+}
+
+// restart reconnects an exited stdio session, doubling backoff after each
+// attempt. It returns nil if the pool stopped or another caller already
+// reconnected.
+func (p *Pool) restart(old *session, backoff *time.Duration) *session {
+	for {
+		select {
+		case <-p.stop:
+			return nil
+		case <-time.After(*backoff):
+		}
+		*backoff = min(*backoff*2, restartBackoffMax)
+
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return nil
+		}
+		if _, ok := p.sessions[old.key]; ok {
+			p.mu.Unlock()
+			return nil
+		}
+		next := &session{
+			key:      old.key,
+			up:       old.up,
+			ready:    make(chan struct{}),
+			lastUsed: old.lastUsed,
+		}
+		p.sessions[old.key] = next
+		p.mu.Unlock()
+
+		if err := p.open(context.Background(), next); err == nil {
+			return next
+		}
+	}
+}
+
+// release marks one use of the session as finished. Broken sessions are
+// closed once the last user releases them.
+func (p *Pool) release(s *session) {
+	p.mu.Lock()
+	s.inUse--
+	s.lastUsed = time.Now()
+	closeNow := s.broken && s.inUse == 0 && s.client != nil
+	p.mu.Unlock()
+	if closeNow {
+		_ = s.client.Close()
+	}
+}
+
+// invalidate removes the session from the pool so the next caller
+// reconnects. Idle sessions are closed immediately.
+func (p *Pool) invalidate(s *session) {
+	p.mu.Lock()
+	s.broken = true
+	if p.sessions[s.key] == s {
+		delete(p.sessions, s.key)
+	}
+	closeNow := s.inUse == 0 && s.client != nil
+	p.mu.Unlock()
+	if closeNow {
+		_ = s.client.Close()
+	}
+}
+
+// evictOldestIdleLocked drops the least recently used idle session to make
+// room for a new one. Caller must hold p.mu.
+func (p *Pool) evictOldestIdleLocked() bool {
+	var oldest *session
+	for _, s := range p.sessions {
+		if s.inUse > 0 || s.client == nil {
+			continue
+		}
+		if oldest == nil || s.lastUsed.Before(oldest.lastUsed) {
+			oldest = s
+		}
+	}
+	if oldest == nil {
+		return false
+	}
+	delete(p.sessions, oldest.key)
+	go func() { _ = oldest.client.Close() }()
+	return true
+}
+
+// janitor periodically evicts idle sessions and pings the rest.
+func (p *Pool) janitor() {
+	defer close(p.done)
+	t := time.NewTicker(p.healthInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-t.C:
+			p.sweep()
+		}
+	}
+}
+
+// sweep closes sessions idle past the idle timeout and pings the others,
+// with at most pingConcurrency pings in flight so hung upstreams do not
+// hold up the rest.
+func (p *Pool) sweep() {
+	now := time.Now()
+	var expired, check []*session
+	p.mu.Lock()
+	for k, s := range p.sessions {
+		if s.inUse > 0 || s.client == nil {
+			continue
+		}
+		if now.Sub(s.lastUsed) >= p.idleTimeout {
+			delete(p.sessions, k)
+			expired = append(expired, s)
+			continue
+		}
+		check = append(check, s)
+	}
+	p.mu.Unlock()
+
+	for _, s := range expired {
+		p.logger.Info("UPSTREAM_SESSION_IDLE_EVICT", "upstream", s.up.String())
+		_ = s.client.Close()
+	}
+	sem := make(chan struct{}, pingConcurrency)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for _, s := range check {
+		select {
+		case <-p.stop:
+			return
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			p.ping(s)
+		}()
+	}
+}
+
+// ping checks an idle session and drops it when the upstream does not
+// answer.
+func (p *Pool) ping(s *session) {
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	err := s.client.Ping(ctx)
+	cancel()
+	if err != nil {
+		p.logger.Warn("UPSTREAM_SESSION_UNHEALTHY",
+			"upstream", s.up.String(), "error", err)
+		p.mu.Lock()
+		s.inUse++
+		p.mu.Unlock()
+		p.invalidate(s)
+		p.release(s)
+	}
+}
+
+// dead reports whether the session's process has exited.
+func (s *session) dead() bool {
+	if s.exited == nil {
+		return false
+	}
+	select {
+	case <-s.exited:
+		return true
+	default:
+		return false
+	}
+}
+
+// isUnsentError reports whether a connection error proves the request
+// never reached the upstream: it refused the session as unknown, or no
+// connection to it could be opened.
+func isUnsentError(err error) bool {
+	var op *net.OpError
+	return errors.Is(err, transport.ErrSessionTerminated) ||
+		(errors.As(err, &op) && op.Op == "dial")
+}
+
+// isConnectionError reports whether err came from the transport rather
+// than from a JSON-RPC error returned by the upstream.
+func isConnectionError(err error) bool {
+	var te *transport.Error
+	return errors.As(err, &te) ||
+		errors.Is(err, transport.ErrSessionTerminated) ||
+		errors.Is(err, errProcessExited)
+}
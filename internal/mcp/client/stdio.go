@@ -0,0 +1,138 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+)
+
+// stdioStopTimeout bounds how long Close waits for a process to exit after
+// its stdin is closed before killing it.
+const stdioStopTimeout = 5 * time.Second
+
+// errProcessExited is returned for requests in flight when the upstream
+// process exits.
+var errProcessExited = errors.New("stdio upstream process exited")
+
+// supervisedStdio wraps the stdio transport so the owner learns when the
+// process exits and in-flight requests fail fast instead of waiting for
+// their deadline.
+type supervisedStdio struct {
+	*transport.Stdio
+
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	exited chan struct{}
+}
+
+func newSupervisedStdio(
+	command string, args []string, env map[string]string,
+) *supervisedStdio {
+	s := &supervisedStdio{exited: make(chan struct{})}
+	s.Stdio = transport.NewStdioWithOptions(command, envList(env), args,
+		transport.WithCommandFunc(s.command))
+	return s
+}
+
+// command builds the process without a context so it is not killed when
+// the connect context ends.
+func (s *supervisedStdio) command(
+	_ context.Context, command string, env []string, args []string,
+) (*exec.Cmd, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Env = append(os.Environ(), env...)
+	s.mu.Lock()
+	s.cmd = cmd
+	s.mu.Unlock()
+	return cmd, nil
+}
+
+// Start spawns the process and begins watching it.
+func (s *supervisedStdio) Start(ctx context.Context) error {
+	if err := s.Stdio.Start(ctx); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	proc, path := s.cmd.Process, s.cmd.Path
+	s.mu.Unlock()
+	// Drain stderr so a chatty process never blocks on a full pipe.
+	go func() {
+		sc := bufio.NewScanner(s.Stderr())
+		for sc.Scan() {
+			slog.Debug("UPSTREAM_STDIO_STDERR",
+				"command", path, "line", sc.Text())
+		}
+	}()
+	go func() {
+		_, _ = proc.Wait()
+		close(s.exited)
+	}()
+	return nil
+}
+
+// SendRequest forwards to the stdio transport, aborting when the process
+// exits.
+func (s *supervisedStdio) SendRequest(
+	ctx context.Context, req transport.JSONRPCRequest,
+) (*transport.JSONRPCResponse, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go func() {
+		select {
+		case <-s.exited:
+			cancel(errProcessExited)
+		case <-ctx.Done():
+		}
+	}()
+	resp, err := s.Stdio.SendRequest(ctx, req)
+	if err != nil && errors.Is(context.Cause(ctx), errProcessExited) {
+		return nil, errProcessExited
+	}
+	return resp, err
+}
+
+// Close stops the process, killing it if it does not exit in time.
+func (s *supervisedStdio) Close() error {
+	done := make(chan error, 1)
+	go func() { done <- s.Stdio.Close() }()
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(stdioStopTimeout):
+		s.mu.Lock()
+		if s.cmd != nil && s.cmd.Process != nil {
+			_ = s.cmd.Process.Kill()
+		}
+		s.mu.Unlock()
+		err = <-done
+	}
+	// The watcher has already reaped the process; the transport's own wait
+	// reports that as an error.
+	select {
+	case <-s.exited:
+		return nil
+	default:
+		return err
+	}
+}
+
+// Exited is closed when the process ends.
+func (s *supervisedStdio) Exited() <-chan struct{} { return s.exited }
+
+// envList converts an env map to KEY=VALUE pairs in a stable order.
+func envList(env map[string]string) []string {
+	out := make([]string, 0, len(env))
+	for k, v := range env {
+		out = append(out, k+"="+v)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,237 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	mclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	ic "github.com/ChiragChiranjib/mcp-proxy/internal/httpclient"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/metrics"
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+// Upstream describes how to reach an upstream MCP server. HTTP transports
+// use URL and Headers; stdio uses Command, Args and Env.
+//
+// Sampling and Elicitation are advertised to the upstream as client
+// capabilities; its requests for them are relayed to the downstream client
+// of the call (see Pool.CallToolStream). The SSE transport cannot answer
+// server requests, so nothing is advertised over it.
+//
+// ServerID and Policy come from the catalog server and govern tool calls
+// made through a Pool; they do not affect which session is used. Nor do
+// CallHeaders, which HTTP transports send with the requests of one call on
+// top of Headers, such as a token identifying its caller.
+type Upstream struct {
+	Transport   m.Transport
+	URL         string
+	Headers     map[string]string
+	CallHeaders map[string]string
+	Command     string
+	Args        []string
+	Env         map[string]string
+	Sampling    bool
+	Elicitation bool
+	ServerID    string
+	Policy      m.CallPolicy
+}
+
+// NewUpstream builds an Upstream for a catalog server.
+func NewUpstream(srv m.MCPServer, headers map[string]string) Upstream {
+	up := newUpstream(
+		srv.Transport, srv.URL, srv.Command, srv.Args, srv.Env, headers)
+	up.ServerID, up.Policy = srv.ID, srv.CallPolicy
+	return up
+}
+
+// NewHubUpstream builds an Upstream for a hub server.
+func NewHubUpstream(
+	hub m.MCPHubServerAggregate, headers map[string]string,
+) Upstream {
+	up := newUpstream(
+		hub.Transport, hub.URL, hub.Command, hub.Args, hub.Env, headers)
+	up.ServerID, up.Policy = hub.MCPServerID, hub.CallPolicy
+	return up
+}
+
+func newUpstream(
+	t m.Transport,
+	url, command string,
+	args, env json.RawMessage,
+	headers map[string]string,
+) Upstream {
+	up := Upstream{
+		Transport: t,
+		URL:       url,
+		Headers:   headers,
+		Command:   command,
+	}
+	if len(args) > 0 {
+		_ = json.Unmarshal(args, &up.Args)
+	}
+	if len(env) > 0 {
+		_ = json.Unmarshal(env, &up.Env)
+	}
+	return up
+}
+
+// String returns a loggable description without credentials.
+func (u Upstream) String() string {
+	if u.Transport == m.TransportStdio {
+		return "stdio:" + u.Command
+	}
+	return u.URL
+}
+
+// key identifies the upstream, the credentials used to reach it and the
+// capabilities advertised to it.
+func (u Upstream) key() string {
+	h := sha256.New()
+	write := func(parts ...string) {
+		for _, p := range parts {
+			h.Write([]byte(p))
+			h.Write([]byte{0})
+		}
+	}
+	writeMap := func(kv map[string]string) {
+		keys := make([]string, 0, len(kv))
+		for k := range kv {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			write(k, kv[k])
+		}
+	}
+	write(string(u.Transport), u.Command)
+	write(u.Args...)
+	writeMap(u.Env)
+	writeMap(u.Headers)
+	caps := u.capabilities()
+	write(fmt.Sprint(caps.Sampling != nil, caps.Elicitation != nil))
+	return string(u.Transport) + "|" + u.URL + "#" +
+		hex.EncodeToString(h.Sum(nil))
+}
+
+// Connect starts and initializes a client for the upstream using the
+// connector matching its transport. Caller must Close via client.Close().
+func Connect(ctx context.Context, up Upstream) (*mclient.Client, error) {
+	c, _, err := dial(ctx, up)
+	return c, err
+}
+
+// dial connects to the upstream. For stdio it also returns a channel that
+// is closed when the spawned process exits.
+func dial(
+	ctx context.Context, up Upstream,
+) (*mclient.Client, <-chan struct{}, error) {
+	switch up.Transport {
+	case m.TransportStdio:
+		if up.Command == "" {
+			return nil, nil, fmt.Errorf("stdio upstream has no command")
+		}
+		trans := newSupervisedStdio(up.Command, up.Args, up.Env)
+		c := mclient.NewClient(trans)
+		// The process must outlive the connect context.
+		if err := c.Start(context.WithoutCancel(ctx)); err != nil {
+			return nil, nil, err
+		}
+		if err := initialize(ctx, c, up.capabilities()); err != nil {
+			_ = c.Close()
+			return nil, nil, err
+		}
+		return c, trans.Exited(), nil
+	case m.TransportSSE:
+		httpClient := ic.NewHTTPClient(ic.WithHeaders(up.Headers))
+		trans, err := transport.NewSSE(up.URL,
+			transport.WithHTTPClient(httpClient),
+			transport.WithHeaderFunc(callHeaders))
+		if err != nil {
+			return nil, nil, err
+		}
+		c := mclient.NewClient(trans)
+		// The SSE stream lives as long as the start context.
+		if err := c.Start(context.WithoutCancel(ctx)); err != nil {
+			_ = c.Close()
+			return nil, nil, err
+		}
+		if err := initialize(ctx, c, mcp.ClientCapabilities{}); err != nil {
+			_ = c.Close()
+			return nil, nil, err
+		}
+		return c, nil, nil
+	default:
+		c, err := connectStreamable(
+			ctx, up.URL, up.Headers, up.capabilities())
+		return c, nil, err
+	}
+}
+
+// capabilities returns the client capabilities advertised to the upstream.
+func (u Upstream) capabilities() mcp.ClientCapabilities {
+	var caps mcp.ClientCapabilities
+	if u.Transport == m.TransportSSE {
+		return caps
+	}
+	if u.Sampling {
+		caps.Sampling = &struct{}{}
+	}
+	if u.Elicitation {
+		caps.Elicitation = &struct{}{}
+	}
+	return caps
+}
+
+// initialize performs the MCP initialize handshake as the proxy client.
+func initialize(
+	ctx context.Context, c *mclient.Client, caps mcp.ClientCapabilities,
+) error {
+	_, err := c.Initialize(ctx, mcp.InitializeRequest{
+		Request: mcp.Request{Method: string(mcp.MethodInitialize)},
+		Params: mcp.InitializeParams{
+			ProtocolVersion: mcp.LATEST_PROTOCOL_VERSION,
+			ClientInfo: mcp.Implementation{
+				Name:    "mcp-proxy-client",
+				Version: "1.0.0",
+			},
+			Capabilities: caps,
+		},
+	})
+	if err != nil {
+		return &initializeError{err: err}
+	}
+	return nil
+}
+
+// initializeError marks a failed initialize handshake, as opposed to a
+// failure to reach or start the upstream.
+type initializeError struct{ err error }
+
+func (e *initializeError) Error() string { return e.err.Error() }
+
+func (e *initializeError) Unwrap() error { return e.err }
+
+// connectStage names the connect step that produced err.
+func connectStage(err error) string {
+	var ie *initializeError
+	if errors.As(err, &ie) {
+		return metrics.StageInitialize
+	}
+	return metrics.StageConnect
+}
+
+// transportLabel returns the upstream transport as a metrics label.
+func (up Upstream) transportLabel() string {
+	if up.Transport == "" {
+		return string(m.TransportStreamableHTTP)
+	}
+	return string(up.Transport)
+}
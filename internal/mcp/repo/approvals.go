@@ -0,0 +1,96 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+// ApprovalFilter narrows tool call approval queries. Empty fields are
+// ignored.
+type ApprovalFilter struct {
+	UserID          string
+	VirtualServerID string
+	Status          m.ApprovalStatus
+	Limit           int
+	Offset          int
+}
+
+// CreateToolCallApproval inserts an approval record.
+func (r *Repo) CreateToolCallApproval(
+	ctx context.Context, a m.ToolCallApproval) error {
+	return r.WithContext(ctx).Create(&a).Error
+}
+
+// GetToolCallApproval returns an approval by id.
+func (r *Repo) GetToolCallApproval(
+	ctx context.Context, id string) (m.ToolCallApproval, error) {
+	var a m.ToolCallApproval
+	err := r.WithContext(ctx).
+		Where("id = ?", id).
+		Take(&a).Error
+	return a, err
+}
+
+// ListToolCallApprovals returns a page of approvals, newest first, and the
+// total matching count.
+func (r *Repo) ListToolCallApprovals(
+	ctx context.Context, f ApprovalFilter) ([]m.ToolCallApproval, int64, error) {
+	qdb := r.WithContext(ctx).Model(&m.ToolCallApproval{})
+	if f.UserID != "" {
+		qdb = qdb.Where("user_id = ?", f.UserID)
+	}
+	if f.VirtualServerID != "" {
+		qdb = qdb.Where("virtual_server_id = ?", f.VirtualServerID)
+	}
+	if f.Status != "" {
+		qdb = qdb.Where("status = ?", f.Status)
+	}
+	var total int64
+	if err := qdb.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []m.ToolCallApproval
+	err := qdb.Order("created_at DESC, id DESC").
+		Limit(f.Limit).
+		Offset(f.Offset).
+		Find(&rows).Error
+	return rows, total, err
+}
+
+// DecideToolCallApproval moves a pending approval to status and reports
+// whether it was still pending. decidedBy is nil for decisions the proxy
+// makes itself.
+func (r *Repo) DecideToolCallApproval(
+	ctx context.Context,
+	id string,
+	status m.ApprovalStatus,
+	decidedBy *string,
+	reason string,
+	at time.Time,
+) (bool, error) {
+	res := r.WithContext(ctx).
+		Model(&m.ToolCallApproval{}).
+		Where("id = ? AND status = ?", id, m.ApprovalPending).
+		Updates(map[string]any{
+			"status":     status,
+			"decided_by": decidedBy,
+			"reason":     reason,
+			"decided_at": at,
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+// ExpireToolCallApprovals marks pending approvals past their deadline as
+// expired, such as those left behind by a replica that stopped.
+func (r *Repo) ExpireToolCallApprovals(
+	ctx context.Context, now time.Time) error {
+	return r.WithContext(ctx).
+		Model(&m.ToolCallApproval{}).
+		Where("status = ? AND expires_at < ?", m.ApprovalPending, now).
+		Updates(map[string]any{
+			"status":     m.ApprovalExpired,
+			"decided_at": now,
+		}).Error
+}
@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+// AuditFilter narrows tool call audit queries. Empty fields are ignored.
+type AuditFilter struct {
+	UserID          string
+	VirtualServerID string
+	HubServerID     string
+	ToolID          string
+	IsError         *bool
+	Since           time.Time
+	Until           time.Time
+	Limit           int
+	Offset          int
+}
+
+// CreateToolCallAudits inserts audit records in bulk.
+func (r *Repo) CreateToolCallAudits(
+	ctx context.Context, recs []m.ToolCallAudit) error {
+	if len(recs) == 0 {
+		return nil
+	}
+	return r.WithContext(ctx).CreateInBatches(&recs, 200).Error
+}
+
+// ListToolCallAudits returns a page of audit records, newest first, and the
+// total matching count.
+func (r *Repo) ListToolCallAudits(
+	ctx context.Context, f AuditFilter) ([]m.ToolCallAudit, int64, error) {
+	qdb := r.WithContext(ctx).Model(&m.ToolCallAudit{})
+	if f.UserID != "" {
+		qdb = qdb.Where("user_id = ?", f.UserID)
+	}
+	if f.VirtualServerID != "" {
+		qdb = qdb.Where("virtual_server_id = ?", f.VirtualServerID)
+	}
+	if f.HubServerID != "" {
+		qdb = qdb.Where("hub_server_id = ?", f.HubServerID)
+	}
+	if f.ToolID != "" {
+		qdb = qdb.Where("tool_id = ?", f.ToolID)
+	}
+	if f.IsError != nil {
+		qdb = qdb.Where("is_error = ?", *f.IsError)
+	}
+	if !f.Since.IsZero() {
+		qdb = qdb.Where("created_at >= ?", f.Since)
+	}
+	if !f.Until.IsZero() {
+		qdb = qdb.Where("created_at < ?", f.Until)
+	}
+	var total int64
+	if err := qdb.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []m.ToolCallAudit
+	err := qdb.Order("created_at DESC, id DESC").
+		Limit(f.Limit).
+		Offset(f.Offset).
+		Find(&rows).Error
+	return rows, total, err
+}
@@ -54,6 +54,35 @@ func (r *Repo) UpdateCatalogServerURLDesc(
 		Updates(updates).Error
 }
 
+// UpdateCatalogServerCallPolicy replaces the call policy of a catalog
+// server, including zero values.
+func (r *Repo) UpdateCatalogServerCallPolicy(
+	ctx context.Context,
+	id string,
+	p m.CallPolicy,
+) error {
+	return r.WithContext(ctx).
+		Model(&m.MCPServer{}).
+		Where("id = ?", id).
+		Select("call_timeout_seconds", "connect_timeout_seconds",
+			"max_retries", "retry_backoff_ms",
+			"breaker_threshold", "breaker_cooldown_seconds").
+		Updates(m.MCPServer{CallPolicy: p}).Error
+}
+
+// UpdateCatalogServerIdentityHeader sets the header a catalog server's
+// calls carry the caller's identity token in; empty stops sending it.
+func (r *Repo) UpdateCatalogServerIdentityHeader(
+	ctx context.Context,
+	id string,
+	header string,
+) error {
+	return r.WithContext(ctx).
+		Model(&m.MCPServer{}).
+		Where("id = ?", id).
+		Update("identity_header", header).Error
+}
+
 // UpdateCatalogServerCapabilities updates capabilities and transport for a catalog server.
 func (r *Repo) UpdateCatalogServerCapabilities(
 	ctx context.Context,
@@ -6,6 +6,27 @@ import (
 	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
 )
 
+// maxAuthErrorLen matches the auth_error column width.
+const maxAuthErrorLen = 2000
+
+// hubAggregateColumns selects hub fields plus the catalogue server fields
+// flattened into m.MCPHubServerAggregate.
+const hubAggregateColumns = "h.id, h.user_id, h.team_id, h.mcp_server_id, " +
+	"h.status, h.auth_type, h.auth_value, h.auth_error, h.last_refreshed_at, " +
+	"h.last_refresh_status, h.last_refresh_error, h.last_probe_at, " +
+	"h.last_probe_latency_ms, h.last_probe_error, h.probe_failures, " +
+	"h.probe_successes, h.created_at, h.updated_at, " +
+	"s.name AS name, s.url AS url, s.description AS description, " +
+	"s.capabilities AS capabilities, s.transport AS transport, " +
+	"s.command AS command, s.args AS args, s.env AS env, " +
+	"s.access_type AS access_type, " +
+	"s.call_timeout_seconds AS call_timeout_seconds, " +
+	"s.connect_timeout_seconds AS connect_timeout_seconds, " +
+	"s.max_retries AS max_retries, s.retry_backoff_ms AS retry_backoff_ms, " +
+	"s.breaker_threshold AS breaker_threshold, " +
+	"s.breaker_cooldown_seconds AS breaker_cooldown_seconds, " +
+	"s.identity_header AS identity_header"
+
 // CreateMCPHubServer ...
 func (r *Repo) CreateMCPHubServer(
 	ctx context.Context, h m.MCPHubServer) error {
@@ -35,27 +56,38 @@ func (r *Repo) GetHubServerWithURL(
 	var out m.MCPHubServerAggregate
 	err := r.WithContext(ctx).
 		Table("mcp_hub_servers h").
-		Select("h.id, h.user_id, h.mcp_server_id, h.status, "+
-			"h.auth_type, h.auth_value, h.created_at, h.updated_at, "+
-			"s.name AS name, s.url AS url, s.description AS description, "+
-			"s.capabilities AS capabilities, s.transport AS transport, s.access_type AS access_type").
+		Select(hubAggregateColumns).
 		Joins("JOIN mcp_servers s ON s.id = h.mcp_server_id").
 		Where("h.id = ?", id).Scan(&out).Error
 	return out, err
 }
 
-// ListUserHubMCPServers ...
+// ListUserHubMCPServers lists a user's personal hubs.
 func (r *Repo) ListUserHubMCPServers(
 	ctx context.Context, userID string) ([]m.MCPHubServerAggregate, error) {
 	var rows []m.MCPHubServerAggregate
 	err := r.WithContext(ctx).
 		Table("mcp_hub_servers h").
-		Select("h.id, h.user_id, h.mcp_server_id, h.status, "+
-			"h.auth_type, h.auth_value, h.created_at, h.updated_at, "+
-			"s.name AS name, s.url AS url, s.description AS description, "+
-			"s.capabilities AS capabilities, s.transport AS transport, s.access_type AS access_type").
+		Select(hubAggregateColumns).
+		Joins("JOIN mcp_servers s ON s.id = h.mcp_server_id").
+		Where("h.user_id = ? AND h.team_id IS NULL", userID).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// ListTeamHubMCPServers lists the hubs of the given teams.
+func (r *Repo) ListTeamHubMCPServers(
+	ctx context.Context, teamIDs []string,
+) ([]m.MCPHubServerAggregate, error) {
+	var rows []m.MCPHubServerAggregate
+	if len(teamIDs) == 0 {
+		return rows, nil
+	}
+	err := r.WithContext(ctx).
+		Table("mcp_hub_servers h").
+		Select(hubAggregateColumns).
 		Joins("JOIN mcp_servers s ON s.id = h.mcp_server_id").
-		Where("h.user_id = ?", userID).
+		Where("h.team_id IN ?", teamIDs).
 		Scan(&rows).Error
 	return rows, err
 }
@@ -69,6 +101,53 @@ func (r *Repo) UpdateHubServerStatus(
 		Update("status", status).Error
 }
 
+// GetHubAuthValue returns the stored auth value of a hub.
+func (r *Repo) GetHubAuthValue(ctx context.Context, id string) ([]byte, error) {
+	var h m.MCPHubServer
+	err := r.WithContext(ctx).
+		Select("auth_value").
+		Where("id = ?", id).
+		Take(&h).Error
+	return h.AuthValue, err
+}
+
+// UpdateHubAuthValue replaces the stored auth value of a hub.
+func (r *Repo) UpdateHubAuthValue(
+	ctx context.Context, id string, value []byte) error {
+	return r.WithContext(ctx).
+		Table("mcp_hub_servers").
+		Where("id = ?", id).
+		Update("auth_value", value).Error
+}
+
+// MarkHubNeedsAuth moves a hub to NEEDS_AUTH, recording why.
+func (r *Repo) MarkHubNeedsAuth(ctx context.Context, id, reason string) error {
+	if len(reason) > maxAuthErrorLen {
+		reason = reason[:maxAuthErrorLen]
+	}
+	return r.WithContext(ctx).
+		Table("mcp_hub_servers").
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":     m.StatusNeedsAuth,
+			"auth_error": reason,
+		}).Error
+}
+
+// SetHubAuthorized stores newly authorized credentials of a hub and makes
+// it ACTIVE again.
+func (r *Repo) SetHubAuthorized(
+	ctx context.Context, id string, value []byte) error {
+	return r.WithContext(ctx).
+		Table("mcp_hub_servers").
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"auth_value": value,
+			"status":     m.StatusActive,
+			"auth_error": "",
+		}).Error
+}
+
 // DeleteHubServer ...
 func (r *Repo) DeleteHubServer(
 	ctx context.Context, id string) error {
@@ -76,20 +155,32 @@ func (r *Repo) DeleteHubServer(
 		Delete(&m.MCPHubServer{ID: id}).Error
 }
 
-// GetHubServerByServerAndUser gets a hub server by server ID and user ID.
+// GetHubServerByServerAndUser gets a user's personal hub server by server
+// ID and user ID.
 func (r *Repo) GetHubServerByServerAndUser(
 	ctx context.Context, serverID, userID string) (m.MCPHubServerAggregate, error) {
 	var result m.MCPHubServerAggregate
 	err := r.WithContext(ctx).
 		Table("mcp_hub_servers h").
-		Select(`
-			h.id, h.user_id, h.mcp_server_id, h.status, h.auth_type, h.auth_value,
-			h.created_at, h.updated_at,
-			s.name AS name, s.url AS url, s.description AS description,
-			s.capabilities AS capabilities, s.transport AS transport, s.access_type AS access_type
-		`).
-		Joins("JOIN mcp_servers s ON h.mcp_server_id = s.id").
+		Select(hubAggregateColumns).
+		Joins("JOIN mcp_servers s ON s.id = h.mcp_server_id").
 		Where("h.mcp_server_id = ? AND h.user_id = ?", serverID, userID).
+		Where("h.team_id IS NULL").
+		Take(&result).Error
+	return result, err
+}
+
+// GetHubServerByServerAndTeam gets a team's hub server by server ID and
+// team ID.
+func (r *Repo) GetHubServerByServerAndTeam(
+	ctx context.Context, serverID, teamID string,
+) (m.MCPHubServerAggregate, error) {
+	var result m.MCPHubServerAggregate
+	err := r.WithContext(ctx).
+		Table("mcp_hub_servers h").
+		Select(hubAggregateColumns).
+		Joins("JOIN mcp_servers s ON s.id = h.mcp_server_id").
+		Where("h.mcp_server_id = ? AND h.team_id = ?", serverID, teamID).
 		Take(&result).Error
 	return result, err
 }
@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+// CreateVirtualServerKey inserts an API key record.
+func (r *Repo) CreateVirtualServerKey(
+	ctx context.Context, k m.VirtualServerKey) error {
+	return r.WithContext(ctx).Create(&k).Error
+}
+
+// ListVirtualServerKeys returns all keys of a virtual server, newest first.
+func (r *Repo) ListVirtualServerKeys(
+	ctx context.Context, vsID string) ([]m.VirtualServerKey, error) {
+	var rows []m.VirtualServerKey
+	err := r.WithContext(ctx).
+		Where("mcp_virtual_server_id = ?", vsID).
+		Order("created_at DESC").
+		Find(&rows).Error
+	return rows, err
+}
+
+// GetVirtualServerKey returns a key by id scoped to its virtual server.
+func (r *Repo) GetVirtualServerKey(
+	ctx context.Context, vsID, keyID string) (m.VirtualServerKey, error) {
+	var k m.VirtualServerKey
+	err := r.WithContext(ctx).
+		Where("id = ? AND mcp_virtual_server_id = ?", keyID, vsID).
+		Take(&k).Error
+	return k, err
+}
+
+// GetActiveVirtualServerKeyByHash returns an ACTIVE key of a virtual server
+// matching the given hash.
+func (r *Repo) GetActiveVirtualServerKeyByHash(
+	ctx context.Context, vsID, hash string) (m.VirtualServerKey, error) {
+	var k m.VirtualServerKey
+	err := r.WithContext(ctx).
+		Where("mcp_virtual_server_id = ? AND key_hash = ? AND status = ?",
+			vsID, hash, m.StatusActive).
+		Take(&k).Error
+	return k, err
+}
+
+// RevokeVirtualServerKey deactivates a key and stamps its revocation time.
+func (r *Repo) RevokeVirtualServerKey(
+	ctx context.Context, vsID, keyID string, at time.Time) error {
+	return r.WithContext(ctx).
+		Model(&m.VirtualServerKey{}).
+		Where("id = ? AND mcp_virtual_server_id = ?", keyID, vsID).
+		Updates(map[string]any{
+			"status":     m.StatusDeactivated,
+			"revoked_at": at,
+		}).Error
+}
+
+// TouchVirtualServerKey records the last time a key was used.
+func (r *Repo) TouchVirtualServerKey(
+	ctx context.Context, keyID string, at time.Time) error {
+	return r.WithContext(ctx).
+		Model(&m.VirtualServerKey{}).
+		Where("id = ?", keyID).
+		UpdateColumn("last_used_at", at).Error
+}
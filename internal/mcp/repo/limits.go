@@ -0,0 +1,101 @@
+package repo
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+// GetRateLimit returns the limit configured for a scope.
+func (r *Repo) GetRateLimit(
+	ctx context.Context, scope m.LimitScope, scopeID string,
+) (m.RateLimit, error) {
+	var l m.RateLimit
+	err := r.WithContext(ctx).
+		Where("scope = ? AND scope_id = ?", scope, scopeID).
+		Take(&l).Error
+	return l, err
+}
+
+// UpsertRateLimit creates or replaces the limit of a scope.
+func (r *Repo) UpsertRateLimit(ctx context.Context, l m.RateLimit) error {
+	return r.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "scope"}, {Name: "scope_id"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"rate_per_minute": l.RatePerMinute,
+			"burst":           l.Burst,
+			"daily_quota":     l.DailyQuota,
+			"monthly_quota":   l.MonthlyQuota,
+		}),
+	}).Create(&l).Error
+}
+
+// DeleteRateLimit ...
+func (r *Repo) DeleteRateLimit(
+	ctx context.Context, scope m.LimitScope, scopeID string) error {
+	return r.WithContext(ctx).
+		Where("scope = ? AND scope_id = ?", scope, scopeID).
+		Delete(&m.RateLimit{}).Error
+}
+
+// GetCallUsage returns the calls counted for a scope in each of the given
+// periods. Periods without calls are absent.
+func (r *Repo) GetCallUsage(
+	ctx context.Context, scope m.LimitScope, scopeID string, periods []string,
+) (map[string]int64, error) {
+	var rows []m.CallUsage
+	err := r.WithContext(ctx).
+		Where("scope = ? AND scope_id = ? AND period IN ?",
+			scope, scopeID, periods).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]int64, len(rows))
+	for _, u := range rows {
+		out[u.Period] = u.Calls
+	}
+	return out, nil
+}
+
+// CountCallWithinQuota counts one call for a scope in each of the given
+// periods unless that would take a period past its quota, zero being none.
+// Each period is a single conditional increment, so concurrent callers on
+// any replica cannot all pass a quota with one call left. It returns the
+// first period over quota; the caller's transaction must then be rolled
+// back to undo the periods counted before it.
+func (r *Repo) CountCallWithinQuota(
+	ctx context.Context, scope m.LimitScope, scopeID string,
+	periods []string, quotas []int64,
+) (string, error) {
+	rows := make([]m.CallUsage, 0, len(periods))
+	for _, p := range periods {
+		rows = append(rows,
+			m.CallUsage{Scope: scope, ScopeID: scopeID, Period: p})
+	}
+	if err := r.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&rows).Error; err != nil {
+		return "", err
+	}
+	for i, p := range periods {
+		q := r.WithContext(ctx).
+			Model(&m.CallUsage{}).
+			Where("scope = ? AND scope_id = ? AND period = ?",
+				scope, scopeID, p)
+		if quotas[i] > 0 {
+			q = q.Where("calls < ?", quotas[i])
+		}
+		res := q.Updates(map[string]any{"calls": gorm.Expr("calls + 1")})
+		if res.Error != nil {
+			return "", res.Error
+		}
+		if res.RowsAffected == 0 {
+			return p, nil
+		}
+	}
+	return "", nil
+}
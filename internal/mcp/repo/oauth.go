@@ -0,0 +1,101 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+// CreateOAuthClient inserts a registered OAuth client.
+func (r *Repo) CreateOAuthClient(ctx context.Context, c m.OAuthClient) error {
+	return r.WithContext(ctx).Create(&c).Error
+}
+
+// GetOAuthClient returns an OAuth client by id.
+func (r *Repo) GetOAuthClient(
+	ctx context.Context, id string) (m.OAuthClient, error) {
+	var c m.OAuthClient
+	err := r.WithContext(ctx).
+		Where("id = ?", id).
+		Take(&c).Error
+	return c, err
+}
+
+// CreateOAuthCode inserts an authorization code.
+func (r *Repo) CreateOAuthCode(ctx context.Context, c m.OAuthCode) error {
+	return r.WithContext(ctx).Create(&c).Error
+}
+
+// GetOAuthCodeByHash returns an authorization code by its hash.
+func (r *Repo) GetOAuthCodeByHash(
+	ctx context.Context, hash string) (m.OAuthCode, error) {
+	var c m.OAuthCode
+	err := r.WithContext(ctx).
+		Where("code_hash = ?", hash).
+		Take(&c).Error
+	return c, err
+}
+
+// UseOAuthCode marks an authorization code used and reports whether it was
+// still unused.
+func (r *Repo) UseOAuthCode(
+	ctx context.Context, id string, at time.Time) (bool, error) {
+	res := r.WithContext(ctx).
+		Model(&m.OAuthCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", at)
+	return res.RowsAffected > 0, res.Error
+}
+
+// CreateOAuthToken inserts an access or refresh token.
+func (r *Repo) CreateOAuthToken(ctx context.Context, t m.OAuthToken) error {
+	return r.WithContext(ctx).Create(&t).Error
+}
+
+// GetOAuthTokenByHash returns a token of the given kind by its hash,
+// whether or not it is revoked or expired.
+func (r *Repo) GetOAuthTokenByHash(
+	ctx context.Context, kind m.OAuthTokenKind, hash string,
+) (m.OAuthToken, error) {
+	var t m.OAuthToken
+	err := r.WithContext(ctx).
+		Where("kind = ? AND token_hash = ?", kind, hash).
+		Take(&t).Error
+	return t, err
+}
+
+// RevokeOAuthToken revokes one token and reports whether it was still
+// active.
+func (r *Repo) RevokeOAuthToken(
+	ctx context.Context, id string, at time.Time) (bool, error) {
+	res := r.WithContext(ctx).
+		Model(&m.OAuthToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at)
+	return res.RowsAffected > 0, res.Error
+}
+
+// RevokeOAuthGrant revokes every token issued from one authorization code.
+func (r *Repo) RevokeOAuthGrant(
+	ctx context.Context, grantID string, at time.Time) error {
+	return r.WithContext(ctx).
+		Model(&m.OAuthToken{}).
+		Where("grant_id = ? AND revoked_at IS NULL", grantID).
+		Update("revoked_at", at).Error
+}
+
+// DeleteExpiredOAuth removes authorization codes and tokens that expired
+// before cutoff.
+func (r *Repo) DeleteExpiredOAuth(ctx context.Context, cutoff time.Time) error {
+	return r.Transaction(func(tx *Repo) error {
+		if err := tx.WithContext(ctx).
+			Where("expires_at < ?", cutoff).
+			Delete(&m.OAuthCode{}).Error; err != nil {
+			return err
+		}
+		return tx.WithContext(ctx).
+			Where("expires_at < ?", cutoff).
+			Delete(&m.OAuthToken{}).Error
+	})
+}
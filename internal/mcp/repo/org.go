@@ -0,0 +1,290 @@
+package repo
+
+import (
+	"context"
+
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+// teamRoleColumn is a user's effective role in a team: owner when they own
+// its organization, else their team role. It expects the team as t, the
+// team membership as tm and the organization membership as om.
+const teamRoleColumn = "CASE WHEN om.role = 'owner' THEN 'owner' " +
+	"ELSE tm.role END AS role"
+
+// CreateOrganization creates an organization with its first owner.
+func (r *Repo) CreateOrganization(
+	ctx context.Context, o m.Organization, owner m.OrganizationMember,
+) error {
+	return r.Transaction(func(tx *Repo) error {
+		if err := tx.WithContext(ctx).Create(&o).Error; err != nil {
+			return err
+		}
+		return tx.WithContext(ctx).Create(&owner).Error
+	})
+}
+
+// GetOrganization ...
+func (r *Repo) GetOrganization(
+	ctx context.Context, id string) (m.Organization, error) {
+	var o m.Organization
+	err := r.WithContext(ctx).Where("id = ?", id).Take(&o).Error
+	return o, err
+}
+
+// ListOrganizationsForUser lists the organizations a user is a member of
+// with their role, or every organization when all is set.
+func (r *Repo) ListOrganizationsForUser(
+	ctx context.Context, userID string, all bool,
+) ([]m.OrganizationWithRole, error) {
+	qdb := r.WithContext(ctx).
+		Table("organizations o").
+		Select("o.*, om.role AS role").
+		Joins("LEFT JOIN organization_members om "+
+			"ON om.organization_id = o.id AND om.user_id = ?", userID)
+	if !all {
+		qdb = qdb.Where("om.user_id IS NOT NULL")
+	}
+	var rows []m.OrganizationWithRole
+	err := qdb.Order("o.name").Scan(&rows).Error
+	return rows, err
+}
+
+// UpdateOrganizationName ...
+func (r *Repo) UpdateOrganizationName(
+	ctx context.Context, id, name string) error {
+	return r.WithContext(ctx).
+		Table("organizations").
+		Where("id = ?", id).
+		Update("name", name).Error
+}
+
+// DeleteOrganization deletes an organization and its memberships.
+func (r *Repo) DeleteOrganization(ctx context.Context, id string) error {
+	return r.WithContext(ctx).Delete(&m.Organization{ID: id}).Error
+}
+
+// CountTeamsInOrganization ...
+func (r *Repo) CountTeamsInOrganization(
+	ctx context.Context, orgID string) (int64, error) {
+	var n int64
+	err := r.WithContext(ctx).
+		Model(&m.Team{}).
+		Where("organization_id = ?", orgID).
+		Count(&n).Error
+	return n, err
+}
+
+// GetOrganizationMember ...
+func (r *Repo) GetOrganizationMember(
+	ctx context.Context, orgID, userID string,
+) (m.OrganizationMember, error) {
+	var om m.OrganizationMember
+	err := r.WithContext(ctx).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Take(&om).Error
+	return om, err
+}
+
+// ListOrganizationMembers ...
+func (r *Repo) ListOrganizationMembers(
+	ctx context.Context, orgID string) ([]m.Member, error) {
+	var rows []m.Member
+	err := r.WithContext(ctx).
+		Table("organization_members om").
+		Select("om.user_id, u.username, om.role, om.created_at").
+		Joins("JOIN users u ON u.id = om.user_id").
+		Where("om.organization_id = ?", orgID).
+		Order("u.username").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// CountOrganizationOwners ...
+func (r *Repo) CountOrganizationOwners(
+	ctx context.Context, orgID string) (int64, error) {
+	var n int64
+	err := r.WithContext(ctx).
+		Model(&m.OrganizationMember{}).
+		Where("organization_id = ? AND role = ?", orgID, m.MemberRoleOwner).
+		Count(&n).Error
+	return n, err
+}
+
+// CreateOrganizationMember ...
+func (r *Repo) CreateOrganizationMember(
+	ctx context.Context, om m.OrganizationMember) error {
+	return r.WithContext(ctx).Create(&om).Error
+}
+
+// UpdateOrganizationMemberRole ...
+func (r *Repo) UpdateOrganizationMemberRole(
+	ctx context.Context, orgID, userID string, role m.MemberRole) error {
+	return r.WithContext(ctx).
+		Model(&m.OrganizationMember{}).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Update("role", role).Error
+}
+
+// DeleteOrganizationMember removes a user from an organization and from
+// all of its teams.
+func (r *Repo) DeleteOrganizationMember(
+	ctx context.Context, orgID, userID string) error {
+	return r.Transaction(func(tx *Repo) error {
+		if err := tx.WithContext(ctx).
+			Where("user_id = ? AND team_id IN (?)", userID,
+				tx.WithContext(ctx).Model(&m.Team{}).Select("id").
+					Where("organization_id = ?", orgID)).
+			Delete(&m.TeamMember{}).Error; err != nil {
+			return err
+		}
+		return tx.WithContext(ctx).
+			Where("organization_id = ? AND user_id = ?", orgID, userID).
+			Delete(&m.OrganizationMember{}).Error
+	})
+}
+
+// CreateTeam creates a team, with its first owner when owner is set.
+func (r *Repo) CreateTeam(
+	ctx context.Context, t m.Team, owner *m.TeamMember) error {
+	return r.Transaction(func(tx *Repo) error {
+		if err := tx.WithContext(ctx).Create(&t).Error; err != nil {
+			return err
+		}
+		if owner == nil {
+			return nil
+		}
+		return tx.WithContext(ctx).Create(owner).Error
+	})
+}
+
+// GetTeam ...
+func (r *Repo) GetTeam(ctx context.Context, id string) (m.Team, error) {
+	var t m.Team
+	err := r.WithContext(ctx).Where("id = ?", id).Take(&t).Error
+	return t, err
+}
+
+// ListTeamsForUser lists the teams a user has a role in, as a member or
+// an owner of the organization, or every team when all is set. An
+// organization limits the list to its teams.
+func (r *Repo) ListTeamsForUser(
+	ctx context.Context, userID, orgID string, all bool,
+) ([]m.TeamWithRole, error) {
+	qdb := r.WithContext(ctx).
+		Table("teams t").
+		Select("t.*, o.name AS organization_name, "+teamRoleColumn).
+		Joins("JOIN organizations o ON o.id = t.organization_id").
+		Joins("LEFT JOIN team_members tm "+
+			"ON tm.team_id = t.id AND tm.user_id = ?", userID).
+		Joins("LEFT JOIN organization_members om "+
+			"ON om.organization_id = t.organization_id AND om.user_id = ?",
+			userID)
+	if !all {
+		qdb = qdb.Where("tm.user_id IS NOT NULL OR om.role = ?",
+			m.MemberRoleOwner)
+	}
+	if orgID != "" {
+		qdb = qdb.Where("t.organization_id = ?", orgID)
+	}
+	var rows []m.TeamWithRole
+	err := qdb.Order("o.name, t.name").Scan(&rows).Error
+	return rows, err
+}
+
+// GetTeamRole returns a user's effective role in a team; it is empty when
+// they have none.
+func (r *Repo) GetTeamRole(
+	ctx context.Context, teamID, userID string) (m.MemberRole, error) {
+	var rows []m.TeamWithRole
+	err := r.WithContext(ctx).
+		Table("teams t").
+		Select("t.id, "+teamRoleColumn).
+		Joins("LEFT JOIN team_members tm "+
+			"ON tm.team_id = t.id AND tm.user_id = ?", userID).
+		Joins("LEFT JOIN organization_members om "+
+			"ON om.organization_id = t.organization_id AND om.user_id = ?",
+			userID).
+		Where("t.id = ?", teamID).
+		Scan(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return "", err
+	}
+	return rows[0].Role, nil
+}
+
+// UpdateTeamName ...
+func (r *Repo) UpdateTeamName(ctx context.Context, id, name string) error {
+	return r.WithContext(ctx).
+		Table("teams").
+		Where("id = ?", id).
+		Update("name", name).Error
+}
+
+// DeleteTeam deletes a team and its memberships.
+func (r *Repo) DeleteTeam(ctx context.Context, id string) error {
+	return r.WithContext(ctx).Delete(&m.Team{ID: id}).Error
+}
+
+// CountTeamServers counts the hubs and virtual servers a team owns.
+func (r *Repo) CountTeamServers(
+	ctx context.Context, teamID string) (int64, error) {
+	var hubs, vss int64
+	if err := r.WithContext(ctx).
+		Model(&m.MCPHubServer{}).
+		Where("team_id = ?", teamID).
+		Count(&hubs).Error; err != nil {
+		return 0, err
+	}
+	err := r.WithContext(ctx).
+		Model(&m.MCPVirtualServer{}).
+		Where("team_id = ?", teamID).
+		Count(&vss).Error
+	return hubs + vss, err
+}
+
+// ListTeamMembers ...
+func (r *Repo) ListTeamMembers(
+	ctx context.Context, teamID string) ([]m.Member, error) {
+	var rows []m.Member
+	err := r.WithContext(ctx).
+		Table("team_members tm").
+		Select("tm.user_id, u.username, tm.role, tm.created_at").
+		Joins("JOIN users u ON u.id = tm.user_id").
+		Where("tm.team_id = ?", teamID).
+		Order("u.username").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// GetTeamMember ...
+func (r *Repo) GetTeamMember(
+	ctx context.Context, teamID, userID string) (m.TeamMember, error) {
+	var tm m.TeamMember
+	err := r.WithContext(ctx).
+		Where("team_id = ? AND user_id = ?", teamID, userID).
+		Take(&tm).Error
+	return tm, err
+}
+
+// CreateTeamMember ...
+func (r *Repo) CreateTeamMember(ctx context.Context, tm m.TeamMember) error {
+	return r.WithContext(ctx).Create(&tm).Error
+}
+
+// UpdateTeamMemberRole ...
+func (r *Repo) UpdateTeamMemberRole(
+	ctx context.Context, teamID, userID string, role m.MemberRole) error {
+	return r.WithContext(ctx).
+		Model(&m.TeamMember{}).
+		Where("team_id = ? AND user_id = ?", teamID, userID).
+		Update("role", role).Error
+}
+
+// DeleteTeamMember ...
+func (r *Repo) DeleteTeamMember(
+	ctx context.Context, teamID, userID string) error {
+	return r.WithContext(ctx).
+		Where("team_id = ? AND user_id = ?", teamID, userID).
+		Delete(&m.TeamMember{}).Error
+}
@@ -0,0 +1,32 @@
+package repo
+
+import (
+	"gorm.io/gorm"
+
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+// globalInventory matches the tools, resources and prompts of public
+// servers, which belong to no user or team.
+const globalInventory = "user_id IS NULL AND team_id IS NULL"
+
+// whereOwned limits an inventory query to the rows o owns.
+func whereOwned(qdb *gorm.DB, o m.Owner) *gorm.DB {
+	switch {
+	case o.TeamID != "":
+		return qdb.Where("team_id = ?", o.TeamID)
+	case o.UserID != "":
+		return qdb.Where("user_id = ?", o.UserID)
+	default:
+		return qdb.Where(globalInventory)
+	}
+}
+
+// whereVisible limits an inventory query to global rows and the rows o
+// owns.
+func whereVisible(qdb *gorm.DB, o m.Owner) *gorm.DB {
+	if o.TeamID != "" {
+		return qdb.Where("("+globalInventory+") OR team_id = ?", o.TeamID)
+	}
+	return qdb.Where("("+globalInventory+") OR user_id = ?", o.UserID)
+}
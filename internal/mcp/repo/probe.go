@@ -0,0 +1,114 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+// maxProbeErrorLen matches the last_probe_error column width.
+const maxProbeErrorLen = 2000
+
+// ListCatalogServersToProbe returns the public catalog servers. Private
+// servers need credentials and are probed through their hubs.
+func (r *Repo) ListCatalogServersToProbe(
+	ctx context.Context) ([]m.MCPServer, error) {
+	var rows []m.MCPServer
+	err := r.WithContext(ctx).
+		Where("access_type = ?", m.AccessTypePublic).
+		Find(&rows).Error
+	return rows, err
+}
+
+// ListHubsToProbe returns hubs that are ACTIVE or UNREACHABLE together with
+// their server details. Deactivated hubs are never probed.
+func (r *Repo) ListHubsToProbe(
+	ctx context.Context) ([]m.MCPHubServerAggregate, error) {
+	var rows []m.MCPHubServerAggregate
+	err := r.WithContext(ctx).
+		Table("mcp_hub_servers h").
+		Select(hubAggregateColumns).
+		Joins("JOIN mcp_servers s ON s.id = h.mcp_server_id").
+		Where("h.status IN ?",
+			[]m.Status{m.StatusActive, m.StatusUnreachable}).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// RecordCatalogServerProbe stores the outcome of a catalog server probe.
+func (r *Repo) RecordCatalogServerProbe(
+	ctx context.Context,
+	id string,
+	at time.Time,
+	latency time.Duration,
+	probeErr error,
+) error {
+	return r.WithContext(ctx).
+		Model(&m.MCPServer{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]any{
+			"last_probe_at":         at,
+			"last_probe_latency_ms": latency.Milliseconds(),
+			"last_probe_error":      probeErrorText(probeErr),
+		}).Error
+}
+
+// UpdateHubProbeState locks the hub row, lets apply update its probe
+// fields and status, and stores them. Hubs that are no longer ACTIVE or
+// UNREACHABLE are left untouched. It returns the status before and after.
+func (r *Repo) UpdateHubProbeState(
+	ctx context.Context,
+	id string,
+	apply func(h *m.MCPHubServer),
+) (before, after m.Status, err error) {
+	err = r.Transaction(func(tx *Repo) error {
+		var h m.MCPHubServer
+		if err := tx.WithContext(ctx).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", id).
+			Take(&h).Error; err != nil {
+			return err
+		}
+		before, after = h.Status, h.Status
+		if h.Status != m.StatusActive && h.Status != m.StatusUnreachable {
+			return nil
+		}
+		apply(&h)
+		after = h.Status
+		return tx.WithContext(ctx).
+			Model(&m.MCPHubServer{}).
+			Where("id = ?", id).
+			UpdateColumns(map[string]any{
+				"status":                h.Status,
+				"last_probe_at":         h.LastProbeAt,
+				"last_probe_latency_ms": h.LastProbeLatencyMS,
+				"last_probe_error":      truncateProbeError(h.LastProbeError),
+				"probe_failures":        h.ProbeFailures,
+				"probe_successes":       h.ProbeSuccesses,
+			}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Hub deleted while being probed
+		return "", "", nil
+	}
+	return before, after, err
+}
+
+func probeErrorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return truncateProbeError(err.Error())
+}
+
+func truncateProbeError(s string) string {
+	if len(s) > maxProbeErrorLen {
+		return s[:maxProbeErrorLen]
+	}
+	return s
+}
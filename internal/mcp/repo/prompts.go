@@ -0,0 +1,147 @@
+package repo
+
+import (
+	"context"
+
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+// ListPromptsForVirtualServer returns prompts joined via
+// prompts_virtual_servers for a vs id.
+func (r *Repo) ListPromptsForVirtualServer(
+	ctx context.Context, vsID string) ([]m.MCPPrompt, error) {
+	var rows []m.MCPPrompt
+	err := r.WithContext(ctx).
+		Table("mcp_prompts").
+		Joins("JOIN prompts_virtual_servers pvs ON pvs.prompt_id = mcp_prompts.id").
+		Where("pvs.mcp_virtual_server_id = ?", vsID).
+		Order("mcp_prompts.name").
+		Find(&rows).Error
+	return rows, err
+}
+
+// ListUserPromptsFiltered returns prompts visible to a user (global and
+// user-specific) filtered by server and hub.
+func (r *Repo) ListUserPromptsFiltered(
+	ctx context.Context,
+	userID,
+	serverID,
+	hubServerID string) ([]m.MCPPrompt, error) {
+	return r.ListOwnerPromptsFiltered(ctx, m.Owner{UserID: userID},
+		serverID, hubServerID)
+}
+
+// ListOwnerPromptsFiltered returns global prompts and the prompts of an
+// owner's hubs, filtered by server and hub.
+func (r *Repo) ListOwnerPromptsFiltered(
+	ctx context.Context,
+	owner m.Owner,
+	serverID,
+	hubServerID string) ([]m.MCPPrompt, error) {
+	qdb := whereVisible(r.WithContext(ctx).Table("mcp_prompts"), owner)
+	if serverID != "" {
+		qdb = qdb.Where("mcp_server_id = ?", serverID)
+	}
+	if hubServerID != "" {
+		qdb = qdb.Where("mcp_hub_server_id = ?", hubServerID)
+	}
+	var rows []m.MCPPrompt
+	err := qdb.Order("name").Find(&rows).Error
+	return rows, err
+}
+
+// GetActivePromptByID returns a prompt by id only if it is ACTIVE and global or
+// owned by o.
+func (r *Repo) GetActivePromptByID(
+	ctx context.Context, id string, o m.Owner) (m.MCPPrompt, error) {
+	var rec m.MCPPrompt
+	err := whereVisible(r.WithContext(ctx), o).
+		Where("id = ? AND status = 'ACTIVE'", id).
+		Take(&rec).Error
+	return rec, err
+}
+
+// SyncPromptsForServer reconciles stored prompts of a server (global for
+// the zero owner) with the desired set, keyed by prompt name.
+func (r *Repo) SyncPromptsForServer(
+	ctx context.Context,
+	serverID string,
+	owner m.Owner,
+	desired []m.MCPPrompt,
+) (added, deleted int, err error) {
+	qdb := whereOwned(
+		r.WithContext(ctx).Where("mcp_server_id = ?", serverID), owner)
+	var current []m.MCPPrompt
+	if err := qdb.Find(&current).Error; err != nil {
+		return 0, 0, err
+	}
+	currentSet := make(map[string]m.MCPPrompt, len(current))
+	for _, c := range current {
+		currentSet[c.Name] = c
+	}
+
+	seen := make(map[string]bool, len(desired))
+	var toInsert []m.MCPPrompt
+	for _, d := range desired {
+		if seen[d.Name] {
+			continue
+		}
+		seen[d.Name] = true
+		cur, ok := currentSet[d.Name]
+		if !ok {
+			toInsert = append(toInsert, d)
+			continue
+		}
+		if err := r.WithContext(ctx).
+			Model(&m.MCPPrompt{}).
+			Where("id = ?", cur.ID).
+			Updates(map[string]any{
+				"description": d.Description,
+				"arguments":   d.Arguments,
+			}).Error; err != nil {
+			return 0, 0, err
+		}
+	}
+	var toDeleteIDs []string
+	for name, c := range currentSet {
+		if !seen[name] {
+			toDeleteIDs = append(toDeleteIDs, c.ID)
+		}
+	}
+	if len(toInsert) > 0 {
+		if err := r.WithContext(ctx).Create(&toInsert).Error; err != nil {
+			return 0, 0, err
+		}
+	}
+	if len(toDeleteIDs) > 0 {
+		if err := r.WithContext(ctx).
+			Where("id IN ?", toDeleteIDs).
+			Delete(&m.MCPPrompt{}).Error; err != nil {
+			return 0, 0, err
+		}
+	}
+	return len(toInsert), len(toDeleteIDs), nil
+}
+
+// ReplaceVirtualServerPrompts removes all prompts of a virtual server.
+func (r *Repo) ReplaceVirtualServerPrompts(
+	ctx context.Context, vsID string) error {
+	return r.WithContext(ctx).
+		Where("mcp_virtual_server_id = ?", vsID).
+		Delete(&m.PromptVirtualServer{}).Error
+}
+
+// AddVirtualServerPrompt ...
+func (r *Repo) AddVirtualServerPrompt(
+	ctx context.Context, vsID, promptID string) error {
+	rec := m.PromptVirtualServer{MCPVirtualServerID: vsID, PromptID: promptID}
+	return r.WithContext(ctx).Create(&rec).Error
+}
+
+// DeleteVirtualServerPrompt removes a single prompt from a virtual server.
+func (r *Repo) DeleteVirtualServerPrompt(
+	ctx context.Context, vsID, promptID string) error {
+	return r.WithContext(ctx).
+		Where("mcp_virtual_server_id = ? AND prompt_id = ?", vsID, promptID).
+		Delete(&m.PromptVirtualServer{}).Error
+}
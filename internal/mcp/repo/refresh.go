@@ -0,0 +1,113 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+// maxRefreshErrorLen matches the last_refresh_error column width.
+const maxRefreshErrorLen = 2000
+
+// AcquireLease takes or renews the named lease for holder until ttl from
+// now, using the database clock. It reports false when another holder owns
+// an unexpired lease.
+func (r *Repo) AcquireLease(
+	ctx context.Context, name, holder string, ttl time.Duration,
+) (bool, error) {
+	// MySQL applies the assignments left to right, so once holder has been
+	// taken over the expiry condition sees the new holder as well.
+	err := r.WithContext(ctx).Exec(`
+INSERT INTO refresh_leases (name, holder, expires_at)
+VALUES (?, ?, NOW(3) + INTERVAL ? MICROSECOND)
+ON DUPLICATE KEY UPDATE
+  holder = IF(expires_at < NOW(3) OR holder = VALUES(holder),
+    VALUES(holder), holder),
+  expires_at = IF(holder = VALUES(holder), VALUES(expires_at), expires_at)`,
+		name, holder, ttl.Microseconds()).Error
+	if err != nil {
+		return false, err
+	}
+	var lease m.RefreshLease
+	if err := r.WithContext(ctx).
+		Where("name = ?", name).
+		Take(&lease).Error; err != nil {
+		return false, err
+	}
+	return lease.Holder == holder, nil
+}
+
+// ReleaseLease drops the named lease if holder still owns it.
+func (r *Repo) ReleaseLease(ctx context.Context, name, holder string) error {
+	return r.WithContext(ctx).
+		Where("name = ? AND holder = ?", name, holder).
+		Delete(&m.RefreshLease{}).Error
+}
+
+// ListCatalogServersDueForRefresh returns public catalog servers whose last
+// refresh started before the given time, or that were never refreshed.
+func (r *Repo) ListCatalogServersDueForRefresh(
+	ctx context.Context, before time.Time) ([]m.MCPServer, error) {
+	var rows []m.MCPServer
+	err := r.WithContext(ctx).
+		Where("access_type = ?", m.AccessTypePublic).
+		Where("last_refreshed_at IS NULL OR last_refreshed_at < ?", before).
+		Order("last_refreshed_at").
+		Find(&rows).Error
+	return rows, err
+}
+
+// ListHubsDueForRefresh returns active hubs on private servers whose last
+// refresh started before the given time, or that were never refreshed.
+// Hubs on public servers share the catalog server's tools.
+func (r *Repo) ListHubsDueForRefresh(
+	ctx context.Context, before time.Time) ([]m.MCPHubServer, error) {
+	var rows []m.MCPHubServer
+	err := r.WithContext(ctx).
+		Table("mcp_hub_servers h").
+		Select("h.*").
+		Joins("JOIN mcp_servers s ON s.id = h.mcp_server_id").
+		Where("h.status = ? AND s.access_type = ?",
+			m.StatusActive, m.AccessTypePrivate).
+		Where("h.last_refreshed_at IS NULL OR h.last_refreshed_at < ?", before).
+		Order("h.last_refreshed_at").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// RecordCatalogServerRefresh stores the outcome of a catalog server refresh.
+func (r *Repo) RecordCatalogServerRefresh(
+	ctx context.Context, id string, at time.Time, refreshErr error) error {
+	return r.WithContext(ctx).
+		Model(&m.MCPServer{}).
+		Where("id = ?", id).
+		// Keep updated_at for edits made by admins
+		UpdateColumns(refreshColumns(at, refreshErr)).Error
+}
+
+// RecordHubRefresh stores the outcome of a hub refresh.
+func (r *Repo) RecordHubRefresh(
+	ctx context.Context, id string, at time.Time, refreshErr error) error {
+	return r.WithContext(ctx).
+		Model(&m.MCPHubServer{}).
+		Where("id = ?", id).
+		UpdateColumns(refreshColumns(at, refreshErr)).Error
+}
+
+func refreshColumns(at time.Time, refreshErr error) map[string]any {
+	cols := map[string]any{
+		"last_refreshed_at":   at,
+		"last_refresh_status": m.RefreshStatusSuccess,
+		"last_refresh_error":  "",
+	}
+	if refreshErr != nil {
+		msg := refreshErr.Error()
+		if len(msg) > maxRefreshErrorLen {
+			msg = msg[:maxRefreshErrorLen]
+		}
+		cols["last_refresh_status"] = m.RefreshStatusError
+		cols["last_refresh_error"] = msg
+	}
+	return cols
+}
@@ -0,0 +1,164 @@
+package repo
+
+import (
+	"context"
+
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+// resourceKey identifies a resource within a server for diffing.
+func resourceKey(r m.MCPResource) string {
+	if r.IsTemplate {
+		return "t:" + r.URI
+	}
+	return "r:" + r.URI
+}
+
+// ListResourcesForVirtualServer returns resources joined via
+// resources_virtual_servers for a vs id.
+func (r *Repo) ListResourcesForVirtualServer(
+	ctx context.Context, vsID string) ([]m.MCPResource, error) {
+	var rows []m.MCPResource
+	err := r.WithContext(ctx).
+		Table("mcp_resources").
+		Joins("JOIN resources_virtual_servers rvs ON rvs.resource_id = mcp_resources.id"). //nolint:lll
+		Where("rvs.mcp_virtual_server_id = ?", vsID).
+		Order("mcp_resources.uri").
+		Find(&rows).Error
+	return rows, err
+}
+
+// ListUserResourcesFiltered returns resources visible to a user (global and
+// user-specific) filtered by server and hub.
+func (r *Repo) ListUserResourcesFiltered(
+	ctx context.Context,
+	userID,
+	serverID,
+	hubServerID string) ([]m.MCPResource, error) {
+	return r.ListOwnerResourcesFiltered(ctx, m.Owner{UserID: userID},
+		serverID, hubServerID)
+}
+
+// ListOwnerResourcesFiltered returns global resources and the resources of an
+// owner's hubs, filtered by server and hub.
+func (r *Repo) ListOwnerResourcesFiltered(
+	ctx context.Context,
+	owner m.Owner,
+	serverID,
+	hubServerID string) ([]m.MCPResource, error) {
+	qdb := whereVisible(r.WithContext(ctx).Table("mcp_resources"), owner)
+	if serverID != "" {
+		qdb = qdb.Where("mcp_server_id = ?", serverID)
+	}
+	if hubServerID != "" {
+		qdb = qdb.Where("mcp_hub_server_id = ?", hubServerID)
+	}
+	var rows []m.MCPResource
+	err := qdb.Order("uri").Find(&rows).Error
+	return rows, err
+}
+
+// GetActiveResourceByID returns a resource by id only if it is ACTIVE and global or
+// owned by o.
+func (r *Repo) GetActiveResourceByID(
+	ctx context.Context, id string, o m.Owner) (m.MCPResource, error) {
+	var rec m.MCPResource
+	err := whereVisible(r.WithContext(ctx), o).
+		Where("id = ? AND status = 'ACTIVE'", id).
+		Take(&rec).Error
+	return rec, err
+}
+
+// SyncResourcesForServer reconciles stored resources of a server (global
+// for the zero owner) with the desired set: new ones are inserted, existing
+// ones refreshed in place so virtual server selections survive, and missing
+// ones deleted.
+func (r *Repo) SyncResourcesForServer(
+	ctx context.Context,
+	serverID string,
+	owner m.Owner,
+	desired []m.MCPResource,
+) (added, deleted int, err error) {
+	qdb := whereOwned(
+		r.WithContext(ctx).Where("mcp_server_id = ?", serverID), owner)
+	var current []m.MCPResource
+	if err := qdb.Find(&current).Error; err != nil {
+		return 0, 0, err
+	}
+	currentSet := make(map[string]m.MCPResource, len(current))
+	for _, c := range current {
+		currentSet[resourceKey(c)] = c
+	}
+
+	seen := make(map[string]bool, len(desired))
+	var toInsert []m.MCPResource
+	for _, d := range desired {
+		k := resourceKey(d)
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		cur, ok := currentSet[k]
+		if !ok {
+			toInsert = append(toInsert, d)
+			continue
+		}
+		if err := r.WithContext(ctx).
+			Model(&m.MCPResource{}).
+			Where("id = ?", cur.ID).
+			Updates(map[string]any{
+				"name":        d.Name,
+				"description": d.Description,
+				"mime_type":   d.MIMEType,
+				"annotations": d.Annotations,
+			}).Error; err != nil {
+			return 0, 0, err
+		}
+	}
+	var toDeleteIDs []string
+	for k, c := range currentSet {
+		if !seen[k] {
+			toDeleteIDs = append(toDeleteIDs, c.ID)
+		}
+	}
+	if len(toInsert) > 0 {
+		if err := r.WithContext(ctx).Create(&toInsert).Error; err != nil {
+			return 0, 0, err
+		}
+	}
+	if len(toDeleteIDs) > 0 {
+		if err := r.WithContext(ctx).
+			Where("id IN ?", toDeleteIDs).
+			Delete(&m.MCPResource{}).Error; err != nil {
+			return 0, 0, err
+		}
+	}
+	return len(toInsert), len(toDeleteIDs), nil
+}
+
+// ReplaceVirtualServerResources removes all resources of a virtual server.
+func (r *Repo) ReplaceVirtualServerResources(
+	ctx context.Context, vsID string) error {
+	return r.WithContext(ctx).
+		Where("mcp_virtual_server_id = ?", vsID).
+		Delete(&m.ResourceVirtualServer{}).Error
+}
+
+// AddVirtualServerResource ...
+func (r *Repo) AddVirtualServerResource(
+	ctx context.Context, vsID, resourceID string) error {
+	rec := m.ResourceVirtualServer{
+		MCPVirtualServerID: vsID,
+		ResourceID:         resourceID,
+	}
+	return r.WithContext(ctx).Create(&rec).Error
+}
+
+// DeleteVirtualServerResource removes a single resource from a virtual
+// server.
+func (r *Repo) DeleteVirtualServerResource(
+	ctx context.Context, vsID, resourceID string) error {
+	return r.WithContext(ctx).
+		Where("mcp_virtual_server_id = ? AND resource_id = ?", vsID, resourceID).
+		Delete(&m.ResourceVirtualServer{}).Error
+}
@@ -0,0 +1,58 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+// CreateSession ...
+func (r *Repo) CreateSession(ctx context.Context, s m.MCPSession) error {
+	return r.WithContext(ctx).Create(&s).Error
+}
+
+// GetSession returns a session by id, expired or not.
+func (r *Repo) GetSession(
+	ctx context.Context, id string) (m.MCPSession, error) {
+	var s m.MCPSession
+	err := r.WithContext(ctx).
+		Where("id = ?", id).
+		Take(&s).Error
+	return s, err
+}
+
+// TouchSession moves a session's expiry to expiresAt.
+func (r *Repo) TouchSession(
+	ctx context.Context, id string, expiresAt time.Time) error {
+	return r.WithContext(ctx).
+		Model(&m.MCPSession{}).
+		Where("id = ?", id).
+		UpdateColumn("expires_at", expiresAt).Error
+}
+
+// UpdateSessionLogLevel ...
+func (r *Repo) UpdateSessionLogLevel(
+	ctx context.Context, id string, level string) error {
+	return r.WithContext(ctx).
+		Model(&m.MCPSession{}).
+		Where("id = ?", id).
+		UpdateColumn("log_level", level).Error
+}
+
+// DeleteSession ...
+func (r *Repo) DeleteSession(ctx context.Context, id string) error {
+	return r.WithContext(ctx).
+		Where("id = ?", id).
+		Delete(&m.MCPSession{}).Error
+}
+
+// DeleteExpiredSessions removes sessions that expired before now and
+// returns how many were removed.
+func (r *Repo) DeleteExpiredSessions(
+	ctx context.Context, now time.Time) (int64, error) {
+	res := r.WithContext(ctx).
+		Where("expires_at < ?", now).
+		Delete(&m.MCPSession{})
+	return res.RowsAffected, res.Error
+}
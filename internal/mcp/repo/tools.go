@@ -22,14 +22,18 @@ func (r *Repo) UpsertTool(ctx context.Context, t m.MCPTool) error {
 }
 
 // ListToolsForVirtualServer returns tools joined via tools_virtual_servers
-// for a vs id.
+// for a vs id, with the alias, exposed name, transform and approval flag
+// from the pivot.
 func (r *Repo) ListToolsForVirtualServer(
-	ctx context.Context, vsID string) ([]m.MCPTool, error) {
-	var tools []m.MCPTool
+	ctx context.Context, vsID string) ([]m.VirtualServerTool, error) {
+	var tools []m.VirtualServerTool
 	err := r.WithContext(ctx).
 		Table("mcp_tools").
+		Select("mcp_tools.*, tvs.alias, tvs.exposed_name, tvs.transform, "+
+			"tvs.require_approval").
 		Joins("JOIN tools_virtual_servers tvs ON tvs.tool_id = mcp_tools.id").
 		Where("tvs.mcp_virtual_server_id = ?", vsID).
+		Order("tvs.exposed_name").
 		Find(&tools).Error
 	return tools, err
 }
@@ -53,8 +57,20 @@ func (r *Repo) ListUserToolsFilteredWithHub(
 	hubServerID,
 	status,
 	q string) ([]m.MCPTool, error) {
-	qdb := r.WithContext(ctx).Table("mcp_tools").
-		Where("user_id IS NULL OR user_id = ?", userID)
+	return r.ListOwnerToolsFiltered(ctx, m.Owner{UserID: userID},
+		serverID, hubServerID, status, q)
+}
+
+// ListOwnerToolsFiltered returns global tools and the tools of an owner's
+// hubs, filtered by server, hub, status, and query.
+func (r *Repo) ListOwnerToolsFiltered(
+	ctx context.Context,
+	owner m.Owner,
+	serverID,
+	hubServerID,
+	status,
+	q string) ([]m.MCPTool, error) {
+	qdb := whereVisible(r.WithContext(ctx).Table("mcp_tools"), owner)
 
 	if serverID != "" {
 		qdb = qdb.Where("mcp_server_id = ?", serverID)
@@ -84,10 +100,10 @@ func (r *Repo) ListToolsForServer(
 
 	if userID == nil {
 		// Only global tools
-		qdb = qdb.Where("user_id IS NULL")
+		qdb = qdb.Where(globalInventory)
 	} else {
 		// Both global tools and user-specific tools
-		qdb = qdb.Where("user_id IS NULL OR user_id = ?", *userID)
+		qdb = whereVisible(qdb, m.Owner{UserID: *userID})
 	}
 
 	var tools []m.MCPTool
@@ -101,7 +117,8 @@ func (r *Repo) ListGlobalToolsForServer(
 	serverID string) ([]m.MCPTool, error) {
 	var tools []m.MCPTool
 	err := r.WithContext(ctx).
-		Where("mcp_server_id = ? AND user_id IS NULL", serverID).
+		Where("mcp_server_id = ?", serverID).
+		Where(globalInventory).
 		Order("original_name").
 		Find(&tools).Error
 	return tools, err
@@ -112,9 +129,15 @@ func (r *Repo) ListUserSpecificToolsForServer(
 	ctx context.Context,
 	serverID string,
 	userID string) ([]m.MCPTool, error) {
+	return r.ListOwnedToolsForServer(ctx, serverID, m.Owner{UserID: userID})
+}
+
+// ListOwnedToolsForServer returns the tools an owner has for a server.
+func (r *Repo) ListOwnedToolsForServer(
+	ctx context.Context, serverID string, owner m.Owner) ([]m.MCPTool, error) {
 	var tools []m.MCPTool
-	err := r.WithContext(ctx).
-		Where("mcp_server_id = ? AND user_id = ?", serverID, userID).
+	err := whereOwned(
+		r.WithContext(ctx).Where("mcp_server_id = ?", serverID), owner).
 		Order("original_name").
 		Find(&tools).Error
 	return tools, err
@@ -129,7 +152,7 @@ func (r *Repo) DeleteToolsForServer(
 
 	if userID == nil {
 		// Delete only global tools
-		qdb = qdb.Where("user_id IS NULL")
+		qdb = qdb.Where(globalInventory)
 	} else {
 		// Delete only user-specific tools
 		qdb = qdb.Where("user_id = ?", *userID)
@@ -138,11 +161,12 @@ func (r *Repo) DeleteToolsForServer(
 	return qdb.Delete(&m.MCPTool{}).Error
 }
 
-// GetActiveToolByID returns a tool by id only if it is ACTIVE.
+// GetActiveToolByID returns a tool by id only if it is ACTIVE and global or
+// owned by o.
 func (r *Repo) GetActiveToolByID(
-	ctx context.Context, id string) (m.MCPTool, error) {
+	ctx context.Context, id string, o m.Owner) (m.MCPTool, error) {
 	var t m.MCPTool
-	err := r.WithContext(ctx).
+	err := whereVisible(r.WithContext(ctx), o).
 		Where("id = ? AND status = 'ACTIVE'", id).
 		Take(&t).Error
 	return t, err
@@ -156,6 +180,25 @@ func (r *Repo) CreateTools(ctx context.Context, tools []m.MCPTool) error {
 	return r.WithContext(ctx).Create(&tools).Error
 }
 
+// UpdateToolDefinitions stores the description, input schema and
+// annotations of existing tools.
+func (r *Repo) UpdateToolDefinitions(
+	ctx context.Context, tools []m.MCPTool) error {
+	for _, t := range tools {
+		if err := r.WithContext(ctx).
+			Model(&m.MCPTool{}).
+			Where("id = ?", t.ID).
+			Updates(map[string]any{
+				"description":  t.Description,
+				"input_schema": t.InputSchema,
+				"annotations":  t.Annotations,
+			}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // DeleteToolsByIDs deletes tools by their IDs.
 func (r *Repo) DeleteToolsByIDs(ctx context.Context, ids []string) error {
 	if len(ids) == 0 {
@@ -165,3 +208,19 @@ func (r *Repo) DeleteToolsByIDs(ctx context.Context, ids []string) error {
 		Where("id IN ?", ids).
 		Delete(&m.MCPTool{}).Error
 }
+
+// ListVirtualServerIDsForTools returns the ids of the virtual servers that
+// include any of the tools.
+func (r *Repo) ListVirtualServerIDsForTools(
+	ctx context.Context, toolIDs []string) ([]string, error) {
+	if len(toolIDs) == 0 {
+		return nil, nil
+	}
+	var ids []string
+	err := r.WithContext(ctx).
+		Model(&m.ToolVirtualServer{}).
+		Distinct("mcp_virtual_server_id").
+		Where("tool_id IN ?", toolIDs).
+		Pluck("mcp_virtual_server_id", &ids).Error
+	return ids, err
+}
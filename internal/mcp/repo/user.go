@@ -18,6 +18,19 @@ func (r *Repo) FindUserByUsername(
 	return &u, nil
 }
 
+// FindUserByAuthSubject returns the user created for an account of an
+// OIDC provider.
+func (r *Repo) FindUserByAuthSubject(
+	ctx context.Context, provider, subject string) (*m.User, error) {
+	var u m.User
+	if err := r.WithContext(ctx).
+		Where("auth_provider = ? AND auth_subject = ?", provider, subject).
+		Take(&u).Error; err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
 // FindUserByID returns the full user record by id
 func (r *Repo) FindUserByID(
 	ctx context.Context, userID string) (*m.User, error) {
@@ -34,3 +47,12 @@ func (r *Repo) FindUserByID(
 func (r *Repo) CreateUser(ctx context.Context, u *m.User) error {
 	return r.WithContext(ctx).Create(u).Error
 }
+
+// UpdateUserRole sets a user's role.
+func (r *Repo) UpdateUserRole(
+	ctx context.Context, userID string, role string) error {
+	return r.WithContext(ctx).
+		Model(&m.User{}).
+		Where("id = ?", userID).
+		Update("role", role).Error
+}
@@ -2,6 +2,7 @@ package repo
 
 import (
 	"context"
+	"encoding/json"
 
 	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
 )
@@ -12,12 +13,25 @@ func (r *Repo) CreateVirtualServer(
 	return r.WithContext(ctx).Create(&vs).Error
 }
 
-// ListVirtualServersForUser ...
+// ListVirtualServersForUser lists a user's personal virtual servers.
 func (r *Repo) ListVirtualServersForUser(
 	ctx context.Context, userID string) ([]m.MCPVirtualServer, error) {
 	var rows []m.MCPVirtualServer
 	err := r.WithContext(ctx).
-		Where("user_id = ?", userID).
+		Where("user_id = ? AND team_id IS NULL", userID).
+		Find(&rows).Error
+	return rows, err
+}
+
+// ListVirtualServersForTeams lists the virtual servers of the given teams.
+func (r *Repo) ListVirtualServersForTeams(
+	ctx context.Context, teamIDs []string) ([]m.MCPVirtualServer, error) {
+	var rows []m.MCPVirtualServer
+	if len(teamIDs) == 0 {
+		return rows, nil
+	}
+	err := r.WithContext(ctx).
+		Where("team_id IN ?", teamIDs).
 		Find(&rows).Error
 	return rows, err
 }
@@ -50,6 +64,15 @@ func (r *Repo) UpdateVirtualServerName(
 		Update("name", name).Error
 }
 
+// UpdateVirtualServerToolNaming ...
+func (r *Repo) UpdateVirtualServerToolNaming(
+	ctx context.Context, id string, naming m.ToolNaming) error {
+	return r.WithContext(ctx).
+		Table("mcp_virtual_servers").
+		Where("id = ?", id).
+		Update("tool_naming", naming).Error
+}
+
 // ReplaceVirtualServerTools ...
 func (r *Repo) ReplaceVirtualServerTools(
 	ctx context.Context, vsID string) error {
@@ -60,11 +83,52 @@ func (r *Repo) ReplaceVirtualServerTools(
 
 // AddVirtualServerTool ...
 func (r *Repo) AddVirtualServerTool(
-	ctx context.Context, vsID, toolID string) error {
-	rec := m.ToolVirtualServer{MCPVirtualServerID: vsID, ToolID: toolID}
+	ctx context.Context, rec m.ToolVirtualServer) error {
 	return r.WithContext(ctx).Create(&rec).Error
 }
 
+// UpdateVirtualServerToolTransform stores the transform rules of a tool
+// link; nil clears them.
+func (r *Repo) UpdateVirtualServerToolTransform(
+	ctx context.Context, vsID, toolID string, transform json.RawMessage) error {
+	var v any
+	if len(transform) > 0 {
+		v = transform
+	}
+	return r.WithContext(ctx).
+		Model(&m.ToolVirtualServer{}).
+		Where("mcp_virtual_server_id = ? AND tool_id = ?", vsID, toolID).
+		Update("transform", v).Error
+}
+
+// UpdateVirtualServerToolRequireApproval sets whether calls to a tool of a
+// virtual server are held for approval and reports whether the tool is
+// attached to it.
+func (r *Repo) UpdateVirtualServerToolRequireApproval(
+	ctx context.Context, vsID, toolID string, require bool) (bool, error) {
+	var n int64
+	err := r.WithContext(ctx).
+		Model(&m.ToolVirtualServer{}).
+		Where("mcp_virtual_server_id = ? AND tool_id = ?", vsID, toolID).
+		Count(&n).Error
+	if err != nil || n == 0 {
+		return false, err
+	}
+	return true, r.WithContext(ctx).
+		Model(&m.ToolVirtualServer{}).
+		Where("mcp_virtual_server_id = ? AND tool_id = ?", vsID, toolID).
+		Update("require_approval", require).Error
+}
+
+// UpdateVirtualServerApproveDestructive ...
+func (r *Repo) UpdateVirtualServerApproveDestructive(
+	ctx context.Context, id string, approve bool) error {
+	return r.WithContext(ctx).
+		Table("mcp_virtual_servers").
+		Where("id = ?", id).
+		Update("approve_destructive", approve).Error
+}
+
 // DeleteVirtualServerTool removes a single tool from a virtual server.
 func (r *Repo) DeleteVirtualServerTool(
 	ctx context.Context, vsID, toolID string) error {
@@ -79,3 +143,53 @@ func (r *Repo) DeleteVirtualServer(
 	return r.WithContext(ctx).
 		Delete(&m.MCPVirtualServer{ID: id}).Error
 }
+
+// UpdateVirtualServerSessionMode ...
+func (r *Repo) UpdateVirtualServerSessionMode(
+	ctx context.Context, id string, mode m.SessionMode) error {
+	return r.WithContext(ctx).
+		Table("mcp_virtual_servers").
+		Where("id = ?", id).
+		Update("session_mode", mode).Error
+}
+
+// UpdateVirtualServerArgValidation ...
+func (r *Repo) UpdateVirtualServerArgValidation(
+	ctx context.Context, id string, mode m.ArgValidation) error {
+	return r.WithContext(ctx).
+		Table("mcp_virtual_servers").
+		Where("id = ?", id).
+		Update("arg_validation", mode).Error
+}
+
+// UpdateVirtualServerMode ...
+func (r *Repo) UpdateVirtualServerMode(
+	ctx context.Context, id string, mode m.AccessMode, filter json.RawMessage,
+) error {
+	return r.WithContext(ctx).
+		Table("mcp_virtual_servers").
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"mode":              mode,
+			"annotation_filter": filter,
+		}).Error
+}
+
+// UpdateVirtualServerClientRequests ...
+func (r *Repo) UpdateVirtualServerClientRequests(
+	ctx context.Context, id string, sampling, elicitation *bool) error {
+	updates := map[string]any{}
+	if sampling != nil {
+		updates["allow_sampling"] = *sampling
+	}
+	if elicitation != nil {
+		updates["allow_elicitation"] = *elicitation
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return r.WithContext(ctx).
+		Table("mcp_virtual_servers").
+		Where("id = ?", id).
+		Updates(updates).Error
+}
@@ -0,0 +1,26 @@
+// Package apikey manages API keys for virtual server MCP endpoints.
+package apikey
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
+)
+
+// Option configures the API key Service (functional options).
+type Option func(*Service)
+
+// WithLogger sets a logger.
+func WithLogger(l *slog.Logger) Option { return func(s *Service) { s.logger = l } }
+
+// WithTimeout sets a per-call timeout.
+func WithTimeout(d time.Duration) Option { return func(s *Service) { s.timeout = d } }
+
+// WithRepo injects the GORM repo.
+func WithRepo(r *repo.Repo) Option { return func(s *Service) { s.repo = r } }
+
+// WithTouchInterval sets how often last_used_at is persisted per key.
+func WithTouchInterval(d time.Duration) Option {
+	return func(s *Service) { s.touchInterval = d }
+}
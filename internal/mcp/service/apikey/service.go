@@ -0,0 +1,213 @@
+// Package apikey manages API keys for virtual server MCP endpoints.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/idgen"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+const (
+	// keyPrefix marks plaintext keys issued by the gateway.
+	keyPrefix = "mcpk_"
+	// displayPrefixLen is how much of the plaintext key is kept for display.
+	displayPrefixLen = 12
+)
+
+var (
+	// ErrInvalidKey is returned when a presented key does not authenticate.
+	ErrInvalidKey = errors.New("invalid api key")
+	// ErrNotFound is returned for an unknown key of a virtual server.
+	ErrNotFound = errors.New("api key not found")
+	// ErrNotActive is returned when revoking or rotating a key that was
+	// already revoked.
+	ErrNotActive = errors.New("api key is not active")
+)
+
+// Service exposes API key operations.
+type Service struct {
+	repo          *repo.Repo
+	logger        *slog.Logger
+	timeout       time.Duration
+	touchInterval time.Duration
+}
+
+// NewService creates a new API key Service.
+func NewService(opts ...Option) *Service {
+	s := &Service{touchInterval: time.Minute}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+func (s *Service) withTimeout(
+	ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, s.timeout)
+}
+
+// Create issues a new key for a virtual server. The plaintext key is only
+// returned here and is never persisted.
+func (s *Service) Create(
+	ctx context.Context, vsID, userID, name string,
+) (m.VirtualServerKey, string, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	rec, plain, err := newKey(vsID, userID, name)
+	if err != nil {
+		return m.VirtualServerKey{}, "", err
+	}
+	if err := s.repo.CreateVirtualServerKey(ctx, rec); err != nil {
+		s.logger.Error("API_KEY_CREATE_ERROR", "error", err)
+		return m.VirtualServerKey{}, "", err
+	}
+	s.logger.Info("API_KEY_CREATE_OK", "id", rec.ID, "vs_id", vsID)
+	return rec, plain, nil
+}
+
+// List returns the keys of a virtual server.
+func (s *Service) List(
+	ctx context.Context, vsID string) ([]m.VirtualServerKey, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return s.repo.ListVirtualServerKeys(ctx, vsID)
+}
+
+// Get returns a key of a virtual server.
+func (s *Service) Get(
+	ctx context.Context, vsID, keyID string) (m.VirtualServerKey, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return s.get(ctx, vsID, keyID)
+}
+
+func (s *Service) get(
+	ctx context.Context, vsID, keyID string) (m.VirtualServerKey, error) {
+	k, err := s.repo.GetVirtualServerKey(ctx, vsID, keyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return m.VirtualServerKey{}, ErrNotFound
+	}
+	return k, err
+}
+
+// Revoke deactivates a key. It returns ErrNotFound for an unknown key and
+// ErrNotActive for one already revoked.
+func (s *Service) Revoke(ctx context.Context, vsID, keyID string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	k, err := s.get(ctx, vsID, keyID)
+	if err != nil {
+		return err
+	}
+	if k.Status != m.StatusActive {
+		return ErrNotActive
+	}
+	if err := s.repo.RevokeVirtualServerKey(
+		ctx, vsID, keyID, time.Now()); err != nil {
+		s.logger.Error("API_KEY_REVOKE_ERROR", "error", err)
+		return err
+	}
+	s.logger.Info("API_KEY_REVOKE_OK", "id", keyID, "vs_id", vsID)
+	return nil
+}
+
+// Rotate revokes a key and issues a replacement with the same name in one
+// transaction. It fails like Revoke for unknown or revoked keys.
+func (s *Service) Rotate(
+	ctx context.Context, vsID, keyID string,
+) (m.VirtualServerKey, string, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	old, err := s.get(ctx, vsID, keyID)
+	if err != nil {
+		return m.VirtualServerKey{}, "", err
+	}
+	if old.Status != m.StatusActive {
+		return m.VirtualServerKey{}, "", ErrNotActive
+	}
+	rec, plain, err := newKey(vsID, old.UserID, old.Name)
+	if err != nil {
+		return m.VirtualServerKey{}, "", err
+	}
+	err = s.repo.Transaction(func(tx *repo.Repo) error {
+		if err := tx.RevokeVirtualServerKey(
+			ctx, vsID, keyID, time.Now()); err != nil {
+			return err
+		}
+		return tx.CreateVirtualServerKey(ctx, rec)
+	})
+	if err != nil {
+		s.logger.Error("API_KEY_ROTATE_ERROR", "error", err)
+		return m.VirtualServerKey{}, "", err
+	}
+	s.logger.Info("API_KEY_ROTATE_OK", "old_id", keyID, "new_id", rec.ID)
+	return rec, plain, nil
+}
+
+// Authenticate resolves a plaintext key presented for a virtual server and
+// records its usage. It returns ErrInvalidKey when the key does not match an
+// active key of that virtual server.
+func (s *Service) Authenticate(
+	ctx context.Context, vsID, plain string) (m.VirtualServerKey, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	if !strings.HasPrefix(plain, keyPrefix) {
+		return m.VirtualServerKey{}, ErrInvalidKey
+	}
+	k, err := s.repo.GetActiveVirtualServerKeyByHash(ctx, vsID, hashKey(plain))
+	if err != nil {
+		return m.VirtualServerKey{}, ErrInvalidKey
+	}
+	// Throttle last_used_at writes; precision of touchInterval is enough
+	// to spot stale keys.
+	now := time.Now()
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= s.touchInterval {
+		if err := s.repo.TouchVirtualServerKey(ctx, k.ID, now); err != nil {
+			s.logger.Error("API_KEY_TOUCH_ERROR", "id", k.ID, "error", err)
+		} else {
+			k.LastUsedAt = &now
+		}
+	}
+	return k, nil
+}
+
+func newKey(
+	vsID, userID, name string) (m.VirtualServerKey, string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return m.VirtualServerKey{}, "", err
+	}
+	plain := keyPrefix + base64.RawURLEncoding.EncodeToString(b[:])
+	if name == "" {
+		name = "default"
+	}
+	return m.VirtualServerKey{
+		ID:                 idgen.NewID(),
+		MCPVirtualServerID: vsID,
+		UserID:             userID,
+		Name:               name,
+		Prefix:             plain[:displayPrefixLen],
+		KeyHash:            hashKey(plain),
+		Status:             m.StatusActive,
+	}, plain, nil
+}
+
+func hashKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,43 @@
+// Package approval holds tool calls for a human decision. A held call
+// waits until the owner of its virtual server approves or rejects it, or
+// until it expires.
+package approval
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
+)
+
+// Option configures the approval Service (functional options).
+type Option func(*Service)
+
+// WithLogger sets a logger.
+func WithLogger(l *slog.Logger) Option { return func(s *Service) { s.logger = l } }
+
+// WithTimeout sets a per-call timeout for DB operations.
+func WithTimeout(d time.Duration) Option { return func(s *Service) { s.timeout = d } }
+
+// WithRepo injects the GORM repo.
+func WithRepo(r *repo.Repo) Option { return func(s *Service) { s.repo = r } }
+
+// WithApprovalTimeout sets how long a held call waits for a decision.
+// Non-positive values keep the default.
+func WithApprovalTimeout(d time.Duration) Option {
+	return func(s *Service) {
+		if d > 0 {
+			s.approvalTimeout = d
+		}
+	}
+}
+
+// WithPollInterval sets how often a waiting call checks the DB for a
+// decision made on another replica. Non-positive values keep the default.
+func WithPollInterval(d time.Duration) Option {
+	return func(s *Service) {
+		if d > 0 {
+			s.pollInterval = d
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/idgen"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+const (
+	defaultApprovalTimeout = 5 * time.Minute
+	defaultPollInterval    = 2 * time.Second
+
+	defaultListLimit = 50
+	maxListLimit     = 200
+
+	maxReasonLen = 1000
+)
+
+var (
+	// ErrNotFound is returned for an unknown approval.
+	ErrNotFound = errors.New("approval not found")
+	// ErrNotPending is returned when deciding an approval that was
+	// already decided, expired or cancelled.
+	ErrNotPending = errors.New("approval is not pending")
+)
+
+// Page is one page of approvals.
+type Page struct {
+	Items  []m.ToolCallApproval `json:"items"`
+	Total  int64                `json:"total"`
+	Limit  int                  `json:"limit"`
+	Offset int                  `json:"offset"`
+}
+
+// Service creates, decides and waits on tool call approvals.
+//
+// Approvals live in the DB, so any replica can decide one. A decision made
+// on the replica holding the call wakes it at once; one made elsewhere is
+// seen at the next poll.
+type Service struct {
+	repo            *repo.Repo
+	logger          *slog.Logger
+	timeout         time.Duration
+	approvalTimeout time.Duration
+	pollInterval    time.Duration
+
+	mu      sync.Mutex
+	waiters map[string]chan struct{}
+}
+
+// NewService creates an approval Service.
+func NewService(opts ...Option) *Service {
+	s := &Service{
+		logger:          slog.Default(),
+		approvalTimeout: defaultApprovalTimeout,
+		pollInterval:    defaultPollInterval,
+		waiters:         map[string]chan struct{}{},
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+func (s *Service) withTimeout(
+	ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, s.timeout)
+}
+
+// Hold records a pending approval for a call and returns it. The caller
+// fills in the call fields; id, status and expiry are set here.
+func (s *Service) Hold(
+	ctx context.Context, a m.ToolCallApproval,
+) (m.ToolCallApproval, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	a.ID = idgen.NewID()
+	a.Status = m.ApprovalPending
+	a.ExpiresAt = time.Now().Add(s.approvalTimeout).UTC().Truncate(time.Second)
+	if err := s.repo.CreateToolCallApproval(ctx, a); err != nil {
+		return m.ToolCallApproval{}, err
+	}
+	s.logger.Info("APPROVAL_HELD", "id", a.ID,
+		"vs_id", a.VirtualServerID, "tool", a.ToolName)
+	return a, nil
+}
+
+// Wait blocks until the approval is decided or expires and returns it in
+// its final state. Approvals still pending at their deadline are expired.
+// If ctx ends first the approval is cancelled and ctx's error returned.
+func (s *Service) Wait(
+	ctx context.Context, a m.ToolCallApproval,
+) (m.ToolCallApproval, error) {
+	wake := s.watch(a.ID)
+	defer s.unwatch(a.ID)
+	poll := time.NewTicker(s.pollInterval)
+	defer poll.Stop()
+	deadline := time.NewTimer(time.Until(a.ExpiresAt))
+	defer deadline.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.settle(context.WithoutCancel(ctx), a.ID, m.ApprovalCancelled)
+			cur, _ := s.Get(context.WithoutCancel(ctx), a.ID)
+			return cur, ctx.Err()
+		case <-deadline.C:
+			s.settle(ctx, a.ID, m.ApprovalExpired)
+		case <-wake:
+		case <-poll.C:
+		}
+		cur, err := s.Get(ctx, a.ID)
+		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			return cur, err
+		}
+		if cur.Status != m.ApprovalPending {
+			return cur, nil
+		}
+	}
+}
+
+// Decide approves or rejects a pending approval on behalf of userID.
+func (s *Service) Decide(
+	ctx context.Context, id string, approve bool, userID, reason string,
+) (m.ToolCallApproval, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	a, err := s.get(ctx, id)
+	if err != nil {
+		return m.ToolCallApproval{}, err
+	}
+	if a.Status != m.ApprovalPending || !time.Now().Before(a.ExpiresAt) {
+		return a, ErrNotPending
+	}
+	status := m.ApprovalRejected
+	if approve {
+		status = m.ApprovalApproved
+	}
+	if len(reason) > maxReasonLen {
+		reason = reason[:maxReasonLen]
+	}
+	ok, err := s.repo.DecideToolCallApproval(
+		ctx, id, status, &userID, reason, time.Now().UTC())
+	if err != nil {
+		return m.ToolCallApproval{}, err
+	}
+	if !ok {
+		return a, ErrNotPending
+	}
+	s.wake(id)
+	s.logger.Info("APPROVAL_DECIDED", "id", id,
+		"status", status, "user_id", userID)
+	return s.get(ctx, id)
+}
+
+// Get returns an approval, or ErrNotFound.
+func (s *Service) Get(
+	ctx context.Context, id string,
+) (m.ToolCallApproval, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return s.get(ctx, id)
+}
+
+// List returns a page of approvals, newest first. Pending approvals past
+// their deadline, such as those of a replica that stopped, are expired
+// first.
+func (s *Service) List(ctx context.Context, f repo.ApprovalFilter) (Page, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	if err := s.repo.ExpireToolCallApprovals(ctx, time.Now().UTC()); err != nil {
+		s.logger.Error("APPROVAL_EXPIRE_ERROR", "error", err)
+	}
+	if f.Limit <= 0 {
+		f.Limit = defaultListLimit
+	}
+	if f.Limit > maxListLimit {
+		f.Limit = maxListLimit
+	}
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
+	items, total, err := s.repo.ListToolCallApprovals(ctx, f)
+	if err != nil {
+		return Page{}, err
+	}
+	return Page{Items: items, Total: total, Limit: f.Limit, Offset: f.Offset}, nil
+}
+
+func (s *Service) get(
+	ctx context.Context, id string,
+) (m.ToolCallApproval, error) {
+	a, err := s.repo.GetToolCallApproval(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return m.ToolCallApproval{}, ErrNotFound
+	}
+	return a, err
+}
+
+// settle ends a pending approval without a human decision.
+func (s *Service) settle(
+	ctx context.Context, id string, status m.ApprovalStatus,
+) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	ok, err := s.repo.DecideToolCallApproval(
+		ctx, id, status, nil, "", time.Now().UTC())
+	if err != nil {
+		s.logger.Error("APPROVAL_SETTLE_ERROR",
+			"id", id, "status", status, "error", err)
+		return
+	}
+	if ok {
+		s.logger.Info("APPROVAL_SETTLED", "id", id, "status", status)
+	}
+}
+
+func (s *Service) watch(id string) <-chan struct{} {
+	ch := make(chan struct{}, 1)
+	s.mu.Lock()
+	s.waiters[id] = ch
+	s.mu.Unlock()
+	return ch
+}
+
+func (s *Service) unwatch(id string) {
+	s.mu.Lock()
+	delete(s.waiters, id)
+	s.mu.Unlock()
+}
+
+func (s *Service) wake(id string) {
+	s.mu.Lock()
+	ch, ok := s.waiters[id]
+	s.mu.Unlock()
+	if ok {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// IsDestructive reports whether a tool may be destructive. As in the MCP
+// spec a tool is destructive unless it is annotated readOnlyHint: true or
+// destructiveHint: false, so tools without annotations are destructive.
+func IsDestructive(annotations json.RawMessage) bool {
+	if len(annotations) == 0 {
+		return true
+	}
+	var a struct {
+		ReadOnlyHint    *bool `json:"readOnlyHint"`
+		DestructiveHint *bool `json:"destructiveHint"`
+	}
+	if err := json.Unmarshal(annotations, &a); err != nil {
+		return true
+	}
+	readOnly := a.ReadOnlyHint != nil && *a.ReadOnlyHint
+	notDestructive := a.DestructiveHint != nil && !*a.DestructiveHint
+	return !readOnly && !notDestructive
+}
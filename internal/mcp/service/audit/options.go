@@ -0,0 +1,68 @@
+// Package audit records proxied tool calls through an asynchronous batched
+// writer and serves audit queries.
+package audit
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
+)
+
+// Option configures the audit Service (functional options).
+type Option func(*Service)
+
+// WithLogger sets a logger.
+func WithLogger(l *slog.Logger) Option { return func(s *Service) { s.logger = l } }
+
+// WithTimeout sets a per-call timeout.
+func WithTimeout(d time.Duration) Option { return func(s *Service) { s.timeout = d } }
+
+// WithRepo injects the GORM repo.
+func WithRepo(r *repo.Repo) Option { return func(s *Service) { s.repo = r } }
+
+// WithBatchSize sets how many records are written per insert.
+// Non-positive values keep the default.
+func WithBatchSize(n int) Option {
+	return func(s *Service) {
+		if n > 0 {
+			s.batchSize = n
+		}
+	}
+}
+
+// WithFlushInterval sets the longest a record waits before being written.
+// Non-positive values keep the default.
+func WithFlushInterval(d time.Duration) Option {
+	return func(s *Service) {
+		if d > 0 {
+			s.flushInterval = d
+		}
+	}
+}
+
+// WithQueueSize sets how many records may be buffered before new ones are
+// dropped. Non-positive values keep the default.
+func WithQueueSize(n int) Option {
+	return func(s *Service) {
+		if n > 0 {
+			s.queueSize = n
+		}
+	}
+}
+
+// WithRedactKeys replaces the argument names whose values are redacted.
+// Matching is case-insensitive and applies at any depth. An empty list
+// keeps the default.
+func WithRedactKeys(keys []string) Option {
+	return func(s *Service) {
+		if len(keys) == 0 {
+			return
+		}
+		s.redactKeys = make(map[string]bool, len(keys))
+		for _, k := range keys {
+			s.redactKeys[strings.ToLower(k)] = true
+		}
+	}
+}
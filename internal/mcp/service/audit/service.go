@@ -0,0 +1,223 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/idgen"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+const (
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+	defaultQueueSize     = 10000
+	flushTimeout         = 10 * time.Second
+
+	defaultListLimit = 50
+	maxListLimit     = 200
+
+	redactedValue = "[REDACTED]"
+)
+
+// defaultRedactKeys are argument names redacted when none are configured.
+var defaultRedactKeys = []string{
+	"password", "secret", "token", "access_token", "refresh_token",
+	"api_key", "apikey", "authorization", "client_secret",
+}
+
+// Service records tool call audits and lists them.
+type Service struct {
+	repo    *repo.Repo
+	logger  *slog.Logger
+	timeout time.Duration
+
+	batchSize     int
+	flushInterval time.Duration
+	queueSize     int
+	redactKeys    map[string]bool
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan m.ToolCallAudit
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewService creates an audit Service and starts its writer.
+func NewService(opts ...Option) *Service {
+	s := &Service{
+		logger:        slog.Default(),
+		batchSize:     defaultBatchSize,
+		flushInterval: defaultFlushInterval,
+		queueSize:     defaultQueueSize,
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	WithRedactKeys(defaultRedactKeys)(s)
+	for _, o := range opts {
+		o(s)
+	}
+	s.queue = make(chan m.ToolCallAudit, s.queueSize)
+	go s.writer()
+	return s
+}
+
+func (s *Service) withTimeout(
+	ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, s.timeout)
+}
+
+// Record queues an audit record without blocking. Records are dropped when
+// the queue is full or the service is closed.
+func (s *Service) Record(rec m.ToolCallAudit) {
+	if rec.ID == "" {
+		rec.ID = idgen.NewID()
+	}
+	if rec.CreatedAt.IsZero() {
+		rec.CreatedAt = time.Now()
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.queue <- rec:
+	default:
+		s.logger.Warn("AUDIT_QUEUE_FULL_DROP",
+			"virtual_server_id", rec.VirtualServerID, "tool_id", rec.ToolID)
+	}
+}
+
+// RedactArguments returns the arguments as JSON with the values of
+// sensitive keys replaced.
+func (s *Service) RedactArguments(args map[string]any) json.RawMessage {
+	if args == nil {
+		return nil
+	}
+	b, err := json.Marshal(s.redact(args))
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+func (s *Service) redact(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, val := range t {
+			if s.redactKeys[strings.ToLower(k)] {
+				out[k] = redactedValue
+				continue
+			}
+			out[k] = s.redact(val)
+		}
+		return out
+	case []any:
+		out := make([]any, len(t))
+		for i, val := range t {
+			out[i] = s.redact(val)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// Page is one page of audit records and the total number of matches.
+type Page struct {
+	Items  []m.ToolCallAudit `json:"items"`
+	Total  int64             `json:"total"`
+	Limit  int               `json:"limit"`
+	Offset int               `json:"offset"`
+}
+
+// List returns a page of audit records matching the filter, newest first.
+func (s *Service) List(ctx context.Context, f repo.AuditFilter) (Page, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	if f.Limit <= 0 {
+		f.Limit = defaultListLimit
+	}
+	if f.Limit > maxListLimit {
+		f.Limit = maxListLimit
+	}
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
+	items, total, err := s.repo.ListToolCallAudits(ctx, f)
+	if err != nil {
+		return Page{}, err
+	}
+	return Page{Items: items, Total: total, Limit: f.Limit, Offset: f.Offset}, nil
+}
+
+// Close stops accepting records and writes everything still queued.
+func (s *Service) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+	close(s.stop)
+	<-s.done
+	return nil
+}
+
+// writer batches queued records and writes them when the batch is full or
+// the flush interval passes.
+func (s *Service) writer() {
+	defer close(s.done)
+	t := time.NewTicker(s.flushInterval)
+	defer t.Stop()
+	batch := make([]m.ToolCallAudit, 0, s.batchSize)
+	for {
+		select {
+		case rec := <-s.queue:
+			batch = append(batch, rec)
+			if len(batch) >= s.batchSize {
+				batch = s.flush(batch)
+			}
+		case <-t.C:
+			batch = s.flush(batch)
+		case <-s.stop:
+			for {
+				select {
+				case rec := <-s.queue:
+					batch = append(batch, rec)
+					if len(batch) >= s.batchSize {
+						batch = s.flush(batch)
+					}
+				default:
+					s.flush(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush writes the batch and returns it emptied for reuse.
+func (s *Service) flush(batch []m.ToolCallAudit) []m.ToolCallAudit {
+	if len(batch) == 0 {
+		return batch
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+	if err := s.repo.CreateToolCallAudits(ctx, batch); err != nil {
+		s.logger.Error("AUDIT_FLUSH_ERROR", "count", len(batch), "error", err)
+	}
+	return batch[:0]
+}
@@ -3,13 +3,35 @@ package catalog
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
 	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
 )
 
+// ErrInvalidCallPolicy is returned for negative call policy values.
+var ErrInvalidCallPolicy = errors.New("call policy values must not be negative")
+
+// ErrInvalidIdentityHeader is returned for an identity header that is not a
+// valid header name, or one the proxy sets itself.
+var ErrInvalidIdentityHeader = errors.New("invalid identity header")
+
+const maxIdentityHeaderLen = 100
+
+// identityHeaderRE matches an HTTP header name (RFC 9110 token).
+var identityHeaderRE = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+// reservedHeaders carry the hub's credentials or the MCP protocol, so the
+// identity token may not replace them.
+var reservedHeaders = []string{
+	"Authorization", "Content-Type", "Content-Length", "Accept", "Host",
+	"Mcp-Session-Id", "Mcp-Protocol-Version", "Last-Event-Id",
+}
+
 // Service exposes catalog operations.
 type Service struct {
 	repo    *repo.Repo
@@ -54,6 +76,55 @@ func (s *Service) Update(
 	return s.repo.UpdateCatalogServerURLDesc(ctx, id, url, description)
 }
 
+// SetCallPolicy replaces the call policy of a catalog server. Values must
+// not be negative; zero keeps the gateway default.
+func (s *Service) SetCallPolicy(
+	ctx context.Context,
+	id string,
+	p m.CallPolicy,
+) error {
+	if p.CallTimeoutSeconds < 0 || p.ConnectTimeoutSeconds < 0 ||
+		p.MaxRetries < 0 || p.RetryBackoffMS < 0 ||
+		p.BreakerThreshold < 0 || p.BreakerCooldownSeconds < 0 {
+		return ErrInvalidCallPolicy
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return s.repo.UpdateCatalogServerCallPolicy(ctx, id, p)
+}
+
+// ValidateIdentityHeader checks a header name for the caller's identity
+// token. Empty is valid and sends no token.
+func ValidateIdentityHeader(h string) error {
+	if h == "" {
+		return nil
+	}
+	if len(h) > maxIdentityHeaderLen || !identityHeaderRE.MatchString(h) {
+		return ErrInvalidIdentityHeader
+	}
+	for _, r := range reservedHeaders {
+		if strings.EqualFold(h, r) {
+			return ErrInvalidIdentityHeader
+		}
+	}
+	return nil
+}
+
+// SetIdentityHeader sets the header calls to a catalog server carry the
+// caller's identity token in; empty stops sending it.
+func (s *Service) SetIdentityHeader(
+	ctx context.Context,
+	id string,
+	header string,
+) error {
+	if err := ValidateIdentityHeader(header); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return s.repo.UpdateCatalogServerIdentityHeader(ctx, id, header)
+}
+
 // UpdateCapabilities modifies capabilities and transport of a catalog server.
 func (s *Service) UpdateCapabilities(
 	ctx context.Context,
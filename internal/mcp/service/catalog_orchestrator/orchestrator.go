@@ -5,8 +5,10 @@ import (
 	"context"
 	"encoding/json"
 	"log/slog"
+	"time"
 
 	"github.com/ChiragChiranjib/mcp-proxy/internal/encryptor"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/events"
 	mcpclient "github.com/ChiragChiranjib/mcp-proxy/internal/mcp/client"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/idgen"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
@@ -24,6 +26,7 @@ type Orchestrator struct {
 	repo    *repo.Repo
 	logger  *slog.Logger
 	encr    *encryptor.AESEncrypter
+	events  *events.Bus
 }
 
 // New creates a catalog orchestrator.
@@ -33,6 +36,7 @@ func New(
 	r *repo.Repo,
 	logger *slog.Logger,
 	encr *encryptor.AESEncrypter,
+	bus *events.Bus,
 ) *Orchestrator {
 	return &Orchestrator{
 		catalog: catalogSvc,
@@ -40,6 +44,7 @@ func New(
 		repo:    r,
 		logger:  logger,
 		encr:    encr,
+		events:  bus,
 	}
 }
 
@@ -52,7 +57,11 @@ func (o *Orchestrator) AddCatalogServer(
 	)
 
 	var caps []byte
-	var toolModels []m.MCPTool
+	var (
+		toolModels     []m.MCPTool
+		resourceModels []m.MCPResource
+		promptModels   []m.MCPPrompt
+	)
 
 	// If access_type is public, fetch capabilities and tools
 	if srv.AccessType == m.AccessTypePublic {
@@ -60,12 +69,12 @@ func (o *Orchestrator) AddCatalogServer(
 		serverName := srv.Name
 
 		// No auth headers for public servers
-		headers := map[string]string{}
+		up := mcpclient.NewUpstream(srv, map[string]string{})
 
 		// Fetch capabilities
 		o.logger.Info("CATALOG_ORCH_INIT_CAPABILITIES_INIT", "server_url", serverURL)
 		var err error
-		caps, err = mcpclient.InitCapabilities(ctx, serverURL, headers)
+		caps, err = mcpclient.InitCapabilities(ctx, up)
 		if err != nil {
 			o.logger.Error("CATALOG_ORCH_INIT_CAPABILITIES_ERROR", "error", err)
 			return "", err
@@ -76,7 +85,7 @@ func (o *Orchestrator) AddCatalogServer(
 
 		// Fetch tools
 		o.logger.Info("CATALOG_ORCH_LIST_TOOLS_INIT")
-		toolsRes, err := mcpclient.ListTools(ctx, serverURL, headers)
+		toolsRes, err := mcpclient.ListTools(ctx, up)
 		if err != nil {
 			o.logger.Error("CATALOG_ORCH_LIST_TOOLS_ERROR", "error", err)
 			return "", err
@@ -101,6 +110,17 @@ func (o *Orchestrator) AddCatalogServer(
 			})
 		}
 		o.logger.Info("CATALOG_ORCH_BUILD_TOOL_MODELS_SUCCESS", "count", len(toolModels))
+
+		o.logger.Info("CATALOG_ORCH_LIST_INVENTORY_INIT")
+		inv, err := mcpclient.ListInventory(ctx, up)
+		if err != nil {
+			o.logger.Error("CATALOG_ORCH_LIST_INVENTORY_ERROR", "error", err)
+			return "", err
+		}
+		resourceModels, promptModels = inv.Models(srv.ID, m.Owner{}, nil)
+		o.logger.Info("CATALOG_ORCH_LIST_INVENTORY_SUCCESS",
+			"resource_count", len(resourceModels),
+			"prompt_count", len(promptModels))
 	}
 
 	// Transaction: create server and create tools
@@ -116,6 +136,20 @@ func (o *Orchestrator) AddCatalogServer(
 			return err
 		}
 
+		// Create resources and prompts if we have them
+		if len(resourceModels) > 0 {
+			if _, _, err := tx.SyncResourcesForServer(
+				ctx, srv.ID, m.Owner{}, resourceModels); err != nil {
+				return err
+			}
+		}
+		if len(promptModels) > 0 {
+			if _, _, err := tx.SyncPromptsForServer(
+				ctx, srv.ID, m.Owner{}, promptModels); err != nil {
+				return err
+			}
+		}
+
 		return nil
 	})
 	if err != nil {
@@ -147,6 +181,15 @@ func (o *Orchestrator) RefreshCatalogServer(
 		return nil, nil, nil // Not an error, just skip
 	}
 
+	started := time.Now()
+	defer func() {
+		// Record even when the caller's context is done
+		if rerr := o.repo.RecordCatalogServerRefresh(
+			context.WithoutCancel(ctx), serverID, started, err); rerr != nil {
+			o.logger.Error("CATALOG_ORCH_REFRESH_RECORD_ERROR", "error", rerr)
+		}
+	}()
+
 	o.logger.Info("CATALOG_ORCH_REFRESH_FETCH_TOOLS_INIT")
 	added, deleted, err = o.fetchAndStoreToolsWithDiff(ctx, srv)
 	if err != nil {
@@ -160,18 +203,19 @@ func (o *Orchestrator) RefreshCatalogServer(
 }
 
 // fetchAndStoreToolsWithDiff connects to a public server, fetches tools,
-// and returns what was added/deleted.
+// and returns what was added/deleted. Kept tools whose definition changed
+// upstream are updated.
 func (o *Orchestrator) fetchAndStoreToolsWithDiff(
 	ctx context.Context, srv m.MCPServer) (added []m.MCPTool, deleted []m.MCPTool, err error) {
 	serverURL := srv.URL
 	serverName := srv.Name
 
 	// No auth headers for public servers
-	headers := map[string]string{}
+	up := mcpclient.NewUpstream(srv, map[string]string{})
 
 	// Fetch capabilities
 	o.logger.Info("CATALOG_ORCH_INIT_CAPABILITIES_INIT", "server_url", serverURL)
-	caps, err := mcpclient.InitCapabilities(ctx, serverURL, headers)
+	caps, err := mcpclient.InitCapabilities(ctx, up)
 	if err != nil {
 		o.logger.Error("CATALOG_ORCH_INIT_CAPABILITIES_ERROR", "error", err)
 		return nil, nil, err
@@ -179,14 +223,14 @@ func (o *Orchestrator) fetchAndStoreToolsWithDiff(
 	o.logger.Info("CATALOG_ORCH_INIT_CAPABILITIES_SUCCESS", "len", len(caps))
 
 	// Update server with capabilities
-	if err := o.catalog.UpdateCapabilities(ctx, srv.ID, caps, srv.Transport); err != nil {
+	if err := o.catalog.UpdateCapabilities(ctx, srv.ID, caps, string(srv.Transport)); err != nil {
 		o.logger.Error("CATALOG_ORCH_UPDATE_CAPABILITIES_ERROR", "error", err)
 		return nil, nil, err
 	}
 
 	// Fetch tools
 	o.logger.Info("CATALOG_ORCH_LIST_TOOLS_INIT")
-	toolsRes, err := mcpclient.ListTools(ctx, serverURL, headers)
+	toolsRes, err := mcpclient.ListTools(ctx, up)
 	if err != nil {
 		o.logger.Error("CATALOG_ORCH_LIST_TOOLS_ERROR", "error", err)
 		return nil, nil, err
@@ -215,28 +259,41 @@ func (o *Orchestrator) fetchAndStoreToolsWithDiff(
 		currentSet[t.ModifiedName] = t
 	}
 
-	// Compute to-add and to-remove
-	var toInsert []m.MCPTool
+	// Compute to-add, to-update and to-remove
+	var toInsert, toUpdate []m.MCPTool
 	for name, dtool := range desired {
-		if _, ok := currentSet[name]; !ok {
-			annotationsJSON, err := json.Marshal(dtool.Annotations)
-			if err != nil {
-				o.logger.Error("CATALOG_ORCH_REFRESH_ANNOTATIONS_MARSHALL_ERROR",
-					"error", err)
-			}
+		annotationsJSON, err := json.Marshal(dtool.Annotations)
+		if err != nil {
+			o.logger.Error("CATALOG_ORCH_REFRESH_ANNOTATIONS_MARSHALL_ERROR",
+				"error", err)
+		}
+		schemaJSON, _ := json.Marshal(dtool.InputSchema)
+		def := m.MCPTool{
+			Description: dtool.Description,
+			InputSchema: schemaJSON,
+			Annotations: annotationsJSON,
+		}
 
-			toInsert = append(toInsert, m.MCPTool{
-				ID:           idgen.NewID(),
-				UserID:       nil, // Global tool
-				MCPServerID:  srv.ID,
-				OriginalName: dtool.Name,
-				ModifiedName: serverName + "-" + dtool.Name,
-				Description:  dtool.Description,
-				InputSchema:  dtool.RawInputSchema,
-				Annotations:  annotationsJSON,
-				Status:       m.StatusActive,
-			})
+		if rec, ok := currentSet[name]; ok {
+			if !rec.SameDefinition(def) {
+				rec.Description = def.Description
+				rec.InputSchema = def.InputSchema
+				rec.Annotations = def.Annotations
+				toUpdate = append(toUpdate, rec)
+			}
+			continue
 		}
+		toInsert = append(toInsert, m.MCPTool{
+			ID:           idgen.NewID(),
+			UserID:       nil, // Global tool
+			MCPServerID:  srv.ID,
+			OriginalName: dtool.Name,
+			ModifiedName: serverName + "-" + dtool.Name,
+			Description:  def.Description,
+			InputSchema:  def.InputSchema,
+			Annotations:  def.Annotations,
+			Status:       m.StatusActive,
+		})
 	}
 
 	var toDeleteIDs []string
@@ -247,16 +304,60 @@ func (o *Orchestrator) fetchAndStoreToolsWithDiff(
 		}
 	}
 
+	// Resources and prompts are left as they are when they cannot be
+	// listed; the tools are still refreshed
+	o.logger.Info("CATALOG_ORCH_REFRESH_LIST_INVENTORY_INIT")
+	var resourceModels []m.MCPResource
+	var promptModels []m.MCPPrompt
+	inv, invErr := mcpclient.ListInventory(ctx, up)
+	if invErr != nil {
+		o.logger.Error("CATALOG_ORCH_REFRESH_LIST_INVENTORY_ERROR",
+			"error", invErr)
+	} else {
+		resourceModels, promptModels = inv.Models(srv.ID, m.Owner{}, nil)
+	}
+
 	// Apply changes transactionally
-	o.logger.Info("CATALOG_ORCH_REFRESH_TX_BEGIN",
-		"to_add", len(toInsert), "to_delete", len(toDeleteIDs))
+	o.logger.Info("CATALOG_ORCH_REFRESH_TX_BEGIN", "to_add", len(toInsert),
+		"to_update", len(toUpdate), "to_delete", len(toDeleteIDs))
+	var affected []string
 	err = o.repo.Transaction(func(tx *repo.Repo) error {
+		// Virtual servers exposing removed or changed tools see their list
+		// change
+		changedIDs := append([]string(nil), toDeleteIDs...)
+		for _, t := range toUpdate {
+			changedIDs = append(changedIDs, t.ID)
+		}
+		var err error
+		if affected, err = tx.ListVirtualServerIDsForTools(
+			ctx, changedIDs); err != nil {
+			return err
+		}
 		if err := tx.CreateTools(ctx, toInsert); err != nil {
 			return err
 		}
+		if err := tx.UpdateToolDefinitions(ctx, toUpdate); err != nil {
+			return err
+		}
 		if err := tx.DeleteToolsByIDs(ctx, toDeleteIDs); err != nil {
 			return err
 		}
+		if invErr != nil {
+			return nil
+		}
+		resAdded, resDeleted, err := tx.SyncResourcesForServer(
+			ctx, srv.ID, m.Owner{}, resourceModels)
+		if err != nil {
+			return err
+		}
+		prAdded, prDeleted, err := tx.SyncPromptsForServer(
+			ctx, srv.ID, m.Owner{}, promptModels)
+		if err != nil {
+			return err
+		}
+		o.logger.Info("CATALOG_ORCH_REFRESH_INVENTORY_SYNCED",
+			"resources_added", resAdded, "resources_deleted", resDeleted,
+			"prompts_added", prAdded, "prompts_deleted", prDeleted)
 		return nil
 	})
 	if err != nil {
@@ -265,8 +366,9 @@ func (o *Orchestrator) fetchAndStoreToolsWithDiff(
 		return nil, nil, err
 	}
 
+	o.events.ToolsChanged(affected...)
 	// Return what was added and deleted
-	o.logger.Info("CATALOG_ORCH_REFRESH_TOOLS_SUCCESS",
-		"added", len(toInsert), "deleted", len(deleted))
+	o.logger.Info("CATALOG_ORCH_REFRESH_TOOLS_SUCCESS", "added", len(toInsert),
+		"updated", len(toUpdate), "deleted", len(deleted))
 	return toInsert, deleted, nil
 }
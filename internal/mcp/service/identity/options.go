@@ -0,0 +1,33 @@
+// Package identity signs the short-lived JWTs that tell upstream servers
+// who made a call, and publishes the public keys that verify them as a
+// JWK set. Its signing key is separate from the session secret.
+package identity
+
+import (
+	"crypto"
+	"log/slog"
+	"time"
+)
+
+// Option configures the identity Service (functional options).
+type Option func(*Service)
+
+// WithLogger sets a logger.
+func WithLogger(l *slog.Logger) Option { return func(s *Service) { s.logger = l } }
+
+// WithSigningKey sets the private key tokens are signed with: an EC P-256
+// key (ES256) or an RSA key of at least 2048 bits (RS256). Without one a
+// key is generated, which other replicas cannot verify.
+func WithSigningKey(k crypto.Signer) Option {
+	return func(s *Service) { s.key = k }
+}
+
+// WithTokenTTL sets how long tokens are valid. Non-positive values keep the
+// default.
+func WithTokenTTL(d time.Duration) Option {
+	return func(s *Service) {
+		if d > 0 {
+			s.ttl = d
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package identity
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/idgen"
+)
+
+const (
+	defaultTokenTTL = 5 * time.Minute
+	minRSABits      = 2048
+)
+
+// ErrUnsupportedKey is returned for a signing key that is neither an EC
+// P-256 key nor an RSA key of at least 2048 bits.
+var ErrUnsupportedKey = errors.New(
+	"signing key must be an EC P-256 key or an RSA key of 2048+ bits")
+
+// Caller is who made a call through a virtual server. APIKeyID is set for
+// calls authenticated with an API key and OAuthGrantID for calls with an
+// OAuth access token.
+type Caller struct {
+	UserID          string
+	Email           string
+	VirtualServerID string
+	APIKeyID        string
+	OAuthGrantID    string
+}
+
+// claims is the payload of an identity token. The subject is the user id
+// and the audience the upstream server's URL.
+type claims struct {
+	jwt.RegisteredClaims
+	Email           string `json:"email,omitempty"`
+	VirtualServerID string `json:"virtual_server_id"`
+	APIKeyID        string `json:"api_key_id,omitempty"`
+	OAuthGrantID    string `json:"oauth_grant_id,omitempty"`
+}
+
+// JWK is a public key in JSON Web Key form (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKSet is the document served at the JWKS endpoint.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Service signs identity tokens.
+type Service struct {
+	logger *slog.Logger
+	key    crypto.Signer
+	ttl    time.Duration
+	method jwt.SigningMethod
+	jwk    JWK
+}
+
+// NewService creates an identity Service. It fails for an unsupported
+// signing key.
+func NewService(opts ...Option) (*Service, error) {
+	s := &Service{ttl: defaultTokenTTL}
+	for _, o := range opts {
+		o(s)
+	}
+	if s.logger == nil {
+		s.logger = slog.Default()
+	}
+	if s.key == nil {
+		k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		s.key = k
+		s.logger.Warn("IDENTITY_EPHEMERAL_KEY",
+			"reason", "no private key configured; tokens cannot be "+
+				"verified with other replicas or after a restart")
+	}
+	jwk, method, err := publicJWK(s.key.Public())
+	if err != nil {
+		return nil, err
+	}
+	s.jwk, s.method = jwk, method
+	return s, nil
+}
+
+// LoadSigningKey reads a PEM encoded private key in PKCS #8, SEC 1 (EC) or
+// PKCS #1 (RSA) form.
+func LoadSigningKey(path string) (crypto.Signer, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data", path)
+	}
+	var key any
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+	return signer, nil
+}
+
+// Mint signs a token identifying the caller to the upstream server at
+// audience, issued by issuer.
+func (s *Service) Mint(issuer, audience string, c Caller) (string, error) {
+	now := time.Now()
+	tok := jwt.NewWithClaims(s.method, claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   c.UserID,
+			Audience:  jwt.ClaimStrings{audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.ttl)),
+			ID:        idgen.NewID(),
+		},
+		Email:           c.Email,
+		VirtualServerID: c.VirtualServerID,
+		APIKeyID:        c.APIKeyID,
+		OAuthGrantID:    c.OAuthGrantID,
+	})
+	tok.Header["kid"] = s.jwk.Kid
+	signed, err := tok.SignedString(s.key)
+	if err != nil {
+		s.logger.Error("IDENTITY_SIGN_ERROR", "error", err)
+		return "", err
+	}
+	return signed, nil
+}
+
+// JWKS returns the public keys that verify identity tokens.
+func (s *Service) JWKS() JWKSet {
+	return JWKSet{Keys: []JWK{s.jwk}}
+}
+
+// publicJWK describes a public key as a JWK identified by its RFC 7638
+// thumbprint, and returns the method tokens are signed with.
+func publicJWK(pub crypto.PublicKey) (JWK, jwt.SigningMethod, error) {
+	b64 := base64.RawURLEncoding.EncodeToString
+	var (
+		jwk    JWK
+		method jwt.SigningMethod
+		// thumb holds the required members in lexicographic order
+		thumb any
+	)
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return JWK{}, nil, ErrUnsupportedKey
+		}
+		ek, err := k.ECDH()
+		if err != nil {
+			return JWK{}, nil, err
+		}
+		// Uncompressed point: 0x04 || X || Y
+		point := ek.Bytes()
+		jwk = JWK{Kty: "EC", Alg: "ES256", Crv: "P-256",
+			X: b64(point[1:33]), Y: b64(point[33:])}
+		method = jwt.SigningMethodES256
+		thumb = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{jwk.Crv, jwk.Kty, jwk.X, jwk.Y}
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSABits {
+			return JWK{}, nil, ErrUnsupportedKey
+		}
+		jwk = JWK{Kty: "RSA", Alg: "RS256", N: b64(k.N.Bytes()),
+			E: b64(big.NewInt(int64(k.E)).Bytes())}
+		method = jwt.SigningMethodRS256
+		thumb = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	default:
+		return JWK{}, nil, ErrUnsupportedKey
+	}
+	raw, err := json.Marshal(thumb)
+	if err != nil {
+		return JWK{}, nil, err
+	}
+	sum := sha256.Sum256(raw)
+	jwk.Use, jwk.Kid = "sig", b64(sum[:])
+	return jwk, method, nil
+}
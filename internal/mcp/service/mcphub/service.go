@@ -66,7 +66,7 @@ func (s *Service) Get(ctx context.Context, id string) (m.MCPHubServer, error) {
 	return r, nil
 }
 
-// ListForUser returns hub servers for a user.
+// ListForUser returns the personal hub servers of a user.
 func (s *Service) ListForUser(
 	ctx context.Context,
 	userID string,
@@ -83,6 +83,40 @@ func (s *Service) ListForUser(
 	return rows, nil
 }
 
+// ListForTeams returns the hub servers of the given teams.
+func (s *Service) ListForTeams(
+	ctx context.Context,
+	teamIDs []string,
+) ([]m.MCPHubServerAggregate, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	s.logger.Info("MCP_HUB_LIST_FOR_TEAMS_INIT", "team_count", len(teamIDs))
+	rows, err := s.repo.ListTeamHubMCPServers(ctx, teamIDs)
+	if err != nil {
+		s.logger.Error("MCP_HUB_LIST_FOR_TEAMS_ERROR", "error", err)
+		return nil, err
+	}
+	s.logger.Info("MCP_HUB_LIST_FOR_TEAMS_OK", "count", len(rows))
+	return rows, nil
+}
+
+// GetByServerAndTeam gets a team's hub server by server ID and team ID.
+func (s *Service) GetByServerAndTeam(
+	ctx context.Context, serverID, teamID string,
+) (m.MCPHubServerAggregate, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	s.logger.Info("MCP_HUB_GET_BY_SERVER_AND_TEAM_INIT",
+		"server_id", serverID, "team_id", teamID)
+	result, err := s.repo.GetHubServerByServerAndTeam(ctx, serverID, teamID)
+	if err != nil {
+		s.logger.Error("MCP_HUB_GET_BY_SERVER_AND_TEAM_ERROR", "error", err)
+		return m.MCPHubServerAggregate{}, err
+	}
+	s.logger.Info("MCP_HUB_GET_BY_SERVER_AND_TEAM_OK", "hub_id", result.ID)
+	return result, nil
+}
+
 // GetByServerAndUser gets a hub server by server ID and user ID.
 func (s *Service) GetByServerAndUser(
 	ctx context.Context, serverID, userID string) (m.MCPHubServerAggregate, error) {
@@ -143,3 +177,40 @@ func (s *Service) Delete(ctx context.Context, id string) error {
 	s.logger.Info("MCP_HUB_DELETE_OK", "id", id)
 	return nil
 }
+
+// GetHubAuthValue returns the stored auth value of a hub.
+func (s *Service) GetHubAuthValue(
+	ctx context.Context, id string) ([]byte, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return s.repo.GetHubAuthValue(ctx, id)
+}
+
+// UpdateHubAuthValue stores refreshed credentials of a hub.
+func (s *Service) UpdateHubAuthValue(
+	ctx context.Context, id string, value []byte) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	s.logger.Info("MCP_HUB_UPDATE_AUTH_INIT", "id", id)
+	if err := s.repo.UpdateHubAuthValue(ctx, id, value); err != nil {
+		s.logger.Error("MCP_HUB_UPDATE_AUTH_ERROR", "error", err)
+		return err
+	}
+	s.logger.Info("MCP_HUB_UPDATE_AUTH_OK", "id", id)
+	return nil
+}
+
+// MarkHubNeedsAuth moves a hub to NEEDS_AUTH until its owner authorizes
+// it again.
+func (s *Service) MarkHubNeedsAuth(
+	ctx context.Context, id, reason string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	s.logger.Info("MCP_HUB_MARK_NEEDS_AUTH_INIT", "id", id, "reason", reason)
+	if err := s.repo.MarkHubNeedsAuth(ctx, id, reason); err != nil {
+		s.logger.Error("MCP_HUB_MARK_NEEDS_AUTH_ERROR", "error", err)
+		return err
+	}
+	s.logger.Info("MCP_HUB_MARK_NEEDS_AUTH_OK", "id", id)
+	return nil
+}
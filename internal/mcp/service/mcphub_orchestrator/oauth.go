@@ -0,0 +1,223 @@
+package mcphub_orchestrator
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	mcpclient "github.com/ChiragChiranjib/mcp-proxy/internal/mcp/client"
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+const (
+	// oauthFlowTTL is how long a started authorization may take to complete.
+	oauthFlowTTL = 10 * time.Minute
+	// oauthClientName is the name the gateway registers with upstreams.
+	oauthClientName = "mcp-proxy"
+)
+
+var (
+	// ErrHubNotFound is returned for a hub that does not exist or belongs
+	// to another user.
+	ErrHubNotFound = errors.New("hub not found")
+	// ErrNotOAuth2 is returned when authorizing a hub that does not use
+	// the oauth2 auth type.
+	ErrNotOAuth2 = errors.New("hub does not use oauth2")
+	// ErrInvalidOAuthState is returned for a callback that matches no
+	// pending authorization, or one that has expired.
+	ErrInvalidOAuthState = errors.New("invalid or expired oauth state")
+)
+
+// oauthConfig is the auth value an oauth2 hub may be added with. Endpoints
+// left empty are discovered from the upstream, and without a client_id
+// the gateway registers itself dynamically.
+type oauthConfig struct {
+	ClientID              string `json:"client_id"`
+	ClientSecret          string `json:"client_secret"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	Scope                 string `json:"scope"`
+}
+
+// encodeOAuthConfig validates and encrypts the auth value of a new oauth2
+// hub.
+func (o *Orchestrator) encodeOAuthConfig(raw json.RawMessage) ([]byte, error) {
+	var cfg oauthConfig
+	if len(raw) > 0 && string(raw) != "null" {
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&cfg); err != nil {
+			return nil, fmt.Errorf("invalid oauth2 auth_value: %w", err)
+		}
+	}
+	return mcpclient.EncodeOAuthCredentials(o.encr, mcpclient.OAuthCredentials{
+		ClientID:              cfg.ClientID,
+		ClientSecret:          cfg.ClientSecret,
+		AuthorizationEndpoint: cfg.AuthorizationEndpoint,
+		TokenEndpoint:         cfg.TokenEndpoint,
+		Scope:                 cfg.Scope,
+	})
+}
+
+// StartOAuth begins authorizing an oauth2 hub with an authorization code
+// flow using PKCE. It discovers the upstream's authorization server and
+// registers a client as needed, stores the pending flow with the hub and
+// returns the URL to send the user to. The authorization server redirects
+// back to redirectURI, whose handler calls CompleteOAuth. Callers check that
+// the user may manage the hub.
+func (o *Orchestrator) StartOAuth(
+	ctx context.Context, hubID, redirectURI string,
+) (string, error) {
+	o.logger.Info("ORCH_OAUTH_START_INIT", "hub_id", hubID)
+	hub, err := o.hubs.GetWithURL(ctx, hubID)
+	if err != nil {
+		return "", ErrHubNotFound
+	}
+	if hub.AuthType != m.AuthTypeOAuth2 {
+		return "", ErrNotOAuth2
+	}
+	creds, err := mcpclient.DecodeOAuthCredentials(o.encr, hub.AuthValue)
+	if err != nil {
+		o.logger.Error("ORCH_OAUTH_DECODE_ERROR", "error", err)
+		return "", err
+	}
+	creds.Resource = hub.URL
+
+	needsClient := creds.ClientID == "" ||
+		(creds.Registered && creds.RedirectURI != redirectURI)
+	var md mcpclient.OAuthMetadata
+	if creds.AuthorizationEndpoint == "" || creds.TokenEndpoint == "" ||
+		needsClient {
+		if md, err = mcpclient.DiscoverOAuth(ctx, hub.URL); err != nil {
+			o.logger.Error("ORCH_OAUTH_DISCOVER_ERROR", "error", err)
+			return "", err
+		}
+		if creds.AuthorizationEndpoint == "" {
+			creds.AuthorizationEndpoint = md.AuthorizationEndpoint
+		}
+		if creds.TokenEndpoint == "" {
+			creds.TokenEndpoint = md.TokenEndpoint
+		}
+		if creds.Scope == "" {
+			creds.Scope = strings.Join(md.Scopes, " ")
+		}
+	}
+	if needsClient {
+		if md.RegistrationEndpoint == "" {
+			return "", errors.New("upstream does not support dynamic client " +
+				"registration; add the hub with a client_id")
+		}
+		id, secret, err := mcpclient.RegisterOAuthClient(
+			ctx, md.RegistrationEndpoint, oauthClientName, redirectURI)
+		if err != nil {
+			o.logger.Error("ORCH_OAUTH_REGISTER_ERROR", "error", err)
+			return "", err
+		}
+		creds.ClientID, creds.ClientSecret, creds.Registered = id, secret, true
+		o.logger.Info("ORCH_OAUTH_REGISTER_SUCCESS", "hub_id", hubID)
+	}
+
+	verifier, challenge, err := mcpclient.NewPKCE()
+	if err != nil {
+		return "", err
+	}
+	state, err := mcpclient.NewOAuthState(hubID)
+	if err != nil {
+		return "", err
+	}
+	expires := time.Now().Add(oauthFlowTTL).UTC()
+	creds.RedirectURI = redirectURI
+	creds.State, creds.Verifier, creds.FlowExpiresAt = state, verifier, &expires
+	enc, err := mcpclient.EncodeOAuthCredentials(o.encr, creds)
+	if err != nil {
+		return "", err
+	}
+	if err := o.repo.UpdateHubAuthValue(ctx, hubID, enc); err != nil {
+		o.logger.Error("ORCH_OAUTH_SAVE_FLOW_ERROR", "error", err)
+		return "", err
+	}
+
+	u, err := url.Parse(creds.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorization endpoint: %w", err)
+	}
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", creds.ClientID)
+	q.Set("redirect_uri", redirectURI)
+	q.Set("state", state)
+	q.Set("code_challenge", challenge)
+	q.Set("code_challenge_method", "S256")
+	q.Set("resource", creds.Resource)
+	if creds.Scope != "" {
+		q.Set("scope", creds.Scope)
+	}
+	u.RawQuery = q.Encode()
+	o.logger.Info("ORCH_OAUTH_START_SUCCESS", "hub_id", hubID)
+	return u.String(), nil
+}
+
+// CompleteOAuth redeems the authorization code of the pending flow that
+// state names, stores the tokens, makes the hub ACTIVE again and refreshes
+// its tools. It returns the hub id. Callers check that the user may manage
+// the hub OAuthStateHub names.
+func (o *Orchestrator) CompleteOAuth(
+	ctx context.Context, state, code string,
+) (string, error) {
+	hubID := OAuthStateHub(state)
+	if hubID == "" || code == "" {
+		return "", ErrInvalidOAuthState
+	}
+	o.logger.Info("ORCH_OAUTH_COMPLETE_INIT", "hub_id", hubID)
+	hub, err := o.hubs.Get(ctx, hubID)
+	if err != nil {
+		return "", ErrHubNotFound
+	}
+	creds, err := mcpclient.DecodeOAuthCredentials(o.encr, hub.AuthValue)
+	if err != nil {
+		o.logger.Error("ORCH_OAUTH_DECODE_ERROR", "error", err)
+		return hubID, err
+	}
+	if creds.State == "" ||
+		subtle.ConstantTimeCompare([]byte(state), []byte(creds.State)) != 1 ||
+		creds.FlowExpiresAt == nil || time.Now().After(*creds.FlowExpiresAt) {
+		return hubID, ErrInvalidOAuthState
+	}
+	if err := mcpclient.ExchangeOAuthCode(ctx, &creds, code); err != nil {
+		o.logger.Error("ORCH_OAUTH_EXCHANGE_ERROR", "error", err)
+		return hubID, err
+	}
+	creds.State, creds.Verifier, creds.FlowExpiresAt = "", "", nil
+	enc, err := mcpclient.EncodeOAuthCredentials(o.encr, creds)
+	if err != nil {
+		return hubID, err
+	}
+	if err := o.repo.SetHubAuthorized(ctx, hubID, enc); err != nil {
+		o.logger.Error("ORCH_OAUTH_SAVE_TOKENS_ERROR", "error", err)
+		return hubID, err
+	}
+	o.logger.Info("ORCH_OAUTH_COMPLETE_SUCCESS", "hub_id", hubID)
+
+	// The hub is authorized even if its tools cannot be fetched yet; the
+	// scheduled refresh tries again
+	if _, _, err := o.RefreshHub(ctx, hubID); err != nil {
+		o.logger.Error("ORCH_OAUTH_REFRESH_TOOLS_ERROR", "error", err)
+	}
+	return hubID, nil
+}
+
+// OAuthStateHub returns the hub an authorization flow's state belongs to,
+// or "" when the state is malformed.
+func OAuthStateHub(state string) string {
+	hubID, _, ok := strings.Cut(state, ".")
+	if !ok {
+		return ""
+	}
+	return hubID
+}
@@ -5,10 +5,12 @@ import (
 	"context"
 	"encoding/json"
 	"log/slog"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 
 	"github.com/ChiragChiranjib/mcp-proxy/internal/encryptor"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/events"
 	mcpclient "github.com/ChiragChiranjib/mcp-proxy/internal/mcp/client"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/idgen"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
@@ -25,11 +27,14 @@ type Orchestrator struct {
 	repo   *repo.Repo
 	logger *slog.Logger
 	encr   *encryptor.AESEncrypter
+	events *events.Bus
 }
 
-// CreateMCPHubServer captures inputs needed to create a hub.
+// CreateMCPHubServer captures inputs needed to create a hub. A hub with a
+// TeamID belongs to that team; UserID is who added it.
 type CreateMCPHubServer struct {
 	UserID      string          `json:"user_id,omitempty"`
+	TeamID      string          `json:"team_id,omitempty"`
 	MCPServerID string          `json:"mcp_server_id"`
 	AuthType    m.AuthType      `json:"auth_type"`
 	AuthValue   json.RawMessage `json:"auth_value"`
@@ -42,6 +47,7 @@ func New(
 	r *repo.Repo,
 	logger *slog.Logger,
 	encr *encryptor.AESEncrypter,
+	bus *events.Bus,
 ) *Orchestrator {
 	return &Orchestrator{
 		hubs:   hubs,
@@ -49,6 +55,7 @@ func New(
 		repo:   r,
 		logger: logger,
 		encr:   encr,
+		events: bus,
 	}
 }
 
@@ -58,6 +65,7 @@ func (o *Orchestrator) AddHub(
 	ctx context.Context, req CreateMCPHubServer) (string, error) {
 	o.logger.Info("ORCH_ADD_HUB_INIT",
 		"user_id", req.UserID,
+		"team_id", req.TeamID,
 		"mcp_server_id", req.MCPServerID,
 		"auth_type", req.AuthType,
 	)
@@ -82,6 +90,24 @@ func (o *Orchestrator) AddHub(
 		AuthType:    req.AuthType,
 		AuthValue:   req.AuthValue,
 	}
+	if req.TeamID != "" {
+		hub.TeamID = &req.TeamID
+	}
+	owner := hub.Owner()
+	ownerUserID, ownerTeamID := owner.Columns()
+
+	// OAuth hubs start unauthorized; their tools are fetched once the owner
+	// completes the authorization flow
+	if req.AuthType == m.AuthTypeOAuth2 {
+		enc, err := o.encodeOAuthConfig(req.AuthValue)
+		if err != nil {
+			o.logger.Error("ORCH_ENCODE_OAUTH_CONFIG_ERROR", "error", err)
+			return "", err
+		}
+		hub.AuthValue = enc
+		hub.Status = m.StatusNeedsAuth
+		hub.AuthError = "not authorized yet"
+	}
 
 	// Encrypt auth value if provided (both bearer tokens and custom headers)
 	if (req.AuthType == m.AuthTypeBearer || req.AuthType == m.AuthTypeCustomHeaders) &&
@@ -98,13 +124,18 @@ func (o *Orchestrator) AddHub(
 
 	// For public servers, skip tool fetching since global tools already exist
 	// For private servers, fetch capabilities and tools with user-specific auth
-	var toolModels []m.MCPTool
-	if srv.AccessType == m.AccessTypePrivate {
-		headers := mcpclient.BuildUpstreamHeaders(o.logger, o.encr, &hub)
+	var (
+		toolModels     []m.MCPTool
+		resourceModels []m.MCPResource
+		promptModels   []m.MCPPrompt
+	)
+	if srv.AccessType == m.AccessTypePrivate && hub.Status == m.StatusActive {
+		up := mcpclient.NewUpstream(srv,
+			mcpclient.BuildUpstreamHeaders(ctx, o.logger, o.encr, o.hubs, &hub))
 
 		// Fetch capabilities via init and tools via client
 		o.logger.Info("ORCH_INIT_CAPABILITIES_INIT", "server_url", serverURL, "access_type", srv.AccessType)
-		caps, err := mcpclient.InitCapabilities(ctx, serverURL, headers)
+		caps, err := mcpclient.InitCapabilities(ctx, up)
 		if err != nil {
 			o.logger.Error("ORCH_INIT_CAPABILITIES_ERROR", "error", err)
 			return "", err
@@ -112,7 +143,7 @@ func (o *Orchestrator) AddHub(
 		o.logger.Info("ORCH_INIT_CAPABILITIES_SUCCESS", "len", len(caps))
 
 		o.logger.Info("ORCH_LIST_TOOLS_INIT")
-		toolsRes, err := mcpclient.ListTools(ctx, serverURL, headers)
+		toolsRes, err := mcpclient.ListTools(ctx, up)
 		if err != nil {
 			o.logger.Error("ORCH_LIST_TOOLS_ERROR", "error", err)
 			return "", err
@@ -126,7 +157,8 @@ func (o *Orchestrator) AddHub(
 			annotationsJSON, _ := json.Marshal(t.Annotations)
 			toolModels = append(toolModels, m.MCPTool{
 				ID:             idgen.NewID(),
-				UserID:         &req.UserID, // User-specific tool
+				UserID:         ownerUserID, // User- or team-specific tool
+				TeamID:         ownerTeamID,
 				MCPServerID:    req.MCPServerID,
 				MCPHubServerID: &hubID, // Link to the hub server
 				OriginalName:   t.Name,
@@ -137,6 +169,20 @@ func (o *Orchestrator) AddHub(
 				Status:         m.StatusActive,
 			})
 		}
+
+		o.logger.Info("ORCH_LIST_INVENTORY_INIT")
+		inv, err := mcpclient.ListInventory(ctx, up)
+		if err != nil {
+			o.logger.Error("ORCH_LIST_INVENTORY_ERROR", "error", err)
+			return "", err
+		}
+		resourceModels, promptModels = inv.Models(
+			req.MCPServerID, owner, &hubID)
+		o.logger.Info("ORCH_LIST_INVENTORY_SUCCESS",
+			"resource_count", len(resourceModels),
+			"prompt_count", len(promptModels))
+	} else if hub.Status == m.StatusNeedsAuth {
+		o.logger.Info("ORCH_SKIP_TOOL_FETCH", "auth_type", req.AuthType, "reason", "awaiting authorization")
 	} else {
 		o.logger.Info("ORCH_SKIP_TOOL_FETCH", "access_type", srv.AccessType, "reason", "global tools already exist")
 	}
@@ -153,6 +199,18 @@ func (o *Orchestrator) AddHub(
 				return err
 			}
 		}
+		if len(resourceModels) > 0 {
+			if _, _, err := tx.SyncResourcesForServer(
+				ctx, req.MCPServerID, owner, resourceModels); err != nil {
+				return err
+			}
+		}
+		if len(promptModels) > 0 {
+			if _, _, err := tx.SyncPromptsForServer(
+				ctx, req.MCPServerID, owner, promptModels); err != nil {
+				return err
+			}
+		}
 		return nil
 	})
 	if err != nil {
@@ -165,20 +223,22 @@ func (o *Orchestrator) AddHub(
 }
 
 // RefreshHub reconciles tools for a hub against upstream and returns details
-// of tools added and deleted.
+// of tools added and deleted; tools whose description, input schema or
+// annotations changed upstream are updated in place. The tools belong to
+// the hub's owner, its team or the user who added it.
 func (o *Orchestrator) RefreshHub(
 	ctx context.Context,
 	hubID string,
-	userID string,
 ) (added []m.MCPTool, deleted []m.MCPTool, err error) {
-	o.logger.Info("ORCH_REFRESH_INIT", "hub_id", hubID, "user_id", userID)
+	o.logger.Info("ORCH_REFRESH_INIT", "hub_id", hubID)
 	info, err := o.hubs.GetWithURL(ctx, hubID)
 	if err != nil {
 		o.logger.Error("ORCH_REFRESH_GET_WITH_URL_ERROR", "error", err)
 		return nil, nil, err
 	}
+	owner := info.MCPHubServer.Owner()
+	ownerUserID, ownerTeamID := owner.Columns()
 
-	serverURL := info.URL
 	serverName := info.Name
 
 	// For public servers, tools are managed globally, not per hub
@@ -187,9 +247,20 @@ func (o *Orchestrator) RefreshHub(
 		return nil, nil, nil // No tools to add/delete for public servers
 	}
 
+	started := time.Now()
+	defer func() {
+		// Record even when the caller's context is done
+		if rerr := o.repo.RecordHubRefresh(
+			context.WithoutCancel(ctx), hubID, started, err); rerr != nil {
+			o.logger.Error("ORCH_REFRESH_RECORD_ERROR", "error", rerr)
+		}
+	}()
+
 	o.logger.Info("ORCH_REFRESH_LIST_TOOLS_INIT", "access_type", info.AccessType)
-	headers := mcpclient.BuildUpstreamHeaders(o.logger, o.encr, &info.MCPHubServer)
-	res, err := mcpclient.ListTools(ctx, serverURL, headers)
+	up := mcpclient.NewHubUpstream(info,
+		mcpclient.BuildUpstreamHeaders(
+			ctx, o.logger, o.encr, o.hubs, &info.MCPHubServer))
+	res, err := mcpclient.ListTools(ctx, up)
 	if err != nil {
 		o.logger.Error("ORCH_REFRESH_LIST_TOOLS_ERROR", "error", err)
 		return nil, nil, err
@@ -202,12 +273,11 @@ func (o *Orchestrator) RefreshHub(
 		desired[serverName+"-"+t.Name] = t
 	}
 
-	// Current set from DB (user-specific tools for this server)
-	var current []m.MCPTool
+	// Current set from DB (the owner's tools for this server)
 	o.logger.Info("ORCH_REFRESH_DB_LOAD_TOOLS_INIT")
-	if err := o.repo.WithContext(ctx).
-		Where("mcp_server_id = ? AND user_id = ?", info.MCPServerID, userID).
-		Find(&current).Error; err != nil {
+	current, err := o.repo.ListOwnedToolsForServer(
+		ctx, info.MCPServerID, owner)
+	if err != nil {
 		o.logger.Error("ORCH_REFRESH_DB_LOAD_TOOLS_ERROR", "error", err)
 		return nil, nil, err
 	}
@@ -218,30 +288,43 @@ func (o *Orchestrator) RefreshHub(
 		currentSet[t.ModifiedName] = t
 	}
 
-	// Compute to-add and to-remove
-	var toInsert []m.MCPTool
+	// Compute to-add, to-update and to-remove
+	var toInsert, toUpdate []m.MCPTool
 	for name, dtool := range desired {
-		if _, ok := currentSet[name]; !ok {
+		annotationsJSON, err := json.Marshal(dtool.Annotations)
+		if err != nil {
+			o.logger.Error("ORCH_REFRESH_ANNOTATIONS_MARSHALL_ERROR",
+				"error", err)
+		}
+		schemaJSON, _ := json.Marshal(dtool.InputSchema)
+		def := m.MCPTool{
+			Description: dtool.Description,
+			InputSchema: schemaJSON,
+			Annotations: annotationsJSON,
+		}
 
-			annotationsJSON, err := json.Marshal(dtool.Annotations)
-			if err != nil {
-				o.logger.Error("ORCH_REFRESH_ANNOTATIONS_MARSHALL_ERROR",
-					"error", err)
+		if rec, ok := currentSet[name]; ok {
+			if !rec.SameDefinition(def) {
+				rec.Description = def.Description
+				rec.InputSchema = def.InputSchema
+				rec.Annotations = def.Annotations
+				toUpdate = append(toUpdate, rec)
 			}
-
-			toInsert = append(toInsert, m.MCPTool{
-				ID:             idgen.NewID(),
-				UserID:         &userID, // User-specific tool
-				MCPServerID:    info.MCPServerID,
-				MCPHubServerID: &hubID, // Link to the hub server
-				OriginalName:   dtool.Name,
-				ModifiedName:   serverName + "-" + dtool.Name,
-				Description:    dtool.Description,
-				InputSchema:    dtool.RawInputSchema,
-				Annotations:    annotationsJSON,
-				Status:         m.StatusActive,
-			})
+			continue
 		}
+		toInsert = append(toInsert, m.MCPTool{
+			ID:             idgen.NewID(),
+			UserID:         ownerUserID, // User- or team-specific tool
+			TeamID:         ownerTeamID,
+			MCPServerID:    info.MCPServerID,
+			MCPHubServerID: &hubID, // Link to the hub server
+			OriginalName:   dtool.Name,
+			ModifiedName:   serverName + "-" + dtool.Name,
+			Description:    def.Description,
+			InputSchema:    def.InputSchema,
+			Annotations:    def.Annotations,
+			Status:         m.StatusActive,
+		})
 	}
 	var toDeleteIDs []string
 	for name, rec := range currentSet {
@@ -251,15 +334,43 @@ func (o *Orchestrator) RefreshHub(
 		}
 	}
 
+	// Resources and prompts are left as they are when they cannot be
+	// listed; the tools are still refreshed
+	o.logger.Info("ORCH_REFRESH_LIST_INVENTORY_INIT")
+	var resourceModels []m.MCPResource
+	var promptModels []m.MCPPrompt
+	inv, invErr := mcpclient.ListInventory(ctx, up)
+	if invErr != nil {
+		o.logger.Error("ORCH_REFRESH_LIST_INVENTORY_ERROR", "error", invErr)
+	} else {
+		resourceModels, promptModels = inv.Models(
+			info.MCPServerID, owner, &hubID)
+	}
+
 	// Apply changes transactionally
-	o.logger.Info("ORCH_REFRESH_TX_BEGIN",
-		"to_add", len(toInsert), "to_delete", len(toDeleteIDs))
+	o.logger.Info("ORCH_REFRESH_TX_BEGIN", "to_add", len(toInsert),
+		"to_update", len(toUpdate), "to_delete", len(toDeleteIDs))
+	var affected []string
 	err = o.repo.Transaction(func(tx *repo.Repo) error {
+		// Virtual servers exposing removed or changed tools see their list
+		// change
+		changedIDs := append([]string(nil), toDeleteIDs...)
+		for _, t := range toUpdate {
+			changedIDs = append(changedIDs, t.ID)
+		}
+		var err error
+		if affected, err = tx.ListVirtualServerIDsForTools(
+			ctx, changedIDs); err != nil {
+			return err
+		}
 		if len(toInsert) > 0 {
 			if err := tx.WithContext(ctx).Create(&toInsert).Error; err != nil {
 				return err
 			}
 		}
+		if err := tx.UpdateToolDefinitions(ctx, toUpdate); err != nil {
+			return err
+		}
 		if len(toDeleteIDs) > 0 {
 			if err := tx.WithContext(ctx).
 				Where("id IN ?", toDeleteIDs).
@@ -267,14 +378,31 @@ func (o *Orchestrator) RefreshHub(
 				return err
 			}
 		}
+		if invErr != nil {
+			return nil
+		}
+		resAdded, resDeleted, err := tx.SyncResourcesForServer(
+			ctx, info.MCPServerID, owner, resourceModels)
+		if err != nil {
+			return err
+		}
+		prAdded, prDeleted, err := tx.SyncPromptsForServer(
+			ctx, info.MCPServerID, owner, promptModels)
+		if err != nil {
+			return err
+		}
+		o.logger.Info("ORCH_REFRESH_INVENTORY_SYNCED",
+			"resources_added", resAdded, "resources_deleted", resDeleted,
+			"prompts_added", prAdded, "prompts_deleted", prDeleted)
 		return nil
 	})
 	if err != nil {
 		o.logger.Error("ORCH_REFRESH_TX_ERROR", "error", err)
 		return nil, nil, err
 	}
+	o.events.ToolsChanged(affected...)
 	// return what was added and deleted
-	o.logger.Info("ORCH_REFRESH_SUCCESS",
-		"added", len(toInsert), "deleted", len(deleted))
+	o.logger.Info("ORCH_REFRESH_SUCCESS", "added", len(toInsert),
+		"updated", len(toUpdate), "deleted", len(deleted))
 	return toInsert, deleted, nil
 }
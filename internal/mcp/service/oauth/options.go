@@ -0,0 +1,64 @@
+// Package oauth is the gateway's OAuth 2.1 authorization server for MCP
+// endpoints. It registers public clients, issues authorization codes bound
+// to a PKCE challenge, and exchanges them for short-lived access tokens
+// scoped to virtual servers, plus rotating refresh tokens.
+package oauth
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
+)
+
+// Option configures the OAuth Service (functional options).
+type Option func(*Service)
+
+// WithLogger sets a logger.
+func WithLogger(l *slog.Logger) Option { return func(s *Service) { s.logger = l } }
+
+// WithTimeout sets a per-call timeout for DB operations.
+func WithTimeout(d time.Duration) Option { return func(s *Service) { s.timeout = d } }
+
+// WithRepo injects the GORM repo.
+func WithRepo(r *repo.Repo) Option { return func(s *Service) { s.repo = r } }
+
+// WithAccessTokenTTL sets how long access tokens are valid. Non-positive
+// values keep the default.
+func WithAccessTokenTTL(d time.Duration) Option {
+	return func(s *Service) {
+		if d > 0 {
+			s.accessTTL = d
+		}
+	}
+}
+
+// WithRefreshTokenTTL sets how long refresh tokens are valid. Non-positive
+// values keep the default.
+func WithRefreshTokenTTL(d time.Duration) Option {
+	return func(s *Service) {
+		if d > 0 {
+			s.refreshTTL = d
+		}
+	}
+}
+
+// WithCodeTTL sets how long authorization codes can be redeemed.
+// Non-positive values keep the default.
+func WithCodeTTL(d time.Duration) Option {
+	return func(s *Service) {
+		if d > 0 {
+			s.codeTTL = d
+		}
+	}
+}
+
+// WithSweepInterval sets how often expired codes and tokens are deleted.
+// Non-positive values keep the default.
+func WithSweepInterval(d time.Duration) Option {
+	return func(s *Service) {
+		if d > 0 {
+			s.sweepInterval = d
+		}
+	}
+}
@@ -0,0 +1,598 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/idgen"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+const (
+	defaultAccessTTL     = 15 * time.Minute
+	defaultRefreshTTL    = 30 * 24 * time.Hour
+	defaultCodeTTL       = 2 * time.Minute
+	defaultSweepInterval = 10 * time.Minute
+	sweepTimeout         = 30 * time.Second
+
+	// accessPrefix and refreshPrefix mark tokens issued by the gateway.
+	accessPrefix  = "mcpat_"
+	refreshPrefix = "mcprt_"
+
+	// ScopePrefix prefixes the virtual server ids in scopes: "vs:<id>".
+	ScopePrefix = "vs:"
+
+	maxClientNameLen    = 255
+	maxRedirectURIs     = 10
+	maxRedirectURILen   = 2000
+	maxVirtualServers   = 50
+	minVerifierLen      = 43
+	maxVerifierLen      = 128
+	challengeMethodS256 = "S256"
+)
+
+var (
+	// ErrInvalidClientMetadata is returned for a registration that cannot
+	// be accepted.
+	ErrInvalidClientMetadata = errors.New("invalid client metadata")
+	// ErrInvalidClient is returned for an unknown client.
+	ErrInvalidClient = errors.New("invalid client")
+	// ErrInvalidRequest is returned for an authorization request missing a
+	// required parameter.
+	ErrInvalidRequest = errors.New("invalid request")
+	// ErrInvalidGrant is returned when an authorization code or refresh
+	// token cannot be redeemed.
+	ErrInvalidGrant = errors.New("invalid grant")
+	// ErrInvalidToken is returned when an access token does not
+	// authenticate.
+	ErrInvalidToken = errors.New("invalid access token")
+)
+
+// Registration is the client metadata a client registers with.
+type Registration struct {
+	Name         string
+	RedirectURIs []string
+}
+
+// Grant is what a user approved: a client's access to virtual servers,
+// redeemable at the redirect URI with the verifier of the PKCE challenge.
+type Grant struct {
+	ClientID         string
+	UserID           string
+	RedirectURI      string
+	CodeChallenge    string
+	VirtualServerIDs []string
+}
+
+// TokenSet is the result of a token request.
+type TokenSet struct {
+	AccessToken      string
+	RefreshToken     string
+	ExpiresIn        int
+	VirtualServerIDs []string
+}
+
+// Access is an authenticated access token.
+type Access struct {
+	TokenID          string
+	GrantID          string
+	ClientID         string
+	UserID           string
+	VirtualServerIDs []string
+}
+
+// Allows reports whether the token was granted access to a virtual server.
+func (a Access) Allows(vsID string) bool {
+	return slices.Contains(a.VirtualServerIDs, vsID)
+}
+
+// Service registers clients and issues and validates codes and tokens.
+type Service struct {
+	repo    *repo.Repo
+	logger  *slog.Logger
+	timeout time.Duration
+
+	accessTTL     time.Duration
+	refreshTTL    time.Duration
+	codeTTL       time.Duration
+	sweepInterval time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewService creates an OAuth Service and starts deleting expired codes
+// and tokens.
+func NewService(opts ...Option) *Service {
+	s := &Service{
+		logger:        slog.Default(),
+		accessTTL:     defaultAccessTTL,
+		refreshTTL:    defaultRefreshTTL,
+		codeTTL:       defaultCodeTTL,
+		sweepInterval: defaultSweepInterval,
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	go s.sweeper()
+	return s
+}
+
+// Close stops the sweeper.
+func (s *Service) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	<-s.done
+	return nil
+}
+
+func (s *Service) withTimeout(
+	ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, s.timeout)
+}
+
+// Register stores a new public client (RFC 7591).
+func (s *Service) Register(
+	ctx context.Context, reg Registration,
+) (m.OAuthClient, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	if len(reg.RedirectURIs) == 0 || len(reg.RedirectURIs) > maxRedirectURIs {
+		return m.OAuthClient{}, fmt.Errorf(
+			"%w: between 1 and %d redirect_uris are required",
+			ErrInvalidClientMetadata, maxRedirectURIs)
+	}
+	for _, u := range reg.RedirectURIs {
+		if err := validateRedirectURI(u); err != nil {
+			return m.OAuthClient{}, err
+		}
+	}
+	if len(reg.Name) > maxClientNameLen {
+		reg.Name = reg.Name[:maxClientNameLen]
+	}
+	uris, err := json.Marshal(reg.RedirectURIs)
+	if err != nil {
+		return m.OAuthClient{}, err
+	}
+	c := m.OAuthClient{
+		ID:           idgen.NewID(),
+		Name:         reg.Name,
+		RedirectURIs: uris,
+		CreatedAt:    time.Now().UTC().Truncate(time.Second),
+	}
+	if err := s.repo.CreateOAuthClient(ctx, c); err != nil {
+		return m.OAuthClient{}, err
+	}
+	s.logger.Info("OAUTH_CLIENT_REGISTERED", "client_id", c.ID, "name", c.Name)
+	return c, nil
+}
+
+// Client returns a registered client, or ErrInvalidClient.
+func (s *Service) Client(
+	ctx context.Context, id string) (m.OAuthClient, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	c, err := s.repo.GetOAuthClient(ctx, id)
+	if err != nil {
+		return m.OAuthClient{}, ErrInvalidClient
+	}
+	return c, nil
+}
+
+// RedirectAllowed reports whether uri is one of the client's registered
+// redirect URIs. Loopback URIs match on any port (RFC 8252).
+func RedirectAllowed(c m.OAuthClient, uri string) bool {
+	var registered []string
+	if err := json.Unmarshal(c.RedirectURIs, &registered); err != nil {
+		return false
+	}
+	for _, r := range registered {
+		if r == uri || loopbackMatch(r, uri) {
+			return true
+		}
+	}
+	return false
+}
+
+// IssueCode records an approved grant and returns its authorization code.
+func (s *Service) IssueCode(ctx context.Context, g Grant) (string, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	if g.CodeChallenge == "" || len(g.VirtualServerIDs) == 0 {
+		return "", ErrInvalidRequest
+	}
+	if len(g.VirtualServerIDs) > maxVirtualServers {
+		g.VirtualServerIDs = g.VirtualServerIDs[:maxVirtualServers]
+	}
+	code, err := newSecret("")
+	if err != nil {
+		return "", err
+	}
+	ids, err := json.Marshal(g.VirtualServerIDs)
+	if err != nil {
+		return "", err
+	}
+	rec := m.OAuthCode{
+		ID:               idgen.NewID(),
+		CodeHash:         hashSecret(code),
+		ClientID:         g.ClientID,
+		UserID:           g.UserID,
+		RedirectURI:      g.RedirectURI,
+		CodeChallenge:    g.CodeChallenge,
+		VirtualServerIDs: ids,
+		ExpiresAt:        time.Now().Add(s.codeTTL).UTC(),
+	}
+	if err := s.repo.CreateOAuthCode(ctx, rec); err != nil {
+		return "", err
+	}
+	s.logger.Info("OAUTH_CODE_ISSUED", "grant_id", rec.ID,
+		"client_id", g.ClientID, "user_id", g.UserID,
+		"vs_ids", g.VirtualServerIDs)
+	return code, nil
+}
+
+// ExchangeCode redeems an authorization code for tokens. A code presented
+// a second time revokes the tokens issued for it.
+func (s *Service) ExchangeCode(
+	ctx context.Context, clientID, code, redirectURI, verifier string,
+) (TokenSet, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	rec, err := s.repo.GetOAuthCodeByHash(ctx, hashSecret(code))
+	if err != nil {
+		return TokenSet{}, ErrInvalidGrant
+	}
+	now := time.Now().UTC()
+	if rec.UsedAt != nil {
+		s.logger.Info("OAUTH_CODE_REPLAYED", "grant_id", rec.ID)
+		if err := s.repo.RevokeOAuthGrant(ctx, rec.ID, now); err != nil {
+			s.logger.Error("OAUTH_REVOKE_GRANT_ERROR", "error", err)
+		}
+		return TokenSet{}, ErrInvalidGrant
+	}
+	if !now.Before(rec.ExpiresAt) || rec.ClientID != clientID ||
+		rec.RedirectURI != redirectURI ||
+		!verifyPKCE(rec.CodeChallenge, verifier) {
+		return TokenSet{}, ErrInvalidGrant
+	}
+	var ids []string
+	if err := json.Unmarshal(rec.VirtualServerIDs, &ids); err != nil {
+		return TokenSet{}, err
+	}
+	var set TokenSet
+	err = s.repo.Transaction(func(tx *repo.Repo) error {
+		ok, err := tx.UseOAuthCode(ctx, rec.ID, now)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidGrant
+		}
+		set, err = s.issueTokens(ctx, tx, rec.ID, clientID, rec.UserID, ids)
+		return err
+	})
+	if err != nil {
+		return TokenSet{}, err
+	}
+	s.logger.Info("OAUTH_CODE_EXCHANGED", "grant_id", rec.ID,
+		"client_id", clientID)
+	return set, nil
+}
+
+// Refresh exchanges a refresh token for new tokens and revokes it. A
+// refresh token presented after it was rotated revokes its whole grant.
+func (s *Service) Refresh(
+	ctx context.Context, clientID, token string,
+) (TokenSet, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	if !strings.HasPrefix(token, refreshPrefix) {
+		return TokenSet{}, ErrInvalidGrant
+	}
+	rec, err := s.repo.GetOAuthTokenByHash(
+		ctx, m.OAuthRefreshToken, hashSecret(token))
+	if err != nil || rec.ClientID != clientID {
+		return TokenSet{}, ErrInvalidGrant
+	}
+	now := time.Now().UTC()
+	if rec.RevokedAt != nil {
+		s.reused(ctx, rec, now)
+		return TokenSet{}, ErrInvalidGrant
+	}
+	if !now.Before(rec.ExpiresAt) {
+		return TokenSet{}, ErrInvalidGrant
+	}
+	var ids []string
+	if err := json.Unmarshal(rec.VirtualServerIDs, &ids); err != nil {
+		return TokenSet{}, err
+	}
+	var set TokenSet
+	err = s.repo.Transaction(func(tx *repo.Repo) error {
+		ok, err := tx.RevokeOAuthToken(ctx, rec.ID, now)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidGrant
+		}
+		set, err = s.issueTokens(
+			ctx, tx, rec.GrantID, clientID, rec.UserID, ids)
+		return err
+	})
+	if errors.Is(err, ErrInvalidGrant) {
+		// Another request rotated the token first.
+		s.reused(ctx, rec, now)
+	}
+	if err != nil {
+		return TokenSet{}, err
+	}
+	s.logger.Info("OAUTH_TOKEN_REFRESHED", "grant_id", rec.GrantID,
+		"client_id", clientID)
+	return set, nil
+}
+
+// reused revokes the grant of a refresh token presented twice.
+func (s *Service) reused(ctx context.Context, rec m.OAuthToken, now time.Time) {
+	s.logger.Info("OAUTH_REFRESH_REUSED", "grant_id", rec.GrantID,
+		"client_id", rec.ClientID)
+	if err := s.repo.RevokeOAuthGrant(ctx, rec.GrantID, now); err != nil {
+		s.logger.Error("OAUTH_REVOKE_GRANT_ERROR", "error", err)
+	}
+}
+
+// Revoke revokes a token of the client (RFC 7009). Revoking a refresh
+// token revokes every token of its grant. Unknown tokens are ignored.
+func (s *Service) Revoke(ctx context.Context, clientID, token string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	kind := m.OAuthAccessToken
+	if strings.HasPrefix(token, refreshPrefix) {
+		kind = m.OAuthRefreshToken
+	}
+	rec, err := s.repo.GetOAuthTokenByHash(ctx, kind, hashSecret(token))
+	if err != nil || rec.ClientID != clientID {
+		return nil
+	}
+	now := time.Now().UTC()
+	if kind == m.OAuthRefreshToken {
+		err = s.repo.RevokeOAuthGrant(ctx, rec.GrantID, now)
+	} else {
+		_, err = s.repo.RevokeOAuthToken(ctx, rec.ID, now)
+	}
+	if err != nil {
+		return err
+	}
+	s.logger.Info("OAUTH_TOKEN_REVOKED", "grant_id", rec.GrantID,
+		"kind", kind)
+	return nil
+}
+
+// IsAccessToken reports whether a bearer token looks like an access token
+// issued by the gateway rather than an API key.
+func IsAccessToken(token string) bool {
+	return strings.HasPrefix(token, accessPrefix)
+}
+
+// Authenticate resolves an access token. It returns ErrInvalidToken when
+// the token is unknown, revoked or expired.
+func (s *Service) Authenticate(
+	ctx context.Context, token string) (Access, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	if !IsAccessToken(token) {
+		return Access{}, ErrInvalidToken
+	}
+	rec, err := s.repo.GetOAuthTokenByHash(
+		ctx, m.OAuthAccessToken, hashSecret(token))
+	if err != nil || rec.RevokedAt != nil ||
+		!time.Now().Before(rec.ExpiresAt) {
+		return Access{}, ErrInvalidToken
+	}
+	var ids []string
+	if err := json.Unmarshal(rec.VirtualServerIDs, &ids); err != nil {
+		return Access{}, ErrInvalidToken
+	}
+	return Access{
+		TokenID:          rec.ID,
+		GrantID:          rec.GrantID,
+		ClientID:         rec.ClientID,
+		UserID:           rec.UserID,
+		VirtualServerIDs: ids,
+	}, nil
+}
+
+// issueTokens creates an access and a refresh token for a grant.
+func (s *Service) issueTokens(
+	ctx context.Context,
+	tx *repo.Repo,
+	grantID, clientID, userID string,
+	vsIDs []string,
+) (TokenSet, error) {
+	ids, err := json.Marshal(vsIDs)
+	if err != nil {
+		return TokenSet{}, err
+	}
+	now := time.Now().UTC()
+	set := TokenSet{
+		ExpiresIn:        int(s.accessTTL.Seconds()),
+		VirtualServerIDs: vsIDs,
+	}
+	for _, t := range []struct {
+		kind   m.OAuthTokenKind
+		prefix string
+		ttl    time.Duration
+		dst    *string
+	}{
+		{m.OAuthAccessToken, accessPrefix, s.accessTTL, &set.AccessToken},
+		{m.OAuthRefreshToken, refreshPrefix, s.refreshTTL, &set.RefreshToken},
+	} {
+		plain, err := newSecret(t.prefix)
+		if err != nil {
+			return TokenSet{}, err
+		}
+		if err := tx.CreateOAuthToken(ctx, m.OAuthToken{
+			ID:               idgen.NewID(),
+			TokenHash:        hashSecret(plain),
+			Kind:             t.kind,
+			GrantID:          grantID,
+			ClientID:         clientID,
+			UserID:           userID,
+			VirtualServerIDs: ids,
+			ExpiresAt:        now.Add(t.ttl),
+		}); err != nil {
+			return TokenSet{}, err
+		}
+		*t.dst = plain
+	}
+	return set, nil
+}
+
+func (s *Service) sweeper() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.sweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), sweepTimeout)
+		err := s.repo.DeleteExpiredOAuth(ctx, time.Now().UTC())
+		cancel()
+		if err != nil {
+			s.logger.Error("OAUTH_SWEEP_ERROR", "error", err)
+		}
+	}
+}
+
+// Scope formats virtual server ids as a space-separated scope.
+func Scope(vsIDs []string) string {
+	parts := make([]string, len(vsIDs))
+	for i, id := range vsIDs {
+		parts[i] = ScopePrefix + id
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParseScope returns the virtual server ids named in a scope. Other scope
+// values are ignored.
+func ParseScope(scope string) []string {
+	var ids []string
+	for _, p := range strings.Fields(scope) {
+		if id, ok := strings.CutPrefix(p, ScopePrefix); ok && id != "" &&
+			!slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// verifyPKCE checks a code verifier against an S256 challenge.
+func verifyPKCE(challenge, verifier string) bool {
+	if len(verifier) < minVerifierLen || len(verifier) > maxVerifierLen {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	want := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(want), []byte(challenge)) == 1
+}
+
+// ValidChallenge reports whether a PKCE challenge and method can be used.
+// Only S256 is supported.
+func ValidChallenge(challenge, method string) bool {
+	if method != challengeMethodS256 {
+		return false
+	}
+	b, err := base64.RawURLEncoding.DecodeString(challenge)
+	return err == nil && len(b) == sha256.Size
+}
+
+// validateRedirectURI accepts absolute URIs without fragments: https,
+// http on loopback hosts, and private-use schemes of native apps.
+func validateRedirectURI(raw string) error {
+	invalid := func(why string) error {
+		return fmt.Errorf("%w: redirect_uri %q %s",
+			ErrInvalidClientMetadata, raw, why)
+	}
+	if len(raw) > maxRedirectURILen {
+		return invalid("is too long")
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" {
+		return invalid("is not an absolute URI")
+	}
+	if u.Fragment != "" {
+		return invalid("has a fragment")
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "https":
+		if u.Host == "" {
+			return invalid("has no host")
+		}
+	case "http":
+		if !isLoopback(u.Hostname()) {
+			return invalid("must use https unless on a loopback host")
+		}
+	case "javascript", "data", "file", "vbscript":
+		return invalid("uses a forbidden scheme")
+	}
+	return nil
+}
+
+// loopbackMatch reports whether two http loopback URIs differ only in
+// port.
+func loopbackMatch(registered, presented string) bool {
+	a, err := url.Parse(registered)
+	if err != nil || a.Scheme != "http" || !isLoopback(a.Hostname()) {
+		return false
+	}
+	b, err := url.Parse(presented)
+	if err != nil {
+		return false
+	}
+	return b.Scheme == a.Scheme && b.Hostname() == a.Hostname() &&
+		b.Path == a.Path && b.RawQuery == a.RawQuery && b.Fragment == ""
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// newSecret returns prefix followed by 32 random bytes, base64url encoded.
+func newSecret(prefix string) (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return prefix + base64.RawURLEncoding.EncodeToString(b[:]), nil
+}
+
+func hashSecret(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
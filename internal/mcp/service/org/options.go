@@ -0,0 +1,23 @@
+// Package org manages organizations and their teams. Teams own hubs and
+// virtual servers that their members share; organization owners manage
+// every team in their organization.
+package org
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
+)
+
+// Option configures the org Service (functional options).
+type Option func(*Service)
+
+// WithLogger sets a logger.
+func WithLogger(l *slog.Logger) Option { return func(s *Service) { s.logger = l } }
+
+// WithTimeout sets a per-call timeout for DB operations.
+func WithTimeout(d time.Duration) Option { return func(s *Service) { s.timeout = d } }
+
+// WithRepo injects the GORM repo.
+func WithRepo(r *repo.Repo) Option { return func(s *Service) { s.repo = r } }
@@ -0,0 +1,441 @@
+package org
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/idgen"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+// maxNameLen matches the name columns of organizations and teams.
+const maxNameLen = 255
+
+var (
+	// ErrNotFound is returned for an unknown organization or team.
+	ErrNotFound = errors.New("organization or team not found")
+	// ErrUserNotFound is returned when adding an unknown user.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrNotMember is returned for a user who is not a member.
+	ErrNotMember = errors.New("user is not a member")
+	// ErrAlreadyMember is returned when adding an existing member.
+	ErrAlreadyMember = errors.New("user is already a member")
+	// ErrNotOrganizationMember is returned when adding a user to a team of
+	// an organization they do not belong to.
+	ErrNotOrganizationMember = errors.New(
+		"user is not a member of the team's organization")
+	// ErrInvalidName is returned for an empty or overlong name.
+	ErrInvalidName = errors.New("invalid name")
+	// ErrInvalidRole is returned for an unknown member role.
+	ErrInvalidRole = errors.New("invalid member role")
+	// ErrLastOwner is returned when removing or demoting the last owner of
+	// an organization.
+	ErrLastOwner = errors.New("organization must keep an owner")
+	// ErrOrganizationNotEmpty is returned when deleting an organization
+	// that still has teams.
+	ErrOrganizationNotEmpty = errors.New("organization still has teams")
+	// ErrTeamNotEmpty is returned when deleting a team that still owns hubs
+	// or virtual servers.
+	ErrTeamNotEmpty = errors.New("team still owns hubs or virtual servers")
+)
+
+// Service manages organizations, teams and their members.
+type Service struct {
+	repo    *repo.Repo
+	logger  *slog.Logger
+	timeout time.Duration
+}
+
+// NewService creates an org Service.
+func NewService(opts ...Option) *Service {
+	s := &Service{logger: slog.Default()}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+func (s *Service) withTimeout(
+	ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, s.timeout)
+}
+
+// notFound maps a missing record to errNotFound.
+func notFound(err, errNotFound error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errNotFound
+	}
+	return err
+}
+
+func normalizeName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxNameLen {
+		return "", ErrInvalidName
+	}
+	return name, nil
+}
+
+// CreateOrganization creates an organization owned by userID.
+func (s *Service) CreateOrganization(
+	ctx context.Context, userID, name string,
+) (m.Organization, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	name, err := normalizeName(name)
+	if err != nil {
+		return m.Organization{}, err
+	}
+	o := m.Organization{ID: idgen.NewID(), Name: name}
+	if err := s.repo.CreateOrganization(ctx, o, m.OrganizationMember{
+		OrganizationID: o.ID, UserID: userID, Role: m.MemberRoleOwner,
+	}); err != nil {
+		return m.Organization{}, err
+	}
+	s.logger.Info("ORG_CREATE_OK", "id", o.ID, "user_id", userID)
+	return o, nil
+}
+
+// GetOrganization returns an organization.
+func (s *Service) GetOrganization(
+	ctx context.Context, id string) (m.Organization, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	o, err := s.repo.GetOrganization(ctx, id)
+	return o, notFound(err, ErrNotFound)
+}
+
+// ListOrganizations lists the organizations of a user with their role, or
+// every organization when all is set.
+func (s *Service) ListOrganizations(
+	ctx context.Context, userID string, all bool,
+) ([]m.OrganizationWithRole, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return s.repo.ListOrganizationsForUser(ctx, userID, all)
+}
+
+// OrganizationRole returns a user's role in an organization; it is empty
+// when they are not a member.
+func (s *Service) OrganizationRole(
+	ctx context.Context, orgID, userID string) (m.MemberRole, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	om, err := s.repo.GetOrganizationMember(ctx, orgID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	return om.Role, err
+}
+
+// RenameOrganization changes the name of an organization.
+func (s *Service) RenameOrganization(
+	ctx context.Context, id, name string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	name, err := normalizeName(name)
+	if err != nil {
+		return err
+	}
+	return s.repo.UpdateOrganizationName(ctx, id, name)
+}
+
+// DeleteOrganization deletes an organization that has no teams left.
+func (s *Service) DeleteOrganization(ctx context.Context, id string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	n, err := s.repo.CountTeamsInOrganization(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return ErrOrganizationNotEmpty
+	}
+	if err := s.repo.DeleteOrganization(ctx, id); err != nil {
+		return err
+	}
+	s.logger.Info("ORG_DELETE_OK", "id", id)
+	return nil
+}
+
+// ListOrganizationMembers lists the members of an organization.
+func (s *Service) ListOrganizationMembers(
+	ctx context.Context, orgID string) ([]m.Member, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return s.repo.ListOrganizationMembers(ctx, orgID)
+}
+
+// AddOrganizationMember adds the user with the given username to an
+// organization.
+func (s *Service) AddOrganizationMember(
+	ctx context.Context, orgID, username string, role m.MemberRole,
+) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	if !role.Valid() {
+		return ErrInvalidRole
+	}
+	u, err := s.repo.FindUserByUsername(ctx, username)
+	if err != nil {
+		return notFound(err, ErrUserNotFound)
+	}
+	_, err = s.repo.GetOrganizationMember(ctx, orgID, u.ID)
+	switch {
+	case err == nil:
+		return ErrAlreadyMember
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return err
+	}
+	if err := s.repo.CreateOrganizationMember(ctx, m.OrganizationMember{
+		OrganizationID: orgID, UserID: u.ID, Role: role,
+	}); err != nil {
+		return err
+	}
+	s.logger.Info("ORG_ADD_MEMBER_OK",
+		"org_id", orgID, "user_id", u.ID, "role", role)
+	return nil
+}
+
+// SetOrganizationMemberRole changes a member's role in an organization.
+func (s *Service) SetOrganizationMemberRole(
+	ctx context.Context, orgID, userID string, role m.MemberRole,
+) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	if !role.Valid() {
+		return ErrInvalidRole
+	}
+	if role != m.MemberRoleOwner {
+		if err := s.checkNotLastOwner(ctx, orgID, userID); err != nil {
+			return err
+		}
+	}
+	return s.repo.UpdateOrganizationMemberRole(ctx, orgID, userID, role)
+}
+
+// RemoveOrganizationMember removes a user from an organization and all of
+// its teams.
+func (s *Service) RemoveOrganizationMember(
+	ctx context.Context, orgID, userID string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	if err := s.checkNotLastOwner(ctx, orgID, userID); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteOrganizationMember(ctx, orgID, userID); err != nil {
+		return err
+	}
+	s.logger.Info("ORG_REMOVE_MEMBER_OK", "org_id", orgID, "user_id", userID)
+	return nil
+}
+
+// checkNotLastOwner fails with ErrLastOwner when userID is the only owner
+// of an organization, and with ErrNotMember when they are not a member.
+func (s *Service) checkNotLastOwner(
+	ctx context.Context, orgID, userID string) error {
+	om, err := s.repo.GetOrganizationMember(ctx, orgID, userID)
+	if err != nil {
+		return notFound(err, ErrNotMember)
+	}
+	if om.Role != m.MemberRoleOwner {
+		return nil
+	}
+	n, err := s.repo.CountOrganizationOwners(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if n <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+// CreateTeam creates a team in an organization with userID as its owner.
+// Owners of the organization manage its teams without being members, so
+// they are not added.
+func (s *Service) CreateTeam(
+	ctx context.Context, orgID, userID, name string,
+) (m.Team, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	name, err := normalizeName(name)
+	if err != nil {
+		return m.Team{}, err
+	}
+	om, err := s.repo.GetOrganizationMember(ctx, orgID, userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return m.Team{}, err
+	}
+	t := m.Team{ID: idgen.NewID(), OrganizationID: orgID, Name: name}
+	var owner *m.TeamMember
+	if err == nil && om.Role != m.MemberRoleOwner {
+		owner = &m.TeamMember{
+			TeamID: t.ID, UserID: userID, Role: m.MemberRoleOwner,
+		}
+	}
+	if err := s.repo.CreateTeam(ctx, t, owner); err != nil {
+		return m.Team{}, err
+	}
+	s.logger.Info("ORG_CREATE_TEAM_OK",
+		"id", t.ID, "org_id", orgID, "user_id", userID)
+	return t, nil
+}
+
+// GetTeam returns a team.
+func (s *Service) GetTeam(ctx context.Context, id string) (m.Team, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	t, err := s.repo.GetTeam(ctx, id)
+	return t, notFound(err, ErrNotFound)
+}
+
+// ListTeams lists the teams a user has a role in, limited to one
+// organization when orgID is set, or every team when all is set.
+func (s *Service) ListTeams(
+	ctx context.Context, userID, orgID string, all bool,
+) ([]m.TeamWithRole, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return s.repo.ListTeamsForUser(ctx, userID, orgID, all)
+}
+
+// TeamRole returns a user's effective role in a team; it is empty when
+// they have none.
+func (s *Service) TeamRole(
+	ctx context.Context, teamID, userID string) (m.MemberRole, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return s.repo.GetTeamRole(ctx, teamID, userID)
+}
+
+// TeamIDsForUser returns the teams in which a user has at least the given
+// role.
+func (s *Service) TeamIDsForUser(
+	ctx context.Context, userID string, min m.MemberRole,
+) ([]string, error) {
+	teams, err := s.ListTeams(ctx, userID, "", false)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(teams))
+	for _, t := range teams {
+		if t.Role.AtLeast(min) {
+			ids = append(ids, t.ID)
+		}
+	}
+	return ids, nil
+}
+
+// RenameTeam changes the name of a team.
+func (s *Service) RenameTeam(ctx context.Context, id, name string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	name, err := normalizeName(name)
+	if err != nil {
+		return err
+	}
+	return s.repo.UpdateTeamName(ctx, id, name)
+}
+
+// DeleteTeam deletes a team that owns no hubs or virtual servers.
+func (s *Service) DeleteTeam(ctx context.Context, id string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	n, err := s.repo.CountTeamServers(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return ErrTeamNotEmpty
+	}
+	if err := s.repo.DeleteTeam(ctx, id); err != nil {
+		return err
+	}
+	s.logger.Info("ORG_DELETE_TEAM_OK", "id", id)
+	return nil
+}
+
+// ListTeamMembers lists the members of a team.
+func (s *Service) ListTeamMembers(
+	ctx context.Context, teamID string) ([]m.Member, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return s.repo.ListTeamMembers(ctx, teamID)
+}
+
+// AddTeamMember adds the user with the given username to a team. They
+// must belong to the team's organization.
+func (s *Service) AddTeamMember(
+	ctx context.Context, teamID, username string, role m.MemberRole,
+) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	if !role.Valid() {
+		return ErrInvalidRole
+	}
+	t, err := s.repo.GetTeam(ctx, teamID)
+	if err != nil {
+		return notFound(err, ErrNotFound)
+	}
+	u, err := s.repo.FindUserByUsername(ctx, username)
+	if err != nil {
+		return notFound(err, ErrUserNotFound)
+	}
+	if _, err := s.repo.GetOrganizationMember(
+		ctx, t.OrganizationID, u.ID); err != nil {
+		return notFound(err, ErrNotOrganizationMember)
+	}
+	_, err = s.repo.GetTeamMember(ctx, teamID, u.ID)
+	switch {
+	case err == nil:
+		return ErrAlreadyMember
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return err
+	}
+	if err := s.repo.CreateTeamMember(ctx, m.TeamMember{
+		TeamID: teamID, UserID: u.ID, Role: role,
+	}); err != nil {
+		return err
+	}
+	s.logger.Info("ORG_ADD_TEAM_MEMBER_OK",
+		"team_id", teamID, "user_id", u.ID, "role", role)
+	return nil
+}
+
+// SetTeamMemberRole changes a member's role in a team.
+func (s *Service) SetTeamMemberRole(
+	ctx context.Context, teamID, userID string, role m.MemberRole,
+) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	if !role.Valid() {
+		return ErrInvalidRole
+	}
+	if _, err := s.repo.GetTeamMember(ctx, teamID, userID); err != nil {
+		return notFound(err, ErrNotMember)
+	}
+	return s.repo.UpdateTeamMemberRole(ctx, teamID, userID, role)
+}
+
+// RemoveTeamMember removes a user from a team.
+func (s *Service) RemoveTeamMember(
+	ctx context.Context, teamID, userID string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	if err := s.repo.DeleteTeamMember(ctx, teamID, userID); err != nil {
+		return err
+	}
+	s.logger.Info("ORG_REMOVE_TEAM_MEMBER_OK",
+		"team_id", teamID, "user_id", userID)
+	return nil
+}
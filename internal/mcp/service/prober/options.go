@@ -0,0 +1,93 @@
+// Package prober periodically checks that upstream servers answer the MCP
+// initialize handshake and ping. It records probe latency and errors on
+// catalog servers and hubs, and moves hubs between ACTIVE and UNREACHABLE
+// after a configurable number of consecutive failures or successes.
+package prober
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/encryptor"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/metrics"
+)
+
+// Option configures the Prober (functional options).
+type Option func(*Prober)
+
+// WithLogger sets a logger.
+func WithLogger(l *slog.Logger) Option { return func(p *Prober) { p.logger = l } }
+
+// WithRepo injects the GORM repo.
+func WithRepo(r *repo.Repo) Option { return func(p *Prober) { p.repo = r } }
+
+// WithEncrypter decrypts hub credentials for probes.
+func WithEncrypter(e *encryptor.AESEncrypter) Option {
+	return func(p *Prober) { p.encr = e }
+}
+
+// WithMetrics records probe latency and hub status changes.
+func WithMetrics(mt *metrics.Metrics) Option {
+	return func(p *Prober) { p.metrics = mt }
+}
+
+// WithInterval sets how often each server and hub is probed.
+// Non-positive values keep the default.
+func WithInterval(d time.Duration) Option {
+	return func(p *Prober) {
+		if d > 0 {
+			p.interval = d
+		}
+	}
+}
+
+// WithJitter sets the maximum random delay added to each probe round.
+// Non-positive values keep the default.
+func WithJitter(d time.Duration) Option {
+	return func(p *Prober) {
+		if d > 0 {
+			p.jitter = d
+		}
+	}
+}
+
+// WithProbeTimeout bounds a single probe.
+// Non-positive values keep the default.
+func WithProbeTimeout(d time.Duration) Option {
+	return func(p *Prober) {
+		if d > 0 {
+			p.probeTimeout = d
+		}
+	}
+}
+
+// WithFailureThreshold sets how many consecutive failed probes mark an
+// ACTIVE hub UNREACHABLE. Non-positive values keep the default.
+func WithFailureThreshold(n int) Option {
+	return func(p *Prober) {
+		if n > 0 {
+			p.failureThreshold = n
+		}
+	}
+}
+
+// WithSuccessThreshold sets how many consecutive successful probes bring
+// an UNREACHABLE hub back to ACTIVE. Non-positive values keep the default.
+func WithSuccessThreshold(n int) Option {
+	return func(p *Prober) {
+		if n > 0 {
+			p.successThreshold = n
+		}
+	}
+}
+
+// WithConcurrency caps how many probes run at once.
+// Non-positive values keep the default.
+func WithConcurrency(n int) Option {
+	return func(p *Prober) {
+		if n > 0 {
+			p.concurrency = n
+		}
+	}
+}
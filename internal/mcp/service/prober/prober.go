@@ -0,0 +1,259 @@
+package prober
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/encryptor"
+	mcpclient "github.com/ChiragChiranjib/mcp-proxy/internal/mcp/client"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/idgen"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/metrics"
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+const (
+	defaultInterval         = time.Minute
+	defaultJitter           = 10 * time.Second
+	defaultProbeTimeout     = 10 * time.Second
+	defaultFailureThreshold = 3
+	defaultSuccessThreshold = 2
+	defaultConcurrency      = 8
+)
+
+// Prober runs health probes in rounds. Like the refresh scheduler it takes
+// the lease "probe:<kind>:<id>" for one interval before probing an item,
+// so each item is probed by one replica per interval.
+type Prober struct {
+	repo    *repo.Repo
+	encr    *encryptor.AESEncrypter
+	metrics *metrics.Metrics
+	logger  *slog.Logger
+
+	interval         time.Duration
+	jitter           time.Duration
+	probeTimeout     time.Duration
+	failureThreshold int
+	successThreshold int
+	concurrency      int
+
+	// holder identifies this process in probe leases
+	holder string
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// New creates a Prober. Call Start to begin probing.
+func New(opts ...Option) *Prober {
+	p := &Prober{
+		logger:           slog.Default(),
+		interval:         defaultInterval,
+		jitter:           defaultJitter,
+		probeTimeout:     defaultProbeTimeout,
+		failureThreshold: defaultFailureThreshold,
+		successThreshold: defaultSuccessThreshold,
+		concurrency:      defaultConcurrency,
+		stop:             make(chan struct{}),
+		done:             make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(p)
+	}
+	host, _ := os.Hostname()
+	p.holder = host + "/" + idgen.NewID()
+	return p
+}
+
+// Start launches the probe loop.
+func (p *Prober) Start() {
+	go p.loop()
+	p.logger.Info("PROBER_STARTED",
+		"holder", p.holder,
+		"interval", p.interval,
+		"failure_threshold", p.failureThreshold,
+		"success_threshold", p.successThreshold)
+}
+
+// Close stops probing and waits for the current round to finish.
+func (p *Prober) Close() error {
+	p.once.Do(func() { close(p.stop) })
+	<-p.done
+	return nil
+}
+
+func (p *Prober) loop() {
+	defer close(p.done)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-p.stop
+		cancel()
+	}()
+
+	for {
+		timer := time.NewTimer(p.interval + p.randomJitter())
+		select {
+		case <-p.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		p.round(ctx)
+	}
+}
+
+// round probes every public catalog server and every ACTIVE or
+// UNREACHABLE hub once, with at most p.concurrency probes in flight.
+func (p *Prober) round(ctx context.Context) {
+	servers, err := p.repo.ListCatalogServersToProbe(ctx)
+	if err != nil {
+		p.logger.Error("PROBER_LIST_SERVERS_ERROR", "error", err)
+	}
+	hubs, err := p.repo.ListHubsToProbe(ctx)
+	if err != nil {
+		p.logger.Error("PROBER_LIST_HUBS_ERROR", "error", err)
+	}
+
+	sem := make(chan struct{}, p.concurrency)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	run := func(fn func()) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn()
+		}()
+		return true
+	}
+	for _, srv := range servers {
+		if !run(func() { p.probeServer(ctx, srv) }) {
+			return
+		}
+	}
+	for _, hub := range hubs {
+		if !run(func() { p.probeHub(ctx, hub) }) {
+			return
+		}
+	}
+}
+
+func (p *Prober) probeServer(ctx context.Context, srv m.MCPServer) {
+	if !p.lease(ctx, "probe:"+metrics.RefreshCatalog+":"+srv.ID) {
+		return
+	}
+	up := mcpclient.NewUpstream(srv, map[string]string{})
+	at, latency, err := p.probe(ctx, metrics.RefreshCatalog, up)
+	if ctx.Err() != nil {
+		// Shutting down; the probe result says nothing about the upstream
+		return
+	}
+	if rerr := p.repo.RecordCatalogServerProbe(
+		ctx, srv.ID, at, latency, err); rerr != nil {
+		p.logger.Error("PROBER_RECORD_SERVER_ERROR",
+			"server_id", srv.ID, "error", rerr)
+	}
+	if err != nil {
+		p.logger.Info("PROBER_SERVER_FAILED",
+			"server_id", srv.ID, "upstream", up.String(), "error", err)
+	}
+}
+
+func (p *Prober) probeHub(ctx context.Context, hub m.MCPHubServerAggregate) {
+	if !p.lease(ctx, "probe:"+metrics.RefreshHub+":"+hub.ID) {
+		return
+	}
+	headers := mcpclient.BuildUpstreamHeaders(
+		ctx, p.logger, p.encr, p.repo, &hub.MCPHubServer)
+	if hub.Status == m.StatusNeedsAuth {
+		// Its token could not be refreshed; probing would only fail
+		return
+	}
+	up := mcpclient.NewHubUpstream(hub, headers)
+	at, latency, err := p.probe(ctx, metrics.RefreshHub, up)
+	if ctx.Err() != nil {
+		return
+	}
+
+	before, after, rerr := p.repo.UpdateHubProbeState(ctx, hub.ID,
+		func(h *m.MCPHubServer) {
+			h.LastProbeAt = &at
+			h.LastProbeLatencyMS = latency.Milliseconds()
+			h.LastProbeError = ""
+			if err != nil {
+				h.LastProbeError = err.Error()
+			}
+			p.transition(h, err == nil)
+		})
+	if rerr != nil {
+		p.logger.Error("PROBER_RECORD_HUB_ERROR",
+			"hub_id", hub.ID, "error", rerr)
+		return
+	}
+	if before != after {
+		p.metrics.ObserveHubTransition(string(after))
+		p.logger.Info("PROBER_HUB_STATUS_CHANGED",
+			"hub_id", hub.ID, "from", before, "to", after, "error", err)
+	}
+}
+
+// transition updates the hub's probe streak and applies hysteresis: an
+// ACTIVE hub becomes UNREACHABLE only after failureThreshold consecutive
+// failures and recovers only after successThreshold consecutive successes.
+func (p *Prober) transition(h *m.MCPHubServer, ok bool) {
+	if ok {
+		h.ProbeSuccesses++
+		h.ProbeFailures = 0
+	} else {
+		h.ProbeFailures++
+		h.ProbeSuccesses = 0
+	}
+	switch {
+	case h.Status == m.StatusActive && h.ProbeFailures >= p.failureThreshold:
+		h.Status = m.StatusUnreachable
+	case h.Status == m.StatusUnreachable && h.ProbeSuccesses >= p.successThreshold:
+		h.Status = m.StatusActive
+	}
+}
+
+// probe runs one probe and returns when it started, how long it took and
+// its error.
+func (p *Prober) probe(
+	ctx context.Context, kind string, up mcpclient.Upstream,
+) (time.Time, time.Duration, error) {
+	pctx, cancel := context.WithTimeout(ctx, p.probeTimeout)
+	defer cancel()
+	started := time.Now()
+	err := mcpclient.Probe(pctx, up)
+	latency := time.Since(started)
+	p.metrics.ObserveProbe(kind, err, latency)
+	return started, latency, err
+}
+
+// lease reports whether this replica should probe the named item now.
+func (p *Prober) lease(ctx context.Context, name string) bool {
+	ok, err := p.repo.AcquireLease(ctx, name, p.holder, p.interval)
+	if err != nil {
+		p.logger.Error("PROBER_LEASE_ERROR", "lease", name, "error", err)
+		return false
+	}
+	return ok
+}
+
+func (p *Prober) randomJitter() time.Duration {
+	if p.jitter <= 0 {
+		return 0
+	}
+	return rand.N(p.jitter)
+}
@@ -0,0 +1,67 @@
+// Package prompt provides the Prompt service for prompt operations.
+package prompt
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+// Service provides prompt operations backed by the GORM repo.
+type Service struct {
+	repo    *repo.Repo
+	logger  *slog.Logger
+	timeout time.Duration
+}
+
+func (s *Service) withTimeout(
+	ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, s.timeout)
+}
+
+// NewService creates a Prompt service.
+func NewService(opts ...Option) *Service {
+	s := &Service{}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// ListForVirtualServer returns prompts for a virtual server.
+func (s *Service) ListForVirtualServer(
+	ctx context.Context,
+	vsID string,
+) ([]m.MCPPrompt, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return s.repo.ListPromptsForVirtualServer(ctx, vsID)
+}
+
+// ListForUserFiltered filters prompts visible to a user by server and hub.
+func (s *Service) ListForUserFiltered(
+	ctx context.Context,
+	userID, serverID, hubServerID string,
+) ([]m.MCPPrompt, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return s.repo.ListUserPromptsFiltered(ctx, userID, serverID, hubServerID)
+}
+
+// ListForOwnerFiltered filters global prompts and an owner's prompts by server
+// and hub.
+func (s *Service) ListForOwnerFiltered(
+	ctx context.Context,
+	owner m.Owner,
+	serverID, hubServerID string,
+) ([]m.MCPPrompt, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return s.repo.ListOwnerPromptsFiltered(ctx, owner, serverID, hubServerID)
+}
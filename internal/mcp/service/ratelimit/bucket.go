@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// bucket is a token bucket holding up to burst tokens, refilled at rate
+// tokens per second. It starts full.
+type bucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(ratePerMinute, burst int, now time.Time) *bucket {
+	b := &bucket{last: now}
+	b.configure(ratePerMinute, burst)
+	b.tokens = b.burst
+	return b
+}
+
+// configure applies a limit. A burst of zero allows one minute's worth of
+// calls at once.
+func (b *bucket) configure(ratePerMinute, burst int) {
+	if burst <= 0 {
+		burst = ratePerMinute
+	}
+	b.rate = float64(ratePerMinute) / 60
+	b.burst = float64(burst)
+	b.tokens = math.Min(b.tokens, b.burst)
+}
+
+func (b *bucket) matches(ratePerMinute, burst int) bool {
+	if burst <= 0 {
+		burst = ratePerMinute
+	}
+	return b.rate == float64(ratePerMinute)/60 && b.burst == float64(burst)
+}
+
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed*b.rate)
+	}
+	b.last = now
+}
+
+// wait returns how long until a token is available; zero if one is.
+func (b *bucket) wait() time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
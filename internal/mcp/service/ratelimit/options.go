@@ -0,0 +1,33 @@
+// Package ratelimit enforces token-bucket rate limits and daily and
+// monthly call quotas on proxied tool calls.
+package ratelimit
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
+)
+
+// Option configures the rate limit Service (functional options).
+type Option func(*Service)
+
+// WithLogger sets a logger.
+func WithLogger(l *slog.Logger) Option { return func(s *Service) { s.logger = l } }
+
+// WithTimeout sets a per-call timeout.
+func WithTimeout(d time.Duration) Option { return func(s *Service) { s.timeout = d } }
+
+// WithRepo injects the GORM repo.
+func WithRepo(r *repo.Repo) Option { return func(s *Service) { s.repo = r } }
+
+// WithCacheTTL sets how long limits read from the DB are reused, which
+// bounds how long a change made on another replica takes to apply.
+// Non-positive values keep the default.
+func WithCacheTTL(d time.Duration) Option {
+	return func(s *Service) {
+		if d > 0 {
+			s.cacheTTL = d
+		}
+	}
+}
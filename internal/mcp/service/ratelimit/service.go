@@ -0,0 +1,367 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/idgen"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+const defaultCacheTTL = 30 * time.Second
+
+// Limit names reported in LimitError.
+const (
+	LimitRate    = "rate"
+	LimitDaily   = "daily_quota"
+	LimitMonthly = "monthly_quota"
+)
+
+var (
+	// ErrNoLimit is returned when a scope has no limit configured.
+	ErrNoLimit = errors.New("no limit configured")
+	// ErrInvalidLimit is returned for negative limit values.
+	ErrInvalidLimit = errors.New("limit values must not be negative")
+)
+
+// LimitError is returned for a call over one of its limits.
+type LimitError struct {
+	Scope      m.LimitScope
+	ScopeID    string
+	Limit      string
+	RetryAfter time.Duration
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s limit exceeded for %s %s",
+		e.Limit, e.Scope, e.ScopeID)
+}
+
+// Call names the scopes a tool call counts against. Empty ids are skipped;
+// calls made with an OAuth access token have no APIKeyID.
+type Call struct {
+	VirtualServerID string
+	APIKeyID        string
+	ToolID          string
+}
+
+// Usage is a scope's consumption of its limits.
+type Usage struct {
+	Day            string   `json:"day"`
+	CallsToday     int64    `json:"calls_today"`
+	Month          string   `json:"month"`
+	CallsThisMonth int64    `json:"calls_this_month"`
+	Tokens         *float64 `json:"tokens,omitempty"`
+}
+
+type scopeKey struct {
+	scope m.LimitScope
+	id    string
+}
+
+// scopedLimit is the limit configured for one scope of a call.
+type scopedLimit struct {
+	key   scopeKey
+	limit m.RateLimit
+}
+
+type cachedLimit struct {
+	limit   *m.RateLimit // nil when the scope has none
+	expires time.Time
+}
+
+// Service checks calls against their limits.
+//
+// Limits and quota counters live in the DB, so quotas hold across
+// replicas. Token buckets are kept per replica: each replica allows the
+// configured rate on its own.
+type Service struct {
+	repo     *repo.Repo
+	logger   *slog.Logger
+	timeout  time.Duration
+	cacheTTL time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	limits  map[scopeKey]cachedLimit
+	buckets map[scopeKey]*bucket
+}
+
+// NewService creates a rate limit Service.
+func NewService(opts ...Option) *Service {
+	s := &Service{
+		logger:   slog.Default(),
+		cacheTTL: defaultCacheTTL,
+		now:      time.Now,
+		limits:   map[scopeKey]cachedLimit{},
+		buckets:  map[scopeKey]*bucket{},
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+func (s *Service) withTimeout(
+	ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, s.timeout)
+}
+
+// Allow admits a call or returns a *LimitError for the first limit it is
+// over. An admitted call takes a token from every rate limited scope and
+// counts against every quota. Limits and counters that cannot be read or
+// written do not block calls.
+func (s *Service) Allow(ctx context.Context, call Call) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var scopes []scopedLimit
+	for _, k := range []scopeKey{
+		{m.LimitScopeVirtualServer, call.VirtualServerID},
+		{m.LimitScopeAPIKey, call.APIKeyID},
+		{m.LimitScopeTool, call.ToolID},
+	} {
+		if k.id == "" {
+			continue
+		}
+		l, err := s.limit(ctx, k)
+		if err != nil {
+			s.logger.Error("RATE_LIMIT_LOOKUP_ERROR",
+				"scope", k.scope, "scope_id", k.id, "error", err)
+			continue
+		}
+		if l != nil {
+			scopes = append(scopes, scopedLimit{k, *l})
+		}
+	}
+	if len(scopes) == 0 {
+		return nil
+	}
+
+	// Take tokens only when every bucket has one, so a call rejected by
+	// one scope does not use up the others.
+	now := s.now().UTC()
+	s.mu.Lock()
+	var taken []*bucket
+	for _, sc := range scopes {
+		if sc.limit.RatePerMinute <= 0 {
+			continue
+		}
+		b := s.bucketLocked(sc.key, sc.limit, now)
+		if wait := b.wait(); wait > 0 {
+			s.mu.Unlock()
+			return &LimitError{sc.key.scope, sc.key.id, LimitRate, wait}
+		}
+		taken = append(taken, b)
+	}
+	for _, b := range taken {
+		b.tokens--
+	}
+	s.mu.Unlock()
+
+	if err := s.count(ctx, scopes, now); err != nil {
+		// The call is not made, so it gives its tokens back.
+		s.mu.Lock()
+		for _, b := range taken {
+			b.tokens = min(b.burst, b.tokens+1)
+		}
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// count counts a call against the quotas of its scopes in one transaction,
+// so a call over one quota counts against none. It returns a *LimitError
+// for the first quota the call is over.
+func (s *Service) count(
+	ctx context.Context, scopes []scopedLimit, now time.Time) error {
+	day, month := periods(now)
+	var over *LimitError
+	err := s.repo.Transaction(func(tx *repo.Repo) error {
+		for _, sc := range scopes {
+			if sc.limit.DailyQuota <= 0 && sc.limit.MonthlyQuota <= 0 {
+				continue
+			}
+			period, err := tx.CountCallWithinQuota(ctx,
+				sc.key.scope, sc.key.id, []string{day, month},
+				[]int64{sc.limit.DailyQuota, sc.limit.MonthlyQuota})
+			switch {
+			case err != nil:
+				return err
+			case period == day:
+				over = &LimitError{sc.key.scope, sc.key.id, LimitDaily,
+					nextDay(now).Sub(now)}
+			case period == month:
+				over = &LimitError{sc.key.scope, sc.key.id, LimitMonthly,
+					nextMonth(now).Sub(now)}
+			}
+			if over != nil {
+				return over
+			}
+		}
+		return nil
+	})
+	if over != nil {
+		return over
+	}
+	if err != nil {
+		s.logger.Error("RATE_LIMIT_COUNT_ERROR", "error", err)
+	}
+	return nil
+}
+
+// Get returns the limit of a scope, or ErrNoLimit.
+func (s *Service) Get(
+	ctx context.Context, scope m.LimitScope, scopeID string,
+) (m.RateLimit, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	l, err := s.repo.GetRateLimit(ctx, scope, scopeID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return m.RateLimit{}, ErrNoLimit
+	}
+	return l, err
+}
+
+// Set creates or replaces the limit of a scope.
+func (s *Service) Set(
+	ctx context.Context, scope m.LimitScope, scopeID string, l m.RateLimit,
+) (m.RateLimit, error) {
+	if l.RatePerMinute < 0 || l.Burst < 0 ||
+		l.DailyQuota < 0 || l.MonthlyQuota < 0 {
+		return m.RateLimit{}, ErrInvalidLimit
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	l.ID = idgen.NewID()
+	l.Scope, l.ScopeID = scope, scopeID
+	if err := s.repo.UpsertRateLimit(ctx, l); err != nil {
+		return m.RateLimit{}, err
+	}
+	s.forget(scopeKey{scope, scopeID})
+	s.logger.Info("RATE_LIMIT_SET", "scope", scope, "scope_id", scopeID)
+	return s.repo.GetRateLimit(ctx, scope, scopeID)
+}
+
+// Delete removes the limit of a scope. Its usage counters are kept.
+func (s *Service) Delete(
+	ctx context.Context, scope m.LimitScope, scopeID string,
+) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	if err := s.repo.DeleteRateLimit(ctx, scope, scopeID); err != nil {
+		return err
+	}
+	s.forget(scopeKey{scope, scopeID})
+	s.logger.Info("RATE_LIMIT_DELETED", "scope", scope, "scope_id", scopeID)
+	return nil
+}
+
+// Usage returns the calls counted for a scope today and this month (UTC)
+// and, when it is rate limited, the tokens left in this replica's bucket.
+func (s *Service) Usage(
+	ctx context.Context, scope m.LimitScope, scopeID string,
+) (Usage, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	now := s.now().UTC()
+	day, month := periods(now)
+	counts, err := s.repo.GetCallUsage(ctx, scope, scopeID,
+		[]string{day, month})
+	if err != nil {
+		return Usage{}, err
+	}
+	u := Usage{
+		Day:            day,
+		CallsToday:     counts[day],
+		Month:          month,
+		CallsThisMonth: counts[month],
+	}
+	l, err := s.limit(ctx, scopeKey{scope, scopeID})
+	if err == nil && l != nil && l.RatePerMinute > 0 {
+		s.mu.Lock()
+		b := s.bucketLocked(scopeKey{scope, scopeID}, *l, now)
+		tokens := b.tokens
+		s.mu.Unlock()
+		u.Tokens = &tokens
+	}
+	return u, nil
+}
+
+// limit returns the cached limit of a scope, reading it from the DB when
+// the cache entry is missing or stale.
+func (s *Service) limit(
+	ctx context.Context, k scopeKey) (*m.RateLimit, error) {
+	now := s.now()
+	s.mu.Lock()
+	c, ok := s.limits[k]
+	s.mu.Unlock()
+	if ok && now.Before(c.expires) {
+		return c.limit, nil
+	}
+	l, err := s.repo.GetRateLimit(ctx, k.scope, k.id)
+	var limit *m.RateLimit
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+	case err != nil:
+		return nil, err
+	default:
+		limit = &l
+	}
+	s.mu.Lock()
+	s.limits[k] = cachedLimit{limit: limit, expires: now.Add(s.cacheTTL)}
+	if limit == nil || limit.RatePerMinute <= 0 {
+		delete(s.buckets, k)
+	}
+	s.mu.Unlock()
+	return limit, nil
+}
+
+// bucketLocked returns the refilled bucket of a scope, creating it or
+// applying a changed limit as needed.
+func (s *Service) bucketLocked(
+	k scopeKey, l m.RateLimit, now time.Time) *bucket {
+	b, ok := s.buckets[k]
+	switch {
+	case !ok:
+		b = newBucket(l.RatePerMinute, l.Burst, now)
+		s.buckets[k] = b
+	case !b.matches(l.RatePerMinute, l.Burst):
+		b.refill(now)
+		b.configure(l.RatePerMinute, l.Burst)
+	}
+	b.refill(now)
+	return b
+}
+
+func (s *Service) forget(k scopeKey) {
+	s.mu.Lock()
+	delete(s.limits, k)
+	s.mu.Unlock()
+}
+
+// periods returns the day and month usage periods of t.
+func periods(t time.Time) (day, month string) {
+	return t.Format("2006-01-02"), t.Format("2006-01")
+}
+
+func nextDay(t time.Time) time.Time {
+	y, mo, d := t.Date()
+	return time.Date(y, mo, d+1, 0, 0, 0, 0, time.UTC)
+}
+
+func nextMonth(t time.Time) time.Time {
+	y, mo, _ := t.Date()
+	return time.Date(y, mo+1, 1, 0, 0, 0, 0, time.UTC)
+}
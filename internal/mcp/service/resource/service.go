@@ -0,0 +1,68 @@
+// Package resource provides the Resource service for resource operations.
+package resource
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+// Service provides resource operations backed by the GORM repo.
+type Service struct {
+	repo    *repo.Repo
+	logger  *slog.Logger
+	timeout time.Duration
+}
+
+func (s *Service) withTimeout(
+	ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, s.timeout)
+}
+
+// NewService creates a Resource service.
+func NewService(opts ...Option) *Service {
+	s := &Service{}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// ListForVirtualServer returns resources and resource templates for a
+// virtual server.
+func (s *Service) ListForVirtualServer(
+	ctx context.Context,
+	vsID string,
+) ([]m.MCPResource, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return s.repo.ListResourcesForVirtualServer(ctx, vsID)
+}
+
+// ListForUserFiltered filters resources visible to a user by server and hub.
+func (s *Service) ListForUserFiltered(
+	ctx context.Context,
+	userID, serverID, hubServerID string,
+) ([]m.MCPResource, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return s.repo.ListUserResourcesFiltered(ctx, userID, serverID, hubServerID)
+}
+
+// ListForOwnerFiltered filters global resources and an owner's resources by
+// server and hub.
+func (s *Service) ListForOwnerFiltered(
+	ctx context.Context,
+	owner m.Owner,
+	serverID, hubServerID string,
+) ([]m.MCPResource, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return s.repo.ListOwnerResourcesFiltered(ctx, owner, serverID, hubServerID)
+}
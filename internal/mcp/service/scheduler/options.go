@@ -0,0 +1,101 @@
+// Package scheduler periodically refreshes tools of public catalog servers
+// and active hubs in the background. Each refresh is guarded by a
+// database lease so that only one gateway replica runs it.
+package scheduler
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
+	catalogOrchestrator "github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/catalog_orchestrator"
+	mcphubOrchestrator "github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/mcphub_orchestrator"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/metrics"
+)
+
+// Option configures the Scheduler (functional options).
+type Option func(*Scheduler)
+
+// WithLogger sets a logger.
+func WithLogger(l *slog.Logger) Option { return func(s *Scheduler) { s.logger = l } }
+
+// WithRepo injects the GORM repo.
+func WithRepo(r *repo.Repo) Option { return func(s *Scheduler) { s.repo = r } }
+
+// WithCatalogOrchestrator sets the orchestrator used to refresh catalog
+// servers. Catalog servers are not refreshed without one.
+func WithCatalogOrchestrator(o *catalogOrchestrator.Orchestrator) Option {
+	return func(s *Scheduler) { s.catalog = o }
+}
+
+// WithMcphubOrchestrator sets the orchestrator used to refresh hubs. Hubs
+// are not refreshed without one.
+func WithMcphubOrchestrator(o *mcphubOrchestrator.Orchestrator) Option {
+	return func(s *Scheduler) { s.hubs = o }
+}
+
+// WithMetrics records refresh durations and tool changes.
+func WithMetrics(mt *metrics.Metrics) Option {
+	return func(s *Scheduler) { s.metrics = mt }
+}
+
+// WithCatalogInterval sets how often each catalog server is refreshed.
+// Non-positive values keep the default.
+func WithCatalogInterval(d time.Duration) Option {
+	return func(s *Scheduler) {
+		if d > 0 {
+			s.catalogInterval = d
+		}
+	}
+}
+
+// WithHubInterval sets how often each hub is refreshed.
+// Non-positive values keep the default.
+func WithHubInterval(d time.Duration) Option {
+	return func(s *Scheduler) {
+		if d > 0 {
+			s.hubInterval = d
+		}
+	}
+}
+
+// WithPollInterval sets how often due refreshes are looked up.
+// Non-positive values keep the default.
+func WithPollInterval(d time.Duration) Option {
+	return func(s *Scheduler) {
+		if d > 0 {
+			s.pollInterval = d
+		}
+	}
+}
+
+// WithJitter sets the maximum random delay added to each poll so replicas
+// started together do not poll in lockstep.
+// Non-positive values keep the default.
+func WithJitter(d time.Duration) Option {
+	return func(s *Scheduler) {
+		if d > 0 {
+			s.jitter = d
+		}
+	}
+}
+
+// WithConcurrency caps how many refreshes of one kind run at once.
+// Non-positive values keep the default.
+func WithConcurrency(n int) Option {
+	return func(s *Scheduler) {
+		if n > 0 {
+			s.concurrency = n
+		}
+	}
+}
+
+// WithRefreshTimeout bounds a single refresh.
+// Non-positive values keep the default.
+func WithRefreshTimeout(d time.Duration) Option {
+	return func(s *Scheduler) {
+		if d > 0 {
+			s.refreshTimeout = d
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/idgen"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
+	catalogOrchestrator "github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/catalog_orchestrator"
+	mcphubOrchestrator "github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/mcphub_orchestrator"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/metrics"
+)
+
+const (
+	defaultCatalogInterval = time.Hour
+	defaultHubInterval     = time.Hour
+	defaultPollInterval    = time.Minute
+	defaultJitter          = 30 * time.Second
+	defaultConcurrency     = 4
+	defaultRefreshTimeout  = 2 * time.Minute
+)
+
+// Scheduler refreshes catalog servers and hubs whose last refresh is older
+// than their interval.
+//
+// Before refreshing an item a replica takes the lease "<kind>:<id>" for the
+// item's interval and keeps it, so a replica working from a stale list of
+// due items cannot repeat a refresh another replica just ran. A replica
+// that dies mid-refresh blocks the item for at most one interval.
+type Scheduler struct {
+	repo    *repo.Repo
+	catalog *catalogOrchestrator.Orchestrator
+	hubs    *mcphubOrchestrator.Orchestrator
+	metrics *metrics.Metrics
+	logger  *slog.Logger
+
+	catalogInterval time.Duration
+	hubInterval     time.Duration
+	pollInterval    time.Duration
+	jitter          time.Duration
+	concurrency     int
+	refreshTimeout  time.Duration
+
+	// holder identifies this process in refresh leases
+	holder string
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+// New creates a Scheduler. Call Start to begin refreshing.
+func New(opts ...Option) *Scheduler {
+	s := &Scheduler{
+		logger:          slog.Default(),
+		catalogInterval: defaultCatalogInterval,
+		hubInterval:     defaultHubInterval,
+		pollInterval:    defaultPollInterval,
+		jitter:          defaultJitter,
+		concurrency:     defaultConcurrency,
+		refreshTimeout:  defaultRefreshTimeout,
+		stop:            make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	host, _ := os.Hostname()
+	s.holder = host + "/" + idgen.NewID()
+	return s
+}
+
+// refreshTask is one due catalog server or hub.
+type refreshTask struct {
+	id  string
+	run func(ctx context.Context) (added, deleted int, err error)
+}
+
+// Start launches one polling loop per configured kind.
+func (s *Scheduler) Start() {
+	if s.catalog != nil {
+		s.wg.Add(1)
+		go s.loop(metrics.RefreshCatalog, s.catalogInterval, s.dueCatalogServers)
+	}
+	if s.hubs != nil {
+		s.wg.Add(1)
+		go s.loop(metrics.RefreshHub, s.hubInterval, s.dueHubs)
+	}
+	s.logger.Info("REFRESH_SCHEDULER_STARTED",
+		"holder", s.holder,
+		"catalog_interval", s.catalogInterval,
+		"hub_interval", s.hubInterval,
+		"concurrency", s.concurrency)
+}
+
+// Close stops polling and waits for running refreshes to finish.
+func (s *Scheduler) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	s.wg.Wait()
+	return nil
+}
+
+// loop polls for due items of one kind until the scheduler is closed.
+func (s *Scheduler) loop(
+	kind string,
+	interval time.Duration,
+	due func(ctx context.Context, before time.Time) ([]refreshTask, error),
+) {
+	defer s.wg.Done()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.stop
+		cancel()
+	}()
+
+	for {
+		timer := time.NewTimer(s.pollInterval + s.randomJitter())
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		tasks, err := due(ctx, time.Now().Add(-interval))
+		if err != nil {
+			s.logger.Error("REFRESH_SCHEDULER_LIST_DUE_ERROR",
+				"kind", kind, "error", err)
+			continue
+		}
+		if len(tasks) > 0 {
+			s.logger.Info("REFRESH_SCHEDULER_DUE", "kind", kind, "count", len(tasks))
+		}
+		s.runAll(ctx, kind, interval, tasks)
+	}
+}
+
+// runAll runs the tasks with at most s.concurrency in flight.
+func (s *Scheduler) runAll(
+	ctx context.Context, kind string, interval time.Duration, tasks []refreshTask,
+) {
+	sem := make(chan struct{}, s.concurrency)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for _, t := range tasks {
+		select {
+		case <-ctx.Done():
+			return
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			s.runOne(ctx, kind, interval, t)
+		}()
+	}
+}
+
+// runOne refreshes a single item if this replica wins its lease.
+func (s *Scheduler) runOne(
+	ctx context.Context, kind string, interval time.Duration, t refreshTask,
+) {
+	name := kind + ":" + t.id
+	ttl := max(interval, s.refreshTimeout)
+	ok, err := s.repo.AcquireLease(ctx, name, s.holder, ttl)
+	if err != nil {
+		s.logger.Error("REFRESH_SCHEDULER_LEASE_ERROR",
+			"lease", name, "error", err)
+		return
+	}
+	if !ok {
+		s.logger.Debug("REFRESH_SCHEDULER_LEASE_HELD", "lease", name)
+		return
+	}
+
+	rctx, cancel := context.WithTimeout(ctx, s.refreshTimeout)
+	defer cancel()
+	started := time.Now()
+	added, deleted, err := t.run(rctx)
+	s.metrics.ObserveRefresh(kind, added, deleted, err, time.Since(started))
+	if err != nil {
+		s.logger.Error("REFRESH_SCHEDULER_REFRESH_ERROR",
+			"kind", kind, "id", t.id, "error", err)
+		return
+	}
+	s.logger.Info("REFRESH_SCHEDULER_REFRESH_SUCCESS",
+		"kind", kind, "id", t.id, "added", added, "deleted", deleted)
+}
+
+func (s *Scheduler) dueCatalogServers(
+	ctx context.Context, before time.Time) ([]refreshTask, error) {
+	rows, err := s.repo.ListCatalogServersDueForRefresh(ctx, before)
+	if err != nil {
+		return nil, err
+	}
+	tasks := make([]refreshTask, 0, len(rows))
+	for _, srv := range rows {
+		tasks = append(tasks, refreshTask{
+			id: srv.ID,
+			run: func(ctx context.Context) (int, int, error) {
+				added, deleted, err := s.catalog.RefreshCatalogServer(ctx, srv.ID)
+				return len(added), len(deleted), err
+			},
+		})
+	}
+	return tasks, nil
+}
+
+func (s *Scheduler) dueHubs(
+	ctx context.Context, before time.Time) ([]refreshTask, error) {
+	rows, err := s.repo.ListHubsDueForRefresh(ctx, before)
+	if err != nil {
+		return nil, err
+	}
+	tasks := make([]refreshTask, 0, len(rows))
+	for _, hub := range rows {
+		tasks = append(tasks, refreshTask{
+			id: hub.ID,
+			run: func(ctx context.Context) (int, int, error) {
+				added, deleted, err := s.hubs.RefreshHub(ctx, hub.ID)
+				return len(added), len(deleted), err
+			},
+		})
+	}
+	return tasks, nil
+}
+
+func (s *Scheduler) randomJitter() time.Duration {
+	if s.jitter <= 0 {
+		return 0
+	}
+	return rand.N(s.jitter)
+}
@@ -0,0 +1,48 @@
+// Package session keeps the client sessions of stateful virtual server
+// endpoints and the streams used to push server-initiated messages.
+package session
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Option configures the session Service (functional options).
+type Option func(*Service)
+
+// WithLogger sets a logger.
+func WithLogger(l *slog.Logger) Option { return func(s *Service) { s.logger = l } }
+
+// WithStore sets where sessions are kept. The default is an in-memory
+// store, which only suits a single replica.
+func WithStore(st Store) Option { return func(s *Service) { s.store = st } }
+
+// WithTTL sets how long a session may stay idle before it expires.
+// Non-positive values keep the default.
+func WithTTL(d time.Duration) Option {
+	return func(s *Service) {
+		if d > 0 {
+			s.ttl = d
+		}
+	}
+}
+
+// WithSweepInterval sets how often expired sessions are removed from the
+// store. Non-positive values keep the default.
+func WithSweepInterval(d time.Duration) Option {
+	return func(s *Service) {
+		if d > 0 {
+			s.sweepInterval = d
+		}
+	}
+}
+
+// WithStreamBuffer sets how many messages may wait for a stream before new
+// ones are dropped. Non-positive values keep the default.
+func WithStreamBuffer(n int) Option {
+	return func(s *Service) {
+		if n > 0 {
+			s.streamBuffer = n
+		}
+	}
+}
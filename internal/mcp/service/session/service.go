@@ -0,0 +1,215 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+const (
+	defaultTTL           = 30 * time.Minute
+	defaultSweepInterval = time.Minute
+	defaultStreamBuffer  = 64
+	sweepTimeout         = 10 * time.Second
+
+	// idPrefix marks session ids issued by the gateway.
+	idPrefix = "mcps_"
+)
+
+var (
+	// ErrNotFound is returned for a session that does not exist, has
+	// expired or belongs to another virtual server or API key.
+	ErrNotFound = errors.New("session not found")
+	// ErrStreamOpen is returned when a session already has a stream open
+	// on this replica.
+	ErrStreamOpen = errors.New("session stream already open")
+)
+
+// Service issues and validates sessions and delivers server-initiated
+// messages to their streams.
+//
+// Sessions live in the Store, so with the DB store any replica can serve
+// any request of a session. Streams are held by the replica that accepted
+// the client's GET; messages published on other replicas do not reach it.
+type Service struct {
+	store  Store
+	logger *slog.Logger
+
+	ttl           time.Duration
+	sweepInterval time.Duration
+	streamBuffer  int
+
+	mu      sync.Mutex
+	streams map[string]*Stream
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewService creates a session Service and starts removing expired
+// sessions.
+func NewService(opts ...Option) *Service {
+	s := &Service{
+		logger:        slog.Default(),
+		ttl:           defaultTTL,
+		sweepInterval: defaultSweepInterval,
+		streamBuffer:  defaultStreamBuffer,
+		streams:       map[string]*Stream{},
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	if s.store == nil {
+		s.store = NewMemoryStore()
+	}
+	go s.sweeper()
+	return s
+}
+
+// Close stops the sweeper and ends every open stream.
+func (s *Service) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	<-s.done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, st := range s.streams {
+		st.end()
+		delete(s.streams, id)
+	}
+	return nil
+}
+
+// Create starts a session for an initialize request.
+func (s *Service) Create(
+	ctx context.Context,
+	vsID string,
+	apiKeyID string,
+	params mcp.InitializeParams,
+) (m.MCPSession, error) {
+	id, err := newSessionID()
+	if err != nil {
+		return m.MCPSession{}, err
+	}
+	caps, err := json.Marshal(params.Capabilities)
+	if err != nil {
+		return m.MCPSession{}, err
+	}
+	now := time.Now()
+	sess := m.MCPSession{
+		ID:                 id,
+		VirtualServerID:    vsID,
+		APIKeyID:           apiKeyID,
+		ProtocolVersion:    params.ProtocolVersion,
+		ClientName:         params.ClientInfo.Name,
+		ClientVersion:      params.ClientInfo.Version,
+		ClientCapabilities: caps,
+		ExpiresAt:          now.Add(s.ttl),
+		CreatedAt:          now,
+	}
+	if err := s.store.Create(ctx, sess); err != nil {
+		return m.MCPSession{}, err
+	}
+	s.logger.Info("MCP_SESSION_CREATED",
+		"session_id", id, "vs_id", vsID, "client", params.ClientInfo.Name)
+	return sess, nil
+}
+
+// Get returns a live session of the virtual server opened with the given
+// API key. Each use moves the expiry forward; the store is only written
+// once less than half the TTL remains.
+func (s *Service) Get(
+	ctx context.Context, id, vsID, apiKeyID string,
+) (m.MCPSession, error) {
+	sess, err := s.store.Get(ctx, id)
+	if err != nil {
+		return m.MCPSession{}, err
+	}
+	now := time.Now()
+	if sess.VirtualServerID != vsID || sess.APIKeyID != apiKeyID ||
+		!sess.ExpiresAt.After(now) {
+		return m.MCPSession{}, ErrNotFound
+	}
+	if sess.ExpiresAt.Sub(now) < s.ttl/2 {
+		sess.ExpiresAt = now.Add(s.ttl)
+		if err := s.store.Touch(ctx, id, sess.ExpiresAt); err != nil {
+			s.logger.Error("MCP_SESSION_TOUCH_ERROR",
+				"session_id", id, "error", err)
+		}
+	}
+	return sess, nil
+}
+
+// SetLogLevel records the minimum level of log messages the client wants.
+func (s *Service) SetLogLevel(
+	ctx context.Context, id string, level mcp.LoggingLevel,
+) error {
+	return s.store.SetLogLevel(ctx, id, string(level))
+}
+
+// Shared reports whether sessions are visible to every replica. A client's
+// answer to a relayed request may then reach a replica other than the one
+// waiting for it.
+func (s *Service) Shared() bool {
+	return s.store.Shared()
+}
+
+// End deletes a session and ends its stream on this replica. Streams on
+// other replicas notice within one heartbeat.
+func (s *Service) End(ctx context.Context, id string) error {
+	if err := s.store.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	if st, ok := s.streams[id]; ok {
+		st.end()
+		delete(s.streams, id)
+	}
+	s.mu.Unlock()
+	s.logger.Info("MCP_SESSION_ENDED", "session_id", id)
+	return nil
+}
+
+func (s *Service) sweeper() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.sweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), sweepTimeout)
+		n, err := s.store.DeleteExpired(ctx, time.Now())
+		cancel()
+		if err != nil {
+			s.logger.Error("MCP_SESSION_SWEEP_ERROR", "error", err)
+			continue
+		}
+		if n > 0 {
+			s.logger.Info("MCP_SESSION_SWEEP", "expired", n)
+		}
+	}
+}
+
+// newSessionID returns an unguessable session id of visible ASCII
+// characters, as the MCP transport requires.
+func newSessionID() (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return idPrefix + base64.RawURLEncoding.EncodeToString(b[:]), nil
+}
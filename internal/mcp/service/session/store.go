@@ -0,0 +1,143 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+// Store keeps sessions. Get returns ErrNotFound for unknown ids; expiry is
+// checked by the Service. Shared reports whether other replicas see the
+// same sessions.
+type Store interface {
+	Create(ctx context.Context, s m.MCPSession) error
+	Get(ctx context.Context, id string) (m.MCPSession, error)
+	Touch(ctx context.Context, id string, expiresAt time.Time) error
+	SetLogLevel(ctx context.Context, id string, level string) error
+	Delete(ctx context.Context, id string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+	Shared() bool
+}
+
+// memoryStore keeps sessions in process memory.
+type memoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]m.MCPSession
+}
+
+// NewMemoryStore returns a Store local to this process.
+func NewMemoryStore() Store {
+	return &memoryStore{sessions: map[string]m.MCPSession{}}
+}
+
+func (st *memoryStore) Shared() bool { return false }
+
+func (st *memoryStore) Create(_ context.Context, s m.MCPSession) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.sessions[s.ID] = s
+	return nil
+}
+
+func (st *memoryStore) Get(_ context.Context, id string) (m.MCPSession, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	s, ok := st.sessions[id]
+	if !ok {
+		return m.MCPSession{}, ErrNotFound
+	}
+	return s, nil
+}
+
+func (st *memoryStore) Touch(
+	_ context.Context, id string, expiresAt time.Time) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if s, ok := st.sessions[id]; ok {
+		s.ExpiresAt = expiresAt
+		st.sessions[id] = s
+	}
+	return nil
+}
+
+func (st *memoryStore) SetLogLevel(
+	_ context.Context, id string, level string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if s, ok := st.sessions[id]; ok {
+		s.LogLevel = level
+		st.sessions[id] = s
+	}
+	return nil
+}
+
+func (st *memoryStore) Delete(_ context.Context, id string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	delete(st.sessions, id)
+	return nil
+}
+
+func (st *memoryStore) DeleteExpired(
+	_ context.Context, now time.Time) (int64, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	var n int64
+	for id, s := range st.sessions {
+		if s.ExpiresAt.Before(now) {
+			delete(st.sessions, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// dbStore keeps sessions in the mcp_sessions table so every replica sees
+// them.
+type dbStore struct {
+	repo *repo.Repo
+}
+
+// NewDBStore returns a Store backed by the database.
+func NewDBStore(r *repo.Repo) Store {
+	return &dbStore{repo: r}
+}
+
+func (st *dbStore) Shared() bool { return true }
+
+func (st *dbStore) Create(ctx context.Context, s m.MCPSession) error {
+	return st.repo.CreateSession(ctx, s)
+}
+
+func (st *dbStore) Get(ctx context.Context, id string) (m.MCPSession, error) {
+	s, err := st.repo.GetSession(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return m.MCPSession{}, ErrNotFound
+	}
+	return s, err
+}
+
+func (st *dbStore) Touch(
+	ctx context.Context, id string, expiresAt time.Time) error {
+	return st.repo.TouchSession(ctx, id, expiresAt)
+}
+
+func (st *dbStore) SetLogLevel(
+	ctx context.Context, id string, level string) error {
+	return st.repo.UpdateSessionLogLevel(ctx, id, level)
+}
+
+func (st *dbStore) Delete(ctx context.Context, id string) error {
+	return st.repo.DeleteSession(ctx, id)
+}
+
+func (st *dbStore) DeleteExpired(
+	ctx context.Context, now time.Time) (int64, error) {
+	return st.repo.DeleteExpiredSessions(ctx, now)
+}
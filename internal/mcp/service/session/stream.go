@@ -0,0 +1,84 @@
+package session
+
+// Stream carries server-initiated messages to the GET stream of one
+// session.
+type Stream struct {
+	vsID string
+	msgs chan any
+	done chan struct{}
+}
+
+// Messages returns the messages to write to the client.
+func (st *Stream) Messages() <-chan any { return st.msgs }
+
+// Done is closed when the session ends or the service closes.
+func (st *Stream) Done() <-chan struct{} { return st.done }
+
+func (st *Stream) end() { close(st.done) }
+
+// OpenStream registers the stream of a session on this replica. A session
+// has at most one stream; call the returned func when the client goes
+// away.
+func (s *Service) OpenStream(id, vsID string) (*Stream, func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.streams[id]; ok {
+		return nil, nil, ErrStreamOpen
+	}
+	st := &Stream{
+		vsID: vsID,
+		msgs: make(chan any, s.streamBuffer),
+		done: make(chan struct{}),
+	}
+	s.streams[id] = st
+	return st, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.streams[id] == st {
+			delete(s.streams, id)
+		}
+	}, nil
+}
+
+// Publish queues msg for the session's stream without blocking. It reports
+// false when the session has no stream on this replica or the stream is
+// backed up, in which case the message is dropped.
+func (s *Service) Publish(id string, msg any) bool {
+	s.mu.Lock()
+	st, ok := s.streams[id]
+	s.mu.Unlock()
+	if !ok {
+		return false
+	}
+	return s.send(id, st, msg)
+}
+
+// PublishToVirtualServer queues msg for every stream this replica holds
+// for sessions of the virtual server and returns how many accepted it.
+func (s *Service) PublishToVirtualServer(vsID string, msg any) int {
+	s.mu.Lock()
+	targets := map[string]*Stream{}
+	for id, st := range s.streams {
+		if st.vsID == vsID {
+			targets[id] = st
+		}
+	}
+	s.mu.Unlock()
+	n := 0
+	for id, st := range targets {
+		if s.send(id, st, msg) {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *Service) send(id string, st *Stream, msg any) bool {
+	select {
+	case st.msgs <- msg:
+		return true
+	default:
+		s.logger.Warn("MCP_SESSION_STREAM_FULL", "session_id", id)
+		return false
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/ChiragChiranjib/mcp-proxy/internal/events"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
 )
 
@@ -19,3 +20,7 @@ func WithTimeout(d time.Duration) Option { return func(s *Service) { s.timeout =
 
 // WithRepo injects the GORM repo
 func WithRepo(r *repo.Repo) Option { return func(s *Service) { s.repo = r } }
+
+// WithEvents sets the bus told when a tool status change affects virtual
+// servers.
+func WithEvents(b *events.Bus) Option { return func(s *Service) { s.events = b } }
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/ChiragChiranjib/mcp-proxy/internal/events"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/idgen"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
 	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
@@ -14,6 +15,7 @@ import (
 // Service provides tool operations backed by the GORM repo.
 type Service struct {
 	repo    *repo.Repo
+	events  *events.Bus
 	logger  *slog.Logger
 	timeout time.Duration
 }
@@ -39,7 +41,7 @@ func NewService(opts ...Option) *Service {
 func (s *Service) ListForVirtualServer(
 	ctx context.Context,
 	vsID string,
-) ([]m.MCPTool, error) {
+) ([]m.VirtualServerTool, error) {
 	ctx, cancel := s.withTimeout(ctx)
 	defer cancel()
 	tools, err := s.repo.ListToolsForVirtualServer(ctx, vsID)
@@ -49,16 +51,30 @@ func (s *Service) ListForVirtualServer(
 	return tools, nil
 }
 
-// SetStatus updates a tool status by id.
+// SetStatus updates a tool status by id and announces the change to the
+// virtual servers that include the tool.
 func (s *Service) SetStatus(
 	ctx context.Context, id string, status string) error {
 	ctx, cancel := s.withTimeout(ctx)
 	defer cancel()
-	return s.repo.WithContext(ctx).
+	if err := s.repo.WithContext(ctx).
 		Table("mcp_tools").
 		Where("id = ?", id).
 		Update("status", status).
-		Error
+		Error; err != nil {
+		return err
+	}
+	if s.events == nil {
+		return nil
+	}
+	vsIDs, err := s.repo.ListVirtualServerIDsForTools(ctx, []string{id})
+	if err != nil {
+		// The status is stored; clients just miss the notification
+		s.logger.Error("TOOL_STATUS_LIST_VS_ERROR", "tool_id", id, "error", err)
+		return nil
+	}
+	s.events.ToolsChanged(vsIDs...)
+	return nil
 }
 
 // Upsert inserts or updates a tool record.
@@ -71,6 +87,15 @@ func (s *Service) Upsert(ctx context.Context, t m.MCPTool) error {
 	return s.repo.UpsertTool(ctx, t)
 }
 
+// GetByID returns a tool by id.
+func (s *Service) GetByID(ctx context.Context, id string) (m.MCPTool, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	var r m.MCPTool
+	err := s.repo.WithContext(ctx).Where("id = ?", id).Take(&r).Error
+	return r, err
+}
+
 // GetByModifiedName returns a tool by user and modified name.
 func (s *Service) GetByModifiedName(
 	ctx context.Context,
@@ -102,6 +127,19 @@ func (s *Service) ListForUserFiltered(
 	return tools, nil
 }
 
+// ListForOwnerFiltered filters global tools and an owner's tools by server,
+// hub, status, and query.
+func (s *Service) ListForOwnerFiltered(
+	ctx context.Context,
+	owner m.Owner,
+	serverID, hubServerID, status, q string,
+) ([]m.MCPTool, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return s.repo.ListOwnerToolsFiltered(
+		ctx, owner, serverID, hubServerID, status, q)
+}
+
 // ListGlobalToolsForServer returns global tools for a specific server.
 func (s *Service) ListGlobalToolsForServer(
 	ctx context.Context,
@@ -3,13 +3,20 @@ package user
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 
+	"gorm.io/gorm"
+
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/idgen"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
 	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
 )
 
+// ErrAccountExists is returned when a sign in would link to a user that
+// was created for another login method or OIDC provider.
+var ErrAccountExists = errors.New("a user with this email already exists")
+
 // Service ...
 type Service struct {
 	repo   *repo.Repo
@@ -17,11 +24,15 @@ type Service struct {
 }
 
 // FetchOrCreateByUsername returns an existing user id for the given email
-// (stored as username), or creates a new user with role USER.
+// (stored as username), or creates a new user with role USER. Users created
+// for an OIDC account are not returned: it fails with ErrAccountExists.
 func (s *Service) FetchOrCreateByUsername(
 	ctx context.Context, username string) (*m.User, error) {
 	user, err := s.repo.FindUserByUsername(ctx, username)
 	if err == nil && user.ID != "" {
+		if user.AuthProvider != nil {
+			return nil, ErrAccountExists
+		}
 		return user, nil
 	}
 
@@ -38,6 +49,41 @@ func (s *Service) FetchOrCreateByUsername(
 	return u, nil
 }
 
+// FetchOrCreateByOIDC returns the user created for an account of an OIDC
+// provider, creating one with role USER on its first sign in. Users are
+// matched by provider and subject, never by email alone: when another user
+// already has the email, including one of a different provider or of
+// Google or basic login, it fails with ErrAccountExists.
+func (s *Service) FetchOrCreateByOIDC(
+	ctx context.Context, provider, subject, email string) (*m.User, error) {
+	user, err := s.repo.FindUserByAuthSubject(ctx, provider, subject)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	_, err = s.repo.FindUserByUsername(ctx, email)
+	switch {
+	case err == nil:
+		return nil, ErrAccountExists
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	u := &m.User{
+		ID:           idgen.NewID(),
+		Username:     email,
+		Role:         string(m.RoleUser),
+		AuthProvider: &provider,
+		AuthSubject:  &subject,
+	}
+	if err := s.repo.CreateUser(ctx, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
 // FindUserByUserName ...
 func (s *Service) FindUserByUserName(
 	ctx context.Context,
@@ -59,3 +105,19 @@ func (s *Service) FindUserByID(
 	}
 	return user, nil
 }
+
+// SetRole changes a user's role, as an identity provider's role mapping
+// decides on login. It updates u in place.
+func (s *Service) SetRole(ctx context.Context, u *m.User, role m.Role) error {
+	if u.Role == string(role) {
+		return nil
+	}
+	if err := s.repo.UpdateUserRole(ctx, u.ID, string(role)); err != nil {
+		return err
+	}
+	if s.logger != nil {
+		s.logger.Info("USER_ROLE_UPDATED", "user_id", u.ID, "role", role)
+	}
+	u.Role = string(role)
+	return nil
+}
@@ -0,0 +1,149 @@
+package virtualmcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+// Annotation hints a filter may test.
+const (
+	hintReadOnly    = "readOnlyHint"
+	hintDestructive = "destructiveHint"
+	hintIdempotent  = "idempotentHint"
+	hintOpenWorld   = "openWorldHint"
+)
+
+// toolHints returns the value of every hint for a tool. Hints the tool does
+// not set take the MCP defaults: destructive and open world, not read-only
+// or idempotent. A read-only tool is taken as not destructive and as
+// idempotent unless it says otherwise.
+func toolHints(annotations json.RawMessage) map[string]bool {
+	set := map[string]*bool{}
+	if len(annotations) > 0 {
+		var raw map[string]any
+		if err := json.Unmarshal(annotations, &raw); err == nil {
+			for _, h := range []string{
+				hintReadOnly, hintDestructive, hintIdempotent, hintOpenWorld,
+			} {
+				if v, ok := raw[h].(bool); ok {
+					set[h] = &v
+				}
+			}
+		}
+	}
+	value := func(h string, def bool) bool {
+		if v := set[h]; v != nil {
+			return *v
+		}
+		return def
+	}
+	readOnly := value(hintReadOnly, false)
+	return map[string]bool{
+		hintReadOnly:    readOnly,
+		hintDestructive: value(hintDestructive, !readOnly),
+		hintIdempotent:  value(hintIdempotent, readOnly),
+		hintOpenWorld:   value(hintOpenWorld, true),
+	}
+}
+
+// parseAnnotationFilter decodes a filter of hint names to required values.
+func parseAnnotationFilter(filter json.RawMessage) (map[string]bool, error) {
+	var f map[string]bool
+	if err := json.Unmarshal(filter, &f); err != nil {
+		return nil, fmt.Errorf("%w: annotation_filter must map hints to booleans",
+			ErrInvalidMode)
+	}
+	if len(f) == 0 {
+		return nil, fmt.Errorf("%w: annotation_filter is empty", ErrInvalidMode)
+	}
+	for h := range f {
+		switch h {
+		case hintReadOnly, hintDestructive, hintIdempotent, hintOpenWorld:
+		default:
+			return nil, fmt.Errorf("%w: unknown annotation hint %q",
+				ErrInvalidMode, h)
+		}
+	}
+	return f, nil
+}
+
+// normalizeMode defaults an empty mode and rejects unknown ones. The
+// filter is kept, re-encoded, only for AccessAnnotationFiltered, which
+// requires one.
+func normalizeMode(
+	mode m.AccessMode, filter json.RawMessage,
+) (m.AccessMode, json.RawMessage, error) {
+	switch mode {
+	case "":
+		return m.AccessFull, nil, nil
+	case m.AccessFull, m.AccessReadOnly:
+		return mode, nil, nil
+	case m.AccessAnnotationFiltered:
+		f, err := parseAnnotationFilter(filter)
+		if err != nil {
+			return "", nil, err
+		}
+		raw, err := json.Marshal(f)
+		if err != nil {
+			return "", nil, err
+		}
+		return mode, raw, nil
+	default:
+		return "", nil, fmt.Errorf("%w: %q", ErrInvalidMode, mode)
+	}
+}
+
+// AllowsTool reports whether a virtual server with the given access mode
+// and annotation filter exposes a tool with the given annotations. Read-only
+// servers expose tools annotated readOnlyHint; filtered servers expose
+// tools whose hints all have the required values. A filter that cannot be
+// parsed exposes nothing.
+func AllowsTool(
+	mode m.AccessMode, filter json.RawMessage, annotations json.RawMessage,
+) bool {
+	switch mode {
+	case m.AccessReadOnly:
+		return toolHints(annotations)[hintReadOnly]
+	case m.AccessAnnotationFiltered:
+		f, err := parseAnnotationFilter(filter)
+		if err != nil {
+			return false
+		}
+		hints := toolHints(annotations)
+		for h, want := range f {
+			if hints[h] != want {
+				return false
+			}
+		}
+		return true
+	default:
+		return true
+	}
+}
+
+// checkMode fails with ErrToolNotAllowed, naming the offending tools, if
+// the access mode does not expose every tool in links.
+func checkMode(
+	mode m.AccessMode, filter json.RawMessage, links []toolLink,
+) error {
+	var denied []string
+	for _, l := range links {
+		if !AllowsTool(mode, filter, l.tool.Annotations) {
+			name := l.tool.ModifiedName
+			if name == "" {
+				name = l.tool.OriginalName
+			}
+			denied = append(denied, name)
+		}
+	}
+	if len(denied) == 0 {
+		return nil
+	}
+	sort.Strings(denied)
+	return fmt.Errorf("%w: %s mode does not expose %s",
+		ErrToolNotAllowed, mode, strings.Join(denied, ", "))
+}
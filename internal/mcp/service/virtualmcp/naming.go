@@ -0,0 +1,186 @@
+package virtualmcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+// maxAliasLen matches the exposed_name column width.
+const maxAliasLen = 255
+
+var (
+	// ErrToolNameConflict is returned when two tools of a virtual server
+	// would be exposed under the same name.
+	ErrToolNameConflict = errors.New("tool name conflict")
+	// ErrInvalidToolNaming is returned for an unknown naming policy.
+	ErrInvalidToolNaming = errors.New("invalid tool naming policy")
+	// ErrInvalidSessionMode is returned for an unknown session mode.
+	ErrInvalidSessionMode = errors.New("invalid session mode")
+	// ErrInvalidArgValidation is returned for an unknown argument
+	// validation mode.
+	ErrInvalidArgValidation = errors.New("invalid argument validation mode")
+	// ErrInvalidAlias is returned for an alias that cannot be stored.
+	ErrInvalidAlias = errors.New("invalid tool alias")
+	// ErrToolNotInVirtualServer is returned when aliasing a tool that is not
+	// attached to the virtual server.
+	ErrToolNotInVirtualServer = errors.New("tool not in virtual server")
+	// ErrInvalidMode is returned for an unknown access mode or an
+	// annotation filter that cannot be applied.
+	ErrInvalidMode = errors.New("invalid virtual server mode")
+	// ErrToolNotAllowed is returned when attaching tools the access mode
+	// of the virtual server does not expose.
+	ErrToolNotAllowed = errors.New("tool not allowed by virtual server mode")
+	// ErrToolNotFound, ErrResourceNotFound and ErrPromptNotFound are
+	// returned when attaching items that do not exist, are not ACTIVE or
+	// belong to someone other than the virtual server's owner.
+	ErrToolNotFound     = errors.New("tool not found")
+	ErrResourceNotFound = errors.New("resource not found")
+	ErrPromptNotFound   = errors.New("prompt not found")
+	// ErrResourceURIConflict and ErrPromptNameConflict are returned when
+	// two resources of a virtual server share a URI, or two prompts a
+	// name, so that one of them could not be reached.
+	ErrResourceURIConflict = errors.New("resource uri conflict")
+	ErrPromptNameConflict  = errors.New("prompt name conflict")
+	// ErrClientRequestsUnavailable is returned when allowing sampling or
+	// elicitation while the gateway cannot relay them, as with sessions
+	// shared between replicas.
+	ErrClientRequestsUnavailable = errors.New(
+		"sampling and elicitation need the memory session store")
+)
+
+// toolLink is a tool to attach to a virtual server with its optional alias
+// and transform rules, and whether its calls need approval.
+type toolLink struct {
+	tool            m.MCPTool
+	alias           *string
+	transform       json.RawMessage
+	requireApproval bool
+}
+
+// normalizeNaming defaults an empty policy and rejects unknown ones.
+func normalizeNaming(n m.ToolNaming) (m.ToolNaming, error) {
+	switch n {
+	case "":
+		return m.ToolNamingOriginal, nil
+	case m.ToolNamingOriginal, m.ToolNamingPrefixed:
+		return n, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidToolNaming, n)
+	}
+}
+
+// normalizeSessionMode defaults an empty mode and rejects unknown ones.
+func normalizeSessionMode(mode m.SessionMode) (m.SessionMode, error) {
+	switch mode {
+	case "":
+		return m.SessionModeStateless, nil
+	case m.SessionModeStateless, m.SessionModeStateful:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidSessionMode, mode)
+	}
+}
+
+// normalizeArgValidation rejects unknown argument validation modes.
+func normalizeArgValidation(mode m.ArgValidation) (m.ArgValidation, error) {
+	switch mode {
+	case m.ArgValidationOff, m.ArgValidationWarn, m.ArgValidationEnforce:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidArgValidation, mode)
+	}
+}
+
+// normalizeAlias trims the alias; an empty alias clears it.
+func normalizeAlias(alias *string) (*string, error) {
+	if alias == nil {
+		return nil, nil
+	}
+	a := strings.TrimSpace(*alias)
+	if a == "" {
+		return nil, nil
+	}
+	if len(a) > maxAliasLen {
+		return nil, fmt.Errorf("%w: longer than %d characters",
+			ErrInvalidAlias, maxAliasLen)
+	}
+	return &a, nil
+}
+
+// exposedName returns the name a tool is advertised and called by.
+func exposedName(naming m.ToolNaming, t m.MCPTool, alias *string) string {
+	if alias != nil {
+		return *alias
+	}
+	if naming == m.ToolNamingPrefixed && t.ModifiedName != "" {
+		return t.ModifiedName
+	}
+	return t.OriginalName
+}
+
+// relinkTools replaces the tool links of a virtual server, naming each tool
+// by its alias or the naming policy. Duplicate tool ids are ignored.
+func relinkTools(
+	ctx context.Context,
+	tx *repo.Repo,
+	vsID string,
+	naming m.ToolNaming,
+	links []toolLink,
+) error {
+	seenTools := make(map[string]bool, len(links))
+	seenNames := make(map[string]bool, len(links))
+	recs := make([]m.ToolVirtualServer, 0, len(links))
+	for _, l := range links {
+		if seenTools[l.tool.ID] {
+			continue
+		}
+		seenTools[l.tool.ID] = true
+		name := exposedName(naming, l.tool, l.alias)
+		if seenNames[name] {
+			return fmt.Errorf("%w: %q", ErrToolNameConflict, name)
+		}
+		seenNames[name] = true
+		recs = append(recs, m.ToolVirtualServer{
+			MCPVirtualServerID: vsID,
+			ToolID:             l.tool.ID,
+			Alias:              l.alias,
+			ExposedName:        name,
+			Transform:          l.transform,
+			RequireApproval:    l.requireApproval,
+		})
+	}
+	if err := tx.ReplaceVirtualServerTools(ctx, vsID); err != nil {
+		return err
+	}
+	for _, rec := range recs {
+		if err := tx.AddVirtualServerTool(ctx, rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// currentLinks returns the tools attached to a virtual server with their
+// aliases, transforms and approval flags.
+func currentLinks(
+	ctx context.Context, tx *repo.Repo, vsID string,
+) ([]toolLink, error) {
+	tools, err := tx.ListToolsForVirtualServer(ctx, vsID)
+	if err != nil {
+		return nil, err
+	}
+	links := make([]toolLink, 0, len(tools))
+	for _, t := range tools {
+		links = append(links, toolLink{
+			tool: t.MCPTool, alias: t.Alias, transform: t.Transform,
+			requireApproval: t.RequireApproval,
+		})
+	}
+	return links, nil
+}
@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/ChiragChiranjib/mcp-proxy/internal/events"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
 )
 
@@ -19,3 +20,13 @@ func WithTimeout(d time.Duration) Option { return func(s *Service) { s.timeout =
 
 // WithRepo injects the GORM repo for virtual service
 func WithRepo(r *repo.Repo) Option { return func(s *Service) { s.repo = r } }
+
+// WithClientRequests sets whether upstream sampling and elicitation
+// requests can be relayed to clients, and so whether virtual servers may
+// allow them. Enabled by default.
+func WithClientRequests(enabled bool) Option {
+	return func(s *Service) { s.noClientRequests = !enabled }
+}
+
+// WithEvents sets the bus told when a virtual server's tools change.
+func WithEvents(b *events.Bus) Option { return func(s *Service) { s.events = b } }
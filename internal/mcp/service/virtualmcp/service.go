@@ -3,19 +3,29 @@ package virtualmcp
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"time"
 
+	"gorm.io/gorm"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/events"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/idgen"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/transform"
 	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
 )
 
 // Service exposes virtual server operations.
 type Service struct {
 	repo    *repo.Repo
+	events  *events.Bus
 	logger  *slog.Logger
 	timeout time.Duration
+
+	noClientRequests bool
 }
 
 // NewService creates a new virtual server Service.
@@ -34,11 +44,49 @@ func (s *Service) withTimeout(ctx context.Context) (context.Context, context.Can
 	return context.WithTimeout(ctx, s.timeout)
 }
 
+// toolsChanged announces that the tools of a virtual server changed unless
+// the change failed with err, which it returns.
+func (s *Service) toolsChanged(vsID string, err error) error {
+	if err == nil {
+		s.events.ToolsChanged(vsID)
+	}
+	return err
+}
+
+// Transaction runs fn with a Service whose writes all go to one database
+// transaction, so several settings changed together are applied together
+// or not at all. Tool list changes made through it are announced once the
+// transaction commits.
+func (s *Service) Transaction(fn func(tx *Service) error) error {
+	var changed []string
+	pending := events.NewBus()
+	pending.Subscribe(func(ev events.Event) {
+		changed = append(changed, ev.VirtualServerIDs...)
+	})
+	err := s.repo.Transaction(func(r *repo.Repo) error {
+		tx := *s
+		tx.repo, tx.events = r, pending
+		return fn(&tx)
+	})
+	if err == nil && len(changed) > 0 {
+		s.events.ToolsChanged(changed...)
+	}
+	return err
+}
+
+// notFound replaces a missing record error with err.
+func notFound(lookupErr, err error) error {
+	if errors.Is(lookupErr, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return lookupErr
+}
+
 // GetTools returns tools attached to a virtual server.
 func (s *Service) GetTools(
 	ctx context.Context,
 	vsID string,
-) ([]m.MCPTool, error) {
+) ([]m.VirtualServerTool, error) {
 	ctx, cancel := s.withTimeout(ctx)
 	defer cancel()
 	tools, err := s.repo.ListToolsForVirtualServer(ctx, vsID)
@@ -48,23 +96,58 @@ func (s *Service) GetTools(
 	return tools, nil
 }
 
-// Create creates a new virtual server for a user.
-func (s *Service) Create(ctx context.Context, userID string, name string) (string, error) {
+// Create creates a new virtual server for a user, owned by a team when
+// teamID is set.
+func (s *Service) Create(
+	ctx context.Context,
+	userID string,
+	teamID string,
+	name string,
+	naming m.ToolNaming,
+	mode m.SessionMode,
+) (string, error) {
 	ctx, cancel := s.withTimeout(ctx)
 	defer cancel()
+	naming, err := normalizeNaming(naming)
+	if err != nil {
+		return "", err
+	}
+	mode, err = normalizeSessionMode(mode)
+	if err != nil {
+		return "", err
+	}
 	id := "vs_" + idgen.NewID()
-	if err := s.repo.CreateVirtualServer(ctx, m.MCPVirtualServer{
-		ID:     id,
-		UserID: userID,
-		Name:   name,
-		Status: m.StatusActive,
-	}); err != nil {
+	if err := s.repo.CreateVirtualServer(ctx,
+		newVirtualServer(id, userID, teamID, name, naming, mode),
+	); err != nil {
 		return "", err
 	}
 	return id, nil
 }
 
-// ListForUser lists virtual servers for a user.
+// newVirtualServer builds an ACTIVE virtual server with default settings.
+func newVirtualServer(
+	id, userID, teamID, name string,
+	naming m.ToolNaming,
+	mode m.SessionMode,
+) m.MCPVirtualServer {
+	vs := m.MCPVirtualServer{
+		ID:            id,
+		UserID:        userID,
+		Name:          name,
+		Status:        m.StatusActive,
+		ToolNaming:    naming,
+		SessionMode:   mode,
+		ArgValidation: m.ArgValidationOff,
+		Mode:          m.AccessFull,
+	}
+	if teamID != "" {
+		vs.TeamID = &teamID
+	}
+	return vs
+}
+
+// ListForUser lists the personal virtual servers of a user.
 func (s *Service) ListForUser(
 	ctx context.Context,
 	userID string,
@@ -78,6 +161,16 @@ func (s *Service) ListForUser(
 	return rows, nil
 }
 
+// ListForTeams lists the virtual servers of the given teams.
+func (s *Service) ListForTeams(
+	ctx context.Context,
+	teamIDs []string,
+) ([]m.MCPVirtualServer, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return s.repo.ListVirtualServersForTeams(ctx, teamIDs)
+}
+
 // GetByID retrieves a virtual server by ID.
 func (s *Service) GetByID(ctx context.Context, id string) (m.MCPVirtualServer, error) {
 	ctx, cancel := s.withTimeout(ctx)
@@ -103,6 +196,10 @@ func (s *Service) UpdateName(ctx context.Context, id string, name *string) error
 }
 
 // ReplaceTools replaces tool set for a virtual server (capped at 50).
+// Aliases and transforms of tools that stay attached are kept. It fails with
+// ErrToolNameConflict if two tools would be exposed under the same name, and
+// with ErrToolNotAllowed if the server's mode does not expose a tool. Tools
+// must be global or the server owner's, else it fails with ErrToolNotFound.
 func (s *Service) ReplaceTools(
 	ctx context.Context,
 	vsID string,
@@ -110,31 +207,260 @@ func (s *Service) ReplaceTools(
 ) error {
 	ctx, cancel := s.withTimeout(ctx)
 	defer cancel()
-	if err := s.repo.ReplaceVirtualServerTools(ctx, vsID); err != nil {
-		return err
-	}
 	if len(toolIDs) > 50 {
 		toolIDs = toolIDs[:50]
 	}
-	for _, tid := range toolIDs {
-		if err := s.repo.AddVirtualServerTool(ctx, vsID, tid); err != nil {
+	err := s.repo.Transaction(func(tx *repo.Repo) error {
+		vs, err := tx.GetVirtualServerByID(ctx, vsID)
+		if err != nil {
+			return err
+		}
+		current, err := currentLinks(ctx, tx, vsID)
+		if err != nil {
 			return err
 		}
+		kept := make(map[string]toolLink, len(current))
+		for _, l := range current {
+			kept[l.tool.ID] = l
+		}
+		links := make([]toolLink, 0, len(toolIDs))
+		for _, tid := range toolIDs {
+			t, err := tx.GetActiveToolByID(ctx, tid, vs.Owner())
+			if err != nil {
+				return notFound(err, ErrToolNotFound)
+			}
+			prev := kept[tid]
+			links = append(links, toolLink{
+				tool: t, alias: prev.alias, transform: prev.transform,
+				requireApproval: prev.requireApproval,
+			})
+		}
+		if err := checkMode(vs.Mode, vs.AnnotationFilter, links); err != nil {
+			return err
+		}
+		return relinkTools(ctx, tx, vsID, vs.ToolNaming, links)
+	})
+	return s.toolsChanged(vsID, err)
+}
+
+// SetToolNaming changes the naming policy of a virtual server and renames
+// its tools accordingly.
+func (s *Service) SetToolNaming(
+	ctx context.Context, vsID string, naming m.ToolNaming,
+) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	naming, err := normalizeNaming(naming)
+	if err != nil {
+		return err
+	}
+	err = s.repo.Transaction(func(tx *repo.Repo) error {
+		if err := tx.UpdateVirtualServerToolNaming(ctx, vsID, naming); err != nil {
+			return err
+		}
+		links, err := currentLinks(ctx, tx, vsID)
+		if err != nil {
+			return err
+		}
+		return relinkTools(ctx, tx, vsID, naming, links)
+	})
+	return s.toolsChanged(vsID, err)
+}
+
+// SetSessionMode switches a virtual server between stateless and stateful
+// session handling. Sessions already issued stop being checked once the
+// server is stateless and are left to expire.
+func (s *Service) SetSessionMode(
+	ctx context.Context, vsID string, mode m.SessionMode,
+) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	mode, err := normalizeSessionMode(mode)
+	if err != nil {
+		return err
+	}
+	return s.repo.UpdateVirtualServerSessionMode(ctx, vsID, mode)
+}
+
+// SetArgValidation sets whether tool call arguments are checked against
+// input schemas, and whether violations are only logged or rejected.
+func (s *Service) SetArgValidation(
+	ctx context.Context, vsID string, mode m.ArgValidation,
+) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	mode, err := normalizeArgValidation(mode)
+	if err != nil {
+		return err
+	}
+	return s.repo.UpdateVirtualServerArgValidation(ctx, vsID, mode)
+}
+
+// SetMode sets which tools a virtual server exposes, judged by their
+// annotations. filter is required for AccessAnnotationFiltered and ignored
+// otherwise. It fails with ErrToolNotAllowed if the mode would not expose
+// a tool already attached.
+func (s *Service) SetMode(
+	ctx context.Context, vsID string, mode m.AccessMode, filter json.RawMessage,
+) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	mode, filter, err := normalizeMode(mode, filter)
+	if err != nil {
+		return err
+	}
+	err = s.repo.Transaction(func(tx *repo.Repo) error {
+		links, err := currentLinks(ctx, tx, vsID)
+		if err != nil {
+			return err
+		}
+		if err := checkMode(mode, filter, links); err != nil {
+			return err
+		}
+		return tx.UpdateVirtualServerMode(ctx, vsID, mode, filter)
+	})
+	return s.toolsChanged(vsID, err)
+}
+
+// SetApproveDestructive sets whether calls to tools annotated
+// destructiveHint are held for the owner's approval.
+func (s *Service) SetApproveDestructive(
+	ctx context.Context, vsID string, approve bool,
+) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return s.repo.UpdateVirtualServerApproveDestructive(ctx, vsID, approve)
+}
+
+// SetToolRequireApproval sets whether every call to a tool of the virtual
+// server is held for the owner's approval.
+func (s *Service) SetToolRequireApproval(
+	ctx context.Context, vsID string, toolID string, require bool,
+) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	ok, err := s.repo.UpdateVirtualServerToolRequireApproval(
+		ctx, vsID, toolID, require)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrToolNotInVirtualServer
 	}
 	return nil
 }
 
+// SetClientRequests allows or denies upstream sampling and elicitation
+// requests reaching the clients of the virtual server. Nil leaves a
+// setting unchanged. Upstream sessions already open keep the capabilities
+// they advertised; new calls pick up the change. Allowing either fails
+// with ErrClientRequestsUnavailable when the gateway cannot relay them.
+func (s *Service) SetClientRequests(
+	ctx context.Context, vsID string, sampling, elicitation *bool,
+) error {
+	if s.noClientRequests &&
+		((sampling != nil && *sampling) || (elicitation != nil && *elicitation)) {
+		return ErrClientRequestsUnavailable
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return s.repo.UpdateVirtualServerClientRequests(
+		ctx, vsID, sampling, elicitation)
+}
+
+// SetToolAlias sets or, with a nil or blank alias, clears the name a tool
+// is exposed under in a virtual server.
+func (s *Service) SetToolAlias(
+	ctx context.Context, vsID string, toolID string, alias *string,
+) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	alias, err := normalizeAlias(alias)
+	if err != nil {
+		return err
+	}
+	err = s.repo.Transaction(func(tx *repo.Repo) error {
+		vs, err := tx.GetVirtualServerByID(ctx, vsID)
+		if err != nil {
+			return err
+		}
+		links, err := currentLinks(ctx, tx, vsID)
+		if err != nil {
+			return err
+		}
+		found := false
+		for i := range links {
+			if links[i].tool.ID == toolID {
+				links[i].alias = alias
+				found = true
+			}
+		}
+		if !found {
+			return ErrToolNotInVirtualServer
+		}
+		return relinkTools(ctx, tx, vsID, vs.ToolNaming, links)
+	})
+	return s.toolsChanged(vsID, err)
+}
+
+// SetToolTransform sets or, with nil or empty rules, clears the transform
+// rules of a tool in a virtual server. Rules are validated against the
+// tool's input schema.
+func (s *Service) SetToolTransform(
+	ctx context.Context, vsID string, toolID string, rules *transform.Rules,
+) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	tools, err := s.repo.ListToolsForVirtualServer(ctx, vsID)
+	if err != nil {
+		return err
+	}
+	var tool *m.VirtualServerTool
+	for i := range tools {
+		if tools[i].ID == toolID {
+			tool = &tools[i]
+			break
+		}
+	}
+	if tool == nil {
+		return ErrToolNotInVirtualServer
+	}
+	var raw json.RawMessage
+	if !rules.IsZero() {
+		if err := rules.Validate(tool.InputSchema); err != nil {
+			return err
+		}
+		if raw, err = json.Marshal(rules); err != nil {
+			return err
+		}
+	}
+	return s.toolsChanged(vsID,
+		s.repo.UpdateVirtualServerToolTransform(ctx, vsID, toolID, raw))
+}
+
 // CreateWithTools creates a virtual server and assigns the provided tool IDs in one transaction.
-// It verifies each tool exists and is ACTIVE before adding.
+// It verifies each tool exists, is ACTIVE and is global or the new server
+// owner's before adding.
 func (s *Service) CreateWithTools(
 	ctx context.Context,
 	userID string,
+	teamID string,
 	name string,
+	naming m.ToolNaming,
+	mode m.SessionMode,
 	toolIDs []string,
 ) (string, error) {
 	ctx, cancel := s.withTimeout(ctx)
 	defer cancel()
 
+	naming, err := normalizeNaming(naming)
+	if err != nil {
+		return "", err
+	}
+	mode, err = normalizeSessionMode(mode)
+	if err != nil {
+		return "", err
+	}
 	if len(toolIDs) > 50 {
 		toolIDs = toolIDs[:50]
 	}
@@ -142,28 +468,24 @@ func (s *Service) CreateWithTools(
 	id := idgen.NewID()
 
 	// Run in a transaction
-	err := s.repo.Transaction(func(tx *repo.Repo) error {
+	err = s.repo.Transaction(func(tx *repo.Repo) error {
 		// Create virtual server
-		if err := tx.CreateVirtualServer(ctx, m.MCPVirtualServer{
-			ID:     id,
-			UserID: userID,
-			Name:   name,
-			Status: m.StatusActive,
-		}); err != nil {
+		vs := newVirtualServer(id, userID, teamID, name, naming, mode)
+		if err := tx.CreateVirtualServer(ctx, vs); err != nil {
 			return err
 		}
 
 		// Add tools after validation
+		links := make([]toolLink, 0, len(toolIDs))
 		for _, tid := range toolIDs {
-			// Validate tool exists and active
-			if _, err := tx.GetActiveToolByID(ctx, tid); err != nil {
-				return err
-			}
-			if err := tx.AddVirtualServerTool(ctx, id, tid); err != nil {
-				return err
+			// Validate tool exists, is active and may be used
+			t, err := tx.GetActiveToolByID(ctx, tid, vs.Owner())
+			if err != nil {
+				return notFound(err, ErrToolNotFound)
 			}
+			links = append(links, toolLink{tool: t})
 		}
-		return nil
+		return relinkTools(ctx, tx, id, naming, links)
 	})
 	if err != nil {
 		return "", err
@@ -184,5 +506,112 @@ func (s *Service) RemoveTool(
 ) error {
 	ctx, cancel := s.withTimeout(ctx)
 	defer cancel()
-	return s.repo.DeleteVirtualServerTool(ctx, vsID, toolID)
+	return s.toolsChanged(vsID,
+		s.repo.DeleteVirtualServerTool(ctx, vsID, toolID))
+}
+
+// ReplaceResources replaces the resource set of a virtual server (capped at
+// 50). Each resource must exist, be ACTIVE and be global or the server
+// owner's; two with the same URI fail with ErrResourceURIConflict.
+func (s *Service) ReplaceResources(
+	ctx context.Context,
+	vsID string,
+	resourceIDs []string,
+) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	if len(resourceIDs) > 50 {
+		resourceIDs = resourceIDs[:50]
+	}
+	return s.repo.Transaction(func(tx *repo.Repo) error {
+		vs, err := tx.GetVirtualServerByID(ctx, vsID)
+		if err != nil {
+			return err
+		}
+		if err := tx.ReplaceVirtualServerResources(ctx, vsID); err != nil {
+			return err
+		}
+		seen := make(map[string]bool, len(resourceIDs))
+		seenURIs := make(map[string]bool, len(resourceIDs))
+		for _, rid := range resourceIDs {
+			if seen[rid] {
+				continue
+			}
+			seen[rid] = true
+			res, err := tx.GetActiveResourceByID(ctx, rid, vs.Owner())
+			if err != nil {
+				return notFound(err, ErrResourceNotFound)
+			}
+			if seenURIs[res.URI] {
+				return fmt.Errorf("%w: %q", ErrResourceURIConflict, res.URI)
+			}
+			seenURIs[res.URI] = true
+			if err := tx.AddVirtualServerResource(ctx, vsID, rid); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RemoveResource detaches one resource from a virtual server.
+func (s *Service) RemoveResource(
+	ctx context.Context, vsID string, resourceID string,
+) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return s.repo.DeleteVirtualServerResource(ctx, vsID, resourceID)
+}
+
+// ReplacePrompts replaces the prompt set of a virtual server (capped at 50).
+// Each prompt must exist, be ACTIVE and be global or the server owner's;
+// two with the same name fail with ErrPromptNameConflict.
+func (s *Service) ReplacePrompts(
+	ctx context.Context,
+	vsID string,
+	promptIDs []string,
+) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	if len(promptIDs) > 50 {
+		promptIDs = promptIDs[:50]
+	}
+	return s.repo.Transaction(func(tx *repo.Repo) error {
+		vs, err := tx.GetVirtualServerByID(ctx, vsID)
+		if err != nil {
+			return err
+		}
+		if err := tx.ReplaceVirtualServerPrompts(ctx, vsID); err != nil {
+			return err
+		}
+		seen := make(map[string]bool, len(promptIDs))
+		seenNames := make(map[string]bool, len(promptIDs))
+		for _, pid := range promptIDs {
+			if seen[pid] {
+				continue
+			}
+			seen[pid] = true
+			p, err := tx.GetActivePromptByID(ctx, pid, vs.Owner())
+			if err != nil {
+				return notFound(err, ErrPromptNotFound)
+			}
+			if seenNames[p.Name] {
+				return fmt.Errorf("%w: %q", ErrPromptNameConflict, p.Name)
+			}
+			seenNames[p.Name] = true
+			if err := tx.AddVirtualServerPrompt(ctx, vsID, pid); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RemovePrompt detaches one prompt from a virtual server.
+func (s *Service) RemovePrompt(
+	ctx context.Context, vsID string, promptID string,
+) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return s.repo.DeleteVirtualServerPrompt(ctx, vsID, promptID)
 }
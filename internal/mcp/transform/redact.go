@@ -0,0 +1,174 @@
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// segment is one step of a redaction path: an object member, an array
+// index, or a wildcard over either.
+type segment struct {
+	name     string
+	index    int
+	wildcard bool
+	isIndex  bool
+}
+
+// parsePath parses the supported JSONPath subset: "$", ".name", "['name']",
+// "[n]", "[*]" and ".*". The last segment must select object members.
+func parsePath(p string) ([]segment, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(p), "$")
+	if !ok {
+		return nil, fmt.Errorf("%w: path %q must start with $", ErrInvalidRules, p)
+	}
+	var segs []segment
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			if name == "" {
+				return nil, fmt.Errorf("%w: empty name in %q", ErrInvalidRules, p)
+			}
+			segs = append(segs, segment{name: name, wildcard: name == "*"})
+			rest = rest[end:]
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unclosed [ in %q", ErrInvalidRules, p)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			switch {
+			case inner == "*":
+				segs = append(segs, segment{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') &&
+				inner[len(inner)-1] == inner[0]:
+				segs = append(segs, segment{name: inner[1 : len(inner)-1]})
+			default:
+				n, err := strconv.Atoi(inner)
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("%w: bad index %q in %q",
+						ErrInvalidRules, inner, p)
+				}
+				segs = append(segs, segment{index: n, isIndex: true})
+			}
+		default:
+			return nil, fmt.Errorf("%w: unexpected %q in %q", ErrInvalidRules, rest, p)
+		}
+	}
+	if len(segs) == 0 || segs[len(segs)-1].isIndex {
+		return nil, fmt.Errorf("%w: path %q must end with a member name",
+			ErrInvalidRules, p)
+	}
+	return segs, nil
+}
+
+// Result removes the redacted paths from a tool result. Paths apply to the
+// structured content and to text content that holds a JSON document.
+func (r *Rules) Result(res *mcp.CallToolResult) {
+	if r == nil || len(r.Redact) == 0 || res == nil {
+		return
+	}
+	paths := make([][]segment, 0, len(r.Redact))
+	for _, p := range r.Redact {
+		if segs, err := parsePath(p); err == nil {
+			paths = append(paths, segs)
+		}
+	}
+	if res.StructuredContent != nil {
+		if doc, ok := toGeneric(res.StructuredContent); ok {
+			for _, segs := range paths {
+				redact(doc, segs)
+			}
+			res.StructuredContent = doc
+		}
+	}
+	for i, c := range res.Content {
+		tc, ok := c.(mcp.TextContent)
+		if !ok {
+			continue
+		}
+		text := strings.TrimSpace(tc.Text)
+		if !strings.HasPrefix(text, "{") && !strings.HasPrefix(text, "[") {
+			continue
+		}
+		var doc any
+		if err := json.Unmarshal([]byte(text), &doc); err != nil {
+			continue
+		}
+		for _, segs := range paths {
+			redact(doc, segs)
+		}
+		b, err := json.Marshal(doc)
+		if err != nil {
+			continue
+		}
+		tc.Text = string(b)
+		res.Content[i] = tc
+	}
+}
+
+// toGeneric converts v into maps and slices so it can be walked.
+func toGeneric(v any) (any, bool) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	var doc any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, false
+	}
+	return doc, true
+}
+
+// redact deletes the members selected by segs from doc in place.
+func redact(doc any, segs []segment) {
+	if len(segs) == 0 {
+		return
+	}
+	seg, last := segs[0], len(segs) == 1
+	switch node := doc.(type) {
+	case map[string]any:
+		if seg.isIndex {
+			return
+		}
+		if seg.wildcard {
+			for k, v := range node {
+				if last {
+					delete(node, k)
+				} else {
+					redact(v, segs[1:])
+				}
+			}
+			return
+		}
+		if last {
+			delete(node, seg.name)
+			return
+		}
+		if v, ok := node[seg.name]; ok {
+			redact(v, segs[1:])
+		}
+	case []any:
+		if last {
+			return
+		}
+		switch {
+		case seg.wildcard:
+			for _, v := range node {
+				redact(v, segs[1:])
+			}
+		case seg.isIndex && seg.index < len(node):
+			redact(node[seg.index], segs[1:])
+		}
+	}
+}
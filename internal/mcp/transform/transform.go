@@ -0,0 +1,209 @@
+// Package transform rewrites tool schemas, arguments and results so a
+// virtual server can expose a narrower form of an upstream tool.
+package transform
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+)
+
+// ErrInvalidRules is returned when rules do not fit the tool's schema.
+var ErrInvalidRules = errors.New("invalid transform rules")
+
+// Rules describe how a virtual server reshapes one tool. Property names in
+// FixedArgs, DefaultArgs, Hidden and the keys of Rename are upstream names.
+type Rules struct {
+	// FixedArgs are always sent upstream and removed from the schema.
+	FixedArgs map[string]any `json:"fixed_args,omitempty"`
+	// DefaultArgs are sent when the client omits the argument.
+	DefaultArgs map[string]any `json:"default_args,omitempty"`
+	// Hidden properties are removed from the schema and dropped from calls.
+	Hidden []string `json:"hidden,omitempty"`
+	// Rename maps upstream property names to the names clients see.
+	Rename map[string]string `json:"rename,omitempty"`
+	// Redact lists JSONPath-style paths removed from structured results,
+	// for example "$.items[*].token".
+	Redact []string `json:"redact,omitempty"`
+}
+
+// Parse decodes stored rules. Empty input yields nil rules.
+func Parse(raw json.RawMessage) (*Rules, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var r Rules
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// IsZero reports whether the rules change nothing.
+func (r *Rules) IsZero() bool {
+	return r == nil || (len(r.FixedArgs) == 0 && len(r.DefaultArgs) == 0 &&
+		len(r.Hidden) == 0 && len(r.Rename) == 0 && len(r.Redact) == 0)
+}
+
+// Validate checks the rules against the upstream input schema.
+func (r *Rules) Validate(schema json.RawMessage) error {
+	if r.IsZero() {
+		return nil
+	}
+	props, required, err := schemaProperties(schema)
+	if err != nil {
+		return err
+	}
+	hidden := map[string]bool{}
+	for _, name := range r.Hidden {
+		if _, ok := props[name]; !ok {
+			return fmt.Errorf("%w: hidden property %q not in schema",
+				ErrInvalidRules, name)
+		}
+		hidden[name] = true
+	}
+	for _, name := range required {
+		_, fixed := r.FixedArgs[name]
+		_, def := r.DefaultArgs[name]
+		if hidden[name] && !fixed && !def {
+			return fmt.Errorf(
+				"%w: hidden required property %q needs a fixed or default value",
+				ErrInvalidRules, name)
+		}
+	}
+	targets := map[string]bool{}
+	for from, to := range r.Rename {
+		if _, ok := props[from]; !ok {
+			return fmt.Errorf("%w: renamed property %q not in schema",
+				ErrInvalidRules, from)
+		}
+		if _, fixed := r.FixedArgs[from]; fixed || hidden[from] {
+			return fmt.Errorf("%w: property %q is not exposed and cannot be renamed",
+				ErrInvalidRules, from)
+		}
+		if to == "" || targets[to] {
+			return fmt.Errorf("%w: invalid or duplicate new name %q for %q",
+				ErrInvalidRules, to, from)
+		}
+		if _, clash := props[to]; clash {
+			if _, movedAway := r.Rename[to]; !movedAway {
+				return fmt.Errorf("%w: new name %q clashes with a property",
+					ErrInvalidRules, to)
+			}
+		}
+		targets[to] = true
+	}
+	for _, p := range r.Redact {
+		if _, err := parsePath(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Schema rewrites an upstream input schema into the one clients see.
+func (r *Rules) Schema(schema json.RawMessage) (json.RawMessage, error) {
+	if r.IsZero() || len(schema) == 0 {
+		return schema, nil
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(schema, &doc); err != nil {
+		return nil, err
+	}
+	props, _ := doc["properties"].(map[string]any)
+	required := map[string]bool{}
+	if list, ok := doc["required"].([]any); ok {
+		for _, v := range list {
+			if s, ok := v.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+
+	out := make(map[string]any, len(props))
+	for name, prop := range props {
+		if _, fixed := r.FixedArgs[name]; fixed || slices.Contains(r.Hidden, name) {
+			delete(required, name)
+			continue
+		}
+		if def, ok := r.DefaultArgs[name]; ok {
+			if ps, ok := prop.(map[string]any); ok {
+				ps = maps.Clone(ps)
+				ps["default"] = def
+				prop = ps
+			}
+			delete(required, name)
+		}
+		exposed := name
+		if to, ok := r.Rename[name]; ok {
+			exposed = to
+			if required[name] {
+				delete(required, name)
+				required[to] = true
+			}
+		}
+		out[exposed] = prop
+	}
+	if props != nil {
+		doc["properties"] = out
+	}
+	if _, ok := doc["required"]; ok || len(required) > 0 {
+		list := make([]string, 0, len(required))
+		for name := range required {
+			list = append(list, name)
+		}
+		sort.Strings(list)
+		doc["required"] = list
+	}
+	return json.Marshal(doc)
+}
+
+// Args rewrites the arguments a client sent into the ones sent upstream.
+func (r *Rules) Args(args map[string]any) map[string]any {
+	if r.IsZero() {
+		return args
+	}
+	reverse := make(map[string]string, len(r.Rename))
+	for from, to := range r.Rename {
+		reverse[to] = from
+	}
+	out := make(map[string]any, len(args)+len(r.FixedArgs))
+	for k, v := range args {
+		if from, ok := reverse[k]; ok {
+			out[from] = v
+			continue
+		}
+		if _, renamed := r.Rename[k]; renamed || slices.Contains(r.Hidden, k) {
+			continue
+		}
+		out[k] = v
+	}
+	for k, v := range r.DefaultArgs {
+		if _, ok := out[k]; !ok {
+			out[k] = v
+		}
+	}
+	for k, v := range r.FixedArgs {
+		out[k] = v
+	}
+	return out
+}
+
+// schemaProperties returns the property map and required list of a schema.
+func schemaProperties(
+	schema json.RawMessage,
+) (map[string]json.RawMessage, []string, error) {
+	var doc struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+		Required   []string                   `json:"required"`
+	}
+	if len(schema) > 0 {
+		if err := json.Unmarshal(schema, &doc); err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidRules, err)
+		}
+	}
+	return doc.Properties, doc.Required, nil
+}
@@ -0,0 +1,276 @@
+// Package metrics defines the Prometheus collectors exported by the gateway.
+//
+// Label values are restricted to bounded sets (known JSON-RPC methods,
+// outcomes, transports) or to ids that already exist as database rows
+// (virtual servers, catalog servers and their tools), so client input can
+// never create new series on its own.
+package metrics
+
+import (
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "mcp_proxy"
+
+// Outcome label values.
+const (
+	OutcomeOK    = "ok"
+	OutcomeError = "error"
+	// OutcomeToolError marks a tool call the upstream answered with isError.
+	OutcomeToolError = "tool_error"
+	// OutcomeCancelled marks a request the client cancelled or abandoned.
+	OutcomeCancelled = "cancelled"
+)
+
+// Upstream connect stages used as failure labels.
+const (
+	StageConnect    = "connect"
+	StageInitialize = "initialize"
+)
+
+// Refresh and probe kinds.
+const (
+	RefreshHub     = "hub"
+	RefreshCatalog = "catalog"
+)
+
+// otherMethod replaces JSON-RPC methods the proxy does not know about.
+const otherMethod = "other"
+
+var knownMethods = map[mcp.MCPMethod]bool{
+	mcp.MethodInitialize:             true,
+	mcp.MethodPing:                   true,
+	mcp.MethodToolsList:              true,
+	mcp.MethodToolsCall:              true,
+	mcp.MethodResourcesList:          true,
+	mcp.MethodResourcesTemplatesList: true,
+	mcp.MethodResourcesRead:          true,
+	mcp.MethodPromptsList:            true,
+	mcp.MethodPromptsGet:             true,
+	mcp.MethodSetLogLevel:            true,
+}
+
+// Metrics holds the gateway collectors. A nil *Metrics is valid and records
+// nothing, so components can be used without metrics wired in.
+type Metrics struct {
+	rpcRequests        *prometheus.CounterVec
+	rpcDuration        *prometheus.HistogramVec
+	toolCalls          *prometheus.CounterVec
+	toolCallDuration   *prometheus.HistogramVec
+	upstreamConnect    *prometheus.HistogramVec
+	upstreamFailures   *prometheus.CounterVec
+	refreshDuration    *prometheus.HistogramVec
+	refreshToolChanges *prometheus.CounterVec
+	probeDuration      *prometheus.HistogramVec
+	hubTransitions     *prometheus.CounterVec
+	breakerTransitions *prometheus.CounterVec
+	breakerState       *prometheus.GaugeVec
+	upstreamRetries    *prometheus.CounterVec
+}
+
+// New creates the collectors and registers them with reg.
+func New(reg prometheus.Registerer) *Metrics {
+	mt := &Metrics{
+		rpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rpc_requests_total",
+			Help:      "MCP JSON-RPC requests by method, virtual server and outcome.",
+		}, []string{"method", "virtual_server", "outcome"}),
+		rpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "rpc_request_duration_seconds",
+			Help:      "MCP JSON-RPC request latency by method and virtual server.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "virtual_server"}),
+		toolCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tool_calls_total",
+			Help:      "Proxied tool calls by upstream server, tool and outcome.",
+		}, []string{"server", "tool", "outcome"}),
+		toolCallDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "tool_call_duration_seconds",
+			Help:      "Proxied tool call latency by upstream server.",
+			Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120},
+		}, []string{"server"}),
+		upstreamConnect: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "upstream_connect_duration_seconds",
+			Help:      "Upstream connect and initialize latency by transport and outcome.",
+			Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"transport", "outcome"}),
+		upstreamFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "upstream_connect_failures_total",
+			Help:      "Failed upstream connects by transport and failing stage.",
+		}, []string{"transport", "stage"}),
+		refreshDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "refresh_duration_seconds",
+			Help:      "Hub and catalog server refresh latency by kind and outcome.",
+			Buckets:   []float64{.1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"kind", "outcome"}),
+		refreshToolChanges: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "refresh_tool_changes_total",
+			Help:      "Tools added or deleted by hub and catalog server refreshes.",
+		}, []string{"kind", "change"}),
+		probeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "probe_duration_seconds",
+			Help:      "Upstream health probe latency by kind and outcome.",
+			Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"kind", "outcome"}),
+		hubTransitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "hub_status_transitions_total",
+			Help:      "Hub status changes made by health probes, by new status.",
+		}, []string{"status"}),
+		breakerTransitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "upstream_breaker_transitions_total",
+			Help:      "Upstream circuit breaker state changes by catalog server and new state.",
+		}, []string{"server", "state"}),
+		breakerState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "upstream_breaker_state",
+			Help:      "Upstream circuit breaker state by catalog server: 0 closed, 1 half-open, 2 open.",
+		}, []string{"server"}),
+		upstreamRetries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "upstream_call_retries_total",
+			Help:      "Retried idempotent tool calls by catalog server.",
+		}, []string{"server"}),
+	}
+	reg.MustRegister(
+		mt.rpcRequests,
+		mt.rpcDuration,
+		mt.toolCalls,
+		mt.toolCallDuration,
+		mt.upstreamConnect,
+		mt.upstreamFailures,
+		mt.refreshDuration,
+		mt.refreshToolChanges,
+		mt.probeDuration,
+		mt.hubTransitions,
+		mt.breakerTransitions,
+		mt.breakerState,
+		mt.upstreamRetries,
+	)
+	return mt
+}
+
+// ObserveRequest records one JSON-RPC request to a virtual server. result
+// is one of OutcomeOK, OutcomeError or OutcomeCancelled. Unknown methods
+// are folded into a single "other" label.
+func (mt *Metrics) ObserveRequest(
+	method mcp.MCPMethod, vsID, result string, d time.Duration,
+) {
+	if mt == nil {
+		return
+	}
+	name := otherMethod
+	if knownMethods[method] {
+		name = string(method)
+	}
+	mt.rpcRequests.WithLabelValues(name, vsID, result).Inc()
+	mt.rpcDuration.WithLabelValues(name, vsID).Observe(d.Seconds())
+}
+
+// ObserveToolCall records a proxied tool call. result is one of OutcomeOK,
+// OutcomeError (the call itself failed), OutcomeToolError or
+// OutcomeCancelled.
+func (mt *Metrics) ObserveToolCall(
+	serverID, tool, result string, d time.Duration,
+) {
+	if mt == nil {
+		return
+	}
+	mt.toolCalls.WithLabelValues(serverID, tool, result).Inc()
+	mt.toolCallDuration.WithLabelValues(serverID).Observe(d.Seconds())
+}
+
+// ObserveUpstreamConnect records an upstream connect attempt. stage names
+// the step that failed and is ignored when err is nil.
+func (mt *Metrics) ObserveUpstreamConnect(
+	transport, stage string, err error, d time.Duration,
+) {
+	if mt == nil {
+		return
+	}
+	mt.upstreamConnect.WithLabelValues(transport, outcome(err != nil)).
+		Observe(d.Seconds())
+	if err != nil {
+		mt.upstreamFailures.WithLabelValues(transport, stage).Inc()
+	}
+}
+
+// ObserveRefresh records a hub or catalog server refresh and the number of
+// tools it added and deleted.
+func (mt *Metrics) ObserveRefresh(
+	kind string, added, deleted int, err error, d time.Duration,
+) {
+	if mt == nil {
+		return
+	}
+	mt.refreshDuration.WithLabelValues(kind, outcome(err != nil)).
+		Observe(d.Seconds())
+	if err != nil {
+		return
+	}
+	mt.refreshToolChanges.WithLabelValues(kind, "added").Add(float64(added))
+	mt.refreshToolChanges.WithLabelValues(kind, "deleted").Add(float64(deleted))
+}
+
+// ObserveProbe records a health probe of a catalog server or hub.
+func (mt *Metrics) ObserveProbe(kind string, err error, d time.Duration) {
+	if mt == nil {
+		return
+	}
+	mt.probeDuration.WithLabelValues(kind, outcome(err != nil)).
+		Observe(d.Seconds())
+}
+
+// ObserveHubTransition records a probe moving a hub to status.
+func (mt *Metrics) ObserveHubTransition(status string) {
+	if mt == nil {
+		return
+	}
+	mt.hubTransitions.WithLabelValues(status).Inc()
+}
+
+// breakerStates maps circuit breaker states to upstream_breaker_state
+// values.
+var breakerStates = map[string]float64{
+	"closed":    0,
+	"half_open": 1,
+	"open":      2,
+}
+
+// ObserveBreakerTransition records a catalog server's circuit breaker
+// moving to state: closed, half_open or open.
+func (mt *Metrics) ObserveBreakerTransition(serverID, state string) {
+	if mt == nil {
+		return
+	}
+	mt.breakerTransitions.WithLabelValues(serverID, state).Inc()
+	mt.breakerState.WithLabelValues(serverID).Set(breakerStates[state])
+}
+
+// ObserveUpstreamRetry records a retried tool call to a catalog server.
+func (mt *Metrics) ObserveUpstreamRetry(serverID string) {
+	if mt == nil {
+		return
+	}
+	mt.upstreamRetries.WithLabelValues(serverID).Inc()
+}
+
+func outcome(failed bool) string {
+	if failed {
+		return OutcomeError
+	}
+	return OutcomeOK
+}
@@ -73,6 +73,9 @@ var skipRoutesForBasicAuth = []string{
 	"/live",
 	"/ready",
 	"/api/auth",
+	// OAuth endpoints are public or check the session themselves.
+	"/oauth",
+	"/.well-known",
 }
 
 // mcpPathRE matches /servers/{22-char-id}/mcp exactly, where the id is
@@ -104,7 +107,8 @@ func BasicAuth(
 				}
 			}
 			p := r.URL.Path
-			// Skip Basic auth for MCP streamable endpoint with 22-char id
+			// Skip Basic auth for MCP streamable endpoint with 22-char id; the
+			// proxy authenticates those requests with virtual server API keys.
 			if mcpPathRE.MatchString(p) {
 				next.ServeHTTP(w, r)
 				return
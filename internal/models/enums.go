@@ -8,6 +8,9 @@ const (
 	StatusActive      Status = "ACTIVE"
 	StatusDeactivated Status = "DEACTIVATED"
 	StatusUnreachable Status = "UNREACHABLE"
+	// StatusNeedsAuth marks a hub whose OAuth tokens must be authorized
+	// again by its owner.
+	StatusNeedsAuth Status = "NEEDS_AUTH"
 )
 
 // AuthType represents supported upstream auth mechanisms for hub connections.
@@ -17,6 +20,7 @@ const (
 	AuthTypeNone          AuthType = "none"
 	AuthTypeBearer        AuthType = "bearer"
 	AuthTypeCustomHeaders AuthType = "custom_headers"
+	AuthTypeOAuth2        AuthType = "oauth2"
 )
 
 // AccessType represents server access patterns for tools.
@@ -27,6 +31,24 @@ const (
 	AccessTypePrivate AccessType = "private" // User-specific tools, auth required
 )
 
+// Transport represents the wire protocol used to reach an upstream server.
+type Transport string
+
+const (
+	TransportStreamableHTTP Transport = "streamable-http"
+	TransportSSE            Transport = "sse"   // Legacy HTTP+SSE transport
+	TransportStdio          Transport = "stdio" // Spawned local command
+)
+
+// ToolNaming is the policy a virtual server uses to name the tools it
+// exposes. A per-tool alias always takes precedence.
+type ToolNaming string
+
+const (
+	ToolNamingOriginal ToolNaming = "original" // Upstream tool name
+	ToolNamingPrefixed ToolNaming = "prefixed" // Server-prefixed modified name
+)
+
 // Role represents application user roles.
 type Role string
 
@@ -34,3 +56,95 @@ const (
 	RoleAdmin Role = "ADMIN"
 	RoleUser  Role = "USER"
 )
+
+// RefreshStatus is the outcome of the last tool refresh of a catalog server
+// or hub. It is empty until the first refresh.
+type RefreshStatus string
+
+const (
+	RefreshStatusSuccess RefreshStatus = "success"
+	RefreshStatusError   RefreshStatus = "error"
+)
+
+// SessionMode is how a virtual server's MCP endpoint handles sessions.
+type SessionMode string
+
+const (
+	SessionModeStateless SessionMode = "stateless" // No Mcp-Session-Id
+	SessionModeStateful  SessionMode = "stateful"  // Sessions with TTLs
+)
+
+// ArgValidation is how a virtual server checks tool call arguments against
+// the tool's input schema.
+type ArgValidation string
+
+const (
+	ArgValidationOff     ArgValidation = "off"     // Arguments are not checked
+	ArgValidationWarn    ArgValidation = "warn"    // Violations are logged
+	ArgValidationEnforce ArgValidation = "enforce" // Violating calls are rejected
+)
+
+// AccessMode is which tools a virtual server exposes, judged by their
+// annotations.
+type AccessMode string
+
+const (
+	AccessFull               AccessMode = "full"                // All attached tools
+	AccessReadOnly           AccessMode = "read_only"           // Tools with readOnlyHint
+	AccessAnnotationFiltered AccessMode = "annotation_filtered" // Tools matching the filter
+)
+
+// ApprovalStatus is the state of a held tool call.
+type ApprovalStatus string
+
+const (
+	ApprovalPending   ApprovalStatus = "pending"   // Waiting for a decision
+	ApprovalApproved  ApprovalStatus = "approved"  // Call was sent upstream
+	ApprovalRejected  ApprovalStatus = "rejected"  // Call was refused
+	ApprovalExpired   ApprovalStatus = "expired"   // No decision in time
+	ApprovalCancelled ApprovalStatus = "cancelled" // Client gave up waiting
+)
+
+// LimitScope is what a rate limit or quota applies to.
+type LimitScope string
+
+const (
+	LimitScopeVirtualServer LimitScope = "virtual_server" // All calls to a VS
+	LimitScopeAPIKey        LimitScope = "api_key"        // Calls with a key
+	LimitScopeTool          LimitScope = "tool"           // Calls to an upstream tool
+)
+
+// BreakerState is the state of an upstream server's circuit breaker.
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // Calls go through
+	BreakerOpen     BreakerState = "open"      // Calls fail fast
+	BreakerHalfOpen BreakerState = "half_open" // One trial call at a time
+)
+
+// MemberRole is a user's role in an organization or team. Owners manage
+// membership, maintainers manage the team's hubs and virtual servers, and
+// members use them.
+type MemberRole string
+
+const (
+	MemberRoleOwner      MemberRole = "owner"
+	MemberRoleMaintainer MemberRole = "maintainer"
+	MemberRoleMember     MemberRole = "member"
+)
+
+// memberRoleRank orders roles; unknown roles rank below members.
+var memberRoleRank = map[MemberRole]int{
+	MemberRoleMember:     1,
+	MemberRoleMaintainer: 2,
+	MemberRoleOwner:      3,
+}
+
+// Valid reports whether r is a known role.
+func (r MemberRole) Valid() bool { return memberRoleRank[r] > 0 }
+
+// AtLeast reports whether r grants everything min does.
+func (r MemberRole) AtLeast(min MemberRole) bool {
+	return r.Valid() && memberRoleRank[r] >= memberRoleRank[min]
+}
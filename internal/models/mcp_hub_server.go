@@ -7,19 +7,43 @@ import (
 
 // MCPHubServer is a user-added upstream in the hub.
 type MCPHubServer struct {
-	ID          string    `gorm:"type:char(22);primaryKey" json:"id"`
-	UserID      string    `gorm:"type:char(22);index" json:"user_id"`
-	MCPServerID string    `gorm:"column:mcp_server_id;type:char(22);index" json:"mcp_server_id"` //nolint:lll
-	Status      Status    `gorm:"type:varchar(30);not null" json:"status"`
-	AuthType    AuthType  `gorm:"type:varchar(30);not null" json:"auth_type"` //nolint:lll
-	AuthValue   []byte    `gorm:"type:json" json:"auth_value"`
-	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID          string   `gorm:"type:char(22);primaryKey" json:"id"`
+	UserID      string   `gorm:"type:char(22);index" json:"user_id"`
+	MCPServerID string   `gorm:"column:mcp_server_id;type:char(22);index" json:"mcp_server_id"` //nolint:lll
+	Status      Status   `gorm:"type:varchar(30);not null" json:"status"`
+	AuthType    AuthType `gorm:"type:varchar(30);not null" json:"auth_type"` //nolint:lll
+	AuthValue   []byte   `gorm:"type:json" json:"auth_value"`
+	// TeamID is set for team hubs, shared by the team's members; UserID is
+	// then who added the hub
+	TeamID *string `gorm:"type:char(22)" json:"team_id"`
+	// Why an oauth2 hub needs authorizing again, while it is NEEDS_AUTH
+	AuthError string `gorm:"type:varchar(2000);default:''" json:"auth_error"`
+	// Outcome of the last tool refresh, manual or scheduled
+	LastRefreshedAt   *time.Time    `json:"last_refreshed_at"`
+	LastRefreshStatus RefreshStatus `gorm:"type:varchar(30);default:''" json:"last_refresh_status"`
+	LastRefreshError  string        `gorm:"type:varchar(2000);default:''" json:"last_refresh_error"`
+	// Outcome of the last health probe and the current streak, used to
+	// move the hub between ACTIVE and UNREACHABLE
+	LastProbeAt        *time.Time `json:"last_probe_at"`
+	LastProbeLatencyMS int64      `gorm:"column:last_probe_latency_ms;default:0" json:"last_probe_latency_ms"`
+	LastProbeError     string     `gorm:"type:varchar(2000);default:''" json:"last_probe_error"`
+	ProbeFailures      int        `gorm:"default:0" json:"probe_failures"`
+	ProbeSuccesses     int        `gorm:"default:0" json:"probe_successes"`
+	CreatedAt          time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt          time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
 // TableName ...
 func (MCPHubServer) TableName() string { return "mcp_hub_servers" }
 
+// Owner is who the hub and its inventory belong to.
+func (h MCPHubServer) Owner() Owner {
+	if h.TeamID != nil {
+		return Owner{TeamID: *h.TeamID}
+	}
+	return Owner{UserID: h.UserID}
+}
+
 // MCPHubServerAggregate flattens hub fields and joins selected
 // catalogue server fields (aliased in the query) to avoid column
 // collisions and make JSON clean for the API.
@@ -29,6 +53,12 @@ type MCPHubServerAggregate struct {
 	URL          string          `json:"url"`
 	Description  string          `json:"description"`
 	Capabilities json.RawMessage `json:"capabilities"`
-	Transport    string          `json:"transport"`
+	Transport    Transport       `json:"transport"`
+	Command      string          `json:"command"`
+	Args         json.RawMessage `json:"args"`
+	Env          json.RawMessage `json:"-"`
 	AccessType   AccessType      `json:"access_type"`
+	CallPolicy
+	IdentityHeader string         `json:"identity_header"`
+	Breaker        *BreakerStatus `gorm:"-" json:"breaker,omitempty"`
 }
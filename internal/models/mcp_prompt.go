@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// MCPPrompt represents a discovered prompt for a server.
+// For public servers: UserID is NULL (global prompts)
+// For private servers: UserID is set (user-specific prompts), or TeamID
+// for the hubs of a team
+type MCPPrompt struct {
+	ID             string          `gorm:"type:char(22);primaryKey" json:"id"`
+	UserID         *string         `gorm:"type:char(22)" json:"user_id"`                                     //nolint:lll
+	TeamID         *string         `gorm:"type:char(22)" json:"team_id"`                                     //nolint:lll
+	MCPServerID    string          `gorm:"column:mcp_server_id;type:char(22);not null" json:"mcp_server_id"` //nolint:lll
+	MCPHubServerID *string         `gorm:"column:mcp_hub_server_id;type:char(22)" json:"mcp_hub_server_id"`  //nolint:lll
+	Name           string          `gorm:"type:varchar(255);not null" json:"name"`
+	Description    string          `gorm:"type:text" json:"description"`
+	Arguments      json.RawMessage `gorm:"type:json" json:"arguments"`
+	Status         Status          `gorm:"type:varchar(30);not null" json:"status"`
+	CreatedAt      time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName ...
+func (MCPPrompt) TableName() string { return "mcp_prompts" }
+
+// PromptVirtualServer is the pivot between prompts and virtual servers.
+type PromptVirtualServer struct {
+	MCPVirtualServerID string    `gorm:"column:mcp_virtual_server_id;type:char(22);primaryKey"` //nolint:lll
+	PromptID           string    `gorm:"type:char(22);primaryKey"`
+	CreatedAt          time.Time `gorm:"autoCreateTime"`
+	UpdatedAt          time.Time `gorm:"autoUpdateTime"`
+}
+
+// TableName ...
+func (PromptVirtualServer) TableName() string { return "prompts_virtual_servers" }
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// MCPResource represents a discovered resource or resource template for a
+// server. Templates store their RFC 6570 URI template in URI.
+// For public servers: UserID is NULL (global resources)
+// For private servers: UserID is set (user-specific resources), or TeamID
+// for the hubs of a team
+type MCPResource struct {
+	ID             string          `gorm:"type:char(22);primaryKey" json:"id"`
+	UserID         *string         `gorm:"type:char(22)" json:"user_id"`                                     //nolint:lll
+	TeamID         *string         `gorm:"type:char(22)" json:"team_id"`                                     //nolint:lll
+	MCPServerID    string          `gorm:"column:mcp_server_id;type:char(22);not null" json:"mcp_server_id"` //nolint:lll
+	MCPHubServerID *string         `gorm:"column:mcp_hub_server_id;type:char(22)" json:"mcp_hub_server_id"`  //nolint:lll
+	URI            string          `gorm:"column:uri;type:varchar(512);not null" json:"uri"`                 //nolint:lll
+	IsTemplate     bool            `gorm:"not null;default:false" json:"is_template"`
+	Name           string          `gorm:"type:varchar(255);not null" json:"name"`
+	Description    string          `gorm:"type:text" json:"description"`
+	MIMEType       string          `gorm:"column:mime_type;type:varchar(255)" json:"mime_type"`
+	Annotations    json.RawMessage `gorm:"type:json" json:"annotations"`
+	Status         Status          `gorm:"type:varchar(30);not null" json:"status"`
+	CreatedAt      time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName ...
+func (MCPResource) TableName() string { return "mcp_resources" }
+
+// ResourceVirtualServer is the pivot between resources and virtual servers.
+type ResourceVirtualServer struct {
+	MCPVirtualServerID string    `gorm:"column:mcp_virtual_server_id;type:char(22);primaryKey"` //nolint:lll
+	ResourceID         string    `gorm:"type:char(22);primaryKey"`
+	CreatedAt          time.Time `gorm:"autoCreateTime"`
+	UpdatedAt          time.Time `gorm:"autoUpdateTime"`
+}
+
+// TableName ...
+func (ResourceVirtualServer) TableName() string {
+	return "resources_virtual_servers"
+}
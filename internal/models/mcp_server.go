@@ -5,17 +5,58 @@ import (
 	"time"
 )
 
-// MCPServer describes an upstream server catalog entry.
+// MCPServer describes an upstream server catalog entry. HTTP transports use
+// URL; stdio servers are spawned from Command with Args (JSON array) and Env
+// (JSON object, never serialized since it may hold secrets).
 type MCPServer struct {
 	ID           string          `gorm:"type:char(22);primaryKey" json:"id"`
 	Name         string          `gorm:"type:varchar(255);uniqueIndex" json:"name"`
 	URL          string          `gorm:"type:varchar(255);not null" json:"url"`
 	Description  string          `gorm:"type:varchar(255);default:''" json:"description"`
 	Capabilities json.RawMessage `gorm:"type:json" json:"capabilities"`
-	Transport    string          `gorm:"type:varchar(30);not null;default:'streamable-http'" json:"transport"`
+	Transport    Transport       `gorm:"type:varchar(30);not null;default:'streamable-http'" json:"transport"`
+	Command      string          `gorm:"type:varchar(1024);default:''" json:"command"`
+	Args         json.RawMessage `gorm:"type:json" json:"args"`
+	Env          json.RawMessage `gorm:"type:json" json:"-"`
 	AccessType   AccessType      `gorm:"type:varchar(30);not null;default:'public'" json:"access_type"`
-	CreatedAt    time.Time       `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt    time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+	// Outcome of the last tool refresh, manual or scheduled
+	LastRefreshedAt   *time.Time    `json:"last_refreshed_at"`
+	LastRefreshStatus RefreshStatus `gorm:"type:varchar(30);default:''" json:"last_refresh_status"`
+	LastRefreshError  string        `gorm:"type:varchar(2000);default:''" json:"last_refresh_error"`
+	// Outcome of the last health probe
+	LastProbeAt        *time.Time `json:"last_probe_at"`
+	LastProbeLatencyMS int64      `gorm:"column:last_probe_latency_ms;default:0" json:"last_probe_latency_ms"`
+	LastProbeError     string     `gorm:"type:varchar(2000);default:''" json:"last_probe_error"`
+	// Timeouts, retries and circuit breaker settings for calls
+	CallPolicy
+	// IdentityHeader names the header calls carry a token identifying the
+	// caller in; empty sends none
+	IdentityHeader string `gorm:"type:varchar(100);default:''" json:"identity_header"`
+	// Breaker is this replica's circuit breaker for the server; filled in by
+	// the admin API, never stored
+	Breaker   *BreakerStatus `gorm:"-" json:"breaker,omitempty"`
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
 func (MCPServer) TableName() string { return "mcp_servers" }
+
+// CallPolicy tunes how the proxy calls an upstream server. Zero values use
+// the gateway defaults.
+type CallPolicy struct {
+	CallTimeoutSeconds    int `gorm:"default:0" json:"call_timeout_seconds"`
+	ConnectTimeoutSeconds int `gorm:"default:0" json:"connect_timeout_seconds"`
+	// Retries apply only to tools annotated read-only or idempotent
+	MaxRetries             int `gorm:"default:0" json:"max_retries"`
+	RetryBackoffMS         int `gorm:"column:retry_backoff_ms;default:0" json:"retry_backoff_ms"`
+	BreakerThreshold       int `gorm:"default:0" json:"breaker_threshold"`
+	BreakerCooldownSeconds int `gorm:"default:0" json:"breaker_cooldown_seconds"`
+}
+
+// BreakerStatus is a snapshot of an upstream server's circuit breaker.
+type BreakerStatus struct {
+	State               BreakerState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	OpenedAt            *time.Time   `json:"opened_at,omitempty"`
+	RetryAt             *time.Time   `json:"retry_at,omitempty"`
+}
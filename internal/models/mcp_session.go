@@ -0,0 +1,26 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// MCPSession is a client session on a stateful virtual server endpoint,
+// identified by the Mcp-Session-Id issued on initialize. A session expires
+// once it has been idle for the configured TTL. APIKeyID holds the API key,
+// or the OAuth grant, the session was opened with.
+type MCPSession struct {
+	ID                 string          `gorm:"type:varchar(64);primaryKey" json:"id"`
+	VirtualServerID    string          `gorm:"column:mcp_virtual_server_id;type:char(22);index" json:"virtual_server_id"` //nolint:lll
+	APIKeyID           string          `gorm:"column:api_key_id;type:char(22)" json:"api_key_id"`
+	ProtocolVersion    string          `gorm:"type:varchar(32);not null;default:''" json:"protocol_version"`
+	ClientName         string          `gorm:"type:varchar(255);not null;default:''" json:"client_name"`
+	ClientVersion      string          `gorm:"type:varchar(64);not null;default:''" json:"client_version"`
+	ClientCapabilities json.RawMessage `gorm:"type:json" json:"client_capabilities"`
+	LogLevel           string          `gorm:"type:varchar(16);not null;default:''" json:"log_level"`
+	ExpiresAt          time.Time       `gorm:"not null;index" json:"expires_at"`
+	CreatedAt          time.Time       `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName ...
+func (MCPSession) TableName() string { return "mcp_sessions" }
@@ -1,16 +1,20 @@
 package models
 
 import (
+	"bytes"
 	"encoding/json"
+	"reflect"
 	"time"
 )
 
 // MCPTool represents a discovered tool for a server.
 // For public servers: UserID is NULL (global tools)
-// For private servers: UserID is set (user-specific tools)
+// For private servers: UserID is set (user-specific tools), or TeamID
+// for the hubs of a team
 type MCPTool struct {
 	ID             string          `gorm:"type:char(22);primaryKey" json:"id"`
 	UserID         *string         `gorm:"type:char(22)" json:"user_id"`                                     //nolint:lll
+	TeamID         *string         `gorm:"type:char(22)" json:"team_id"`                                     //nolint:lll
 	MCPServerID    string          `gorm:"column:mcp_server_id;type:char(22);not null" json:"mcp_server_id"` //nolint:lll
 	MCPHubServerID *string         `gorm:"column:mcp_hub_server_id;type:char(22)" json:"mcp_hub_server_id"`  //nolint:lll
 	OriginalName   string          `gorm:"type:varchar(255);not null" json:"original_name"`                  //nolint:lll
@@ -25,3 +29,31 @@ type MCPTool struct {
 
 // TableName ...
 func (MCPTool) TableName() string { return "mcp_tools" }
+
+// SameDefinition reports whether t and u have the same description, input
+// schema and annotations. JSON is compared by value since the database
+// does not keep its formatting.
+func (t MCPTool) SameDefinition(u MCPTool) bool {
+	return t.Description == u.Description &&
+		jsonEqual(t.InputSchema, u.InputSchema) &&
+		jsonEqual(t.Annotations, u.Annotations)
+}
+
+// jsonEqual compares JSON documents by value; empty counts as null.
+func jsonEqual(a, b json.RawMessage) bool {
+	va, errA := jsonValue(a)
+	vb, errB := jsonValue(b)
+	if errA != nil || errB != nil {
+		return bytes.Equal(a, b)
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func jsonValue(raw json.RawMessage) (any, error) {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return nil, nil
+	}
+	var v any
+	err := json.Unmarshal(raw, &v)
+	return v, err
+}
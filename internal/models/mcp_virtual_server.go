@@ -1,18 +1,49 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // MCPVirtualServer is a user-composed virtual server of tools.
 type MCPVirtualServer struct {
-	ID        string    `gorm:"type:char(22);primaryKey" json:"id"`
-	UserID    string    `gorm:"type:char(22);index" json:"user_id"`
-	Name      string    `gorm:"type:varchar(255);not null" json:"name"`
-	Status    Status    `gorm:"type:varchar(30);not null" json:"status"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID          string      `gorm:"type:char(22);primaryKey" json:"id"`
+	UserID      string      `gorm:"type:char(22);index" json:"user_id"`
+	Name        string      `gorm:"type:varchar(255);not null" json:"name"`
+	Status      Status      `gorm:"type:varchar(30);not null" json:"status"`
+	ToolNaming  ToolNaming  `gorm:"type:varchar(30);not null;default:'original'" json:"tool_naming"`   //nolint:lll
+	SessionMode SessionMode `gorm:"type:varchar(30);not null;default:'stateless'" json:"session_mode"` //nolint:lll
+	// TeamID is set for team virtual servers; UserID is then who created
+	// the server
+	TeamID *string `gorm:"type:char(22)" json:"team_id"`
+	// AllowSampling and AllowElicitation let upstreams send those requests
+	// to the clients of stateful sessions.
+	AllowSampling    bool `gorm:"not null;default:false" json:"allow_sampling"`
+	AllowElicitation bool `gorm:"not null;default:false" json:"allow_elicitation"`
+	// ArgValidation checks tool call arguments against input schemas.
+	ArgValidation ArgValidation `gorm:"type:varchar(30);not null;default:'off'" json:"arg_validation"` //nolint:lll
+	// ApproveDestructive holds calls to tools annotated destructiveHint
+	// for the owner's approval.
+	ApproveDestructive bool `gorm:"not null;default:false" json:"approve_destructive"`
+	// Mode limits the tools exposed to those whose annotations allow it;
+	// under AccessAnnotationFiltered, AnnotationFilter maps hint names to
+	// required values.
+	Mode             AccessMode      `gorm:"type:varchar(30);not null;default:'full'" json:"mode"` //nolint:lll
+	AnnotationFilter json.RawMessage `gorm:"type:json" json:"annotation_filter,omitempty"`
+	CreatedAt        time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
 // TableName ...
 func (MCPVirtualServer) TableName() string {
 	return "mcp_virtual_servers"
 }
+
+// Owner is who the virtual server belongs to; it may only use their
+// inventory and global inventory.
+func (v MCPVirtualServer) Owner() Owner {
+	if v.TeamID != nil {
+		return Owner{TeamID: *v.TeamID}
+	}
+	return Owner{UserID: v.UserID}
+}
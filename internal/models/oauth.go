@@ -0,0 +1,65 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OAuthClient is a client registered with the gateway's authorization
+// server. Clients are public: they authenticate with PKCE, not a secret.
+type OAuthClient struct {
+	ID           string          `gorm:"type:char(22);primaryKey" json:"client_id"`
+	Name         string          `gorm:"type:varchar(255);default:''" json:"client_name"`              //nolint:lll
+	RedirectURIs json.RawMessage `gorm:"column:redirect_uris;type:json;not null" json:"redirect_uris"` //nolint:lll
+	CreatedAt    time.Time       `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName ...
+func (OAuthClient) TableName() string { return "oauth_clients" }
+
+// OAuthCode is an authorization code granting a client access to virtual
+// servers on behalf of a user. Only the SHA-256 hash of the code is
+// persisted, and a code can be redeemed once.
+type OAuthCode struct {
+	ID               string          `gorm:"type:char(22);primaryKey"`
+	CodeHash         string          `gorm:"type:char(64);uniqueIndex"`
+	ClientID         string          `gorm:"type:char(22);not null"`
+	UserID           string          `gorm:"type:char(22);not null"`
+	RedirectURI      string          `gorm:"column:redirect_uri;type:varchar(2000);not null"` //nolint:lll
+	CodeChallenge    string          `gorm:"type:varchar(128);not null"`
+	VirtualServerIDs json.RawMessage `gorm:"type:json;not null"`
+	ExpiresAt        time.Time       `gorm:"not null"`
+	UsedAt           *time.Time
+	CreatedAt        time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName ...
+func (OAuthCode) TableName() string { return "oauth_codes" }
+
+// OAuthTokenKind tells access tokens from refresh tokens.
+type OAuthTokenKind string
+
+const (
+	OAuthAccessToken  OAuthTokenKind = "access"  // Presented to the MCP endpoint
+	OAuthRefreshToken OAuthTokenKind = "refresh" // Exchanged for new tokens
+)
+
+// OAuthToken is an access or refresh token issued to a client. Tokens
+// issued from the same authorization code share a GrantID so that reuse of
+// a rotated refresh token can revoke them all. Only the SHA-256 hash of
+// the token is persisted.
+type OAuthToken struct {
+	ID               string          `gorm:"type:char(22);primaryKey"`
+	TokenHash        string          `gorm:"type:char(64);uniqueIndex"`
+	Kind             OAuthTokenKind  `gorm:"type:varchar(30);not null"`
+	GrantID          string          `gorm:"type:char(22);not null"`
+	ClientID         string          `gorm:"type:char(22);not null"`
+	UserID           string          `gorm:"type:char(22);not null"`
+	VirtualServerIDs json.RawMessage `gorm:"type:json;not null"`
+	ExpiresAt        time.Time       `gorm:"not null"`
+	RevokedAt        *time.Time
+	CreatedAt        time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName ...
+func (OAuthToken) TableName() string { return "oauth_tokens" }
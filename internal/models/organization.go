@@ -0,0 +1,92 @@
+package models
+
+import "time"
+
+// Organization groups teams. Its owners manage every team in it.
+type Organization struct {
+	ID        string    `gorm:"type:char(22);primaryKey" json:"id"`
+	Name      string    `gorm:"type:varchar(255);not null" json:"name"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName ...
+func (Organization) TableName() string { return "organizations" }
+
+// OrganizationMember is a user's membership of an organization. Only
+// members of an organization can join its teams.
+type OrganizationMember struct {
+	OrganizationID string     `gorm:"type:char(22);primaryKey" json:"organization_id"` //nolint:lll
+	UserID         string     `gorm:"type:char(22);primaryKey" json:"user_id"`
+	Role           MemberRole `gorm:"type:varchar(30);not null" json:"role"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName ...
+func (OrganizationMember) TableName() string { return "organization_members" }
+
+// Team owns hubs and virtual servers shared by its members.
+type Team struct {
+	ID             string    `gorm:"type:char(22);primaryKey" json:"id"`
+	OrganizationID string    `gorm:"type:char(22);not null" json:"organization_id"`
+	Name           string    `gorm:"type:varchar(255);not null" json:"name"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName ...
+func (Team) TableName() string { return "teams" }
+
+// TeamMember is a user's membership of a team.
+type TeamMember struct {
+	TeamID    string     `gorm:"type:char(22);primaryKey" json:"team_id"`
+	UserID    string     `gorm:"type:char(22);primaryKey" json:"user_id"`
+	Role      MemberRole `gorm:"type:varchar(30);not null" json:"role"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName ...
+func (TeamMember) TableName() string { return "team_members" }
+
+// OrganizationWithRole is an organization as listed for a user, with their
+// role in it.
+type OrganizationWithRole struct {
+	Organization
+	Role MemberRole `json:"role"`
+}
+
+// TeamWithRole is a team as listed for a user, with their effective role:
+// owner for owners of the organization, else their team role.
+type TeamWithRole struct {
+	Team
+	OrganizationName string     `json:"organization_name"`
+	Role             MemberRole `json:"role"`
+}
+
+// Member is a membership as listed for an organization or team.
+type Member struct {
+	UserID    string     `json:"user_id"`
+	Username  string     `json:"username"`
+	Role      MemberRole `json:"role"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Owner is who a hub and the tools, resources and prompts found on it
+// belong to: the team when TeamID is set, else the user. The zero Owner
+// stands for the global inventory of public servers.
+type Owner struct {
+	UserID string
+	TeamID string
+}
+
+// Columns returns the user_id and team_id of rows o owns.
+func (o Owner) Columns() (userID, teamID *string) {
+	switch {
+	case o.TeamID != "":
+		return nil, &o.TeamID
+	case o.UserID != "":
+		return &o.UserID, nil
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// RateLimit caps the tool calls of one scope: a token bucket refilled at
+// RatePerMinute holding up to Burst calls, and daily and monthly call
+// quotas. Zero values leave that part unlimited.
+type RateLimit struct {
+	ID            string     `gorm:"type:char(22);primaryKey" json:"id"`
+	Scope         LimitScope `gorm:"type:varchar(30);not null;uniqueIndex:uq_rate_limits_scope" json:"scope"` //nolint:lll
+	ScopeID       string     `gorm:"type:char(22);not null;uniqueIndex:uq_rate_limits_scope" json:"scope_id"` //nolint:lll
+	RatePerMinute int        `gorm:"not null;default:0" json:"rate_per_minute"`
+	Burst         int        `gorm:"not null;default:0" json:"burst"`
+	DailyQuota    int64      `gorm:"not null;default:0" json:"daily_quota"`
+	MonthlyQuota  int64      `gorm:"not null;default:0" json:"monthly_quota"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName ...
+func (RateLimit) TableName() string { return "rate_limits" }
+
+// CallUsage counts the calls admitted for a scope in one UTC period, a day
+// ("2006-01-02") or a month ("2006-01").
+type CallUsage struct {
+	Scope     LimitScope `gorm:"type:varchar(30);primaryKey" json:"scope"`
+	ScopeID   string     `gorm:"type:char(22);primaryKey" json:"scope_id"`
+	Period    string     `gorm:"type:varchar(10);primaryKey" json:"period"`
+	Calls     int64      `gorm:"not null;default:0" json:"calls"`
+	UpdatedAt time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName ...
+func (CallUsage) TableName() string { return "call_usage" }
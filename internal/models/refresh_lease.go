@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// RefreshLease is a named, expiring lock that lets one gateway replica
+// run a background refresh at a time.
+type RefreshLease struct {
+	Name      string    `gorm:"type:varchar(128);primaryKey" json:"name"`
+	Holder    string    `gorm:"type:varchar(128);not null" json:"holder"`
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+}
+
+// TableName ...
+func (RefreshLease) TableName() string { return "refresh_leases" }
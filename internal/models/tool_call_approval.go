@@ -0,0 +1,29 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ToolCallApproval is a tool call held until the owner of its virtual
+// server approves or rejects it. Arguments are stored after redaction.
+type ToolCallApproval struct {
+	ID              string          `gorm:"type:char(22);primaryKey" json:"id"`
+	VirtualServerID string          `gorm:"type:char(22);not null" json:"virtual_server_id"`
+	UserID          string          `gorm:"type:char(22);not null" json:"user_id"`
+	ToolID          string          `gorm:"type:char(22);not null" json:"tool_id"`
+	ToolName        string          `gorm:"type:varchar(255);not null" json:"tool_name"`
+	APIKeyID        *string         `gorm:"column:api_key_id;type:char(22)" json:"api_key_id"`
+	SessionID       string          `gorm:"type:varchar(128);default:''" json:"session_id"`
+	Arguments       json.RawMessage `gorm:"type:json" json:"arguments"`
+	Status          ApprovalStatus  `gorm:"type:varchar(30);not null" json:"status"`
+	Reason          string          `gorm:"type:varchar(1000);default:''" json:"reason"`
+	DecidedBy       *string         `gorm:"type:char(22)" json:"decided_by"`
+	DecidedAt       *time.Time      `json:"decided_at"`
+	ExpiresAt       time.Time       `gorm:"not null" json:"expires_at"`
+	CreatedAt       time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName ...
+func (ToolCallApproval) TableName() string { return "tool_call_approvals" }
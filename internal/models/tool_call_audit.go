@@ -0,0 +1,34 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ToolCallAudit records one tool call proxied through a virtual server.
+// Arguments are stored after redaction. Calls the client cancelled or
+// abandoned are marked Cancelled rather than IsError. Calls held for
+// approval carry the approval and its outcome.
+type ToolCallAudit struct {
+	ID              string          `gorm:"type:char(22);primaryKey" json:"id"`
+	VirtualServerID string          `gorm:"type:char(22);not null" json:"virtual_server_id"`
+	UserID          string          `gorm:"type:char(22);not null" json:"user_id"`
+	HubServerID     *string         `gorm:"type:char(22)" json:"hub_server_id"`
+	ToolID          string          `gorm:"type:char(22);not null" json:"tool_id"`
+	ToolName        string          `gorm:"type:varchar(255);not null" json:"tool_name"`
+	APIKeyID        *string         `gorm:"column:api_key_id;type:char(22)" json:"api_key_id"`
+	SessionID       string          `gorm:"type:varchar(128);default:''" json:"session_id"`
+	RequestID       string          `gorm:"type:varchar(64);default:''" json:"request_id"`
+	Arguments       json.RawMessage `gorm:"type:json" json:"arguments"`
+	ResultBytes     int             `gorm:"not null;default:0" json:"result_bytes"`
+	IsError         bool            `gorm:"not null;default:false" json:"is_error"`
+	Cancelled       bool            `gorm:"not null;default:false" json:"cancelled"`
+	LatencyMS       int64           `gorm:"column:latency_ms;not null;default:0" json:"latency_ms"`
+	Error           string          `gorm:"type:text" json:"error"`
+	ApprovalID      *string         `gorm:"type:char(22)" json:"approval_id"`
+	ApprovalStatus  ApprovalStatus  `gorm:"type:varchar(30);default:''" json:"approval_status"`
+	CreatedAt       time.Time       `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName ...
+func (ToolCallAudit) TableName() string { return "tool_call_audits" }
@@ -1,14 +1,34 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // ToolVirtualServer is the pivot between tools and virtual servers.
+// ExposedName is the name clients see and call; it is unique per virtual
+// server and derived from the server's ToolNaming policy unless Alias is set.
+// Transform holds optional argument and result rewrite rules (JSON).
+// RequireApproval holds every call to the tool for the owner's approval.
 type ToolVirtualServer struct {
-	MCPVirtualServerID string    `gorm:"column:mcp_virtual_server_id;type:char(22);primaryKey"` //nolint:lll
-	ToolID             string    `gorm:"type:char(22);primaryKey"`
-	CreatedAt          time.Time `gorm:"autoCreateTime"`
-	UpdatedAt          time.Time `gorm:"autoUpdateTime"`
+	MCPVirtualServerID string          `gorm:"column:mcp_virtual_server_id;type:char(22);primaryKey"` //nolint:lll
+	ToolID             string          `gorm:"type:char(22);primaryKey"`
+	Alias              *string         `gorm:"type:varchar(255)"`
+	ExposedName        string          `gorm:"type:varchar(255);not null"`
+	Transform          json.RawMessage `gorm:"type:json"`
+	RequireApproval    bool            `gorm:"not null;default:false"`
+	CreatedAt          time.Time       `gorm:"autoCreateTime"`
+	UpdatedAt          time.Time       `gorm:"autoUpdateTime"`
 }
 
 // TableName ...
 func (ToolVirtualServer) TableName() string { return "tools_virtual_servers" }
+
+// VirtualServerTool is a tool together with how a virtual server exposes it.
+type VirtualServerTool struct {
+	MCPTool
+	Alias           *string         `json:"alias"`
+	ExposedName     string          `json:"exposed_name"`
+	Transform       json.RawMessage `json:"transform"`
+	RequireApproval bool            `json:"require_approval"`
+}
@@ -9,6 +9,11 @@ type User struct {
 	Role      string    `gorm:"type:varchar(50);not null"`
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+
+	// AuthProvider and AuthSubject name the OIDC provider and account a
+	// user was created for; both are nil for other users.
+	AuthProvider *string `gorm:"type:varchar(64)"`
+	AuthSubject  *string `gorm:"type:varchar(255)"`
 }
 
 // TableName ...
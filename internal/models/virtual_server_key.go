@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// VirtualServerKey is an API key granting access to a virtual server's
+// MCP endpoint. Only the SHA-256 hash of the key is persisted.
+type VirtualServerKey struct {
+	ID                 string     `gorm:"type:char(22);primaryKey" json:"id"`
+	MCPVirtualServerID string     `gorm:"column:mcp_virtual_server_id;type:char(22);index" json:"mcp_virtual_server_id"` //nolint:lll
+	UserID             string     `gorm:"type:char(22);index" json:"user_id"`
+	Name               string     `gorm:"type:varchar(255);not null" json:"name"`
+	Prefix             string     `gorm:"type:varchar(16);not null" json:"prefix"`
+	KeyHash            string     `gorm:"type:char(64);uniqueIndex" json:"-"`
+	Status             Status     `gorm:"type:varchar(30);not null" json:"status"`
+	LastUsedAt         *time.Time `json:"last_used_at"`
+	RevokedAt          *time.Time `json:"revoked_at"`
+	CreatedAt          time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt          time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName ...
+func (VirtualServerKey) TableName() string { return "virtual_server_keys" }
@@ -0,0 +1,151 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+)
+
+// keyRefetch limits how often unknown key ids make the keys be fetched
+// again.
+const keyRefetch = time.Minute
+
+// asymmetricAlgs are the ID token algorithms accepted; symmetric and
+// unsigned tokens never are.
+var asymmetricAlgs = []string{
+	"RS256", "RS384", "RS512", "PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+}
+
+// supportedAlgs returns the provider's algorithms that are accepted.
+func supportedAlgs(algs []string) []string {
+	var out []string
+	for _, a := range algs {
+		if slices.Contains(asymmetricAlgs, a) {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// keySet is a provider's signing keys by key id.
+type keySet struct {
+	keys    map[string]any
+	fetched time.Time
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// key returns the public key with the given id. Keys are fetched on first
+// use and again, at most every keyRefetch, for an id not seen before, so
+// rotated keys are picked up. A token without a key id may use the only
+// key.
+func (p *Provider) key(ctx context.Context, uri, kid string) (any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if k, ok := p.keys.find(kid); ok {
+		return k, nil
+	}
+	if p.keys != nil && time.Since(p.keys.fetched) < keyRefetch {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := p.getJSON(ctx, uri, &doc); err != nil {
+		p.logger.Error("OIDC_JWKS_FETCH_ERROR",
+			"provider", p.cfg.Name, "error", err)
+		return nil, err
+	}
+	set := &keySet{keys: map[string]any{}, fetched: time.Now()}
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if pub, err := k.publicKey(); err == nil {
+			set.keys[k.Kid] = pub
+		}
+	}
+	p.keys = set
+	if k, ok := p.keys.find(kid); ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+func (s *keySet) find(kid string) (any, bool) {
+	if s == nil {
+		return nil, false
+	}
+	if k, ok := s.keys[kid]; ok {
+		return k, true
+	}
+	if kid == "" && len(s.keys) == 1 {
+		for _, k := range s.keys {
+			return k, true
+		}
+	}
+	return nil, false
+}
+
+// publicKey decodes an RSA or EC key.
+func (k jwk) publicKey() (any, error) {
+	b64 := base64.RawURLEncoding.DecodeString
+	switch k.Kty {
+	case "RSA":
+		n, err := b64(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := b64(k.E)
+		if err != nil || len(e) > 4 {
+			return nil, fmt.Errorf("invalid rsa exponent")
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := b64(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := b64(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
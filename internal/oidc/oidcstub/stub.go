@@ -0,0 +1,297 @@
+// Package oidcstub is a minimal OpenID Connect provider shaped like a
+// Keycloak realm, for signing in locally and in tests without a real
+// identity provider. It serves discovery, keys, an authorization page that
+// lets you pick one of its users, and a token endpoint issuing RS256 ID
+// tokens. It keeps everything in memory and must not be used in production.
+package oidcstub
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	keyID    = "stub"
+	codeTTL  = time.Minute
+	tokenTTL = 5 * time.Minute
+)
+
+// User is someone who can sign in. Roles are issued both as
+// realm_access.roles and as groups.
+type User struct {
+	Email string
+	Name  string
+	Roles []string
+}
+
+// Config configures the stub. BaseURL is where it is served; the issuer is
+// BaseURL/realms/Realm. When AutoLogin names a user, authorization requests
+// sign them in without showing the page.
+type Config struct {
+	BaseURL      string
+	Realm        string
+	ClientID     string
+	ClientSecret string
+	Users        []User
+	AutoLogin    string
+}
+
+// Stub is the provider. It implements http.Handler.
+type Stub struct {
+	cfg Config
+	key *rsa.PrivateKey
+	mux *http.ServeMux
+
+	mu    sync.Mutex
+	codes map[string]pendingCode
+}
+
+type pendingCode struct {
+	user        User
+	clientID    string
+	redirectURI string
+	challenge   string
+	nonce       string
+	expires     time.Time
+}
+
+// New creates a stub with a fresh signing key.
+func New(cfg Config) (*Stub, error) {
+	if cfg.Realm == "" {
+		cfg.Realm = "dev"
+	}
+	if cfg.ClientID == "" || len(cfg.Users) == 0 {
+		return nil, errors.New("oidcstub: a client id and users are required")
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	s := &Stub{cfg: cfg, key: key, mux: http.NewServeMux(),
+		codes: map[string]pendingCode{}}
+	base := "/realms/" + cfg.Realm
+	s.mux.HandleFunc("GET "+base+"/.well-known/openid-configuration",
+		s.discovery)
+	s.mux.HandleFunc("GET "+base+"/protocol/openid-connect/certs", s.certs)
+	s.mux.HandleFunc(base+"/protocol/openid-connect/auth", s.authorize)
+	s.mux.HandleFunc("POST "+base+"/protocol/openid-connect/token", s.token)
+	return s, nil
+}
+
+// NewServer starts a stub on a local test server; its BaseURL is set from
+// the server. Close the server when done.
+func NewServer(cfg Config) (*httptest.Server, *Stub, error) {
+	srv := httptest.NewUnstartedServer(nil)
+	cfg.BaseURL = "http://" + srv.Listener.Addr().String()
+	s, err := New(cfg)
+	if err != nil {
+		srv.Close()
+		return nil, nil, err
+	}
+	srv.Config.Handler = s
+	srv.Start()
+	return srv, s, nil
+}
+
+// Issuer is the issuer to configure the gateway's provider with.
+func (s *Stub) Issuer() string {
+	return s.cfg.BaseURL + "/realms/" + s.cfg.Realm
+}
+
+func (s *Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Stub) discovery(w http.ResponseWriter, _ *http.Request) {
+	oidc := s.Issuer() + "/protocol/openid-connect"
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                s.Issuer(),
+		"authorization_endpoint":                oidc + "/auth",
+		"token_endpoint":                        oidc + "/token",
+		"jwks_uri":                              oidc + "/certs",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (s *Stub) certs(w http.ResponseWriter, _ *http.Request) {
+	b64 := base64.RawURLEncoding.EncodeToString
+	pub := s.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]any{"keys": []map[string]string{{
+		"kty": "RSA", "use": "sig", "alg": "RS256", "kid": keyID,
+		"n": b64(pub.N.Bytes()),
+		"e": b64(big.NewInt(int64(pub.E)).Bytes()),
+	}}})
+}
+
+var loginPage = template.Must(template.New("login").Parse(`<!doctype html>
+<html><head><title>Stub identity provider</title></head><body>
+<h1>Sign in to realm {{.Realm}}</h1>
+<form method="post">
+{{range $k, $v := .Query}}
+<input type="hidden" name="{{$k}}" value="{{index $v 0}}">{{end}}
+{{range .Users}}<p><button name="login" value="{{.Email}}">{{.Email}}</button>
+{{range .Roles}} {{.}}{{end}}</p>
+{{end}}
+</form></body></html>`))
+
+// authorize shows the user picker on GET and issues a code on POST, or
+// straight away for the AutoLogin user.
+func (s *Stub) authorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q := r.Form
+	if q.Get("client_id") != s.cfg.ClientID ||
+		q.Get("response_type") != "code" || q.Get("redirect_uri") == "" ||
+		q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+	login := q.Get("login")
+	if login == "" {
+		login = s.cfg.AutoLogin
+	}
+	if login == "" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = loginPage.Execute(w, map[string]any{
+			"Realm": s.cfg.Realm, "Users": s.cfg.Users, "Query": r.URL.Query(),
+		})
+		return
+	}
+	var user *User
+	for i := range s.cfg.Users {
+		if s.cfg.Users[i].Email == login {
+			user = &s.cfg.Users[i]
+		}
+	}
+	if user == nil {
+		http.Error(w, "unknown user", http.StatusBadRequest)
+		return
+	}
+	code := randomString()
+	s.mu.Lock()
+	s.codes[code] = pendingCode{
+		user:        *user,
+		clientID:    q.Get("client_id"),
+		redirectURI: q.Get("redirect_uri"),
+		challenge:   q.Get("code_challenge"),
+		nonce:       q.Get("nonce"),
+		expires:     time.Now().Add(codeTTL),
+	}
+	s.mu.Unlock()
+	u, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	rq := u.Query()
+	rq.Set("code", code)
+	rq.Set("state", q.Get("state"))
+	u.RawQuery = rq.Encode()
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+// token redeems a code for an ID token, checking the client secret, the
+// redirect URI and the PKCE verifier.
+func (s *Stub) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, "invalid_request")
+		return
+	}
+	id, secret, ok := r.BasicAuth()
+	if ok {
+		id, _ = url.QueryUnescape(id)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if id != s.cfg.ClientID || subtle.ConstantTimeCompare(
+		[]byte(secret), []byte(s.cfg.ClientSecret)) != 1 {
+		tokenError(w, "invalid_client")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, "unsupported_grant_type")
+		return
+	}
+	code := r.PostForm.Get("code")
+	s.mu.Lock()
+	pc, found := s.codes[code]
+	delete(s.codes, code)
+	s.mu.Unlock()
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !found || time.Now().After(pc.expires) || pc.clientID != id ||
+		pc.redirectURI != r.PostForm.Get("redirect_uri") ||
+		base64.RawURLEncoding.EncodeToString(sum[:]) != pc.challenge {
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            s.Issuer(),
+		"sub":            pc.user.Email,
+		"aud":            id,
+		"azp":            id,
+		"iat":            now.Unix(),
+		"exp":            now.Add(tokenTTL).Unix(),
+		"email":          pc.user.Email,
+		"email_verified": true,
+		"name":           pc.user.Name,
+		"realm_access":   map[string]any{"roles": pc.user.Roles},
+		"groups":         pc.user.Roles,
+	}
+	if pc.nonce != "" {
+		claims["nonce"] = pc.nonce
+	}
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tok.Header["kid"] = keyID
+	idToken, err := tok.SignedString(s.key)
+	if err != nil {
+		tokenError(w, "server_error")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   int(tokenTTL.Seconds()),
+		"id_token":     idToken,
+	})
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func randomString() string {
+	var b [32]byte
+	_, _ = rand.Read(b[:])
+	return base64.RawURLEncoding.EncodeToString(b[:])
+}
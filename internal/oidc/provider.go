@@ -0,0 +1,373 @@
+// Package oidc signs users in with OpenID Connect providers. It runs the
+// authorization code flow with PKCE, verifies ID tokens against the keys
+// the provider publishes, and maps their claims to an email and a role.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/config"
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+const (
+	httpTimeout    = 15 * time.Second
+	maxBodySize    = 1 << 20
+	defaultEmail   = "email"
+	clockSkew      = time.Minute
+	discoveryRetry = 30 * time.Second
+)
+
+var (
+	// ErrEmailNotAllowed is returned for a user whose email is missing,
+	// unverified or outside the provider's allowed domains.
+	ErrEmailNotAllowed = errors.New("email not allowed")
+	// ErrInvalidIDToken is returned for an ID token that does not verify.
+	ErrInvalidIDToken = errors.New("invalid id token")
+
+	defaultScopes = []string{"openid", "email", "profile"}
+	nameRE        = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+)
+
+// Identity is a user signed in with a provider.
+type Identity struct {
+	Subject string
+	Email   string
+	// Role is set when the provider maps a claim to roles
+	Role m.Role
+}
+
+// metadata is the part of the provider's discovery document the flow uses.
+type metadata struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	SigningAlgs           []string `json:"id_token_signing_alg_values_supported"`
+}
+
+// Provider is one configured OpenID Connect provider. Its discovery
+// document is fetched on first use and kept; its keys are fetched again
+// when a token names a key it does not know.
+type Provider struct {
+	cfg    config.OIDCProviderConfig
+	logger *slog.Logger
+	client *http.Client
+
+	mu          sync.Mutex
+	md          *metadata
+	lastAttempt time.Time
+	keys        *keySet
+}
+
+// NewProvider validates a provider's configuration.
+func NewProvider(
+	cfg config.OIDCProviderConfig, logger *slog.Logger,
+) (*Provider, error) {
+	if !nameRE.MatchString(cfg.Name) {
+		return nil, fmt.Errorf("oidc provider name %q must match %s",
+			cfg.Name, nameRE)
+	}
+	if cfg.Issuer == "" || cfg.ClientID == "" {
+		return nil, fmt.Errorf("oidc provider %s: issuer and client_id "+
+			"are required", cfg.Name)
+	}
+	for k, v := range cfg.RoleMapping {
+		if m.Role(strings.ToUpper(v)) != m.RoleAdmin &&
+			m.Role(strings.ToUpper(v)) != m.RoleUser {
+			return nil, fmt.Errorf("oidc provider %s: role_mapping %q: "+
+				"unknown role %q", cfg.Name, k, v)
+		}
+	}
+	cfg.Issuer = strings.TrimRight(cfg.Issuer, "/")
+	if cfg.DisplayName == "" {
+		cfg.DisplayName = cfg.Name
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = defaultScopes
+	}
+	if cfg.EmailClaim == "" {
+		cfg.EmailClaim = defaultEmail
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Provider{
+		cfg:    cfg,
+		logger: logger,
+		client: &http.Client{Timeout: httpTimeout},
+	}, nil
+}
+
+// Name is the provider's id in login URLs.
+func (p *Provider) Name() string { return p.cfg.Name }
+
+// DisplayName is the provider's name on login pages.
+func (p *Provider) DisplayName() string { return p.cfg.DisplayName }
+
+// AuthCodeURL returns the URL that starts a sign in. The provider redirects
+// back to redirectURI with state, and the ID token it issues carries nonce.
+func (p *Provider) AuthCodeURL(
+	ctx context.Context, redirectURI, state, nonce, verifier string,
+) (string, error) {
+	oc, err := p.oauth2Config(ctx, redirectURI)
+	if err != nil {
+		return "", err
+	}
+	return oc.AuthCodeURL(state,
+		oauth2.SetAuthURLParam("nonce", nonce),
+		oauth2.S256ChallengeOption(verifier)), nil
+}
+
+// Exchange redeems an authorization code, verifies the ID token issued
+// with it and returns who signed in. Users the provider does not allow get
+// ErrEmailNotAllowed.
+func (p *Provider) Exchange(
+	ctx context.Context, redirectURI, code, verifier, nonce string,
+) (Identity, error) {
+	oc, err := p.oauth2Config(ctx, redirectURI)
+	if err != nil {
+		return Identity{}, err
+	}
+	tok, err := oc.Exchange(p.clientContext(ctx), code,
+		oauth2.VerifierOption(verifier))
+	if err != nil {
+		return Identity{}, err
+	}
+	raw, _ := tok.Extra("id_token").(string)
+	if raw == "" {
+		return Identity{}, fmt.Errorf("%w: token response has none",
+			ErrInvalidIDToken)
+	}
+	claims, err := p.verify(ctx, raw, nonce)
+	if err != nil {
+		return Identity{}, err
+	}
+	return p.identity(claims)
+}
+
+// verify checks an ID token's signature, issuer, audience, lifetime and
+// nonce, and returns its claims.
+func (p *Provider) verify(
+	ctx context.Context, raw, nonce string,
+) (jwt.MapClaims, error) {
+	md, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	algs := md.SigningAlgs
+	if len(algs) == 0 {
+		algs = []string{"RS256"}
+	}
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(raw, claims,
+		func(t *jwt.Token) (any, error) {
+			kid, _ := t.Header["kid"].(string)
+			return p.key(ctx, md.JWKSURI, kid)
+		},
+		jwt.WithValidMethods(supportedAlgs(algs)),
+		jwt.WithIssuer(md.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockSkew),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if got, _ := claims["nonce"].(string); got == "" || got != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	return claims, nil
+}
+
+// identity reads the subject, email and role from verified claims. The
+// email must be marked verified unless the provider is trusted to verify
+// every email it issues.
+func (p *Provider) identity(claims jwt.MapClaims) (Identity, error) {
+	id := Identity{}
+	id.Subject, _ = claims["sub"].(string)
+	if id.Subject == "" {
+		return Identity{}, fmt.Errorf("%w: no subject", ErrInvalidIDToken)
+	}
+	email, _ := lookup(claims, p.cfg.EmailClaim).(string)
+	email = strings.ToLower(strings.TrimSpace(email))
+	if verified, _ := claims["email_verified"].(bool); !verified &&
+		!p.cfg.TrustEmail {
+		return Identity{}, fmt.Errorf("%w: email not verified",
+			ErrEmailNotAllowed)
+	}
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return Identity{}, fmt.Errorf("%w: no email in %q claim",
+			ErrEmailNotAllowed, p.cfg.EmailClaim)
+	}
+	if len(p.cfg.AllowedDomains) > 0 &&
+		!slices.ContainsFunc(p.cfg.AllowedDomains, func(d string) bool {
+			return strings.EqualFold(d, email[at+1:])
+		}) {
+		return Identity{}, fmt.Errorf("%w: domain %s", ErrEmailNotAllowed,
+			email[at+1:])
+	}
+	id.Email = email
+	if p.cfg.RoleClaim != "" {
+		id.Role = p.role(lookup(claims, p.cfg.RoleClaim))
+	}
+	return id, nil
+}
+
+// role maps the values of the role claim: ADMIN if any value maps to it,
+// else USER.
+func (p *Provider) role(v any) m.Role {
+	var values []string
+	switch t := v.(type) {
+	case string:
+		values = []string{t}
+	case []any:
+		for _, x := range t {
+			if s, ok := x.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+	for _, val := range values {
+		for k, role := range p.cfg.RoleMapping {
+			if strings.EqualFold(k, val) &&
+				m.Role(strings.ToUpper(role)) == m.RoleAdmin {
+				return m.RoleAdmin
+			}
+		}
+	}
+	return m.RoleUser
+}
+
+// lookup returns a claim by name, or follows a dotted path through nested
+// claims.
+func lookup(claims map[string]any, path string) any {
+	if v, ok := claims[path]; ok {
+		return v
+	}
+	var cur any = claims
+	for _, part := range strings.Split(path, ".") {
+		obj, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = obj[part]
+	}
+	return cur
+}
+
+// oauth2Config builds the client configuration from the discovered
+// endpoints.
+func (p *Provider) oauth2Config(
+	ctx context.Context, redirectURI string,
+) (*oauth2.Config, error) {
+	md, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  md.AuthorizationEndpoint,
+			TokenURL: md.TokenEndpoint,
+		},
+		RedirectURL: redirectURI,
+		Scopes:      p.cfg.Scopes,
+	}, nil
+}
+
+// metadata returns the discovery document, fetching it on first use. A
+// failed fetch is retried after discoveryRetry at the earliest.
+func (p *Provider) metadata(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.md != nil {
+		return p.md, nil
+	}
+	if time.Since(p.lastAttempt) < discoveryRetry {
+		return nil, fmt.Errorf("oidc provider %s is unavailable", p.cfg.Name)
+	}
+	p.lastAttempt = time.Now()
+	p.logger.Info("OIDC_DISCOVERY_INIT", "provider", p.cfg.Name)
+	var md metadata
+	err := p.getJSON(ctx,
+		p.cfg.Issuer+"/.well-known/openid-configuration", &md)
+	if err == nil && strings.TrimRight(md.Issuer, "/") != p.cfg.Issuer {
+		err = fmt.Errorf("discovery names issuer %q", md.Issuer)
+	}
+	if err == nil && (md.AuthorizationEndpoint == "" ||
+		md.TokenEndpoint == "" || md.JWKSURI == "") {
+		err = errors.New("discovery document is missing endpoints")
+	}
+	if err != nil {
+		p.logger.Error("OIDC_DISCOVERY_ERROR",
+			"provider", p.cfg.Name, "error", err)
+		return nil, fmt.Errorf("oidc provider %s: %w", p.cfg.Name, err)
+	}
+	p.md = &md
+	p.logger.Info("OIDC_DISCOVERY_SUCCESS", "provider", p.cfg.Name)
+	return p.md, nil
+}
+
+// clientContext makes the oauth2 package use the provider's HTTP client.
+func (p *Provider) clientContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, p.client)
+}
+
+func (p *Provider) getJSON(ctx context.Context, u string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", u, res.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(res.Body, maxBodySize)).Decode(out)
+}
+
+// Flow is a sign in in progress: the state the callback must return, the
+// nonce its ID token must carry and the PKCE verifier of its code.
+type Flow struct {
+	State    string
+	Nonce    string
+	Verifier string
+}
+
+// NewFlow starts a sign in with fresh random values.
+func NewFlow() (Flow, error) {
+	var f Flow
+	for _, dst := range []*string{&f.State, &f.Nonce} {
+		var b [32]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return Flow{}, err
+		}
+		*dst = base64.RawURLEncoding.EncodeToString(b[:])
+	}
+	f.Verifier = oauth2.GenerateVerifier()
+	return f, nil
+}
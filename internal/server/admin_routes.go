@@ -2,13 +2,26 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	ck "github.com/ChiragChiranjib/mcp-proxy/internal/contextkey"
 	"github.com/gorilla/mux"
 
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/idgen"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/repo"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/apikey"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/approval"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/catalog"
 	orchestrator "github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/mcphub_orchestrator"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/ratelimit"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/virtualmcp"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/transform"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/metrics"
 	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
 )
 
@@ -16,7 +29,14 @@ func addAdminRoutes(r *mux.Router, deps Deps, cfg Config) {
 	addCatalogRoutes(r, deps, cfg)
 	addToolsRoutes(r, deps, cfg)
 	addVirtualServerRoutes(r, deps, cfg)
+	addVirtualServerKeyRoutes(r, deps, cfg)
+	addLimitRoutes(r, deps, cfg)
+	addResourceRoutes(r, deps, cfg)
+	addPromptRoutes(r, deps, cfg)
+	addAuditRoutes(r, deps, cfg)
+	addApprovalRoutes(r, deps, cfg)
 	addHubRoutes(r, deps, cfg)
+	addOrgRoutes(r, deps, cfg)
 }
 
 // Catalog routes
@@ -39,6 +59,12 @@ func addCatalogRoutes(r *mux.Router, deps Deps, cfg Config) {
 				)
 				return
 			}
+			if deps.Pool != nil {
+				for i := range items {
+					b := deps.Pool.Breaker(items[i].ID)
+					items[i].Breaker = &b
+				}
+			}
 			deps.Logger.Info("LIST_CATALOG_SERVERS_SUCCESS", "count", len(items))
 			WriteJSON(w, http.StatusOK, map[string]any{"items": items})
 		},
@@ -57,21 +83,25 @@ func addCatalogRoutes(r *mux.Router, deps Deps, cfg Config) {
 			deps.Logger.Info("CREATE_CATALOG_SERVER_INIT")
 
 			var body struct {
-				Name        string       `json:"name"`
-				URL         string       `json:"url"`
-				Description string       `json:"description"`
-				AccessType  m.AccessType `json:"access_type"`
-				Transport   string       `json:"transport"`
+				Name        string            `json:"name"`
+				URL         string            `json:"url"`
+				Description string            `json:"description"`
+				AccessType  m.AccessType      `json:"access_type"`
+				Transport   m.Transport       `json:"transport"`
+				Command     string            `json:"command"`
+				Args        []string          `json:"args"`
+				Env         map[string]string `json:"env"`
+				// Header calls carry the caller's identity token in
+				IdentityHeader string `json:"identity_header"`
 			}
 			if !ReadJSON(w, r, &body) {
 				deps.Logger.Error("CREATE_CATALOG_SERVER_READ_BODY_ERROR")
 				return
 			}
-
-			if body.Name == "" || body.URL == "" {
-				deps.Logger.Error("CREATE_CATALOG_SERVER_MISSING_FIELDS")
+			if err := catalog.ValidateIdentityHeader(
+				body.IdentityHeader); err != nil {
 				WriteJSON(w, http.StatusBadRequest,
-					map[string]string{"error": "missing fields"})
+					map[string]string{"error": err.Error()})
 				return
 			}
 
@@ -80,7 +110,34 @@ func addCatalogRoutes(r *mux.Router, deps Deps, cfg Config) {
 				body.AccessType = m.AccessTypePublic
 			}
 			if body.Transport == "" {
-				body.Transport = "streamable-http"
+				body.Transport = m.TransportStreamableHTTP
+			}
+
+			switch body.Transport {
+			case m.TransportStreamableHTTP, m.TransportSSE:
+				if body.Name == "" || body.URL == "" {
+					deps.Logger.Error("CREATE_CATALOG_SERVER_MISSING_FIELDS")
+					WriteJSON(w, http.StatusBadRequest,
+						map[string]string{"error": "missing fields"})
+					return
+				}
+			case m.TransportStdio:
+				if body.Name == "" || body.Command == "" {
+					deps.Logger.Error("CREATE_CATALOG_SERVER_MISSING_FIELDS")
+					WriteJSON(w, http.StatusBadRequest,
+						map[string]string{"error": "missing fields"})
+					return
+				}
+				// URL is unique per catalog entry; stdio servers have none.
+				if body.URL == "" {
+					body.URL = "stdio://" + body.Name
+				}
+			default:
+				deps.Logger.Error("CREATE_CATALOG_SERVER_INVALID_TRANSPORT",
+					"transport", body.Transport)
+				WriteJSON(w, http.StatusBadRequest,
+					map[string]string{"error": "invalid transport"})
+				return
 			}
 
 			rec := m.MCPServer{
@@ -90,6 +147,13 @@ func addCatalogRoutes(r *mux.Router, deps Deps, cfg Config) {
 				Description: body.Description,
 				AccessType:  body.AccessType,
 				Transport:   body.Transport,
+
+				IdentityHeader: body.IdentityHeader,
+			}
+			if body.Transport == m.TransportStdio {
+				rec.Command = body.Command
+				rec.Args, _ = json.Marshal(body.Args)
+				rec.Env, _ = json.Marshal(body.Env)
 			}
 
 			// Use catalog orchestrator to handle auto-fetching for public servers
@@ -121,30 +185,94 @@ func addCatalogRoutes(r *mux.Router, deps Deps, cfg Config) {
 			var body struct {
 				URL         *string `json:"url"`
 				Description *string `json:"description"`
+				// Call policy; omitted fields are left unchanged
+				CallTimeoutSeconds     *int `json:"call_timeout_seconds"`
+				ConnectTimeoutSeconds  *int `json:"connect_timeout_seconds"`
+				MaxRetries             *int `json:"max_retries"`
+				RetryBackoffMS         *int `json:"retry_backoff_ms"`
+				BreakerThreshold       *int `json:"breaker_threshold"`
+				BreakerCooldownSeconds *int `json:"breaker_cooldown_seconds"`
+				// Empty stops sending the caller's identity token
+				IdentityHeader *string `json:"identity_header"`
 			}
 			if !ReadJSON(w, r, &body) {
 				deps.Logger.Error("UPDATE_CATALOG_SERVER_READ_BODY_ERROR")
 				return
 			}
-			if (body.URL == nil || *body.URL == "") &&
-				(body.Description == nil || *body.Description == "") {
+			setPolicy := body.CallTimeoutSeconds != nil ||
+				body.ConnectTimeoutSeconds != nil || body.MaxRetries != nil ||
+				body.RetryBackoffMS != nil || body.BreakerThreshold != nil ||
+				body.BreakerCooldownSeconds != nil
+			setURLDesc := (body.URL != nil && *body.URL != "") ||
+				(body.Description != nil && *body.Description != "")
+			if !setURLDesc && !setPolicy && body.IdentityHeader == nil {
 				WriteJSON(w, http.StatusBadRequest,
 					map[string]string{"error": "no fields to update"})
 				return
 			}
-			url := ""
-			desc := ""
-			if body.URL != nil {
-				url = *body.URL
+			if setPolicy {
+				srv, err := deps.Catalog.GetByID(r.Context(), id)
+				if err != nil {
+					deps.Logger.Error("UPDATE_CATALOG_SERVER_NOT_FOUND", "error", err)
+					WriteJSON(w, http.StatusNotFound,
+						map[string]string{"error": "not found"})
+					return
+				}
+				policy := srv.CallPolicy
+				set := func(dst *int, v *int) {
+					if v != nil {
+						*dst = *v
+					}
+				}
+				set(&policy.CallTimeoutSeconds, body.CallTimeoutSeconds)
+				set(&policy.ConnectTimeoutSeconds, body.ConnectTimeoutSeconds)
+				set(&policy.MaxRetries, body.MaxRetries)
+				set(&policy.RetryBackoffMS, body.RetryBackoffMS)
+				set(&policy.BreakerThreshold, body.BreakerThreshold)
+				set(&policy.BreakerCooldownSeconds, body.BreakerCooldownSeconds)
+				err = deps.Catalog.SetCallPolicy(r.Context(), id, policy)
+				if errors.Is(err, catalog.ErrInvalidCallPolicy) {
+					WriteJSON(w, http.StatusBadRequest,
+						map[string]string{"error": err.Error()})
+					return
+				}
+				if err != nil {
+					deps.Logger.Error("UPDATE_CATALOG_SERVER_DB_ERROR", "error", err)
+					WriteJSON(w, http.StatusInternalServerError,
+						map[string]string{"error": err.Error()})
+					return
+				}
 			}
-			if body.Description != nil {
-				desc = *body.Description
+			if body.IdentityHeader != nil {
+				err := deps.Catalog.SetIdentityHeader(r.Context(), id,
+					*body.IdentityHeader)
+				if errors.Is(err, catalog.ErrInvalidIdentityHeader) {
+					WriteJSON(w, http.StatusBadRequest,
+						map[string]string{"error": err.Error()})
+					return
+				}
+				if err != nil {
+					deps.Logger.Error("UPDATE_CATALOG_SERVER_DB_ERROR", "error", err)
+					WriteJSON(w, http.StatusInternalServerError,
+						map[string]string{"error": err.Error()})
+					return
+				}
 			}
-			if err := deps.Catalog.Update(r.Context(), id, url, desc); err != nil {
-				deps.Logger.Error("UPDATE_CATALOG_SERVER_DB_ERROR", "error", err)
-				WriteJSON(w, http.StatusInternalServerError,
-					map[string]string{"error": err.Error()})
-				return
+			if setURLDesc {
+				url := ""
+				desc := ""
+				if body.URL != nil {
+					url = *body.URL
+				}
+				if body.Description != nil {
+					desc = *body.Description
+				}
+				if err := deps.Catalog.Update(r.Context(), id, url, desc); err != nil {
+					deps.Logger.Error("UPDATE_CATALOG_SERVER_DB_ERROR", "error", err)
+					WriteJSON(w, http.StatusInternalServerError,
+						map[string]string{"error": err.Error()})
+					return
+				}
 			}
 
 			deps.Logger.Info("UPDATE_CATALOG_SERVER_SUCCESS", "id", id)
@@ -166,7 +294,10 @@ func addCatalogRoutes(r *mux.Router, deps Deps, cfg Config) {
 			deps.Logger.Info("REFRESH_CATALOG_SERVER_INIT", "id", id)
 
 			// Use catalog orchestrator to refresh tools for public servers
+			started := time.Now()
 			added, deleted, err := deps.CatalogOrchestrator.RefreshCatalogServer(r.Context(), id)
+			deps.Metrics.ObserveRefresh(metrics.RefreshCatalog,
+				len(added), len(deleted), err, time.Since(started))
 			if err != nil {
 				deps.Logger.Error("REFRESH_CATALOG_SERVER_ERROR", "error", err)
 				WriteJSON(w, http.StatusInternalServerError,
@@ -235,8 +366,14 @@ func addToolsRoutes(r *mux.Router, deps Deps, cfg Config) {
 			hubServerID := r.URL.Query().Get("hub_server_id") // For filtering by hub
 			status := r.URL.Query().Get("status")
 			q := r.URL.Query().Get("q")
+			// A team's tools with team_id, else the caller's
+			owner, ok := ownerFromQuery(w, r, deps, m.MemberRoleMember)
+			if !ok {
+				return
+			}
 			deps.Logger.Info("LIST_TOOLS_INIT",
 				"user_id", userID,
+				"team_id", owner.TeamID,
 				"server_id", serverID,
 				"hub_server_id", hubServerID,
 				"status", status,
@@ -244,8 +381,8 @@ func addToolsRoutes(r *mux.Router, deps Deps, cfg Config) {
 			)
 
 			if deps.Tools != nil {
-				items, err := deps.Tools.ListForUserFiltered(
-					r.Context(), userID, serverID, hubServerID, status, q,
+				items, err := deps.Tools.ListForOwnerFiltered(
+					r.Context(), owner, serverID, hubServerID, status, q,
 				)
 				if err != nil {
 					deps.Logger.Error("LIST_TOOLS_ERROR", "error", err)
@@ -286,6 +423,9 @@ func addToolsRoutes(r *mux.Router, deps Deps, cfg Config) {
 				return
 			}
 			id := mux.Vars(r)["id"]
+			if !canManageTool(w, r, deps, id) {
+				return
+			}
 			deps.Logger.Info("UPDATE_TOOL_STATUS_INIT",
 				"id", id, "status", body.Status)
 			if err := deps.Tools.SetStatus(
@@ -309,6 +449,9 @@ func addToolsRoutes(r *mux.Router, deps Deps, cfg Config) {
 		cfg.AdminPrefix+"/tools/{id}",
 		func(w http.ResponseWriter, r *http.Request) {
 			id := mux.Vars(r)["id"]
+			if !canManageTool(w, r, deps, id) {
+				return
+			}
 			deps.Logger.Info("DELETE_TOOL_INIT", "id", id)
 			if err := deps.Tools.SetStatus(
 				r.Context(), id, string(m.StatusDeactivated),
@@ -336,30 +479,41 @@ func addVirtualServerRoutes(r *mux.Router, deps Deps, cfg Config) {
 		func(w http.ResponseWriter, r *http.Request) {
 			userID := ck.GetUserIDFromContext(r.Context())
 			var body struct {
-				Name    string   `json:"name"`
-				ToolIDs []string `json:"tool_ids"`
+				Name        string        `json:"name"`
+				TeamID      string        `json:"team_id"`
+				ToolNaming  m.ToolNaming  `json:"tool_naming"`
+				SessionMode m.SessionMode `json:"session_mode"`
+				ToolIDs     []string      `json:"tool_ids"`
 			}
 			if !ReadJSON(w, r, &body) {
 				deps.Logger.Error("CREATE_VS_READ_BODY_ERROR")
 				return
 			}
+			if body.TeamID != "" && !requireTeamRole(
+				w, r, deps, body.TeamID, m.MemberRoleMaintainer) {
+				return
+			}
 			deps.Logger.Info("CREATE_VIRTUAL_SERVER_INIT",
 				"user_id", userID,
+				"team_id", body.TeamID,
 				"tool_ids_len", len(body.ToolIDs))
 			var (
 				id  string
 				err error
 			)
 			if len(body.ToolIDs) > 0 {
-				id, err = deps.Virtual.CreateWithTools(r.Context(), userID, body.Name, body.ToolIDs)
+				id, err = deps.Virtual.CreateWithTools(
+					r.Context(), userID, body.TeamID, body.Name, body.ToolNaming,
+					body.SessionMode, body.ToolIDs)
 			} else {
-				id, err = deps.Virtual.Create(r.Context(), userID, body.Name)
+				id, err = deps.Virtual.Create(r.Context(), userID, body.TeamID,
+					body.Name, body.ToolNaming, body.SessionMode)
 			}
 			if err != nil {
 				deps.Logger.Error("CREATE_VIRTUAL_SERVER_DB_ERROR", "error", err)
 				WriteJSON(
 					w,
-					http.StatusInternalServerError,
+					virtualServerErrorStatus(err),
 					map[string]string{"error": err.Error()},
 				)
 				return
@@ -369,13 +523,19 @@ func addVirtualServerRoutes(r *mux.Router, deps Deps, cfg Config) {
 		},
 	).Methods(http.MethodPost)
 
-	// List for user
+	// List the user's virtual servers and those of their teams, or one
+	// team's with team_id
 	r.HandleFunc(
 		cfg.AdminPrefix+"/virtual-servers",
 		func(w http.ResponseWriter, r *http.Request) {
 			userID := ck.GetUserIDFromContext(r.Context())
-			deps.Logger.Info("LIST_VIRTUAL_SERVERS_INIT", "user_id", userID)
-			items, err := deps.Virtual.ListForUser(r.Context(), userID)
+			owner, ok := ownerFromQuery(w, r, deps, m.MemberRoleMember)
+			if !ok {
+				return
+			}
+			deps.Logger.Info("LIST_VIRTUAL_SERVERS_INIT",
+				"user_id", userID, "team_id", owner.TeamID)
+			items, err := listVirtualServers(r, deps, owner)
 			if err != nil {
 				deps.Logger.Error("LIST_VIRTUAL_SERVERS_ERROR", "error", err)
 				WriteJSON(
@@ -395,6 +555,9 @@ func addVirtualServerRoutes(r *mux.Router, deps Deps, cfg Config) {
 		cfg.AdminPrefix+"/virtual-servers/{id}/tools",
 		func(w http.ResponseWriter, r *http.Request) {
 			id := mux.Vars(r)["id"]
+			if !canManageVirtualServer(w, r, deps, id) {
+				return
+			}
 			var body struct {
 				ToolIDs []string `json:"tool_ids"`
 			}
@@ -410,7 +573,7 @@ func addVirtualServerRoutes(r *mux.Router, deps Deps, cfg Config) {
 				deps.Logger.Error("REPLACE_VS_TOOLS_DB_ERROR", "error", err)
 				WriteJSON(
 					w,
-					http.StatusInternalServerError,
+					virtualServerErrorStatus(err),
 					map[string]string{"error": err.Error()},
 				)
 				return
@@ -430,6 +593,9 @@ func addVirtualServerRoutes(r *mux.Router, deps Deps, cfg Config) {
 				WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "missing ids"})
 				return
 			}
+			if !canManageVirtualServer(w, r, deps, vsID) {
+				return
+			}
 			if err := deps.Virtual.RemoveTool(r.Context(), vsID, toolID); err != nil {
 				WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 				return
@@ -438,12 +604,127 @@ func addVirtualServerRoutes(r *mux.Router, deps Deps, cfg Config) {
 		},
 	).Methods(http.MethodDelete)
 
+	// Set or clear the alias a tool is exposed under, and whether its calls
+	// are held for approval. Fields left out are unchanged.
+	r.HandleFunc(
+		cfg.AdminPrefix+"/virtual-servers/{id}/tools/{tool_id}",
+		func(w http.ResponseWriter, r *http.Request) {
+			vsID := mux.Vars(r)["id"]
+			toolID := mux.Vars(r)["tool_id"]
+			if !canManageVirtualServer(w, r, deps, vsID) {
+				return
+			}
+			var body struct {
+				Alias           json.RawMessage `json:"alias"`
+				RequireApproval *bool           `json:"require_approval"`
+			}
+			if !ReadJSON(w, r, &body) {
+				deps.Logger.Error("UPDATE_VS_TOOL_READ_BODY_ERROR")
+				return
+			}
+			if len(body.Alias) == 0 && body.RequireApproval == nil {
+				WriteJSON(w, http.StatusBadRequest,
+					map[string]string{"error": "nothing to update"})
+				return
+			}
+			deps.Logger.Info("UPDATE_VS_TOOL_INIT",
+				"id", vsID, "tool_id", toolID)
+			if len(body.Alias) > 0 {
+				var alias *string
+				if err := json.Unmarshal(body.Alias, &alias); err != nil {
+					WriteJSON(w, http.StatusBadRequest,
+						map[string]string{"error": "invalid alias"})
+					return
+				}
+				if err := deps.Virtual.SetToolAlias(
+					r.Context(), vsID, toolID, alias,
+				); err != nil {
+					deps.Logger.Error("UPDATE_VS_TOOL_ALIAS_ERROR", "error", err)
+					WriteJSON(w, virtualServerErrorStatus(err),
+						map[string]string{"error": err.Error()})
+					return
+				}
+			}
+			if body.RequireApproval != nil {
+				if err := deps.Virtual.SetToolRequireApproval(
+					r.Context(), vsID, toolID, *body.RequireApproval,
+				); err != nil {
+					deps.Logger.Error("UPDATE_VS_TOOL_APPROVAL_ERROR", "error", err)
+					WriteJSON(w, virtualServerErrorStatus(err),
+						map[string]string{"error": err.Error()})
+					return
+				}
+			}
+			deps.Logger.Info("UPDATE_VS_TOOL_SUCCESS",
+				"id", vsID, "tool_id", toolID)
+			WriteJSON(w, http.StatusOK, map[string]string{"ok": "true"})
+		},
+	).Methods(http.MethodPatch)
+
+	// Set transform rules for a tool in a virtual server
+	r.HandleFunc(
+		cfg.AdminPrefix+"/virtual-servers/{id}/tools/{tool_id}/transform",
+		func(w http.ResponseWriter, r *http.Request) {
+			vsID := mux.Vars(r)["id"]
+			toolID := mux.Vars(r)["tool_id"]
+			if !canManageVirtualServer(w, r, deps, vsID) {
+				return
+			}
+			var body transform.Rules
+			if !ReadJSON(w, r, &body) {
+				deps.Logger.Error("UPDATE_VS_TOOL_TRANSFORM_READ_BODY_ERROR")
+				return
+			}
+			deps.Logger.Info("UPDATE_VS_TOOL_TRANSFORM_INIT",
+				"id", vsID, "tool_id", toolID)
+			if err := deps.Virtual.SetToolTransform(
+				r.Context(), vsID, toolID, &body,
+			); err != nil {
+				deps.Logger.Error("UPDATE_VS_TOOL_TRANSFORM_ERROR", "error", err)
+				WriteJSON(w, virtualServerErrorStatus(err),
+					map[string]string{"error": err.Error()})
+				return
+			}
+			deps.Logger.Info("UPDATE_VS_TOOL_TRANSFORM_SUCCESS",
+				"id", vsID, "tool_id", toolID)
+			WriteJSON(w, http.StatusOK, map[string]string{"ok": "true"})
+		},
+	).Methods(http.MethodPut)
+
+	// Clear transform rules for a tool in a virtual server
+	r.HandleFunc(
+		cfg.AdminPrefix+"/virtual-servers/{id}/tools/{tool_id}/transform",
+		func(w http.ResponseWriter, r *http.Request) {
+			vsID := mux.Vars(r)["id"]
+			toolID := mux.Vars(r)["tool_id"]
+			if !canManageVirtualServer(w, r, deps, vsID) {
+				return
+			}
+			deps.Logger.Info("DELETE_VS_TOOL_TRANSFORM_INIT",
+				"id", vsID, "tool_id", toolID)
+			if err := deps.Virtual.SetToolTransform(
+				r.Context(), vsID, toolID, nil,
+			); err != nil {
+				deps.Logger.Error("DELETE_VS_TOOL_TRANSFORM_ERROR", "error", err)
+				WriteJSON(w, virtualServerErrorStatus(err),
+					map[string]string{"error": err.Error()})
+				return
+			}
+			deps.Logger.Info("DELETE_VS_TOOL_TRANSFORM_SUCCESS",
+				"id", vsID, "tool_id", toolID)
+			WriteJSON(w, http.StatusOK, map[string]string{"ok": "true"})
+		},
+	).Methods(http.MethodDelete)
+
 	// List tools for a virtual server
 	r.HandleFunc(
 		cfg.AdminPrefix+"/virtual-servers/{id}/tools",
 		func(w http.ResponseWriter, r *http.Request) {
 			vsID := mux.Vars(r)["id"]
 			deps.Logger.Info("LIST_VS_TOOLS_INIT", "id", vsID)
+			if !canAccessVirtualServer(w, r, deps, vsID, m.MemberRoleMember) {
+				return
+			}
 			items, err := deps.Tools.ListForVirtualServer(
 				r.Context(), vsID,
 			)
@@ -466,6 +747,9 @@ func addVirtualServerRoutes(r *mux.Router, deps Deps, cfg Config) {
 		cfg.AdminPrefix+"/virtual-servers/{id}/status",
 		func(w http.ResponseWriter, r *http.Request) {
 			id := mux.Vars(r)["id"]
+			if !canManageVirtualServer(w, r, deps, id) {
+				return
+			}
 			var body struct {
 				Status string `json:"status"`
 			}
@@ -501,13 +785,26 @@ func addVirtualServerRoutes(r *mux.Router, deps Deps, cfg Config) {
 		},
 	).Methods(http.MethodPatch)
 
-	// Update virtual server properties (name)
+	// Update virtual server properties (name, tool naming policy, session
+	// mode, sampling and elicitation policy, argument validation, approval
+	// of destructive tools, access mode); all of them or none are applied
 	r.HandleFunc(
 		cfg.AdminPrefix+"/virtual-servers/{id}",
 		func(w http.ResponseWriter, r *http.Request) {
 			id := mux.Vars(r)["id"]
+			if !canManageVirtualServer(w, r, deps, id) {
+				return
+			}
 			var body struct {
-				Name *string `json:"name"`
+				Name               *string          `json:"name"`
+				ToolNaming         *m.ToolNaming    `json:"tool_naming"`
+				SessionMode        *m.SessionMode   `json:"session_mode"`
+				AllowSampling      *bool            `json:"allow_sampling"`
+				AllowElicitation   *bool            `json:"allow_elicitation"`
+				ArgValidation      *m.ArgValidation `json:"arg_validation"`
+				ApproveDestructive *bool            `json:"approve_destructive"`
+				Mode               *m.AccessMode    `json:"mode"`
+				AnnotationFilter   json.RawMessage  `json:"annotation_filter"`
 			}
 			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 				WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid JSON"})
@@ -520,13 +817,54 @@ func addVirtualServerRoutes(r *mux.Router, deps Deps, cfg Config) {
 				return
 			}
 
-			if err := deps.Virtual.UpdateName(r.Context(), id, body.Name); err != nil {
+			if body.Mode == nil && len(body.AnnotationFilter) > 0 {
+				WriteJSON(w, http.StatusBadRequest,
+					map[string]string{"error": "annotation_filter requires mode"})
+				return
+			}
+
+			ctx := r.Context()
+			err := deps.Virtual.Transaction(func(tx *virtualmcp.Service) error {
+				if err := tx.UpdateName(ctx, id, body.Name); err != nil {
+					return err
+				}
+				if body.ToolNaming != nil {
+					if err := tx.SetToolNaming(
+						ctx, id, *body.ToolNaming); err != nil {
+						return err
+					}
+				}
+				if body.SessionMode != nil {
+					if err := tx.SetSessionMode(
+						ctx, id, *body.SessionMode); err != nil {
+						return err
+					}
+				}
+				if body.ArgValidation != nil {
+					if err := tx.SetArgValidation(
+						ctx, id, *body.ArgValidation); err != nil {
+						return err
+					}
+				}
+				if body.Mode != nil {
+					if err := tx.SetMode(
+						ctx, id, *body.Mode, body.AnnotationFilter); err != nil {
+						return err
+					}
+				}
+				if body.ApproveDestructive != nil {
+					if err := tx.SetApproveDestructive(
+						ctx, id, *body.ApproveDestructive); err != nil {
+						return err
+					}
+				}
+				return tx.SetClientRequests(
+					ctx, id, body.AllowSampling, body.AllowElicitation)
+			})
+			if err != nil {
 				deps.Logger.Error("UPDATE_VS_ERROR", "error", err)
-				WriteJSON(
-					w,
-					http.StatusInternalServerError,
-					map[string]string{"error": err.Error()},
-				)
+				WriteJSON(w, virtualServerErrorStatus(err),
+					map[string]string{"error": err.Error()})
 				return
 			}
 
@@ -541,6 +879,9 @@ func addVirtualServerRoutes(r *mux.Router, deps Deps, cfg Config) {
 		func(w http.ResponseWriter, r *http.Request) {
 			id := mux.Vars(r)["id"]
 			deps.Logger.Info("DELETE_VS_INIT", "id", id)
+			if !canManageVirtualServer(w, r, deps, id) {
+				return
+			}
 			if err := deps.Virtual.Delete(r.Context(), id); err != nil {
 				deps.Logger.Error("DELETE_VS_ERROR", "error", err)
 				WriteJSON(
@@ -557,19 +898,114 @@ func addVirtualServerRoutes(r *mux.Router, deps Deps, cfg Config) {
 	).Methods(http.MethodDelete)
 }
 
-// Hub routes
-func addHubRoutes(r *mux.Router, deps Deps, cfg Config) {
-	orch := deps.McphubOrchestrator
-	// List user's hubs
+// virtualServerErrorStatus maps virtual server service errors to HTTP
+// status codes.
+func virtualServerErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, virtualmcp.ErrToolNameConflict),
+		errors.Is(err, virtualmcp.ErrToolNotAllowed),
+		errors.Is(err, virtualmcp.ErrResourceURIConflict),
+		errors.Is(err, virtualmcp.ErrPromptNameConflict),
+		errors.Is(err, virtualmcp.ErrClientRequestsUnavailable):
+		return http.StatusConflict
+	case errors.Is(err, virtualmcp.ErrInvalidToolNaming),
+		errors.Is(err, virtualmcp.ErrInvalidSessionMode),
+		errors.Is(err, virtualmcp.ErrInvalidArgValidation),
+		errors.Is(err, virtualmcp.ErrInvalidMode),
+		errors.Is(err, virtualmcp.ErrInvalidAlias),
+		errors.Is(err, transform.ErrInvalidRules):
+		return http.StatusBadRequest
+	case errors.Is(err, virtualmcp.ErrToolNotInVirtualServer),
+		errors.Is(err, virtualmcp.ErrToolNotFound),
+		errors.Is(err, virtualmcp.ErrResourceNotFound),
+		errors.Is(err, virtualmcp.ErrPromptNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// apiKeyErrorStatus maps API key service errors to HTTP status codes.
+func apiKeyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, apikey.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, apikey.ErrNotActive):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// canManageVirtualServer reports whether the caller owns the virtual server,
+// maintains the team owning it or is an admin. It writes the error response
+// when it returns false.
+func canManageVirtualServer(
+	w http.ResponseWriter, r *http.Request, deps Deps, vsID string,
+) bool {
+	return canAccessVirtualServer(w, r, deps, vsID, m.MemberRoleMaintainer)
+}
+
+// canAccessVirtualServer reports whether the caller owns the virtual
+// server, has at least min in the team owning it or is an admin. It writes
+// the error response when it returns false.
+func canAccessVirtualServer(
+	w http.ResponseWriter, r *http.Request, deps Deps, vsID string,
+	min m.MemberRole,
+) bool {
+	vs, err := deps.Virtual.GetByID(r.Context(), vsID)
+	if err != nil {
+		WriteJSON(w, http.StatusNotFound,
+			map[string]string{"error": "virtual server not found"})
+		return false
+	}
+	ok, err := callerCanAccess(r, deps, vs.UserID, vs.TeamID, min)
+	if err != nil {
+		WriteJSON(w, http.StatusInternalServerError,
+			map[string]string{"error": err.Error()})
+		return false
+	}
+	if !ok {
+		WriteJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+		return false
+	}
+	return true
+}
+
+// listVirtualServers lists the virtual servers of a team, or a user's own
+// and those of the teams they are a member of.
+func listVirtualServers(
+	r *http.Request, deps Deps, owner m.Owner,
+) ([]m.MCPVirtualServer, error) {
+	if owner.TeamID != "" {
+		return deps.Virtual.ListForTeams(r.Context(), []string{owner.TeamID})
+	}
+	items, err := deps.Virtual.ListForUser(r.Context(), owner.UserID)
+	if err != nil {
+		return nil, err
+	}
+	teamIDs, err := callerTeamIDs(r, deps, m.MemberRoleMember)
+	if err != nil {
+		return nil, err
+	}
+	team, err := deps.Virtual.ListForTeams(r.Context(), teamIDs)
+	return append(items, team...), err
+}
+
+// Virtual server API key routes
+func addVirtualServerKeyRoutes(r *mux.Router, deps Deps, cfg Config) {
+	// List keys (hashes are never returned)
 	r.HandleFunc(
-		cfg.AdminPrefix+"/hub/servers",
+		cfg.AdminPrefix+"/virtual-servers/{id}/keys",
 		func(w http.ResponseWriter, r *http.Request) {
-			deps.Logger.Info("LIST_HUB_SERVERS_INIT",
-				"user_id", ck.GetUserIDFromContext(r.Context()))
-			userID := ck.GetUserIDFromContext(r.Context())
-			items, err := deps.Hubs.ListForUser(r.Context(), userID)
+			vsID := mux.Vars(r)["id"]
+			deps.Logger.Info("LIST_VS_KEYS_INIT", "id", vsID)
+			if !canManageVirtualServer(w, r, deps, vsID) {
+				return
+			}
+			items, err := deps.Keys.List(r.Context(), vsID)
 			if err != nil {
-				deps.Logger.Error("LIST_HUB_SERVERS_ERROR", "error", err)
+				deps.Logger.Error("LIST_VS_KEYS_ERROR", "error", err)
 				WriteJSON(
 					w,
 					http.StatusInternalServerError,
@@ -577,31 +1013,31 @@ func addHubRoutes(r *mux.Router, deps Deps, cfg Config) {
 				)
 				return
 			}
-
-			deps.Logger.Info("LIST_HUB_SERVERS_SUCCESS", "count", len(items))
+			deps.Logger.Info("LIST_VS_KEYS_SUCCESS", "count", len(items))
 			WriteJSON(w, http.StatusOK, map[string]any{"items": items})
 		},
 	).Methods(http.MethodGet)
 
-	// Add hub server via orchestrator
+	// Create a key; the plaintext key is only returned in this response
 	r.HandleFunc(
-		cfg.AdminPrefix+"/hub/servers",
+		cfg.AdminPrefix+"/virtual-servers/{id}/keys",
 		func(w http.ResponseWriter, r *http.Request) {
-			deps.Logger.Info("CREATE_HUB_SERVER_INIT")
-			var body orchestrator.CreateMCPHubServer
+			vsID := mux.Vars(r)["id"]
+			var body struct {
+				Name string `json:"name"`
+			}
 			if !ReadJSON(w, r, &body) {
-				deps.Logger.Error("CREATE_HUB_SERVER_READ_BODY_ERROR")
+				deps.Logger.Error("CREATE_VS_KEY_READ_BODY_ERROR")
 				return
 			}
-
-			deps.Logger.Info("CREATE_HUB_SERVER_REQ", "req", body)
-
-			// Always trust server-side authenticated user
-			body.UserID = ck.GetUserIDFromContext(r.Context())
-
-			id, err := orch.AddHub(r.Context(), body)
+			deps.Logger.Info("CREATE_VS_KEY_INIT", "id", vsID)
+			if !canManageVirtualServer(w, r, deps, vsID) {
+				return
+			}
+			rec, plain, err := deps.Keys.Create(r.Context(), vsID,
+				ck.GetUserIDFromContext(r.Context()), body.Name)
 			if err != nil {
-				deps.Logger.Error("CREATE_HUB_SERVER_ERROR", "error", err)
+				deps.Logger.Error("CREATE_VS_KEY_ERROR", "error", err)
 				WriteJSON(
 					w,
 					http.StatusInternalServerError,
@@ -609,57 +1045,577 @@ func addHubRoutes(r *mux.Router, deps Deps, cfg Config) {
 				)
 				return
 			}
-
-			deps.Logger.Info("CREATE_HUB_SERVER_SUCCESS", "id", id)
-			WriteJSON(w, http.StatusCreated, map[string]any{"id": id, "ok": true})
+			deps.Logger.Info("CREATE_VS_KEY_SUCCESS", "key_id", rec.ID)
+			WriteJSON(w, http.StatusCreated,
+				map[string]any{"item": rec, "key": plain})
 		},
 	).Methods(http.MethodPost)
 
-	// Update status / Delete hub
+	// Revoke a key
 	r.HandleFunc(
-		cfg.AdminPrefix+"/hub/servers/{id}",
+		cfg.AdminPrefix+"/virtual-servers/{id}/keys/{key_id}",
 		func(w http.ResponseWriter, r *http.Request) {
-			id := mux.Vars(r)["id"]
-			switch r.Method {
-			case http.MethodDelete:
-				deps.Logger.Info("DELETE_HUB_SERVER_INIT", "id", id)
-				if err := deps.Hubs.Delete(r.Context(), id); err != nil {
-					deps.Logger.Error("DELETE_HUB_SERVER_ERROR", "error", err)
-					WriteJSON(
-						w,
-						http.StatusInternalServerError,
-						map[string]string{"error": err.Error()},
-					)
-					return
+			vsID := mux.Vars(r)["id"]
+			keyID := mux.Vars(r)["key_id"]
+			deps.Logger.Info("REVOKE_VS_KEY_INIT", "id", vsID, "key_id", keyID)
+			if !canManageVirtualServer(w, r, deps, vsID) {
+				return
+			}
+			if err := deps.Keys.Revoke(r.Context(), vsID, keyID); err != nil {
+				deps.Logger.Error("REVOKE_VS_KEY_ERROR", "error", err)
+				WriteJSON(
+					w,
+					apiKeyErrorStatus(err),
+					map[string]string{"error": err.Error()},
+				)
+				return
+			}
+			deps.Logger.Info("REVOKE_VS_KEY_SUCCESS", "key_id", keyID)
+			WriteJSON(w, http.StatusOK, map[string]string{"ok": "true"})
+		},
+	).Methods(http.MethodDelete)
+
+	// Rotate a key: revoke it and issue a replacement with the same name
+	r.HandleFunc(
+		cfg.AdminPrefix+"/virtual-servers/{id}/keys/{key_id}/rotate",
+		func(w http.ResponseWriter, r *http.Request) {
+			vsID := mux.Vars(r)["id"]
+			keyID := mux.Vars(r)["key_id"]
+			deps.Logger.Info("ROTATE_VS_KEY_INIT", "id", vsID, "key_id", keyID)
+			if !canManageVirtualServer(w, r, deps, vsID) {
+				return
+			}
+			rec, plain, err := deps.Keys.Rotate(r.Context(), vsID, keyID)
+			if err != nil {
+				deps.Logger.Error("ROTATE_VS_KEY_ERROR", "error", err)
+				WriteJSON(
+					w,
+					apiKeyErrorStatus(err),
+					map[string]string{"error": err.Error()},
+				)
+				return
+			}
+			deps.Logger.Info("ROTATE_VS_KEY_SUCCESS", "key_id", rec.ID)
+			WriteJSON(w, http.StatusCreated,
+				map[string]any{"item": rec, "key": plain})
+		},
+	).Methods(http.MethodPost)
+}
+
+// Rate limit routes
+func addLimitRoutes(r *mux.Router, deps Deps, cfg Config) {
+	if deps.Limits == nil {
+		return
+	}
+	// Limits of a virtual server, one of its API keys and an upstream tool.
+	// Tool limits protect the upstream for everyone, so only admins manage
+	// them.
+	scopes := []struct {
+		path  string
+		scope m.LimitScope
+		idVar string
+		can   func(w http.ResponseWriter, r *http.Request) bool
+	}{
+		{
+			path:  "/virtual-servers/{id}/limits",
+			scope: m.LimitScopeVirtualServer,
+			idVar: "id",
+			can: func(w http.ResponseWriter, r *http.Request) bool {
+				return canManageVirtualServer(w, r, deps, mux.Vars(r)["id"])
+			},
+		},
+		{
+			path:  "/virtual-servers/{id}/keys/{key_id}/limits",
+			scope: m.LimitScopeAPIKey,
+			idVar: "key_id",
+			can: func(w http.ResponseWriter, r *http.Request) bool {
+				vsID := mux.Vars(r)["id"]
+				if !canManageVirtualServer(w, r, deps, vsID) {
+					return false
+				}
+				if _, err := deps.Keys.Get(
+					r.Context(), vsID, mux.Vars(r)["key_id"]); err != nil {
+					WriteJSON(w, http.StatusNotFound,
+						map[string]string{"error": "api key not found"})
+					return false
+				}
+				return true
+			},
+		},
+		{
+			path:  "/tools/{id}/limits",
+			scope: m.LimitScopeTool,
+			idVar: "id",
+			can: func(w http.ResponseWriter, r *http.Request) bool {
+				if ck.GetUserRoleFromContext(r.Context()) != string(m.RoleAdmin) {
+					WriteJSON(w, http.StatusForbidden,
+						map[string]string{"error": "forbidden"})
+					return false
 				}
+				return true
+			},
+		},
+	}
 
-				deps.Logger.Info("DELETE_HUB_SERVER_SUCCESS", "id", id)
-				WriteJSON(w, http.StatusOK, map[string]string{"ok": "true"})
-			case http.MethodPatch:
-				type req struct {
-					Status string `json:"status"`
+	for _, sc := range scopes {
+		// Read the limit (null when none is set) and current usage
+		r.HandleFunc(
+			cfg.AdminPrefix+sc.path,
+			func(w http.ResponseWriter, r *http.Request) {
+				id := mux.Vars(r)[sc.idVar]
+				deps.Logger.Info("GET_LIMITS_INIT", "scope", sc.scope, "id", id)
+				if !sc.can(w, r) {
+					return
 				}
-				var b req
-				if !ReadJSON(w, r, &b) {
-					deps.Logger.Error("UPDATE_HUB_STATUS_READ_BODY_ERROR")
+				var limit *m.RateLimit
+				l, err := deps.Limits.Get(r.Context(), sc.scope, id)
+				switch {
+				case err == nil:
+					limit = &l
+				case !errors.Is(err, ratelimit.ErrNoLimit):
+					deps.Logger.Error("GET_LIMITS_ERROR", "error", err)
+					WriteJSON(w, http.StatusInternalServerError,
+						map[string]string{"error": err.Error()})
 					return
 				}
-				if b.Status == "" {
-					deps.Logger.Error("UPDATE_HUB_STATUS_MISSING_STATUS")
-					WriteJSON(
-						w,
-						http.StatusBadRequest,
-						map[string]string{"error": "missing status"},
-					)
+				usage, err := deps.Limits.Usage(r.Context(), sc.scope, id)
+				if err != nil {
+					deps.Logger.Error("GET_LIMITS_USAGE_ERROR", "error", err)
+					WriteJSON(w, http.StatusInternalServerError,
+						map[string]string{"error": err.Error()})
 					return
 				}
+				deps.Logger.Info("GET_LIMITS_SUCCESS", "scope", sc.scope, "id", id)
+				WriteJSON(w, http.StatusOK,
+					map[string]any{"limit": limit, "usage": usage})
+			},
+		).Methods(http.MethodGet)
 
-				deps.Logger.Info("UPDATE_HUB_STATUS_INIT",
-					"id", id, "status", b.Status)
-				if err := deps.Hubs.SetStatus(
-					r.Context(), id, b.Status,
-				); err != nil {
-					deps.Logger.Error("UPDATE_HUB_STATUS_DB_ERROR", "error", err)
+		// Set the limit; zero values are unlimited
+		r.HandleFunc(
+			cfg.AdminPrefix+sc.path,
+			func(w http.ResponseWriter, r *http.Request) {
+				id := mux.Vars(r)[sc.idVar]
+				var body struct {
+					RatePerMinute int   `json:"rate_per_minute"`
+					Burst         int   `json:"burst"`
+					DailyQuota    int64 `json:"daily_quota"`
+					MonthlyQuota  int64 `json:"monthly_quota"`
+				}
+				if !ReadJSON(w, r, &body) {
+					deps.Logger.Error("SET_LIMITS_READ_BODY_ERROR")
+					return
+				}
+				deps.Logger.Info("SET_LIMITS_INIT", "scope", sc.scope, "id", id)
+				if !sc.can(w, r) {
+					return
+				}
+				item, err := deps.Limits.Set(r.Context(), sc.scope, id,
+					m.RateLimit{
+						RatePerMinute: body.RatePerMinute,
+						Burst:         body.Burst,
+						DailyQuota:    body.DailyQuota,
+						MonthlyQuota:  body.MonthlyQuota,
+					})
+				if err != nil {
+					deps.Logger.Error("SET_LIMITS_ERROR", "error", err)
+					status := http.StatusInternalServerError
+					if errors.Is(err, ratelimit.ErrInvalidLimit) {
+						status = http.StatusBadRequest
+					}
+					WriteJSON(w, status, map[string]string{"error": err.Error()})
+					return
+				}
+				deps.Logger.Info("SET_LIMITS_SUCCESS", "scope", sc.scope, "id", id)
+				WriteJSON(w, http.StatusOK, map[string]any{"item": item})
+			},
+		).Methods(http.MethodPut)
+
+		// Remove the limit
+		r.HandleFunc(
+			cfg.AdminPrefix+sc.path,
+			func(w http.ResponseWriter, r *http.Request) {
+				id := mux.Vars(r)[sc.idVar]
+				deps.Logger.Info("DELETE_LIMITS_INIT", "scope", sc.scope, "id", id)
+				if !sc.can(w, r) {
+					return
+				}
+				if err := deps.Limits.Delete(r.Context(), sc.scope, id); err != nil {
+					deps.Logger.Error("DELETE_LIMITS_ERROR", "error", err)
+					WriteJSON(w, http.StatusInternalServerError,
+						map[string]string{"error": err.Error()})
+					return
+				}
+				deps.Logger.Info("DELETE_LIMITS_SUCCESS", "scope", sc.scope, "id", id)
+				WriteJSON(w, http.StatusOK, map[string]string{"ok": "true"})
+			},
+		).Methods(http.MethodDelete)
+	}
+}
+
+// Resource routes
+func addResourceRoutes(r *mux.Router, deps Deps, cfg Config) {
+	// List resources visible to the user
+	r.HandleFunc(
+		cfg.AdminPrefix+"/resources",
+		func(w http.ResponseWriter, r *http.Request) {
+			userID := ck.GetUserIDFromContext(r.Context())
+			serverID := r.URL.Query().Get("server_id")
+			hubServerID := r.URL.Query().Get("hub_server_id")
+			owner, ok := ownerFromQuery(w, r, deps, m.MemberRoleMember)
+			if !ok {
+				return
+			}
+			deps.Logger.Info("LIST_RESOURCES_INIT",
+				"user_id", userID,
+				"team_id", owner.TeamID,
+				"server_id", serverID,
+				"hub_server_id", hubServerID,
+			)
+			items, err := deps.Resources.ListForOwnerFiltered(
+				r.Context(), owner, serverID, hubServerID,
+			)
+			if err != nil {
+				deps.Logger.Error("LIST_RESOURCES_ERROR", "error", err)
+				WriteJSON(
+					w,
+					http.StatusInternalServerError,
+					map[string]string{"error": err.Error()},
+				)
+				return
+			}
+			deps.Logger.Info("LIST_RESOURCES_SUCCESS", "count", len(items))
+			WriteJSON(w, http.StatusOK, map[string]any{"items": items})
+		},
+	).Methods(http.MethodGet)
+
+	// List resources for a virtual server
+	r.HandleFunc(
+		cfg.AdminPrefix+"/virtual-servers/{id}/resources",
+		func(w http.ResponseWriter, r *http.Request) {
+			vsID := mux.Vars(r)["id"]
+			deps.Logger.Info("LIST_VS_RESOURCES_INIT", "id", vsID)
+			if !canAccessVirtualServer(w, r, deps, vsID, m.MemberRoleMember) {
+				return
+			}
+			items, err := deps.Resources.ListForVirtualServer(r.Context(), vsID)
+			if err != nil {
+				deps.Logger.Error("LIST_VS_RESOURCES_ERROR", "error", err)
+				WriteJSON(
+					w,
+					http.StatusInternalServerError,
+					map[string]string{"error": err.Error()},
+				)
+				return
+			}
+			deps.Logger.Info("LIST_VS_RESOURCES_SUCCESS", "count", len(items))
+			WriteJSON(w, http.StatusOK, map[string]any{"items": items})
+		},
+	).Methods(http.MethodGet)
+
+	// Replace resources of a virtual server
+	r.HandleFunc(
+		cfg.AdminPrefix+"/virtual-servers/{id}/resources",
+		func(w http.ResponseWriter, r *http.Request) {
+			vsID := mux.Vars(r)["id"]
+			var body struct {
+				IDs []string `json:"resource_ids"`
+			}
+			if !ReadJSON(w, r, &body) {
+				deps.Logger.Error("REPLACE_VS_RESOURCES_READ_BODY_ERROR")
+				return
+			}
+			deps.Logger.Info("REPLACE_VS_RESOURCES_INIT",
+				"id", vsID, "resource_ids_len", len(body.IDs))
+			if !canManageVirtualServer(w, r, deps, vsID) {
+				return
+			}
+			if err := deps.Virtual.ReplaceResources(
+				r.Context(), vsID, body.IDs,
+			); err != nil {
+				deps.Logger.Error("REPLACE_VS_RESOURCES_DB_ERROR", "error", err)
+				WriteJSON(
+					w,
+					virtualServerErrorStatus(err),
+					map[string]string{"error": err.Error()},
+				)
+				return
+			}
+			deps.Logger.Info("REPLACE_VS_RESOURCES_SUCCESS", "id", vsID)
+			WriteJSON(w, http.StatusOK, map[string]string{"ok": "true"})
+		},
+	).Methods(http.MethodPut)
+
+	// Remove one resource from a virtual server
+	r.HandleFunc(
+		cfg.AdminPrefix+"/virtual-servers/{id}/resources/{resource_id}",
+		func(w http.ResponseWriter, r *http.Request) {
+			vsID := mux.Vars(r)["id"]
+			itemID := mux.Vars(r)["resource_id"]
+			deps.Logger.Info("REMOVE_VS_RESOURCE_INIT",
+				"id", vsID, "resource_id", itemID)
+			if !canManageVirtualServer(w, r, deps, vsID) {
+				return
+			}
+			if err := deps.Virtual.RemoveResource(
+				r.Context(), vsID, itemID,
+			); err != nil {
+				deps.Logger.Error("REMOVE_VS_RESOURCE_ERROR", "error", err)
+				WriteJSON(
+					w,
+					http.StatusInternalServerError,
+					map[string]string{"error": err.Error()},
+				)
+				return
+			}
+			deps.Logger.Info("REMOVE_VS_RESOURCE_SUCCESS", "id", vsID)
+			WriteJSON(w, http.StatusOK, map[string]string{"ok": "true"})
+		},
+	).Methods(http.MethodDelete)
+}
+
+// Prompt routes
+func addPromptRoutes(r *mux.Router, deps Deps, cfg Config) {
+	// List prompts visible to the user
+	r.HandleFunc(
+		cfg.AdminPrefix+"/prompts",
+		func(w http.ResponseWriter, r *http.Request) {
+			userID := ck.GetUserIDFromContext(r.Context())
+			serverID := r.URL.Query().Get("server_id")
+			hubServerID := r.URL.Query().Get("hub_server_id")
+			owner, ok := ownerFromQuery(w, r, deps, m.MemberRoleMember)
+			if !ok {
+				return
+			}
+			deps.Logger.Info("LIST_PROMPTS_INIT",
+				"user_id", userID,
+				"team_id", owner.TeamID,
+				"server_id", serverID,
+				"hub_server_id", hubServerID,
+			)
+			items, err := deps.Prompts.ListForOwnerFiltered(
+				r.Context(), owner, serverID, hubServerID,
+			)
+			if err != nil {
+				deps.Logger.Error("LIST_PROMPTS_ERROR", "error", err)
+				WriteJSON(
+					w,
+					http.StatusInternalServerError,
+					map[string]string{"error": err.Error()},
+				)
+				return
+			}
+			deps.Logger.Info("LIST_PROMPTS_SUCCESS", "count", len(items))
+			WriteJSON(w, http.StatusOK, map[string]any{"items": items})
+		},
+	).Methods(http.MethodGet)
+
+	// List prompts for a virtual server
+	r.HandleFunc(
+		cfg.AdminPrefix+"/virtual-servers/{id}/prompts",
+		func(w http.ResponseWriter, r *http.Request) {
+			vsID := mux.Vars(r)["id"]
+			deps.Logger.Info("LIST_VS_PROMPTS_INIT", "id", vsID)
+			if !canAccessVirtualServer(w, r, deps, vsID, m.MemberRoleMember) {
+				return
+			}
+			items, err := deps.Prompts.ListForVirtualServer(r.Context(), vsID)
+			if err != nil {
+				deps.Logger.Error("LIST_VS_PROMPTS_ERROR", "error", err)
+				WriteJSON(
+					w,
+					http.StatusInternalServerError,
+					map[string]string{"error": err.Error()},
+				)
+				return
+			}
+			deps.Logger.Info("LIST_VS_PROMPTS_SUCCESS", "count", len(items))
+			WriteJSON(w, http.StatusOK, map[string]any{"items": items})
+		},
+	).Methods(http.MethodGet)
+
+	// Replace prompts of a virtual server
+	r.HandleFunc(
+		cfg.AdminPrefix+"/virtual-servers/{id}/prompts",
+		func(w http.ResponseWriter, r *http.Request) {
+			vsID := mux.Vars(r)["id"]
+			var body struct {
+				IDs []string `json:"prompt_ids"`
+			}
+			if !ReadJSON(w, r, &body) {
+				deps.Logger.Error("REPLACE_VS_PROMPTS_READ_BODY_ERROR")
+				return
+			}
+			deps.Logger.Info("REPLACE_VS_PROMPTS_INIT",
+				"id", vsID, "prompt_ids_len", len(body.IDs))
+			if !canManageVirtualServer(w, r, deps, vsID) {
+				return
+			}
+			if err := deps.Virtual.ReplacePrompts(
+				r.Context(), vsID, body.IDs,
+			); err != nil {
+				deps.Logger.Error("REPLACE_VS_PROMPTS_DB_ERROR", "error", err)
+				WriteJSON(
+					w,
+					virtualServerErrorStatus(err),
+					map[string]string{"error": err.Error()},
+				)
+				return
+			}
+			deps.Logger.Info("REPLACE_VS_PROMPTS_SUCCESS", "id", vsID)
+			WriteJSON(w, http.StatusOK, map[string]string{"ok": "true"})
+		},
+	).Methods(http.MethodPut)
+
+	// Remove one prompt from a virtual server
+	r.HandleFunc(
+		cfg.AdminPrefix+"/virtual-servers/{id}/prompts/{prompt_id}",
+		func(w http.ResponseWriter, r *http.Request) {
+			vsID := mux.Vars(r)["id"]
+			itemID := mux.Vars(r)["prompt_id"]
+			deps.Logger.Info("REMOVE_VS_PROMPT_INIT",
+				"id", vsID, "prompt_id", itemID)
+			if !canManageVirtualServer(w, r, deps, vsID) {
+				return
+			}
+			if err := deps.Virtual.RemovePrompt(
+				r.Context(), vsID, itemID,
+			); err != nil {
+				deps.Logger.Error("REMOVE_VS_PROMPT_ERROR", "error", err)
+				WriteJSON(
+					w,
+					http.StatusInternalServerError,
+					map[string]string{"error": err.Error()},
+				)
+				return
+			}
+			deps.Logger.Info("REMOVE_VS_PROMPT_SUCCESS", "id", vsID)
+			WriteJSON(w, http.StatusOK, map[string]string{"ok": "true"})
+		},
+	).Methods(http.MethodDelete)
+}
+
+// Hub routes
+func addHubRoutes(r *mux.Router, deps Deps, cfg Config) {
+	orch := deps.McphubOrchestrator
+	// List the user's hubs and those of their teams, or one team's with
+	// team_id
+	r.HandleFunc(
+		cfg.AdminPrefix+"/hub/servers",
+		func(w http.ResponseWriter, r *http.Request) {
+			owner, ok := ownerFromQuery(w, r, deps, m.MemberRoleMember)
+			if !ok {
+				return
+			}
+			deps.Logger.Info("LIST_HUB_SERVERS_INIT",
+				"user_id", ck.GetUserIDFromContext(r.Context()),
+				"team_id", owner.TeamID)
+			items, err := listHubs(r, deps, owner)
+			if err != nil {
+				deps.Logger.Error("LIST_HUB_SERVERS_ERROR", "error", err)
+				WriteJSON(
+					w,
+					http.StatusInternalServerError,
+					map[string]string{"error": err.Error()},
+				)
+				return
+			}
+
+			if deps.Pool != nil {
+				for i := range items {
+					b := deps.Pool.Breaker(items[i].MCPServerID)
+					items[i].Breaker = &b
+				}
+			}
+			deps.Logger.Info("LIST_HUB_SERVERS_SUCCESS", "count", len(items))
+			WriteJSON(w, http.StatusOK, map[string]any{"items": items})
+		},
+	).Methods(http.MethodGet)
+
+	// Add hub server via orchestrator
+	r.HandleFunc(
+		cfg.AdminPrefix+"/hub/servers",
+		func(w http.ResponseWriter, r *http.Request) {
+			deps.Logger.Info("CREATE_HUB_SERVER_INIT")
+			var body orchestrator.CreateMCPHubServer
+			if !ReadJSON(w, r, &body) {
+				deps.Logger.Error("CREATE_HUB_SERVER_READ_BODY_ERROR")
+				return
+			}
+
+			deps.Logger.Info("CREATE_HUB_SERVER_REQ", "req", body)
+
+			// Always trust server-side authenticated user
+			body.UserID = ck.GetUserIDFromContext(r.Context())
+			if body.TeamID != "" && !requireTeamRole(
+				w, r, deps, body.TeamID, m.MemberRoleMaintainer) {
+				return
+			}
+
+			id, err := orch.AddHub(r.Context(), body)
+			if err != nil {
+				deps.Logger.Error("CREATE_HUB_SERVER_ERROR", "error", err)
+				WriteJSON(
+					w,
+					http.StatusInternalServerError,
+					map[string]string{"error": err.Error()},
+				)
+				return
+			}
+
+			deps.Logger.Info("CREATE_HUB_SERVER_SUCCESS", "id", id)
+			WriteJSON(w, http.StatusCreated, map[string]any{"id": id, "ok": true})
+		},
+	).Methods(http.MethodPost)
+
+	// Update status / Delete hub
+	r.HandleFunc(
+		cfg.AdminPrefix+"/hub/servers/{id}",
+		func(w http.ResponseWriter, r *http.Request) {
+			id := mux.Vars(r)["id"]
+			if !canManageHub(w, r, deps, id) {
+				return
+			}
+			switch r.Method {
+			case http.MethodDelete:
+				deps.Logger.Info("DELETE_HUB_SERVER_INIT", "id", id)
+				if err := deps.Hubs.Delete(r.Context(), id); err != nil {
+					deps.Logger.Error("DELETE_HUB_SERVER_ERROR", "error", err)
+					WriteJSON(
+						w,
+						http.StatusInternalServerError,
+						map[string]string{"error": err.Error()},
+					)
+					return
+				}
+
+				deps.Logger.Info("DELETE_HUB_SERVER_SUCCESS", "id", id)
+				WriteJSON(w, http.StatusOK, map[string]string{"ok": "true"})
+			case http.MethodPatch:
+				type req struct {
+					Status string `json:"status"`
+				}
+				var b req
+				if !ReadJSON(w, r, &b) {
+					deps.Logger.Error("UPDATE_HUB_STATUS_READ_BODY_ERROR")
+					return
+				}
+				if b.Status == "" {
+					deps.Logger.Error("UPDATE_HUB_STATUS_MISSING_STATUS")
+					WriteJSON(
+						w,
+						http.StatusBadRequest,
+						map[string]string{"error": "missing status"},
+					)
+					return
+				}
+
+				deps.Logger.Info("UPDATE_HUB_STATUS_INIT",
+					"id", id, "status", b.Status)
+				if err := deps.Hubs.SetStatus(
+					r.Context(), id, b.Status,
+				); err != nil {
+					deps.Logger.Error("UPDATE_HUB_STATUS_DB_ERROR", "error", err)
 					WriteJSON(
 						w,
 						http.StatusInternalServerError,
@@ -682,8 +1638,14 @@ func addHubRoutes(r *mux.Router, deps Deps, cfg Config) {
 			id := mux.Vars(r)["id"]
 			userID := ck.GetUserIDFromContext(r.Context())
 			deps.Logger.Info("REFRESH_HUB_INIT", "id", id, "user_id", userID)
+			if !canManageHub(w, r, deps, id) {
+				return
+			}
 
-			added, deleted, err := orch.RefreshHub(r.Context(), id, userID)
+			started := time.Now()
+			added, deleted, err := orch.RefreshHub(r.Context(), id)
+			deps.Metrics.ObserveRefresh(metrics.RefreshHub,
+				len(added), len(deleted), err, time.Since(started))
 			if err != nil {
 				deps.Logger.Error("REFRESH_HUB_ERROR", "error", err)
 				WriteJSON(
@@ -708,4 +1670,417 @@ func addHubRoutes(r *mux.Router, deps Deps, cfg Config) {
 			})
 		},
 	).Methods(http.MethodPost)
+
+	// Start authorizing an oauth2 hub; the caller sends the user to the
+	// returned URL
+	r.HandleFunc(
+		cfg.AdminPrefix+"/hub/servers/{id}/oauth/start",
+		func(w http.ResponseWriter, r *http.Request) {
+			id := mux.Vars(r)["id"]
+			userID := ck.GetUserIDFromContext(r.Context())
+			deps.Logger.Info("START_HUB_OAUTH_INIT", "id", id, "user_id", userID)
+			if !canManageHub(w, r, deps, id) {
+				return
+			}
+
+			base := publicBaseURL(deps)
+			if base == "" {
+				WriteJSON(w, http.StatusServiceUnavailable, map[string]string{
+					"error": "oauth.issuer must be configured for oauth2 hubs"})
+				return
+			}
+			redirectURI := base + cfg.AdminPrefix + hubOAuthCallbackPath
+			authURL, err := orch.StartOAuth(r.Context(), id, redirectURI)
+			if err != nil {
+				deps.Logger.Error("START_HUB_OAUTH_ERROR", "error", err)
+				WriteJSON(w, hubOAuthErrorStatus(err),
+					map[string]string{"error": err.Error()})
+				return
+			}
+			deps.Logger.Info("START_HUB_OAUTH_SUCCESS", "id", id)
+			WriteJSON(w, http.StatusOK,
+				map[string]string{"authorization_url": authURL})
+		},
+	).Methods(http.MethodPost)
+
+	// Authorization servers redirect the user back here
+	r.HandleFunc(
+		cfg.AdminPrefix+hubOAuthCallbackPath,
+		func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			userID := ck.GetUserIDFromContext(r.Context())
+			deps.Logger.Info("HUB_OAUTH_CALLBACK_INIT", "user_id", userID)
+			back := url.Values{}
+			if e := q.Get("error"); e != "" {
+				deps.Logger.Info("HUB_OAUTH_CALLBACK_DENIED", "error", e)
+				back.Set("oauth", "error")
+				back.Set("error", strings.TrimSpace(e+" "+q.Get("error_description")))
+				http.Redirect(w, r, "/hub?"+back.Encode(), http.StatusFound)
+				return
+			}
+			hubID := orchestrator.OAuthStateHub(q.Get("state"))
+			var err error
+			switch {
+			case hubID == "":
+				err = orchestrator.ErrInvalidOAuthState
+			case !mayManageHub(r, deps, hubID):
+				err = orchestrator.ErrHubNotFound
+			default:
+				hubID, err = orch.CompleteOAuth(
+					r.Context(), q.Get("state"), q.Get("code"))
+			}
+			back.Set("hub_id", hubID)
+			if err != nil {
+				deps.Logger.Error("HUB_OAUTH_CALLBACK_ERROR", "error", err)
+				back.Set("oauth", "error")
+				back.Set("error", err.Error())
+				http.Redirect(w, r, "/hub?"+back.Encode(), http.StatusFound)
+				return
+			}
+			deps.Logger.Info("HUB_OAUTH_CALLBACK_SUCCESS", "hub_id", hubID)
+			back.Set("oauth", "connected")
+			http.Redirect(w, r, "/hub?"+back.Encode(), http.StatusFound)
+		},
+	).Methods(http.MethodGet)
+}
+
+// hubOAuthCallbackPath is where upstream authorization servers return
+// users, under the admin prefix.
+const hubOAuthCallbackPath = "/hub/oauth/callback"
+
+// listHubs lists the hubs of a team, or a user's own and those of the
+// teams they are a member of.
+func listHubs(
+	r *http.Request, deps Deps, owner m.Owner,
+) ([]m.MCPHubServerAggregate, error) {
+	if owner.TeamID != "" {
+		return deps.Hubs.ListForTeams(r.Context(), []string{owner.TeamID})
+	}
+	items, err := deps.Hubs.ListForUser(r.Context(), owner.UserID)
+	if err != nil {
+		return nil, err
+	}
+	teamIDs, err := callerTeamIDs(r, deps, m.MemberRoleMember)
+	if err != nil {
+		return nil, err
+	}
+	team, err := deps.Hubs.ListForTeams(r.Context(), teamIDs)
+	return append(items, team...), err
+}
+
+// canManageHub reports whether the caller added the personal hub, maintains
+// the team owning it or is an admin. It writes the error response when it
+// returns false.
+func canManageHub(
+	w http.ResponseWriter, r *http.Request, deps Deps, hubID string,
+) bool {
+	hub, err := deps.Hubs.Get(r.Context(), hubID)
+	if err != nil {
+		WriteJSON(w, http.StatusNotFound,
+			map[string]string{"error": orchestrator.ErrHubNotFound.Error()})
+		return false
+	}
+	ok, err := callerCanAccess(
+		r, deps, hub.UserID, hub.TeamID, m.MemberRoleMaintainer)
+	if err != nil {
+		WriteJSON(w, http.StatusInternalServerError,
+			map[string]string{"error": err.Error()})
+		return false
+	}
+	if !ok {
+		WriteJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+		return false
+	}
+	return true
+}
+
+// canManageTool reports whether the caller may manage the hub a tool was
+// discovered on, or the tool's owner when it has no hub; tools of public
+// catalog servers are shared by everyone and only admins manage them. It
+// writes the error response when it returns false.
+func canManageTool(
+	w http.ResponseWriter, r *http.Request, deps Deps, toolID string,
+) bool {
+	t, err := deps.Tools.GetByID(r.Context(), toolID)
+	if err != nil {
+		WriteJSON(w, http.StatusNotFound,
+			map[string]string{"error": "tool not found"})
+		return false
+	}
+	if t.MCPHubServerID != nil {
+		return canManageHub(w, r, deps, *t.MCPHubServerID)
+	}
+	ok := isAdminCaller(r)
+	if t.UserID != nil || t.TeamID != nil {
+		userID := ""
+		if t.UserID != nil {
+			userID = *t.UserID
+		}
+		if ok, err = callerCanAccess(
+			r, deps, userID, t.TeamID, m.MemberRoleMaintainer); err != nil {
+			WriteJSON(w, http.StatusInternalServerError,
+				map[string]string{"error": err.Error()})
+			return false
+		}
+	}
+	if !ok {
+		WriteJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+		return false
+	}
+	return true
+}
+
+// mayManageHub is canManageHub without writing a response.
+func mayManageHub(r *http.Request, deps Deps, hubID string) bool {
+	hub, err := deps.Hubs.Get(r.Context(), hubID)
+	if err != nil {
+		return false
+	}
+	ok, err := callerCanAccess(
+		r, deps, hub.UserID, hub.TeamID, m.MemberRoleMaintainer)
+	return err == nil && ok
+}
+
+// hubOAuthErrorStatus maps hub authorization errors to HTTP statuses.
+func hubOAuthErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, orchestrator.ErrHubNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, orchestrator.ErrNotOAuth2):
+		return http.StatusBadRequest
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// Audit routes
+func addAuditRoutes(r *mux.Router, deps Deps, cfg Config) {
+	// List tool call audit records. Users see calls made through their own
+	// virtual servers, and team maintainers those of a team virtual server
+	// named by virtual_server_id; admins see everything and may filter by
+	// user_id.
+	r.HandleFunc(
+		cfg.AdminPrefix+"/audit/calls",
+		func(w http.ResponseWriter, r *http.Request) {
+			userID := ck.GetUserIDFromContext(r.Context())
+			isAdmin := ck.GetUserRoleFromContext(r.Context()) == string(m.RoleAdmin)
+			q := r.URL.Query()
+			deps.Logger.Info("LIST_AUDIT_CALLS_INIT",
+				"user_id", userID, "is_admin", isAdmin)
+
+			f := repo.AuditFilter{
+				UserID:          q.Get("user_id"),
+				VirtualServerID: q.Get("virtual_server_id"),
+				HubServerID:     q.Get("hub_server_id"),
+				ToolID:          q.Get("tool_id"),
+			}
+			if !isAdmin {
+				if f.UserID != "" && f.UserID != userID {
+					WriteJSON(w, http.StatusForbidden,
+						map[string]string{"error": "forbidden"})
+					return
+				}
+				// Maintainers see all calls of their team's virtual servers
+				if !callerManagesTeamServer(r, deps, f.VirtualServerID) {
+					f.UserID = userID
+				}
+			}
+			if v := q.Get("is_error"); v != "" {
+				b, err := strconv.ParseBool(v)
+				if err != nil {
+					WriteJSON(w, http.StatusBadRequest,
+						map[string]string{"error": "invalid is_error"})
+					return
+				}
+				f.IsError = &b
+			}
+			for _, tp := range []struct {
+				name string
+				dst  *time.Time
+			}{{"since", &f.Since}, {"until", &f.Until}} {
+				v := q.Get(tp.name)
+				if v == "" {
+					continue
+				}
+				t, err := time.Parse(time.RFC3339, v)
+				if err != nil {
+					WriteJSON(w, http.StatusBadRequest,
+						map[string]string{"error": "invalid " + tp.name})
+					return
+				}
+				*tp.dst = t
+			}
+			for _, ip := range []struct {
+				name string
+				dst  *int
+			}{{"limit", &f.Limit}, {"offset", &f.Offset}} {
+				v := q.Get(ip.name)
+				if v == "" {
+					continue
+				}
+				n, err := strconv.Atoi(v)
+				if err != nil || n < 0 {
+					WriteJSON(w, http.StatusBadRequest,
+						map[string]string{"error": "invalid " + ip.name})
+					return
+				}
+				*ip.dst = n
+			}
+
+			page, err := deps.Audit.List(r.Context(), f)
+			if err != nil {
+				deps.Logger.Error("LIST_AUDIT_CALLS_ERROR", "error", err)
+				WriteJSON(w, http.StatusInternalServerError,
+					map[string]string{"error": err.Error()})
+				return
+			}
+			deps.Logger.Info("LIST_AUDIT_CALLS_SUCCESS",
+				"count", len(page.Items), "total", page.Total)
+			WriteJSON(w, http.StatusOK, page)
+		},
+	).Methods(http.MethodGet)
+}
+
+// Approval routes
+func addApprovalRoutes(r *mux.Router, deps Deps, cfg Config) {
+	// List tool call approvals. Users see calls held on their own virtual
+	// servers, and team maintainers those of a team virtual server named by
+	// virtual_server_id; admins see everything and may filter by user_id.
+	r.HandleFunc(
+		cfg.AdminPrefix+"/approvals",
+		func(w http.ResponseWriter, r *http.Request) {
+			userID := ck.GetUserIDFromContext(r.Context())
+			isAdmin := ck.GetUserRoleFromContext(r.Context()) == string(m.RoleAdmin)
+			q := r.URL.Query()
+			deps.Logger.Info("LIST_APPROVALS_INIT",
+				"user_id", userID, "is_admin", isAdmin)
+
+			f := repo.ApprovalFilter{
+				UserID:          q.Get("user_id"),
+				VirtualServerID: q.Get("virtual_server_id"),
+				Status:          m.ApprovalStatus(q.Get("status")),
+			}
+			if !isAdmin {
+				if f.UserID != "" && f.UserID != userID {
+					WriteJSON(w, http.StatusForbidden,
+						map[string]string{"error": "forbidden"})
+					return
+				}
+				// Maintainers see all calls of their team's virtual servers
+				if !callerManagesTeamServer(r, deps, f.VirtualServerID) {
+					f.UserID = userID
+				}
+			}
+			for _, ip := range []struct {
+				name string
+				dst  *int
+			}{{"limit", &f.Limit}, {"offset", &f.Offset}} {
+				v := q.Get(ip.name)
+				if v == "" {
+					continue
+				}
+				n, err := strconv.Atoi(v)
+				if err != nil || n < 0 {
+					WriteJSON(w, http.StatusBadRequest,
+						map[string]string{"error": "invalid " + ip.name})
+					return
+				}
+				*ip.dst = n
+			}
+
+			page, err := deps.Approvals.List(r.Context(), f)
+			if err != nil {
+				deps.Logger.Error("LIST_APPROVALS_ERROR", "error", err)
+				WriteJSON(w, http.StatusInternalServerError,
+					map[string]string{"error": err.Error()})
+				return
+			}
+			deps.Logger.Info("LIST_APPROVALS_SUCCESS",
+				"count", len(page.Items), "total", page.Total)
+			WriteJSON(w, http.StatusOK, page)
+		},
+	).Methods(http.MethodGet)
+
+	// Get one approval
+	r.HandleFunc(
+		cfg.AdminPrefix+"/approvals/{id}",
+		func(w http.ResponseWriter, r *http.Request) {
+			id := mux.Vars(r)["id"]
+			deps.Logger.Info("GET_APPROVAL_INIT", "id", id)
+			a, ok := approvalForCaller(w, r, deps, id)
+			if !ok {
+				return
+			}
+			deps.Logger.Info("GET_APPROVAL_SUCCESS", "id", id)
+			WriteJSON(w, http.StatusOK, a)
+		},
+	).Methods(http.MethodGet)
+
+	// Approve or reject a pending approval, with an optional reason
+	for _, action := range []string{"approve", "reject"} {
+		approve := action == "approve"
+		r.HandleFunc(
+			cfg.AdminPrefix+"/approvals/{id}/"+action,
+			func(w http.ResponseWriter, r *http.Request) {
+				id := mux.Vars(r)["id"]
+				var body struct {
+					Reason string `json:"reason"`
+				}
+				if r.ContentLength != 0 && !ReadJSON(w, r, &body) {
+					deps.Logger.Error("DECIDE_APPROVAL_READ_BODY_ERROR")
+					return
+				}
+				if _, ok := approvalForCaller(w, r, deps, id); !ok {
+					return
+				}
+				deps.Logger.Info("DECIDE_APPROVAL_INIT",
+					"id", id, "approve", approve)
+				a, err := deps.Approvals.Decide(r.Context(), id, approve,
+					ck.GetUserIDFromContext(r.Context()), body.Reason)
+				if err != nil {
+					deps.Logger.Error("DECIDE_APPROVAL_ERROR", "error", err)
+					WriteJSON(w, approvalErrorStatus(err),
+						map[string]string{"error": err.Error()})
+					return
+				}
+				deps.Logger.Info("DECIDE_APPROVAL_SUCCESS",
+					"id", id, "status", a.Status)
+				WriteJSON(w, http.StatusOK, a)
+			},
+		).Methods(http.MethodPost)
+	}
+}
+
+// approvalForCaller returns an approval if the caller owns its virtual
+// server, maintains the team owning it or is an admin. It writes the error
+// response when it returns false.
+func approvalForCaller(
+	w http.ResponseWriter, r *http.Request, deps Deps, id string,
+) (m.ToolCallApproval, bool) {
+	a, err := deps.Approvals.Get(r.Context(), id)
+	if err != nil {
+		deps.Logger.Error("GET_APPROVAL_ERROR", "id", id, "error", err)
+		WriteJSON(w, approvalErrorStatus(err),
+			map[string]string{"error": err.Error()})
+		return m.ToolCallApproval{}, false
+	}
+	if a.UserID != ck.GetUserIDFromContext(r.Context()) &&
+		ck.GetUserRoleFromContext(r.Context()) != string(m.RoleAdmin) &&
+		!callerManagesTeamServer(r, deps, a.VirtualServerID) {
+		WriteJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+		return m.ToolCallApproval{}, false
+	}
+	return a, true
+}
+
+// approvalErrorStatus maps approval service errors to HTTP status codes.
+func approvalErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, approval.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, approval.ErrNotPending):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
 }
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	"google.golang.org/api/idtoken"
 
 	ck "github.com/ChiragChiranjib/mcp-proxy/internal/contextkey"
+	usersvc "github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/user"
 	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
 )
 
@@ -85,6 +87,12 @@ func addAuthRoutes(r *mux.Router, deps Deps, cfg Config) {
 			}
 
 			user, err := deps.UserService.FetchOrCreateByUsername(r.Context(), email)
+			if errors.Is(err, usersvc.ErrAccountExists) {
+				deps.Logger.Error("AUTH_GOOGLE_ACCOUNT_EXISTS", "email", email)
+				WriteJSON(w, http.StatusConflict, map[string]string{
+					"error": "an account with this email already exists"})
+				return
+			}
 			if err != nil {
 				deps.Logger.Error("AUTH_GOOGLE_TOKEN_ERROR", "error", err)
 				WriteJSON(w, http.StatusInternalServerError,
@@ -92,36 +100,13 @@ func addAuthRoutes(r *mux.Router, deps Deps, cfg Config) {
 				return
 			}
 
-			// build JWT Token
-			token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-				"email":    email,
-				"uid":      user.ID,
-				"username": user.Username,
-				"role":     user.Role,
-				"auth":     "sso",
-				"exp":      time.Now().Add(120 * time.Minute).Unix(),
-				"iat":      time.Now().Unix(),
-			})
-
-			s, err := token.SignedString([]byte(deps.AppConfig.Security.JWTSecret))
-			if err != nil {
+			if err := setSessionCookie(w, deps, user, "sso"); err != nil {
 				deps.Logger.Error("AUTH_GOOGLE_TOKEN_ERROR", "error", err)
 				WriteJSON(w, http.StatusInternalServerError,
 					map[string]string{"error": "token error"})
 				return
 			}
 
-			// set cookie
-			http.SetCookie(w, &http.Cookie{
-				Name:     "session",
-				Value:    s,
-				Path:     "/",
-				HttpOnly: true,
-				SameSite: http.SameSiteLaxMode,
-				Secure:   false,
-				MaxAge:   3600,
-			})
-
 			deps.Logger.Info("AUTH_GOOGLE_SUCCESS", "user_id", user.ID)
 			WriteJSON(w, http.StatusOK,
 				map[string]any{"user_id": user.ID, "email": email, "name": name})
@@ -164,21 +149,7 @@ func addAuthRoutes(r *mux.Router, deps Deps, cfg Config) {
 				return
 			}
 
-			token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-				"email":    userEntity.Username,
-				"uid":      userEntity.ID,
-				"username": userEntity.Username,
-				"role":     userEntity.Role,
-				"auth":     "basic",
-				"exp":      time.Now().Add(120 * time.Minute).Unix(),
-				"iat":      time.Now().Unix(),
-			})
-			secret := ""
-			if deps.AppConfig != nil {
-				secret = deps.AppConfig.Security.JWTSecret
-			}
-			s, err := token.SignedString([]byte(secret))
-			if err != nil {
+			if err := setSessionCookie(w, deps, userEntity, "basic"); err != nil {
 				if deps.Logger != nil {
 					deps.Logger.Error("AUTH_BASIC_TOKEN_ERROR", "error", err)
 				}
@@ -186,15 +157,6 @@ func addAuthRoutes(r *mux.Router, deps Deps, cfg Config) {
 					map[string]string{"error": "token error"})
 				return
 			}
-			http.SetCookie(w, &http.Cookie{
-				Name:     "session",
-				Value:    s,
-				Path:     "/",
-				HttpOnly: true,
-				SameSite: http.SameSiteLaxMode,
-				Secure:   false,
-				MaxAge:   3600,
-			})
 			deps.Logger.Info("AUTH_BASIC_SUCCESS", "user_id", userEntity.ID)
 			WriteJSON(w, http.StatusOK,
 				map[string]any{"user_id": userEntity.ID, "username": userEntity.Username})
@@ -220,3 +182,32 @@ func addAuthRoutes(r *mux.Router, deps Deps, cfg Config) {
 				"user_id", ck.GetUserIDFromContext(r.Context()))
 		}).Methods(http.MethodPost)
 }
+
+// setSessionCookie signs a session token for the user and sets it as the
+// session cookie. auth records how the user signed in.
+func setSessionCookie(
+	w http.ResponseWriter, deps Deps, u *m.User, auth string) error {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"email":    u.Username,
+		"uid":      u.ID,
+		"username": u.Username,
+		"role":     u.Role,
+		"auth":     auth,
+		"exp":      time.Now().Add(120 * time.Minute).Unix(),
+		"iat":      time.Now().Unix(),
+	})
+	s, err := token.SignedString(sessionSecret(deps))
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     "session",
+		Value:    s,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		Secure:   false,
+		MaxAge:   3600,
+	})
+	return nil
+}
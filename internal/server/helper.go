@@ -1,11 +1,17 @@
 package server
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
+	"sync"
 
+	"github.com/ChiragChiranjib/mcp-proxy/internal/metrics"
 	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/yosida95/uritemplate/v3"
 )
 
 // WriteJSON ...
@@ -46,6 +52,60 @@ func CreateMCPTool(t m.MCPTool) mcp.Tool {
 	return tool
 }
 
+// CreateMCPResource constructs an mcp-go Resource from a DB resource record.
+func CreateMCPResource(rs m.MCPResource) mcp.Resource {
+	res := mcp.Resource{
+		URI:         rs.URI,
+		Name:        rs.Name,
+		Description: rs.Description,
+		MIMEType:    rs.MIMEType,
+	}
+	if len(rs.Annotations) > 0 {
+		var ann mcp.Annotations
+		if err := json.Unmarshal(rs.Annotations, &ann); err == nil {
+			res.Annotations = &ann
+		}
+	}
+	return res
+}
+
+// CreateMCPResourceTemplate constructs an mcp-go ResourceTemplate from a DB
+// resource record flagged as template.
+func CreateMCPResourceTemplate(rs m.MCPResource) (mcp.ResourceTemplate, error) {
+	tpl, err := uritemplate.New(rs.URI)
+	if err != nil {
+		return mcp.ResourceTemplate{}, err
+	}
+	res := mcp.ResourceTemplate{
+		URITemplate: &mcp.URITemplate{Template: tpl},
+		Name:        rs.Name,
+		Description: rs.Description,
+		MIMEType:    rs.MIMEType,
+	}
+	if len(rs.Annotations) > 0 {
+		var ann mcp.Annotations
+		if err := json.Unmarshal(rs.Annotations, &ann); err == nil {
+			res.Annotations = &ann
+		}
+	}
+	return res, nil
+}
+
+// CreateMCPPrompt constructs an mcp-go Prompt from a DB prompt record.
+func CreateMCPPrompt(pr m.MCPPrompt) mcp.Prompt {
+	prompt := mcp.Prompt{
+		Name:        pr.Name,
+		Description: pr.Description,
+	}
+	if len(pr.Arguments) > 0 {
+		var args []mcp.PromptArgument
+		if err := json.Unmarshal(pr.Arguments, &args); err == nil {
+			prompt.Arguments = args
+		}
+	}
+	return prompt
+}
+
 // writeRPCResult writes a JSON-RPC success response.
 func writeRPCResult(w http.ResponseWriter, id json.RawMessage, result any) {
 	w.Header().Set("Content-Type", "application/json")
@@ -58,20 +118,190 @@ func writeRPCResult(w http.ResponseWriter, id json.RawMessage, result any) {
 
 // writeRPCError writes a JSON-RPC error response with a message.
 func writeRPCError(w http.ResponseWriter, id json.RawMessage, code int, msg string) {
+	writeRPCErrorStatus(w, http.StatusOK, id, code, msg)
+}
+
+// writeRPCErrorStatus writes a JSON-RPC error response with an explicit
+// HTTP status code.
+func writeRPCErrorStatus(
+	w http.ResponseWriter, status int, id json.RawMessage, code int, msg string,
+) {
+	writeRPCErrorData(w, status, id, code, msg, nil)
+}
+
+// writeRPCErrorData writes a JSON-RPC error response carrying data.
+func writeRPCErrorData(
+	w http.ResponseWriter,
+	status int,
+	id json.RawMessage,
+	code int,
+	msg string,
+	data any,
+) {
+	if rec, ok := w.(rpcErrorMarker); ok {
+		rec.markRPCError()
+	}
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(struct {
 		JSONRPC string          `json:"jsonrpc"`
 		ID      json.RawMessage `json:"id"`
-		Error   struct {
-			Code    int    `json:"code"`
-			Message string `json:"message"`
-		} `json:"error"`
+		Error   rpcError        `json:"error"`
 	}{
 		JSONRPC: "2.0",
 		ID:      id,
-		Error: struct {
-			Code    int    `json:"code"`
-			Message string `json:"message"`
-		}{Code: code, Message: msg},
+		Error:   rpcError{Code: code, Message: msg, Data: data},
 	})
 }
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// rpcRecorder remembers whether a JSON-RPC request failed, either with an
+// HTTP error status or a JSON-RPC error written by writeRPCErrorStatus, or
+// was cancelled by the client.
+type rpcRecorder struct {
+	http.ResponseWriter
+	status    int
+	rpcError  bool
+	cancelled bool
+}
+
+// rpcErrorMarker is implemented by writers that track JSON-RPC errors and
+// cancelled requests.
+type rpcErrorMarker interface {
+	markRPCError()
+	markCancelled()
+}
+
+func (r *rpcRecorder) markRPCError() { r.rpcError = true }
+
+func (r *rpcRecorder) markCancelled() { r.cancelled = true }
+
+func (r *rpcRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Flush keeps streamed responses working through the recorder.
+func (r *rpcRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (r *rpcRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// outcome classifies the request for metrics.
+func (r *rpcRecorder) outcome() string {
+	switch {
+	case r.cancelled:
+		return metrics.OutcomeCancelled
+	case r.rpcError || r.status >= http.StatusBadRequest:
+		return metrics.OutcomeError
+	default:
+		return metrics.OutcomeOK
+	}
+}
+
+// sseWriter answers a single JSON-RPC request with a text/event-stream
+// response: notifications are sent as they arrive and the final response
+// written through writeRPCResult or writeRPCError ends the stream. It is
+// safe for concurrent use; events after the final response are dropped.
+type sseWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+
+	mu   sync.Mutex
+	done bool
+}
+
+// newSSEWriter starts the event stream on w.
+func newSSEWriter(w http.ResponseWriter) *sseWriter {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return &sseWriter{w: w, header: http.Header{}}
+}
+
+// Header returns a scratch header; the stream headers are already sent.
+func (s *sseWriter) Header() http.Header { return s.header }
+
+// WriteHeader is a no-op: JSON-RPC errors travel inside the stream.
+func (s *sseWriter) WriteHeader(int) {}
+
+// Write sends one encoded JSON-RPC response as the final event.
+func (s *sseWriter) Write(b []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done {
+		return len(b), nil
+	}
+	s.done = true
+	if err := s.eventLocked(bytes.TrimSpace(b)); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (s *sseWriter) markRPCError() {
+	if rec, ok := s.w.(rpcErrorMarker); ok {
+		rec.markRPCError()
+	}
+}
+
+func (s *sseWriter) markCancelled() {
+	if rec, ok := s.w.(rpcErrorMarker); ok {
+		rec.markCancelled()
+	}
+}
+
+// notify sends a server-initiated JSON-RPC message (a notification or a
+// request relayed from an upstream) as an event.
+func (s *sseWriter) notify(v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done {
+		return
+	}
+	_ = s.eventLocked(b)
+}
+
+func (s *sseWriter) eventLocked(data []byte) error {
+	if _, err := fmt.Fprintf(s.w, "event: message\ndata: %s\n\n", data); err != nil {
+		return err
+	}
+	if f, ok := s.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// acceptsEventStream reports whether the client accepts an SSE response.
+func acceptsEventStream(r *http.Request) bool {
+	for _, v := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(v, ",") {
+			mt, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+			if strings.EqualFold(strings.TrimSpace(mt), "text/event-stream") {
+				return true
+			}
+		}
+	}
+	return false
+}
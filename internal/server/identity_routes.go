@@ -0,0 +1,20 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// jwksPath serves the keys that verify the identity tokens sent upstream.
+const jwksPath = "/.well-known/jwks.json"
+
+func addIdentityRoutes(r *mux.Router, deps Deps) {
+	if deps.Identity == nil {
+		return
+	}
+	r.HandleFunc(jwksPath, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		WriteJSON(w, http.StatusOK, deps.Identity.JWKS())
+	}).Methods(http.MethodGet)
+}
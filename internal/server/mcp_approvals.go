@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mark3labs/mcp-go/mcp"
+	mserver "github.com/mark3labs/mcp-go/server"
+
+	ck "github.com/ChiragChiranjib/mcp-proxy/internal/contextkey"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/approval"
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+// rpcApprovalDenied is the JSON-RPC error code returned when a held tool
+// call is rejected by the virtual server's owner or expires undecided.
+const rpcApprovalDenied = -32005
+
+// approvalNoticeInterval is how often a held call tells a streaming client
+// that it is still waiting.
+const approvalNoticeInterval = 10 * time.Second
+
+// needsApproval reports whether calls to tool are held for approval: the
+// tool is listed explicitly, or it is destructive and the virtual server
+// holds destructive tools.
+func needsApproval(target upstreamTarget, tool *m.VirtualServerTool) bool {
+	return tool.RequireApproval ||
+		(target.approveDestructive && approval.IsDestructive(tool.Annotations))
+}
+
+// holdForApproval holds a tools/call until the owner of the virtual server
+// decides it. It returns the approval, nil when the call needs none, and
+// whether the call may go ahead. When it returns false it has audited the
+// call and written the response. Streaming clients are sent progress
+// notifications while they wait, or log messages without a progress token.
+func (p *proxyHTTPHandler) holdForApproval(
+	ctx context.Context,
+	w http.ResponseWriter,
+	r *http.Request,
+	id json.RawMessage,
+	target upstreamTarget,
+	tool *m.VirtualServerTool,
+	args map[string]any,
+	sse *sseWriter,
+	progressToken mcp.ProgressToken,
+) (*m.ToolCallApproval, bool) {
+	if p.deps.Approvals == nil || !needsApproval(target, tool) {
+		return nil, true
+	}
+	vsID := mux.Vars(r)["virtual_server_id"]
+	held := m.ToolCallApproval{
+		VirtualServerID: vsID,
+		UserID:          target.ownerID,
+		ToolID:          tool.ID,
+		ToolName:        tool.ExposedName,
+		SessionID:       truncate(r.Header.Get(mserver.HeaderKeySessionID), 128),
+	}
+	if keyID := ck.GetAPIKeyIDFromContext(r.Context()); keyID != "" {
+		held.APIKeyID = &keyID
+	}
+	if p.deps.Audit != nil {
+		held.Arguments = p.deps.Audit.RedactArguments(args)
+	}
+	held, err := p.deps.Approvals.Hold(ctx, held)
+	if err != nil {
+		p.deps.Logger.Error("MCP_CALL_HOLD_ERROR",
+			"vs_id", vsID, "tool_id", tool.ID, "error", err)
+		writeRPCError(w, id, mcp.INTERNAL_ERROR, "could not hold call for approval")
+		return nil, false
+	}
+
+	if sse != nil {
+		stop := p.noticeWaiting(sse, r, held, progressToken)
+		defer stop()
+	}
+	decided, err := p.deps.Approvals.Wait(ctx, held)
+	switch {
+	case errors.Is(err, context.Canceled):
+		if decided.ID == "" {
+			decided = held
+			decided.Status = m.ApprovalCancelled
+		}
+		p.auditToolCall(r, target, tool, args, nil, err, 0, &decided)
+		writeCallCancelled(w)
+		return nil, false
+	case err != nil:
+		p.deps.Logger.Error("MCP_CALL_APPROVAL_ERROR",
+			"vs_id", vsID, "approval_id", held.ID, "error", err)
+		writeRPCError(w, id, mcp.INTERNAL_ERROR, err.Error())
+		return nil, false
+	case decided.Status == m.ApprovalApproved:
+		return &decided, true
+	}
+
+	msg := "tool call " + string(decided.Status)
+	if decided.Reason != "" {
+		msg += ": " + decided.Reason
+	}
+	p.deps.Logger.Info("MCP_CALL_NOT_APPROVED", "vs_id", vsID,
+		"approval_id", decided.ID, "status", decided.Status)
+	p.auditToolCall(r, target, tool, args, nil, errors.New(msg), 0, &decided)
+	writeRPCErrorData(w, http.StatusOK, id, rpcApprovalDenied, msg,
+		map[string]any{
+			"approvalId": decided.ID,
+			"status":     decided.Status,
+			"reason":     decided.Reason,
+		})
+	return nil, false
+}
+
+// noticeWaiting tells a streaming client its call is held, at once and
+// then every approvalNoticeInterval, until the returned func is called.
+func (p *proxyHTTPHandler) noticeWaiting(
+	sse *sseWriter,
+	r *http.Request,
+	held m.ToolCallApproval,
+	progressToken mcp.ProgressToken,
+) func() {
+	sess, _ := sessionFromContext(r.Context())
+	started := time.Now()
+	total := time.Until(held.ExpiresAt).Seconds()
+	msg := fmt.Sprintf("waiting for approval %s of tool %s until %s",
+		held.ID, held.ToolName, held.ExpiresAt.UTC().Format(time.RFC3339))
+	send := func() {
+		var note mcp.JSONRPCNotification
+		if progressToken != nil {
+			note = notification("notifications/progress", map[string]any{
+				"progressToken": progressToken,
+				"progress":      time.Since(started).Seconds(),
+				"total":         total,
+				"message":       msg,
+			})
+		} else {
+			note = notification(methodNotificationMessage, map[string]any{
+				"level":  string(mcp.LoggingLevelInfo),
+				"logger": "mcp-proxy",
+				"data":   msg,
+			})
+		}
+		if logLevelAllows(note, sess.LogLevel) {
+			sse.notify(note)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		t := time.NewTicker(approvalNoticeInterval)
+		defer t.Stop()
+		send()
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+				send()
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// notification builds a JSON-RPC notification with the given params.
+func notification(method string, params map[string]any) mcp.JSONRPCNotification {
+	return mcp.JSONRPCNotification{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		Notification: mcp.Notification{
+			Method: method,
+			Params: mcp.NotificationParams{AdditionalFields: params},
+		},
+	}
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/transform"
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+// validateArgs checks the arguments of a tools/call, as they will be sent
+// upstream, against the tool's stored input schema. Paths in violations
+// use the argument names the client sees. Under ArgValidationEnforce a
+// violating call is answered with INVALID_PARAMS listing every violation;
+// under ArgValidationWarn it is logged and let through. Tools whose schema
+// cannot be compiled are not checked.
+func (p *proxyHTTPHandler) validateArgs(
+	w http.ResponseWriter,
+	r *http.Request,
+	id json.RawMessage,
+	mode m.ArgValidation,
+	tool *m.VirtualServerTool,
+	rules *transform.Rules,
+	args map[string]any,
+) bool {
+	if mode != m.ArgValidationWarn && mode != m.ArgValidationEnforce {
+		return true
+	}
+	var names map[string]string
+	if rules != nil {
+		names = rules.Rename
+	}
+	vsID := mux.Vars(r)["virtual_server_id"]
+	violations, err := p.schemas.Validate(
+		tool.ID, tool.InputSchema, args, names)
+	if err != nil {
+		p.deps.Logger.Error("MCP_CALL_SCHEMA_ERROR",
+			"vs_id", vsID, "tool_id", tool.ID, "error", err)
+		return true
+	}
+	if len(violations) == 0 {
+		return true
+	}
+	msgs := make([]string, 0, len(violations))
+	for _, v := range violations {
+		path := v.Path
+		if path == "" {
+			path = "arguments"
+		}
+		msgs = append(msgs, path+": "+v.Message)
+	}
+	p.deps.Logger.Info("MCP_CALL_ARGS_INVALID",
+		"vs_id", vsID, "tool_id", tool.ID, "mode", mode,
+		"violations", msgs)
+	if mode == m.ArgValidationWarn {
+		return true
+	}
+	writeRPCErrorData(w, http.StatusOK, id, mcp.INVALID_PARAMS,
+		"invalid arguments: "+strings.Join(msgs, "; "),
+		map[string]any{"violations": violations})
+	return false
+}
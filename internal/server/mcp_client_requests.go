@@ -0,0 +1,157 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	mcpclient "github.com/ChiragChiranjib/mcp-proxy/internal/mcp/client"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/idgen"
+)
+
+// clientRequests decides which requests an upstream may make of the client
+// during a tools/call and returns the RequestFunc relaying them. Only
+// stateful sessions qualify, since the client POSTs its answer with the
+// session id, and only for capabilities both the client declared and the
+// virtual server allows. Requests go out on the call's event stream when
+// it has one and on the session's GET stream otherwise. Pending requests
+// live in this process, so relaying is off with a shared session store.
+func (p *proxyHTTPHandler) clientRequests(
+	ctx context.Context, r *http.Request, sse *sseWriter,
+) (sampling, elicitation bool, relay mcpclient.RequestFunc) {
+	sess, ok := sessionFromContext(r.Context())
+	if !ok || p.deps.Sessions.Shared() {
+		return false, false, nil
+	}
+	vs, err := p.deps.Virtual.GetByID(ctx, sess.VirtualServerID)
+	if err != nil {
+		return false, false, nil
+	}
+	var caps mcp.ClientCapabilities
+	if len(sess.ClientCapabilities) > 0 {
+		_ = json.Unmarshal(sess.ClientCapabilities, &caps)
+	}
+	sampling = vs.AllowSampling && caps.Sampling != nil
+	elicitation = vs.AllowElicitation && caps.Elicitation != nil
+	if !sampling && !elicitation {
+		return false, false, nil
+	}
+
+	relay = func(
+		rctx context.Context, req transport.JSONRPCRequest,
+	) (*transport.JSONRPCResponse, error) {
+		allowed := (req.Method == string(mcp.MethodSamplingCreateMessage) &&
+			sampling) ||
+			(req.Method == string(mcp.MethodElicitationCreate) && elicitation)
+		if !allowed {
+			p.deps.Logger.Info("MCP_CLIENT_REQUEST_DENIED",
+				"vs_id", sess.VirtualServerID, "method", req.Method)
+			return mcpclient.ErrorResponse(req.ID, mcp.METHOD_NOT_FOUND,
+				req.Method+" is not allowed"), nil
+		}
+
+		// The upstream's id is only unique on its own session, so the
+		// client sees one issued by the proxy.
+		id := "mcp-proxy-" + idgen.NewID()
+		answer, done := p.pending.add(sess.ID, id)
+		defer done()
+		msg := transport.JSONRPCRequest{
+			JSONRPC: mcp.JSONRPC_VERSION,
+			ID:      mcp.NewRequestId(id),
+			Method:  req.Method,
+			Params:  req.Params,
+		}
+		if sse != nil {
+			sse.notify(msg)
+		} else if !p.deps.Sessions.Publish(sess.ID, msg) {
+			return mcpclient.ErrorResponse(req.ID, mcp.INTERNAL_ERROR,
+				"client has no open stream"), nil
+		}
+		p.deps.Logger.Info("MCP_CLIENT_REQUEST_RELAYED",
+			"vs_id", sess.VirtualServerID, "session_id", sess.ID,
+			"method", req.Method)
+
+		select {
+		case resp := <-answer:
+			resp.ID = req.ID
+			return resp, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-rctx.Done():
+			return nil, rctx.Err()
+		}
+	}
+	return sampling, elicitation, relay
+}
+
+// handleClientResponse delivers a client's response to a relayed request
+// to the call waiting for it on this gateway.
+func (p *proxyHTTPHandler) handleClientResponse(
+	w http.ResponseWriter, r *http.Request, body []byte,
+) {
+	var resp transport.JSONRPCResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		writeRPCError(w, nil, mcp.INVALID_REQUEST, "invalid response")
+		return
+	}
+	sess, _ := sessionFromContext(r.Context())
+	id, _ := resp.ID.Value().(string)
+	if !p.pending.resolve(sess.ID, id, &resp) {
+		p.deps.Logger.Info("MCP_CLIENT_RESPONSE_UNMATCHED",
+			"vs_id", mux.Vars(r)["virtual_server_id"],
+			"session_id", sess.ID, "id", id)
+		writeRPCErrorStatus(w, http.StatusBadRequest, nil,
+			mcp.INVALID_REQUEST, "no pending request with this id")
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// pendingRequests correlates requests relayed to clients with the
+// responses the clients POST back.
+type pendingRequests struct {
+	mu   sync.Mutex
+	reqs map[string]chan *transport.JSONRPCResponse
+}
+
+func newPendingRequests() *pendingRequests {
+	return &pendingRequests{reqs: map[string]chan *transport.JSONRPCResponse{}}
+}
+
+// add registers request id of a session and returns the channel its
+// response arrives on and a func that removes it.
+func (pr *pendingRequests) add(
+	sessionID, id string,
+) (<-chan *transport.JSONRPCResponse, func()) {
+	key := sessionID + "\x00" + id
+	ch := make(chan *transport.JSONRPCResponse, 1)
+	pr.mu.Lock()
+	pr.reqs[key] = ch
+	pr.mu.Unlock()
+	return ch, func() {
+		pr.mu.Lock()
+		delete(pr.reqs, key)
+		pr.mu.Unlock()
+	}
+}
+
+// resolve hands resp to the request it answers and reports whether one was
+// waiting. Each request takes a single response.
+func (pr *pendingRequests) resolve(
+	sessionID, id string, resp *transport.JSONRPCResponse,
+) bool {
+	key := sessionID + "\x00" + id
+	pr.mu.Lock()
+	ch, ok := pr.reqs[key]
+	delete(pr.reqs, key)
+	pr.mu.Unlock()
+	if ok {
+		ch <- resp
+	}
+	return ok
+}
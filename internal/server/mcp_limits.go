@@ -0,0 +1,54 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	ck "github.com/ChiragChiranjib/mcp-proxy/internal/contextkey"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/ratelimit"
+)
+
+// rpcRateLimited is the JSON-RPC error code returned for a call over a
+// rate limit or quota.
+const rpcRateLimited = -32004
+
+// allowCall checks a tools/call against the limits of its virtual server,
+// API key and upstream tool. A call over a limit is answered with
+// rpcRateLimited and a Retry-After header; the error data says which limit
+// was hit and when to retry.
+func (p *proxyHTTPHandler) allowCall(
+	w http.ResponseWriter,
+	r *http.Request,
+	id json.RawMessage,
+	vsID string,
+	toolID string,
+) bool {
+	if p.deps.Limits == nil {
+		return true
+	}
+	err := p.deps.Limits.Allow(r.Context(), ratelimit.Call{
+		VirtualServerID: vsID,
+		APIKeyID:        ck.GetAPIKeyIDFromContext(r.Context()),
+		ToolID:          toolID,
+	})
+	var le *ratelimit.LimitError
+	if !errors.As(err, &le) {
+		return true
+	}
+	retryAfter := max(int(math.Ceil(le.RetryAfter.Seconds())), 1)
+	p.deps.Logger.Info("MCP_CALL_RATE_LIMITED",
+		"vs_id", vsID, "scope", le.Scope, "scope_id", le.ScopeID,
+		"limit", le.Limit, "retry_after", retryAfter)
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	writeRPCErrorData(w, http.StatusOK, id, rpcRateLimited, le.Error(),
+		map[string]any{
+			"scope":             le.Scope,
+			"scopeId":           le.ScopeID,
+			"limit":             le.Limit,
+			"retryAfterSeconds": retryAfter,
+		})
+	return false
+}
@@ -4,29 +4,73 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"math"
 	"mime"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/mark3labs/mcp-go/mcp"
 	mserver "github.com/mark3labs/mcp-go/server"
+	"github.com/yosida95/uritemplate/v3"
 
+	ck "github.com/ChiragChiranjib/mcp-proxy/internal/contextkey"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/argschema"
 	mcpclient "github.com/ChiragChiranjib/mcp-proxy/internal/mcp/client"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/identity"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/oauth"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/virtualmcp"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/transform"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/metrics"
 	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
 )
 
 func addMCPRoutes(r *mux.Router, deps Deps, cfg Config) {
-	// Build MCP server core
+	deps.Logger.Info("STREAMABLE_SERVER_BUILD_INIT")
+	// Only stateful sessions can be told their tool list changed, so only
+	// their core advertises listChanged, and only when change events flow.
+	listChanged := deps.Sessions != nil && deps.Events != nil
+	proxy := &proxyHTTPHandler{
+		core:         newCoreHandler(false),
+		statefulCore: newCoreHandler(listChanged),
+		deps:         deps,
+		calls:        newInflightCalls(),
+		pending:      newPendingRequests(),
+		schemas:      argschema.NewValidator(),
+	}
+	deps.Logger.Info("STREAMABLE_SERVER_BUILD_SUCCESS",
+		"tools_list_changed", listChanged)
+	if listChanged {
+		deps.Events.Subscribe(proxy.onEvent)
+	}
+	if deps.Sessions != nil && deps.Sessions.Shared() {
+		deps.Logger.Warn("MCP_CLIENT_REQUESTS_DISABLED",
+			"reason", "shared session store")
+	}
+
+	// Mount handler
+	r.Path(cfg.MCPMount).Handler(proxy).
+		Methods(http.MethodGet, http.MethodPost, http.MethodDelete)
+	deps.Logger.Info("MCP_ROUTES_MOUNTED", "mount", cfg.MCPMount)
+}
+
+// newCoreHandler builds the MCP server core and its streamable HTTP
+// handler, injecting mux vars into the request context.
+func newCoreHandler(toolsListChanged bool) http.Handler {
 	srv := mserver.NewMCPServer(
 		"mcp-proxy-server", "1.0.0",
 		mserver.WithLogging(),
-		mserver.WithToolCapabilities(true),
+		mserver.WithToolCapabilities(toolsListChanged),
+		mserver.WithResourceCapabilities(false, false),
+		mserver.WithPromptCapabilities(false),
 	)
-
-	deps.Logger.Info("STREAMABLE_SERVER_BUILD_INIT")
-	// Build streamable HTTP handler and inject mux vars
-	core := mserver.NewStreamableHTTPServer(
+	return mserver.NewStreamableHTTPServer(
 		srv,
 		mserver.WithStateLess(true),
 		mserver.WithHTTPContextFunc(
@@ -34,30 +78,54 @@ func addMCPRoutes(r *mux.Router, deps Deps, cfg Config) {
 				return context.WithValue(ctx, requestVarsKey{}, mux.Vars(r))
 			}),
 	)
-	deps.Logger.Info("STREAMABLE_SERVER_BUILD_SUCCESS")
-
-	// Wrap core with proxy that intercepts tools/list and tools/call only.
-	proxy := &proxyHTTPHandler{core: core, deps: deps}
-
-	// Mount handler
-	r.Path(cfg.MCPMount).Handler(proxy).
-		Methods(http.MethodGet, http.MethodPost, http.MethodDelete)
-	deps.Logger.Info("MCP_ROUTES_MOUNTED", "mount", cfg.MCPMount)
 }
 
 // requestVarsKey is used to stash mux vars into context for mcp-go hooks.
 type requestVarsKey struct{}
 
-// proxyHTTPHandler intercepts only list_tools and call_tool POST requests,
-// delegating all other methods to the core streamable handler.
+// rpcUnauthorized is the JSON-RPC error code returned when the caller does
+// not present a valid API key for the virtual server.
+const rpcUnauthorized = -32001
+
+// rpcUpstreamUnreachable is the JSON-RPC error code returned when the hub
+// serving a request has been marked UNREACHABLE by health probes, or when
+// the circuit breaker of its upstream server is open.
+const rpcUpstreamUnreachable = -32003
+
+// unreachableToolsFlag lists tools of UNREACHABLE hubs with a marker
+// instead of hiding them.
+const unreachableToolsFlag = "flag"
+
+// proxyHTTPHandler intercepts tool, resource and prompt POST requests and
+// the session lifecycle of stateful virtual servers, delegating all other
+// methods to the core streamable handler.
 type proxyHTTPHandler struct {
-	core http.Handler
-	deps Deps
+	core         http.Handler
+	statefulCore http.Handler // answers initialize for stateful servers
+	deps         Deps
+	calls        *inflightCalls
+	pending      *pendingRequests
+	schemas      *argschema.Validator
 }
 
 func (p *proxyHTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r, ok := p.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	// Stateful virtual servers keep sessions in the session service; the
+	// core handler is stateless and only answers their POSTs.
+	stateful := p.stateful(r)
 	if r.Method != http.MethodPost {
-		p.core.ServeHTTP(w, r)
+		switch {
+		case stateful && r.Method == http.MethodGet:
+			p.handleStream(w, r)
+		case stateful && r.Method == http.MethodDelete:
+			p.handleEndSession(w, r)
+		default:
+			p.core.ServeHTTP(w, r)
+		}
 		return
 	}
 
@@ -90,6 +158,30 @@ func (p *proxyHTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	started := time.Now()
+	rec := &rpcRecorder{ResponseWriter: w}
+	w = rec
+	defer func() {
+		p.deps.Metrics.ObserveRequest(base.Method,
+			mux.Vars(r)["virtual_server_id"], rec.outcome(), time.Since(started))
+	}()
+
+	if stateful {
+		if r, ok = p.session(w, r, base.ID, base.Method, body); !ok {
+			return
+		}
+		if base.Method == mcp.MethodSetLogLevel {
+			p.handleSetLevel(w, r, base.ID, body)
+			return
+		}
+		// Answers to sampling and elicitation requests relayed from
+		// upstreams
+		if base.Method == "" && (len(base.Result) > 0 || len(base.Error) > 0) {
+			p.handleClientResponse(w, r, body)
+			return
+		}
+	}
+
 	switch base.Method {
 	case mcp.MethodToolsList:
 		p.handleListTools(w, r, base.ID)
@@ -97,14 +189,142 @@ func (p *proxyHTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	case mcp.MethodToolsCall:
 		p.handleCallTool(w, r, base.ID, body)
 		return
+	case mcp.MethodResourcesList:
+		p.handleListResources(w, r, base.ID)
+		return
+	case mcp.MethodResourcesTemplatesList:
+		p.handleListResourceTemplates(w, r, base.ID)
+		return
+	case mcp.MethodResourcesRead:
+		p.handleReadResource(w, r, base.ID, body)
+		return
+	case mcp.MethodPromptsList:
+		p.handleListPrompts(w, r, base.ID)
+		return
+	case mcp.MethodPromptsGet:
+		p.handleGetPrompt(w, r, base.ID, body)
+		return
+	case methodCancelled:
+		p.handleCancelled(w, r, body)
+		return
 	default:
 		// Delegate to core for all other methods
 		r.Body = io.NopCloser(bytes.NewReader(body))
-		p.core.ServeHTTP(w, r)
+		if stateful {
+			p.statefulCore.ServeHTTP(w, r)
+		} else {
+			p.core.ServeHTTP(w, r)
+		}
 		return
 	}
 }
 
+// authenticate validates the Bearer API key, or OAuth access token, against
+// the virtual server in the path. On success the key id, or the grant id of
+// the token, is stored in the request context.
+func (p *proxyHTTPHandler) authenticate(
+	w http.ResponseWriter, r *http.Request,
+) (*http.Request, bool) {
+	vsID := mux.Vars(r)["virtual_server_id"]
+	raw, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	raw = strings.TrimSpace(raw)
+	if !found || raw == "" {
+		p.deps.Logger.Info("MCP_AUTH_MISSING_KEY", "vs_id", vsID)
+		p.writeUnauthorized(w, r, "", "missing api key")
+		return r, false
+	}
+	if p.deps.OAuth != nil && oauth.IsAccessToken(raw) {
+		return p.authenticateOAuth(w, r, raw)
+	}
+	key, err := p.deps.Keys.Authenticate(r.Context(), vsID, raw)
+	if err != nil {
+		p.deps.Logger.Info("MCP_AUTH_INVALID_KEY", "vs_id", vsID)
+		p.writeUnauthorized(w, r, "", "invalid api key")
+		return r, false
+	}
+	if p.teamAccessRevoked(r.Context(), vsID, key.UserID) {
+		p.deps.Logger.Info("MCP_AUTH_TEAM_ACCESS_REVOKED",
+			"vs_id", vsID, "key_id", key.ID)
+		writeRPCErrorStatus(w, http.StatusForbidden, nil, rpcUnauthorized,
+			"no longer a member of the virtual server's team")
+		return r, false
+	}
+	ctx := context.WithValue(r.Context(), ck.APIKeyIDKey, key.ID)
+	ctx = context.WithValue(ctx, ck.UserIDKey, key.UserID)
+	return r.WithContext(ctx), true
+}
+
+// authenticateOAuth validates an OAuth access token, which must have been
+// granted access to the virtual server in the path.
+func (p *proxyHTTPHandler) authenticateOAuth(
+	w http.ResponseWriter, r *http.Request, raw string,
+) (*http.Request, bool) {
+	vsID := mux.Vars(r)["virtual_server_id"]
+	access, err := p.deps.OAuth.Authenticate(r.Context(), raw)
+	if err != nil {
+		p.deps.Logger.Info("MCP_AUTH_INVALID_TOKEN", "vs_id", vsID)
+		p.writeUnauthorized(w, r, "invalid_token", "invalid access token")
+		return r, false
+	}
+	if !access.Allows(vsID) {
+		p.deps.Logger.Info("MCP_AUTH_INSUFFICIENT_SCOPE",
+			"vs_id", vsID, "grant_id", access.GrantID)
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+			`Bearer error="insufficient_scope", scope="%s", resource_metadata="%s"`,
+			oauth.Scope([]string{vsID}), resourceMetadataURL(p.deps, r)))
+		writeRPCErrorStatus(w, http.StatusForbidden, nil, rpcUnauthorized,
+			"access token does not grant this virtual server")
+		return r, false
+	}
+	if p.teamAccessRevoked(r.Context(), vsID, access.UserID) {
+		p.deps.Logger.Info("MCP_AUTH_TEAM_ACCESS_REVOKED",
+			"vs_id", vsID, "grant_id", access.GrantID)
+		writeRPCErrorStatus(w, http.StatusForbidden, nil, rpcUnauthorized,
+			"no longer a member of the virtual server's team")
+		return r, false
+	}
+	ctx := context.WithValue(r.Context(), ck.OAuthGrantIDKey, access.GrantID)
+	ctx = context.WithValue(ctx, ck.UserIDKey, access.UserID)
+	return r.WithContext(ctx), true
+}
+
+// teamAccessRevoked reports whether the user an API key or grant was issued
+// to has since lost access to a team virtual server, by leaving its team.
+func (p *proxyHTTPHandler) teamAccessRevoked(
+	ctx context.Context, vsID, userID string,
+) bool {
+	vs, err := p.deps.Virtual.GetByID(ctx, vsID)
+	if err != nil || vs.TeamID == nil || p.deps.Orgs == nil {
+		return false
+	}
+	role, err := p.deps.Orgs.TeamRole(ctx, *vs.TeamID, userID)
+	if err != nil {
+		return true
+	}
+	if role.AtLeast(m.MemberRoleMember) {
+		return false
+	}
+	u, err := p.deps.UserService.FindUserByID(ctx, userID)
+	return err != nil || u.Role != string(m.RoleAdmin)
+}
+
+// writeUnauthorized answers 401. When the OAuth server is enabled the
+// challenge points clients at the protected resource metadata, from which
+// they discover where to obtain a token; errCode is the RFC 6750 error.
+func (p *proxyHTTPHandler) writeUnauthorized(
+	w http.ResponseWriter, r *http.Request, errCode, msg string) {
+	challenge := `Bearer realm="mcp-proxy"`
+	if errCode != "" {
+		challenge += fmt.Sprintf(`, error="%s"`, errCode)
+	}
+	if p.deps.OAuth != nil {
+		challenge += fmt.Sprintf(`, resource_metadata="%s"`,
+			resourceMetadataURL(p.deps, r))
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	writeRPCErrorStatus(w, http.StatusUnauthorized, nil, rpcUnauthorized, msg)
+}
+
 func (p *proxyHTTPHandler) handleListTools(
 	w http.ResponseWriter,
 	r *http.Request,
@@ -119,11 +339,45 @@ func (p *proxyHTTPHandler) handleListTools(
 		return
 	}
 
+	vs, err := p.deps.Virtual.GetByID(r.Context(), vsID)
+	if err != nil {
+		writeRPCError(w, id, mcp.INTERNAL_ERROR, err.Error())
+		return
+	}
+	unreachable, err := p.unreachableServers(r.Context(), vs)
+	if err != nil {
+		writeRPCError(w, id, mcp.INTERNAL_ERROR, err.Error())
+		return
+	}
+	flag := p.deps.AppConfig != nil &&
+		p.deps.AppConfig.Health.UnreachableTools == unreachableToolsFlag
+
 	tools := make([]mcp.Tool, 0, len(items))
 	for _, t := range items {
-		tool := CreateMCPTool(t)
-		// Use original name for listing
-		tool.Name = t.OriginalName
+		if t.Status != m.StatusActive ||
+			!virtualmcp.AllowsTool(vs.Mode, vs.AnnotationFilter, t.Annotations) {
+			continue
+		}
+		down := unreachable[t.MCPServerID]
+		if down && !flag {
+			continue
+		}
+		rules, err := transform.Parse(t.Transform)
+		if err != nil {
+			p.deps.Logger.Error("MCP_LIST_TOOLS_TRANSFORM_ERROR",
+				"tool_id", t.ID, "error", err)
+		} else if schema, err := rules.Schema(t.InputSchema); err == nil {
+			t.InputSchema = schema
+		}
+		tool := CreateMCPTool(t.MCPTool)
+		// Advertise the name chosen by the virtual server's naming policy
+		tool.Name = t.ExposedName
+		if down {
+			tool.Description = "[unreachable] " + tool.Description
+			tool.Meta = &mcp.Meta{
+				AdditionalFields: map[string]any{"unreachable": true},
+			}
+		}
 		tools = append(tools, tool)
 	}
 	res := mcp.ListToolsResult{Tools: tools}
@@ -144,17 +398,18 @@ func (p *proxyHTTPHandler) handleCallTool(
 		writeRPCError(w, id, mcp.INVALID_REQUEST, "invalid call_tool")
 		return
 	}
-	name := req.Params.Name // original name expected
+	name := req.Params.Name // exposed name expected
 
-	// Fetch tools for VS and find by original name
+	// Fetch tools for VS and find by exposed name
 	items, err := p.deps.Tools.ListForVirtualServer(r.Context(), vsID)
 	if err != nil {
 		writeRPCError(w, id, mcp.INTERNAL_ERROR, err.Error())
 		return
 	}
-	var found *m.MCPTool
+	var found *m.VirtualServerTool
 	for i := range items {
-		if items[i].OriginalName == name {
+		if items[i].ExposedName == name &&
+			items[i].Status == m.StatusActive {
 			found = &items[i]
 			break
 		}
@@ -164,34 +419,583 @@ func (p *proxyHTTPHandler) handleCallTool(
 		return
 	}
 
+	target, ok := p.upstreamFor(w, r, id, vsID, found.MCPServerID)
+	if !ok {
+		return
+	}
+	// Tools the server's mode hides are not callable either, even if the
+	// client remembers their names.
+	if !virtualmcp.AllowsTool(target.mode, target.annotationFilter,
+		found.Annotations) {
+		p.deps.Logger.Info("MCP_CALL_MODE_DENIED",
+			"vs_id", vsID, "tool_id", found.ID, "mode", target.mode)
+		writeRPCError(w, id, mcp.RESOURCE_NOT_FOUND,
+			"tool not available in "+string(target.mode)+" mode")
+		return
+	}
+
+	// Extract arguments
+	args := map[string]any{}
+	if req.Params.Arguments != nil {
+		if m, ok := req.Params.Arguments.(map[string]any); ok {
+			args = m
+		}
+	}
+
+	rules, err := transform.Parse(found.Transform)
+	if err != nil {
+		writeRPCError(w, id, mcp.INTERNAL_ERROR, "invalid tool transform")
+		return
+	}
+	upstreamArgs := rules.Args(args)
+
+	// Malformed calls are turned away before they count against limits.
+	if !p.validateArgs(w, r, id, target.argValidation, found, rules,
+		upstreamArgs) {
+		return
+	}
+	if !p.allowCall(w, r, id, vsID, found.ID) {
+		return
+	}
+
+	// The call ends when the client disconnects or cancels the request id.
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	defer p.calls.add(callKey(r, id), cancel)()
+
+	// Stream upstream progress and log notifications when the client
+	// accepts SSE; the final response becomes the last event.
+	var progressToken mcp.ProgressToken
+	if req.Params.Meta != nil {
+		progressToken = req.Params.Meta.ProgressToken
+	}
+	var onNotify mcpclient.NotificationFunc = func(mcp.JSONRPCNotification) {}
+	var sse *sseWriter
+	if acceptsEventStream(r) {
+		sess, _ := sessionFromContext(r.Context())
+		sse = newSSEWriter(w)
+		onNotify = func(note mcp.JSONRPCNotification) {
+			if logLevelAllows(note, sess.LogLevel) {
+				sse.notify(note)
+			}
+		}
+		w = sse
+	}
+
+	// The upstream session advertises sampling and elicitation only when
+	// they can be relayed to this client.
+	up := target.up
+	var onRequest mcpclient.RequestFunc
+	up.Sampling, up.Elicitation, onRequest = p.clientRequests(ctx, r, sse)
+
+	approved, ok := p.holdForApproval(ctx, w, r, id, target, found, args,
+		sse, progressToken)
+	if !ok {
+		return
+	}
+
+	started := time.Now()
+	res, err := p.deps.Pool.CallToolStream(ctx, up, mcpclient.ToolCall{
+		Name:          found.OriginalName,
+		Arguments:     upstreamArgs,
+		ProgressToken: progressToken,
+		OnNotify:      onNotify,
+		OnRequest:     onRequest,
+		Idempotent:    mcpclient.IsIdempotent(found.Annotations),
+	})
+	latency := time.Since(started)
+	if err == nil {
+		rules.Result(res)
+	}
+	p.deps.Metrics.ObserveToolCall(found.MCPServerID, found.OriginalName,
+		toolCallOutcome(res, err), latency)
+	p.auditToolCall(r, target, found, args, res, err, latency, approved)
+	if errors.Is(err, context.Canceled) {
+		writeCallCancelled(w)
+		return
+	}
+	// Calls to an upstream server that keeps failing are refused until its
+	// breaker lets a trial call through.
+	var open *mcpclient.CircuitOpenError
+	if errors.As(err, &open) {
+		retryAfter := max(int(math.Ceil(open.RetryAfter.Seconds())), 1)
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		writeRPCErrorData(w, http.StatusOK, id, rpcUpstreamUnreachable,
+			open.Error(), map[string]any{
+				"serverId":          open.ServerID,
+				"retryAfterSeconds": retryAfter,
+			})
+		return
+	}
+	if err != nil {
+		writeRPCError(w, id, mcp.INTERNAL_ERROR, err.Error())
+		return
+	}
+	writeRPCResult(w, id, res)
+}
+
+// writeCallCancelled ends a cancelled request, which gets no response.
+func writeCallCancelled(w http.ResponseWriter) {
+	if rec, ok := w.(rpcErrorMarker); ok {
+		rec.markCancelled()
+	}
+	if _, streaming := w.(*sseWriter); !streaming {
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// methodCancelled is the notification a client sends to cancel a request.
+const methodCancelled mcp.MCPMethod = "notifications/cancelled"
+
+// handleCancelled cancels the in-flight tools/call named by the
+// notification, if it is running on this gateway, and acknowledges the
+// notification.
+func (p *proxyHTTPHandler) handleCancelled(
+	w http.ResponseWriter, r *http.Request, body []byte,
+) {
+	var note struct {
+		Params struct {
+			RequestID json.RawMessage `json:"requestId"`
+			Reason    string          `json:"reason,omitempty"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(body, &note); err == nil &&
+		len(note.Params.RequestID) > 0 {
+		if p.calls.cancel(callKey(r, note.Params.RequestID)) {
+			p.deps.Logger.Info("MCP_CALL_CANCELLED",
+				"vs_id", mux.Vars(r)["virtual_server_id"],
+				"request_id", string(note.Params.RequestID),
+				"reason", note.Params.Reason)
+		}
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// credentialID identifies what authenticated an MCP request: the API key,
+// or the grant of an OAuth access token.
+func credentialID(ctx context.Context) string {
+	if id := ck.GetAPIKeyIDFromContext(ctx); id != "" {
+		return id
+	}
+	return ck.GetOAuthGrantIDFromContext(ctx)
+}
+
+// callKey identifies a client request: JSON-RPC ids are only unique per
+// client, so the key includes the virtual server, credential and MCP session.
+func callKey(r *http.Request, id json.RawMessage) string {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, id); err != nil {
+		compact.Write(id)
+	}
+	return strings.Join([]string{
+		mux.Vars(r)["virtual_server_id"],
+		credentialID(r.Context()),
+		r.Header.Get(mserver.HeaderKeySessionID),
+		compact.String(),
+	}, "\x00")
+}
+
+// inflightCalls tracks the cancel functions of running tools/call requests.
+type inflightCalls struct {
+	mu    sync.Mutex
+	calls map[string]context.CancelFunc
+}
+
+func newInflightCalls() *inflightCalls {
+	return &inflightCalls{calls: map[string]context.CancelFunc{}}
+}
+
+// add registers cancel under key and returns a func that removes it.
+func (c *inflightCalls) add(key string, cancel context.CancelFunc) func() {
+	c.mu.Lock()
+	c.calls[key] = cancel
+	c.mu.Unlock()
+	return func() {
+		c.mu.Lock()
+		delete(c.calls, key)
+		c.mu.Unlock()
+	}
+}
+
+// cancel cancels the call registered under key and reports whether there
+// was one.
+func (c *inflightCalls) cancel(key string) bool {
+	c.mu.Lock()
+	cancel, ok := c.calls[key]
+	c.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// toolCallOutcome classifies a proxied tool call for metrics.
+func toolCallOutcome(res *mcp.CallToolResult, err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return metrics.OutcomeCancelled
+	case err != nil:
+		return metrics.OutcomeError
+	case res != nil && res.IsError:
+		return metrics.OutcomeToolError
+	default:
+		return metrics.OutcomeOK
+	}
+}
+
+// maxAuditErrorLen caps the error text stored per audit record.
+const maxAuditErrorLen = 2000
+
+// auditToolCall queues an audit record for a proxied tool call. Arguments
+// are the ones the client sent, redacted by the audit service. A held
+// call is recorded with the decision on its approval.
+func (p *proxyHTTPHandler) auditToolCall(
+	r *http.Request,
+	target upstreamTarget,
+	tool *m.VirtualServerTool,
+	args map[string]any,
+	res *mcp.CallToolResult,
+	callErr error,
+	latency time.Duration,
+	held *m.ToolCallApproval,
+) {
+	if p.deps.Audit == nil {
+		return
+	}
+	ctx := r.Context()
+	rec := m.ToolCallAudit{
+		VirtualServerID: mux.Vars(r)["virtual_server_id"],
+		UserID:          target.ownerID,
+		ToolID:          tool.ID,
+		ToolName:        tool.ExposedName,
+		SessionID:       truncate(r.Header.Get(mserver.HeaderKeySessionID), 128),
+		RequestID:       truncate(ck.GetRequestIDFromContext(ctx), 64),
+		Arguments:       p.deps.Audit.RedactArguments(args),
+		LatencyMS:       latency.Milliseconds(),
+	}
+	if target.hubID != "" {
+		rec.HubServerID = &target.hubID
+	}
+	if keyID := ck.GetAPIKeyIDFromContext(ctx); keyID != "" {
+		rec.APIKeyID = &keyID
+	}
+	if held != nil {
+		rec.ApprovalID = &held.ID
+		rec.ApprovalStatus = held.Status
+	}
+	switch {
+	case errors.Is(callErr, context.Canceled):
+		rec.Cancelled = true
+	case callErr != nil:
+		rec.IsError = true
+		rec.Error = truncate(callErr.Error(), maxAuditErrorLen)
+	case res != nil:
+		if b, err := json.Marshal(res); err == nil {
+			rec.ResultBytes = len(b)
+		}
+		rec.IsError = res.IsError
+		if res.IsError {
+			for _, c := range res.Content {
+				if tc, ok := c.(mcp.TextContent); ok {
+					rec.Error = truncate(tc.Text, maxAuditErrorLen)
+					break
+				}
+			}
+		}
+	}
+	p.deps.Audit.Record(rec)
+}
+
+// truncate shortens s to at most n bytes.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+// unreachableServers returns the upstream servers whose hub, for the
+// virtual server's owner, is marked UNREACHABLE or NEEDS_AUTH.
+func (p *proxyHTTPHandler) unreachableServers(
+	ctx context.Context, vs m.MCPVirtualServer,
+) (map[string]bool, error) {
+	var (
+		hubs []m.MCPHubServerAggregate
+		err  error
+	)
+	if vs.TeamID != nil {
+		hubs, err = p.deps.Hubs.ListForTeams(ctx, []string{*vs.TeamID})
+	} else {
+		hubs, err = p.deps.Hubs.ListForUser(ctx, vs.UserID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]bool{}
+	for _, h := range hubs {
+		if h.Status == m.StatusUnreachable || h.Status == m.StatusNeedsAuth {
+			out[h.MCPServerID] = true
+		}
+	}
+	return out, nil
+}
+
+// upstreamTarget is the hub a proxied request is sent to and the virtual
+// server owner it is sent for.
+type upstreamTarget struct {
+	up                 mcpclient.Upstream
+	hubID              string
+	ownerID            string
+	argValidation      m.ArgValidation
+	approveDestructive bool
+	mode               m.AccessMode
+	annotationFilter   json.RawMessage
+}
+
+// upstreamFor resolves how to reach the hub that serves an upstream server
+// for the virtual server's owner, including its credentials. It writes the
+// JSON-RPC error when it returns false.
+func (p *proxyHTTPHandler) upstreamFor(
+	w http.ResponseWriter,
+	r *http.Request,
+	id json.RawMessage,
+	vsID string,
+	serverID string,
+) (upstreamTarget, bool) {
 	// Authorization: VS owner must have this server in their hub
 	vs, err := p.deps.Virtual.GetByID(r.Context(), vsID)
 	if err != nil {
 		writeRPCError(w, id, mcp.INVALID_REQUEST, "virtual server not found")
-		return
+		return upstreamTarget{}, false
 	}
-	hub, err := p.deps.Hubs.GetByServerAndUser(
-		r.Context(), found.MCPServerID, vs.UserID,
-	)
+	hub, err := p.hubFor(r.Context(), vs, serverID)
 	if err != nil {
 		writeRPCError(w, id, mcp.INVALID_PARAMS, "unauthorized")
+		return upstreamTarget{}, false
+	}
+	if hub.Status == m.StatusUnreachable {
+		// Fail fast rather than waiting for the upstream to time out
+		msg := "upstream server " + hub.Name + " is unreachable"
+		if hub.LastProbeError != "" {
+			msg += ": " + hub.LastProbeError
+		}
+		writeRPCError(w, id, rpcUpstreamUnreachable, msg)
+		return upstreamTarget{}, false
+	}
+	var headers map[string]string
+	if hub.Status != m.StatusNeedsAuth {
+		// May refresh an OAuth token, or find it can no longer be refreshed
+		headers = mcpclient.BuildUpstreamHeaders(r.Context(),
+			p.deps.Logger, p.deps.Encrypter, p.deps.Hubs, &hub.MCPHubServer,
+		)
+	}
+	if hub.Status == m.StatusNeedsAuth {
+		msg := "upstream server " + hub.Name + " needs to be authorized again"
+		if hub.AuthError != "" {
+			msg += ": " + hub.AuthError
+		}
+		writeRPCError(w, id, rpcUpstreamUnreachable, msg)
+		return upstreamTarget{}, false
+	}
+	up := mcpclient.NewHubUpstream(hub, headers)
+	if hub.IdentityHeader != "" && p.deps.Identity != nil &&
+		hub.Transport != m.TransportStdio {
+		token, err := p.identityToken(r, vsID, hub.URL)
+		if err != nil {
+			writeRPCError(w, id, mcp.INTERNAL_ERROR,
+				"failed to sign identity token")
+			return upstreamTarget{}, false
+		}
+		up.CallHeaders = map[string]string{hub.IdentityHeader: token}
+	}
+	return upstreamTarget{
+		up:                 up,
+		hubID:              hub.ID,
+		ownerID:            vs.UserID,
+		argValidation:      vs.ArgValidation,
+		approveDestructive: vs.ApproveDestructive,
+		mode:               vs.Mode,
+		annotationFilter:   vs.AnnotationFilter,
+	}, true
+}
+
+// hubFor returns the hub that serves an upstream server for a virtual
+// server: its team's hub for team virtual servers, else its owner's.
+func (p *proxyHTTPHandler) hubFor(
+	ctx context.Context, vs m.MCPVirtualServer, serverID string,
+) (m.MCPHubServerAggregate, error) {
+	if vs.TeamID != nil {
+		return p.deps.Hubs.GetByServerAndTeam(ctx, serverID, *vs.TeamID)
+	}
+	return p.deps.Hubs.GetByServerAndUser(ctx, serverID, vs.UserID)
+}
+
+// identityToken signs a token telling the upstream at audience who is
+// calling through the virtual server.
+func (p *proxyHTTPHandler) identityToken(
+	r *http.Request, vsID, audience string,
+) (string, error) {
+	ctx := r.Context()
+	caller := identity.Caller{
+		UserID:          ck.GetUserIDFromContext(ctx),
+		VirtualServerID: vsID,
+		APIKeyID:        ck.GetAPIKeyIDFromContext(ctx),
+		OAuthGrantID:    ck.GetOAuthGrantIDFromContext(ctx),
+	}
+	// Users who signed in with Google are named by their email address
+	u, err := p.deps.UserService.FindUserByID(ctx, caller.UserID)
+	if err == nil && strings.Contains(u.Username, "@") {
+		caller.Email = u.Username
+	}
+	return p.deps.Identity.Mint(publicBaseURL(p.deps), audience, caller)
+}
+
+func (p *proxyHTTPHandler) handleListResources(
+	w http.ResponseWriter,
+	r *http.Request,
+	id json.RawMessage,
+) {
+	vsID := mux.Vars(r)["virtual_server_id"]
+	items, err := p.deps.Resources.ListForVirtualServer(r.Context(), vsID)
+	if err != nil {
+		writeRPCError(w, id, mcp.INTERNAL_ERROR, err.Error())
 		return
 	}
+	resources := make([]mcp.Resource, 0, len(items))
+	for _, it := range items {
+		if it.IsTemplate || it.Status != m.StatusActive {
+			continue
+		}
+		resources = append(resources, CreateMCPResource(it))
+	}
+	writeRPCResult(w, id, mcp.ListResourcesResult{Resources: resources})
+}
 
-	headers := mcpclient.BuildUpstreamHeaders(
-		p.deps.Logger, p.deps.Encrypter, &hub.MCPHubServer,
-	)
+func (p *proxyHTTPHandler) handleListResourceTemplates(
+	w http.ResponseWriter,
+	r *http.Request,
+	id json.RawMessage,
+) {
+	vsID := mux.Vars(r)["virtual_server_id"]
+	items, err := p.deps.Resources.ListForVirtualServer(r.Context(), vsID)
+	if err != nil {
+		writeRPCError(w, id, mcp.INTERNAL_ERROR, err.Error())
+		return
+	}
+	templates := make([]mcp.ResourceTemplate, 0, len(items))
+	for _, it := range items {
+		if !it.IsTemplate || it.Status != m.StatusActive {
+			continue
+		}
+		tpl, err := CreateMCPResourceTemplate(it)
+		if err != nil {
+			p.deps.Logger.Error("RESOURCE_TEMPLATE_PARSE_ERROR",
+				"resource_id", it.ID, "error", err)
+			continue
+		}
+		templates = append(templates, tpl)
+	}
+	writeRPCResult(w, id,
+		mcp.ListResourceTemplatesResult{ResourceTemplates: templates})
+}
 
-	// Extract arguments
-	args := map[string]any{}
-	if req.Params.Arguments != nil {
-		if m, ok := req.Params.Arguments.(map[string]any); ok {
-			args = m
+func (p *proxyHTTPHandler) handleReadResource(
+	w http.ResponseWriter,
+	r *http.Request,
+	id json.RawMessage,
+	body []byte,
+) {
+	vsID := mux.Vars(r)["virtual_server_id"]
+
+	var req mcp.ReadResourceRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeRPCError(w, id, mcp.INVALID_REQUEST, "invalid read_resource")
+		return
+	}
+	uri := req.Params.URI
+
+	items, err := p.deps.Resources.ListForVirtualServer(r.Context(), vsID)
+	if err != nil {
+		writeRPCError(w, id, mcp.INTERNAL_ERROR, err.Error())
+		return
+	}
+	found := matchResource(items, uri)
+	if found == nil {
+		writeRPCError(w, id, mcp.RESOURCE_NOT_FOUND, "resource not found")
+		return
+	}
+
+	target, ok := p.upstreamFor(w, r, id, vsID, found.MCPServerID)
+	if !ok {
+		return
+	}
+	res, err := p.deps.Pool.ReadResource(r.Context(), target.up, uri)
+	if err != nil {
+		writeRPCError(w, id, mcp.INTERNAL_ERROR, err.Error())
+		return
+	}
+	writeRPCResult(w, id, res)
+}
+
+func (p *proxyHTTPHandler) handleListPrompts(
+	w http.ResponseWriter,
+	r *http.Request,
+	id json.RawMessage,
+) {
+	vsID := mux.Vars(r)["virtual_server_id"]
+	items, err := p.deps.Prompts.ListForVirtualServer(r.Context(), vsID)
+	if err != nil {
+		writeRPCError(w, id, mcp.INTERNAL_ERROR, err.Error())
+		return
+	}
+	prompts := make([]mcp.Prompt, 0, len(items))
+	for _, it := range items {
+		if it.Status != m.StatusActive {
+			continue
+		}
+		prompts = append(prompts, CreateMCPPrompt(it))
+	}
+	writeRPCResult(w, id, mcp.ListPromptsResult{Prompts: prompts})
+}
+
+func (p *proxyHTTPHandler) handleGetPrompt(
+	w http.ResponseWriter,
+	r *http.Request,
+	id json.RawMessage,
+	body []byte,
+) {
+	vsID := mux.Vars(r)["virtual_server_id"]
+
+	var req mcp.GetPromptRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeRPCError(w, id, mcp.INVALID_REQUEST, "invalid get_prompt")
+		return
+	}
+
+	items, err := p.deps.Prompts.ListForVirtualServer(r.Context(), vsID)
+	if err != nil {
+		writeRPCError(w, id, mcp.INTERNAL_ERROR, err.Error())
+		return
+	}
+	var found *m.MCPPrompt
+	for i := range items {
+		if items[i].Name == req.Params.Name &&
+			items[i].Status == m.StatusActive {
+			found = &items[i]
+			break
 		}
 	}
+	if found == nil {
+		writeRPCError(w, id, mcp.INVALID_PARAMS, "prompt not found")
+		return
+	}
 
-	res, err := mcpclient.CallTool(
-		r.Context(), hub.URL, found.OriginalName, args, headers,
+	target, ok := p.upstreamFor(w, r, id, vsID, found.MCPServerID)
+	if !ok {
+		return
+	}
+	res, err := p.deps.Pool.GetPrompt(
+		r.Context(), target.up, found.Name, req.Params.Arguments,
 	)
 	if err != nil {
 		writeRPCError(w, id, mcp.INTERNAL_ERROR, err.Error())
@@ -199,3 +1003,29 @@ func (p *proxyHTTPHandler) handleCallTool(
 	}
 	writeRPCResult(w, id, res)
 }
+
+// matchResource finds the virtual server resource serving uri: an exact
+// resource URI wins over a matching resource template.
+func matchResource(items []m.MCPResource, uri string) *m.MCPResource {
+	var tplMatch *m.MCPResource
+	for i := range items {
+		it := &items[i]
+		if it.Status != m.StatusActive {
+			continue
+		}
+		if !it.IsTemplate {
+			if it.URI == uri {
+				return it
+			}
+			continue
+		}
+		if tplMatch != nil {
+			continue
+		}
+		tpl, err := uritemplate.New(it.URI)
+		if err == nil && tpl.Match(uri) != nil {
+			tplMatch = it
+		}
+	}
+	return tplMatch
+}
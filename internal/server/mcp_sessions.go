@@ -0,0 +1,258 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mark3labs/mcp-go/mcp"
+	mserver "github.com/mark3labs/mcp-go/server"
+
+	"github.com/ChiragChiranjib/mcp-proxy/internal/events"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/session"
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+// defaultSessionHeartbeat is how often an idle GET stream is pinged and
+// its session re-checked when no interval is configured.
+const defaultSessionHeartbeat = 30 * time.Second
+
+// methodNotificationMessage is the MCP log message notification method.
+const methodNotificationMessage = "notifications/message"
+
+// sessionKey stashes the MCP session of a stateful request in context.
+type sessionKey struct{}
+
+// sessionFromContext returns the MCP session of a stateful request.
+func sessionFromContext(ctx context.Context) (m.MCPSession, bool) {
+	s, ok := ctx.Value(sessionKey{}).(m.MCPSession)
+	return s, ok
+}
+
+// stateful reports whether the virtual server in the path runs in
+// stateful session mode. Lookup errors fall back to stateless handling,
+// which reports them.
+func (p *proxyHTTPHandler) stateful(r *http.Request) bool {
+	if p.deps.Sessions == nil {
+		return false
+	}
+	vs, err := p.deps.Virtual.GetByID(
+		r.Context(), mux.Vars(r)["virtual_server_id"])
+	return err == nil && vs.SessionMode == m.SessionModeStateful
+}
+
+// session resolves the MCP session of a POST to a stateful virtual server.
+// initialize starts a new session and returns its id in the Mcp-Session-Id
+// response header; every other message must carry a live session id. It
+// writes the error response when it returns false.
+func (p *proxyHTTPHandler) session(
+	w http.ResponseWriter,
+	r *http.Request,
+	id json.RawMessage,
+	method mcp.MCPMethod,
+	body []byte,
+) (*http.Request, bool) {
+	if method != mcp.MethodInitialize {
+		sess, ok := p.lookupSession(w, r, id)
+		if !ok {
+			return r, false
+		}
+		return withSession(r, sess), true
+	}
+
+	var req mcp.InitializeRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeRPCError(w, id, mcp.INVALID_REQUEST, "invalid initialize")
+		return r, false
+	}
+	vsID := mux.Vars(r)["virtual_server_id"]
+	sess, err := p.deps.Sessions.Create(r.Context(), vsID,
+		credentialID(r.Context()), req.Params)
+	if err != nil {
+		p.deps.Logger.Error("MCP_SESSION_CREATE_ERROR",
+			"vs_id", vsID, "error", err)
+		writeRPCError(w, id, mcp.INTERNAL_ERROR, "could not create session")
+		return r, false
+	}
+	w.Header().Set(mserver.HeaderKeySessionID, sess.ID)
+	return withSession(r, sess), true
+}
+
+func withSession(r *http.Request, sess m.MCPSession) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), sessionKey{}, sess))
+}
+
+// lookupSession validates the Mcp-Session-Id of a request to a stateful
+// virtual server: 400 when it is missing, 404 when it is unknown or has
+// expired, in which case the client must initialize again.
+func (p *proxyHTTPHandler) lookupSession(
+	w http.ResponseWriter, r *http.Request, id json.RawMessage,
+) (m.MCPSession, bool) {
+	sid := r.Header.Get(mserver.HeaderKeySessionID)
+	if sid == "" {
+		writeRPCErrorStatus(w, http.StatusBadRequest, id,
+			mcp.INVALID_REQUEST, "missing "+mserver.HeaderKeySessionID+" header")
+		return m.MCPSession{}, false
+	}
+	sess, err := p.deps.Sessions.Get(r.Context(), sid,
+		mux.Vars(r)["virtual_server_id"], credentialID(r.Context()))
+	switch {
+	case errors.Is(err, session.ErrNotFound):
+		writeRPCErrorStatus(w, http.StatusNotFound, id,
+			mcp.INVALID_REQUEST, "session not found")
+		return m.MCPSession{}, false
+	case err != nil:
+		p.deps.Logger.Error("MCP_SESSION_LOOKUP_ERROR",
+			"session_id", sid, "error", err)
+		writeRPCErrorStatus(w, http.StatusInternalServerError, id,
+			mcp.INTERNAL_ERROR, "session lookup failed")
+		return m.MCPSession{}, false
+	}
+	return sess, true
+}
+
+// handleSetLevel stores the minimum level of log messages forwarded to a
+// stateful session.
+func (p *proxyHTTPHandler) handleSetLevel(
+	w http.ResponseWriter,
+	r *http.Request,
+	id json.RawMessage,
+	body []byte,
+) {
+	var req mcp.SetLevelRequest
+	if err := json.Unmarshal(body, &req); err != nil ||
+		!req.Params.Level.ShouldSendTo(req.Params.Level) {
+		writeRPCError(w, id, mcp.INVALID_PARAMS, "invalid logging level")
+		return
+	}
+	sess, _ := sessionFromContext(r.Context())
+	if err := p.deps.Sessions.SetLogLevel(
+		r.Context(), sess.ID, req.Params.Level); err != nil {
+		writeRPCError(w, id, mcp.INTERNAL_ERROR, err.Error())
+		return
+	}
+	writeRPCResult(w, id, mcp.EmptyResult{})
+}
+
+// handleEndSession ends a stateful session on DELETE.
+func (p *proxyHTTPHandler) handleEndSession(
+	w http.ResponseWriter, r *http.Request,
+) {
+	sess, ok := p.lookupSession(w, r, nil)
+	if !ok {
+		return
+	}
+	if err := p.deps.Sessions.End(r.Context(), sess.ID); err != nil {
+		p.deps.Logger.Error("MCP_SESSION_END_ERROR",
+			"session_id", sess.ID, "error", err)
+		http.Error(w, "session termination failed",
+			http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleStream serves the GET event stream of a stateful session, which
+// carries server-initiated messages. The stream is pinged with an SSE
+// comment every heartbeat; the session is re-checked at the same time so
+// a session ended on another replica closes the stream.
+func (p *proxyHTTPHandler) handleStream(w http.ResponseWriter, r *http.Request) {
+	if !acceptsEventStream(r) {
+		http.Error(w, "Accept must include text/event-stream",
+			http.StatusNotAcceptable)
+		return
+	}
+	sess, ok := p.lookupSession(w, r, nil)
+	if !ok {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	stream, closeStream, err := p.deps.Sessions.OpenStream(
+		sess.ID, sess.VirtualServerID)
+	if err != nil {
+		http.Error(w, "session already has an open stream", http.StatusConflict)
+		return
+	}
+	defer closeStream()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(p.sessionHeartbeat())
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-stream.Done():
+			return
+		case msg := <-stream.Messages():
+			b, err := json.Marshal(msg)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: message\ndata: %s\n\n", b); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			if _, err := p.deps.Sessions.Get(r.Context(), sess.ID,
+				sess.VirtualServerID, sess.APIKeyID); err != nil {
+				return
+			}
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// onEvent forwards change events to the streams of the affected virtual
+// servers' sessions.
+func (p *proxyHTTPHandler) onEvent(ev events.Event) {
+	if ev.Kind != events.KindToolsChanged {
+		return
+	}
+	note := mcp.JSONRPCNotification{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		Notification: mcp.Notification{
+			Method: mcp.MethodNotificationToolsListChanged,
+		},
+	}
+	for _, vsID := range ev.VirtualServerIDs {
+		n := p.deps.Sessions.PublishToVirtualServer(vsID, note)
+		p.deps.Logger.Debug("MCP_TOOLS_LIST_CHANGED_SENT",
+			"vs_id", vsID, "streams", n)
+	}
+}
+
+func (p *proxyHTTPHandler) sessionHeartbeat() time.Duration {
+	if p.deps.AppConfig != nil && p.deps.AppConfig.Sessions.HeartbeatSeconds > 0 {
+		return time.Duration(p.deps.AppConfig.Sessions.HeartbeatSeconds) *
+			time.Second
+	}
+	return defaultSessionHeartbeat
+}
+
+// logLevelAllows reports whether a notification may be forwarded to a
+// session that asked for log messages of at least minLevel. Notifications
+// other than log messages always pass.
+func logLevelAllows(note mcp.JSONRPCNotification, minLevel string) bool {
+	if minLevel == "" || note.Method != methodNotificationMessage {
+		return true
+	}
+	level, _ := note.Params.AdditionalFields["level"].(string)
+	return mcp.LoggingLevel(level).ShouldSendTo(mcp.LoggingLevel(minLevel))
+}
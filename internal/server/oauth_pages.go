@@ -0,0 +1,166 @@
+package server
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+// loginPage asks the user to sign in before authorizing a client. Both
+// forms post to the existing auth endpoints, which set the session cookie,
+// and then reload the authorization request.
+type loginPage struct {
+	Client         string
+	GoogleClientID string
+	AuthPrefix     string
+	// Providers link to OpenID Connect sign in, returning to this page
+	Providers []oidcProviderLink
+}
+
+// consentPage asks the user which virtual servers to grant a client.
+type consentPage struct {
+	Client   string
+	Redirect string
+	User     string
+	Servers  []consentServer
+	Request  authorizeRequest
+}
+
+type consentServer struct {
+	ID      string
+	Name    string
+	Checked bool
+}
+
+type errorPage struct {
+	Message string
+}
+
+var oauthPages = template.Must(template.New("oauth").Parse(`
+{{define "head"}}<!doctype html>
+<html lang="en"><head><meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>MCP Proxy authorization</title>
+<style>
+body{font-family:system-ui,sans-serif;background:#f6f7f9;margin:0}
+main{max-width:26rem;margin:4rem auto;background:#fff;padding:2rem;
+border-radius:.5rem;box-shadow:0 1px 3px rgba(0,0,0,.1)}
+h1{font-size:1.25rem;margin-top:0}
+label{display:block;margin:.5rem 0}
+input[type=text],input[type=password]{width:100%;padding:.5rem;
+box-sizing:border-box}
+button{padding:.5rem 1rem;margin-right:.5rem}
+.muted{color:#666;font-size:.875rem}
+.error{color:#b00020}
+</style></head><body><main>{{end}}
+
+{{define "foot"}}</main></body></html>{{end}}
+
+{{define "error"}}{{template "head"}}
+<h1>Authorization failed</h1>
+<p class="error">{{.Message}}</p>
+{{template "foot"}}{{end}}
+
+{{define "login"}}{{template "head"}}
+<h1>Sign in to authorize {{.Client}}</h1>
+<p id="err" class="error"></p>
+{{range .Providers}}
+<p><a href="{{.LoginURL}}">Sign in with {{.DisplayName}}</a></p>
+{{end}}
+{{if .Providers}}<p class="muted">or</p>{{end}}
+{{if .GoogleClientID}}
+<script src="https://accounts.google.com/gsi/client" async></script>
+<div id="g_id_onload" data-client_id="{{.GoogleClientID}}"
+ data-callback="onGoogle"></div>
+<div class="g_id_signin" data-type="standard"></div>
+<p class="muted">or</p>
+{{end}}
+<form id="basic">
+<label>Username <input type="text" name="username" required></label>
+<label>Password <input type="password" name="password" required></label>
+<button type="submit">Sign in</button>
+</form>
+<script>
+const authPrefix = {{.AuthPrefix}};
+function login(path, body) {
+  fetch(authPrefix + path, {
+    method: "POST",
+    headers: {"Content-Type": "application/json"},
+    body: JSON.stringify(body),
+  }).then(function (res) {
+    if (res.ok) { location.reload(); return; }
+    document.getElementById("err").textContent = "Sign in failed.";
+  });
+}
+function onGoogle(r) { login("/google", {credential: r.credential}); }
+document.getElementById("basic").addEventListener("submit", function (e) {
+  e.preventDefault();
+  login("/basic", {
+    username: e.target.username.value,
+    password: e.target.password.value,
+  });
+});
+</script>
+{{template "foot"}}{{end}}
+
+{{define "consent"}}{{template "head"}}
+<h1>Authorize {{.Client}}</h1>
+<p>{{.Client}} wants to call tools on your behalf on the virtual servers
+you select. You will be returned to <strong>{{.Redirect}}</strong>.</p>
+<form method="post">
+{{range .Servers}}
+<label><input type="checkbox" name="vs" value="{{.ID}}"
+{{if .Checked}}checked{{end}}> {{.Name}} <span class="muted">{{.ID}}</span>
+</label>
+{{end}}
+{{with .Request}}
+<input type="hidden" name="client_id" value="{{.ClientID}}">
+<input type="hidden" name="redirect_uri" value="{{.RedirectURI}}">
+<input type="hidden" name="response_type" value="{{.ResponseType}}">
+<input type="hidden" name="state" value="{{.State}}">
+<input type="hidden" name="scope" value="{{.Scope}}">
+<input type="hidden" name="code_challenge" value="{{.CodeChallenge}}">
+<input type="hidden" name="code_challenge_method"
+ value="{{.CodeChallengeMethod}}">
+{{end}}
+<p>
+<button type="submit" name="decision" value="approve">Allow</button>
+<button type="submit" name="decision" value="deny">Deny</button>
+</p>
+</form>
+<p class="muted">Signed in as {{.User}}</p>
+{{template "foot"}}{{end}}
+`))
+
+// renderOAuthPage writes one of the authorization pages. The pages may not
+// be framed, so consent cannot be clickjacked.
+func renderOAuthPage(w http.ResponseWriter, status int, page string, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.WriteHeader(status)
+	_ = oauthPages.ExecuteTemplate(w, page, data)
+}
+
+// clientLabel names a client on the authorization pages.
+func clientLabel(c m.OAuthClient) string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return "client " + c.ID
+}
+
+// redirectHost shows where a redirect URI leads: its host, or its scheme
+// for native apps.
+func redirectHost(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	if u.Host != "" {
+		return u.Host
+	}
+	return u.Scheme + ":"
+}
@@ -0,0 +1,589 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	ck "github.com/ChiragChiranjib/mcp-proxy/internal/contextkey"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/oauth"
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+const (
+	// authServerMetadataPath serves RFC 8414 authorization server metadata.
+	authServerMetadataPath = "/.well-known/oauth-authorization-server"
+	// protectedResourcePath prefixes RFC 9728 protected resource metadata;
+	// the metadata of an MCP endpoint is at this path followed by its own.
+	protectedResourcePath = "/.well-known/oauth-protected-resource"
+
+	oauthAuthorizePath = "/oauth/authorize"
+	oauthTokenPath     = "/oauth/token"
+	oauthRegisterPath  = "/oauth/register"
+	oauthRevokePath    = "/oauth/revoke"
+
+	grantAuthorizationCode = "authorization_code"
+	grantRefreshToken      = "refresh_token"
+
+	maxRegistrationBytes = 64 << 10
+)
+
+// mcpResourceRE extracts the virtual server id from the path of an MCP
+// endpoint URL.
+var mcpResourceRE = regexp.MustCompile(`^/servers/([A-Za-z0-9_-]+)/mcp/?$`)
+
+// oauthGrantTypes are the grant types clients may use.
+var oauthGrantTypes = []string{grantAuthorizationCode, grantRefreshToken}
+
+// addOAuthRoutes mounts the OAuth 2.1 authorization server that lets MCP
+// clients obtain access tokens for virtual servers, when it is enabled.
+// Users sign in on the authorization page with the existing Google or
+// basic login, then choose which of their virtual servers to grant.
+func addOAuthRoutes(r *mux.Router, deps Deps, cfg Config) {
+	if deps.OAuth == nil {
+		return
+	}
+
+	// Authorization server metadata (RFC 8414)
+	r.HandleFunc(authServerMetadataPath, allowCORS(
+		func(w http.ResponseWriter, r *http.Request) {
+			issuer := publicBaseURL(deps)
+			doc := map[string]any{
+				"issuer":                                     issuer,
+				"authorization_endpoint":                     issuer + oauthAuthorizePath,
+				"token_endpoint":                             issuer + oauthTokenPath,
+				"registration_endpoint":                      issuer + oauthRegisterPath,
+				"revocation_endpoint":                        issuer + oauthRevokePath,
+				"response_types_supported":                   []string{"code"},
+				"grant_types_supported":                      oauthGrantTypes,
+				"code_challenge_methods_supported":           []string{"S256"},
+				"token_endpoint_auth_methods_supported":      []string{"none"},
+				"revocation_endpoint_auth_methods_supported": []string{"none"},
+			}
+			doc["authorization_response_iss_parameter_supported"] = true
+			WriteJSON(w, http.StatusOK, doc)
+		})).Methods(http.MethodGet, http.MethodOptions)
+
+	// Protected resource metadata (RFC 9728), for the gateway and for
+	// each MCP endpoint
+	r.PathPrefix(protectedResourcePath).HandlerFunc(allowCORS(
+		func(w http.ResponseWriter, r *http.Request) {
+			issuer := publicBaseURL(deps)
+			doc := map[string]any{
+				"resource":                 issuer,
+				"authorization_servers":    []string{issuer},
+				"bearer_methods_supported": []string{"header"},
+			}
+			rest := strings.TrimPrefix(r.URL.Path, protectedResourcePath)
+			if rest != "" {
+				match := mcpResourceRE.FindStringSubmatch(rest)
+				if match == nil {
+					WriteJSON(w, http.StatusNotFound,
+						map[string]string{"error": "unknown resource"})
+					return
+				}
+				doc["resource"] = issuer + rest
+				doc["scopes_supported"] = []string{oauth.Scope(match[1:])}
+			}
+			WriteJSON(w, http.StatusOK, doc)
+		})).Methods(http.MethodGet, http.MethodOptions)
+
+	// Dynamic client registration (RFC 7591). Only public clients are
+	// registered; they authenticate with PKCE.
+	r.HandleFunc(oauthRegisterPath, allowCORS(
+		func(w http.ResponseWriter, r *http.Request) {
+			deps.Logger.Info("OAUTH_REGISTER_INIT")
+			var body struct {
+				ClientName    string   `json:"client_name"`
+				RedirectURIs  []string `json:"redirect_uris"`
+				GrantTypes    []string `json:"grant_types"`
+				ResponseTypes []string `json:"response_types"`
+			}
+			err := json.NewDecoder(
+				io.LimitReader(r.Body, maxRegistrationBytes)).Decode(&body)
+			if err != nil {
+				writeOAuthError(w, http.StatusBadRequest,
+					"invalid_client_metadata", "body must be a JSON object")
+				return
+			}
+			for _, g := range body.GrantTypes {
+				if g != grantAuthorizationCode && g != grantRefreshToken {
+					writeOAuthError(w, http.StatusBadRequest,
+						"invalid_client_metadata", "unsupported grant type "+g)
+					return
+				}
+			}
+			for _, t := range body.ResponseTypes {
+				if t != "code" {
+					writeOAuthError(w, http.StatusBadRequest,
+						"invalid_client_metadata", "unsupported response type "+t)
+					return
+				}
+			}
+			c, err := deps.OAuth.Register(r.Context(), oauth.Registration{
+				Name:         body.ClientName,
+				RedirectURIs: body.RedirectURIs,
+			})
+			if errors.Is(err, oauth.ErrInvalidClientMetadata) {
+				deps.Logger.Info("OAUTH_REGISTER_INVALID", "error", err)
+				writeOAuthError(w, http.StatusBadRequest,
+					"invalid_redirect_uri", err.Error())
+				return
+			}
+			if err != nil {
+				deps.Logger.Error("OAUTH_REGISTER_ERROR", "error", err)
+				writeOAuthError(w, http.StatusInternalServerError,
+					"server_error", "could not register client")
+				return
+			}
+			deps.Logger.Info("OAUTH_REGISTER_SUCCESS", "client_id", c.ID)
+			w.Header().Set("Cache-Control", "no-store")
+			WriteJSON(w, http.StatusCreated, map[string]any{
+				"client_id":                  c.ID,
+				"client_id_issued_at":        c.CreatedAt.Unix(),
+				"client_name":                c.Name,
+				"redirect_uris":              body.RedirectURIs,
+				"grant_types":                oauthGrantTypes,
+				"response_types":             []string{"code"},
+				"token_endpoint_auth_method": "none",
+			})
+		})).Methods(http.MethodPost, http.MethodOptions)
+
+	// Authorization endpoint: sign in, then consent
+	r.HandleFunc(oauthAuthorizePath,
+		func(w http.ResponseWriter, r *http.Request) {
+			req := parseAuthorizeRequest(r.URL.Query())
+			deps.Logger.Info("OAUTH_AUTHORIZE_INIT", "client_id", req.ClientID)
+			client, ok := authorizeClient(w, r, deps, req)
+			if !ok || !checkAuthorizeRequest(w, r, deps, req) {
+				return
+			}
+			ids, errCode := requestedServers(req)
+			if errCode != "" {
+				redirectAuthorizeError(w, r, deps, req, errCode,
+					"resource is not an MCP endpoint of this gateway")
+				return
+			}
+
+			uid := ck.GetUserIDFromContext(r.Context())
+			if uid == "" {
+				google := ""
+				if deps.AppConfig != nil {
+					google = deps.AppConfig.Google.ClientID
+				}
+				renderOAuthPage(w, http.StatusOK, "login", loginPage{
+					Client:         clientLabel(client),
+					GoogleClientID: google,
+					AuthPrefix:     cfg.AdminPrefix + "/auth",
+					Providers: oidcProviderLinks(deps, cfg,
+						r.URL.RequestURI()),
+				})
+				return
+			}
+
+			var servers []m.MCPVirtualServer
+			if len(ids) > 0 {
+				for _, id := range ids {
+					vs, err := grantableServer(r, deps, id)
+					if err != nil {
+						redirectAuthorizeError(w, r, deps, req, "invalid_scope",
+							"virtual server "+id+" cannot be granted")
+						return
+					}
+					servers = append(servers, vs)
+				}
+			} else {
+				rows, err := listVirtualServers(
+					r, deps, m.Owner{UserID: uid})
+				if err != nil {
+					deps.Logger.Error("OAUTH_AUTHORIZE_LIST_ERROR", "error", err)
+					renderOAuthPage(w, http.StatusInternalServerError, "error",
+						errorPage{Message: "Could not list your virtual servers."})
+					return
+				}
+				servers = rows
+			}
+			if len(servers) == 0 {
+				renderOAuthPage(w, http.StatusOK, "error", errorPage{
+					Message: "You have no virtual servers to grant access to."})
+				return
+			}
+
+			page := consentPage{
+				Client:   clientLabel(client),
+				Redirect: redirectHost(req.RedirectURI),
+				User:     ck.GetUserEmailFromContext(r.Context()),
+				Request:  req,
+			}
+			for _, vs := range servers {
+				page.Servers = append(page.Servers, consentServer{
+					ID:      vs.ID,
+					Name:    vs.Name,
+					Checked: slices.Contains(ids, vs.ID),
+				})
+			}
+			renderOAuthPage(w, http.StatusOK, "consent", page)
+		}).Methods(http.MethodGet)
+
+	// Consent decision
+	r.HandleFunc(oauthAuthorizePath,
+		func(w http.ResponseWriter, r *http.Request) {
+			uid := ck.GetUserIDFromContext(r.Context())
+			if uid == "" {
+				renderOAuthPage(w, http.StatusUnauthorized, "error",
+					errorPage{Message: "Your session has expired; start again."})
+				return
+			}
+			if origin := r.Header.Get("Origin"); origin != "" &&
+				origin != publicBaseURL(deps) {
+				deps.Logger.Info("OAUTH_AUTHORIZE_FOREIGN_ORIGIN", "origin", origin)
+				renderOAuthPage(w, http.StatusForbidden, "error",
+					errorPage{Message: "Cross-origin request refused."})
+				return
+			}
+			if err := r.ParseForm(); err != nil {
+				renderOAuthPage(w, http.StatusBadRequest, "error",
+					errorPage{Message: "Malformed request."})
+				return
+			}
+			req := parseAuthorizeRequest(r.PostForm)
+			if _, ok := authorizeClient(w, r, deps, req); !ok ||
+				!checkAuthorizeRequest(w, r, deps, req) {
+				return
+			}
+			if r.PostForm.Get("decision") != "approve" {
+				deps.Logger.Info("OAUTH_AUTHORIZE_DENIED",
+					"client_id", req.ClientID, "user_id", uid)
+				redirectAuthorizeError(w, r, deps, req, "access_denied",
+					"the user denied access")
+				return
+			}
+
+			var ids []string
+			for _, id := range r.PostForm["vs"] {
+				if slices.Contains(ids, id) {
+					continue
+				}
+				if _, err := grantableServer(r, deps, id); err != nil {
+					redirectAuthorizeError(w, r, deps, req, "access_denied",
+						"virtual server "+id+" cannot be granted")
+					return
+				}
+				ids = append(ids, id)
+			}
+			if len(ids) == 0 {
+				redirectAuthorizeError(w, r, deps, req, "access_denied",
+					"no virtual server was selected")
+				return
+			}
+
+			code, err := deps.OAuth.IssueCode(r.Context(), oauth.Grant{
+				ClientID:         req.ClientID,
+				UserID:           uid,
+				RedirectURI:      req.RedirectURI,
+				CodeChallenge:    req.CodeChallenge,
+				VirtualServerIDs: ids,
+			})
+			if err != nil {
+				deps.Logger.Error("OAUTH_AUTHORIZE_ERROR", "error", err)
+				redirectAuthorizeError(w, r, deps, req, "server_error",
+					"could not issue an authorization code")
+				return
+			}
+			deps.Logger.Info("OAUTH_AUTHORIZE_SUCCESS",
+				"client_id", req.ClientID, "user_id", uid, "vs_ids", ids)
+			redirectAuthorize(w, r, deps, req, url.Values{"code": {code}})
+		}).Methods(http.MethodPost)
+
+	// Token endpoint
+	r.HandleFunc(oauthTokenPath, allowCORS(
+		func(w http.ResponseWriter, r *http.Request) {
+			if err := r.ParseForm(); err != nil {
+				writeOAuthError(w, http.StatusBadRequest,
+					"invalid_request", "malformed form body")
+				return
+			}
+			grantType := r.PostForm.Get("grant_type")
+			clientID := tokenClientID(r)
+			deps.Logger.Info("OAUTH_TOKEN_INIT",
+				"client_id", clientID, "grant_type", grantType)
+			if clientID == "" {
+				writeOAuthError(w, http.StatusUnauthorized,
+					"invalid_client", "client_id is required")
+				return
+			}
+			if _, err := deps.OAuth.Client(r.Context(), clientID); err != nil {
+				writeOAuthError(w, http.StatusUnauthorized,
+					"invalid_client", "unknown client")
+				return
+			}
+
+			var set oauth.TokenSet
+			var err error
+			switch grantType {
+			case grantAuthorizationCode:
+				set, err = deps.OAuth.ExchangeCode(r.Context(), clientID,
+					r.PostForm.Get("code"), r.PostForm.Get("redirect_uri"),
+					r.PostForm.Get("code_verifier"))
+			case grantRefreshToken:
+				set, err = deps.OAuth.Refresh(r.Context(), clientID,
+					r.PostForm.Get("refresh_token"))
+			default:
+				writeOAuthError(w, http.StatusBadRequest,
+					"unsupported_grant_type", "unsupported grant_type")
+				return
+			}
+			if errors.Is(err, oauth.ErrInvalidGrant) {
+				deps.Logger.Info("OAUTH_TOKEN_INVALID_GRANT", "client_id", clientID)
+				writeOAuthError(w, http.StatusBadRequest, "invalid_grant",
+					"the grant is invalid, expired or revoked")
+				return
+			}
+			if err != nil {
+				deps.Logger.Error("OAUTH_TOKEN_ERROR", "error", err)
+				writeOAuthError(w, http.StatusInternalServerError,
+					"server_error", "could not issue tokens")
+				return
+			}
+			deps.Logger.Info("OAUTH_TOKEN_SUCCESS", "client_id", clientID)
+			w.Header().Set("Cache-Control", "no-store")
+			WriteJSON(w, http.StatusOK, map[string]any{
+				"access_token":  set.AccessToken,
+				"token_type":    "Bearer",
+				"expires_in":    set.ExpiresIn,
+				"refresh_token": set.RefreshToken,
+				"scope":         oauth.Scope(set.VirtualServerIDs),
+			})
+		})).Methods(http.MethodPost, http.MethodOptions)
+
+	// Token revocation (RFC 7009)
+	r.HandleFunc(oauthRevokePath, allowCORS(
+		func(w http.ResponseWriter, r *http.Request) {
+			if err := r.ParseForm(); err != nil ||
+				r.PostForm.Get("token") == "" {
+				writeOAuthError(w, http.StatusBadRequest,
+					"invalid_request", "token is required")
+				return
+			}
+			err := deps.OAuth.Revoke(r.Context(), tokenClientID(r),
+				r.PostForm.Get("token"))
+			if err != nil {
+				deps.Logger.Error("OAUTH_REVOKE_ERROR", "error", err)
+				writeOAuthError(w, http.StatusServiceUnavailable,
+					"server_error", "could not revoke token")
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		})).Methods(http.MethodPost, http.MethodOptions)
+}
+
+// authorizeRequest is an authorization request (RFC 6749 4.1.1) with its
+// PKCE (RFC 7636) and resource indicator (RFC 8707) parameters.
+type authorizeRequest struct {
+	ClientID            string
+	RedirectURI         string
+	ResponseType        string
+	State               string
+	Scope               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Resources           []string
+}
+
+func parseAuthorizeRequest(v url.Values) authorizeRequest {
+	return authorizeRequest{
+		ClientID:            v.Get("client_id"),
+		RedirectURI:         v.Get("redirect_uri"),
+		ResponseType:        v.Get("response_type"),
+		State:               v.Get("state"),
+		Scope:               v.Get("scope"),
+		CodeChallenge:       v.Get("code_challenge"),
+		CodeChallengeMethod: v.Get("code_challenge_method"),
+		Resources:           v["resource"],
+	}
+}
+
+// authorizeClient resolves the client of an authorization request and
+// checks its redirect URI. Until both are known good, errors are shown to
+// the user instead of being sent to the redirect URI.
+func authorizeClient(
+	w http.ResponseWriter, r *http.Request, deps Deps, req authorizeRequest,
+) (m.OAuthClient, bool) {
+	client, err := deps.OAuth.Client(r.Context(), req.ClientID)
+	if err != nil {
+		deps.Logger.Info("OAUTH_AUTHORIZE_UNKNOWN_CLIENT",
+			"client_id", req.ClientID)
+		renderOAuthPage(w, http.StatusBadRequest, "error",
+			errorPage{Message: "Unknown client."})
+		return m.OAuthClient{}, false
+	}
+	if req.RedirectURI == "" || !oauth.RedirectAllowed(client, req.RedirectURI) {
+		deps.Logger.Info("OAUTH_AUTHORIZE_BAD_REDIRECT",
+			"client_id", req.ClientID)
+		renderOAuthPage(w, http.StatusBadRequest, "error",
+			errorPage{Message: "The redirect URI is not registered for this client."})
+		return m.OAuthClient{}, false
+	}
+	return client, true
+}
+
+// checkAuthorizeRequest redirects with an error unless the request asks
+// for a code with an S256 PKCE challenge.
+func checkAuthorizeRequest(
+	w http.ResponseWriter, r *http.Request, deps Deps, req authorizeRequest,
+) bool {
+	if req.ResponseType != "code" {
+		redirectAuthorizeError(w, r, deps, req, "unsupported_response_type",
+			"only the code response type is supported")
+		return false
+	}
+	if !oauth.ValidChallenge(req.CodeChallenge, req.CodeChallengeMethod) {
+		redirectAuthorizeError(w, r, deps, req, "invalid_request",
+			"an S256 code_challenge is required")
+		return false
+	}
+	return true
+}
+
+// requestedServers returns the ids of the virtual servers named by the
+// resource indicators and scope of a request, or an error code when a
+// resource is not an MCP endpoint.
+func requestedServers(req authorizeRequest) ([]string, string) {
+	var ids []string
+	for _, res := range req.Resources {
+		u, err := url.Parse(res)
+		if err != nil {
+			return nil, "invalid_target"
+		}
+		match := mcpResourceRE.FindStringSubmatch(u.Path)
+		if match == nil {
+			return nil, "invalid_target"
+		}
+		if !slices.Contains(ids, match[1]) {
+			ids = append(ids, match[1])
+		}
+	}
+	for _, id := range oauth.ParseScope(req.Scope) {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, ""
+}
+
+// grantableServer returns a virtual server the signed-in user may grant:
+// one they own or one of their teams', or any for an admin.
+func grantableServer(
+	r *http.Request, deps Deps, id string,
+) (m.MCPVirtualServer, error) {
+	vs, err := deps.Virtual.GetByID(r.Context(), id)
+	if err != nil {
+		return m.MCPVirtualServer{}, err
+	}
+	ok, err := callerCanAccess(
+		r, deps, vs.UserID, vs.TeamID, m.MemberRoleMember)
+	if err != nil {
+		return m.MCPVirtualServer{}, err
+	}
+	if !ok {
+		return m.MCPVirtualServer{}, errors.New("forbidden")
+	}
+	return vs, nil
+}
+
+// redirectAuthorize sends the user back to the client with params, the
+// request state and the issuer (RFC 9207).
+func redirectAuthorize(
+	w http.ResponseWriter,
+	r *http.Request,
+	deps Deps,
+	req authorizeRequest,
+	params url.Values,
+) {
+	u, err := url.Parse(req.RedirectURI)
+	if err != nil {
+		renderOAuthPage(w, http.StatusBadRequest, "error",
+			errorPage{Message: "Invalid redirect URI."})
+		return
+	}
+	q := u.Query()
+	for k, v := range params {
+		q[k] = v
+	}
+	if req.State != "" {
+		q.Set("state", req.State)
+	}
+	q.Set("iss", publicBaseURL(deps))
+	u.RawQuery = q.Encode()
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+func redirectAuthorizeError(
+	w http.ResponseWriter,
+	r *http.Request,
+	deps Deps,
+	req authorizeRequest,
+	code, desc string,
+) {
+	redirectAuthorize(w, r, deps, req, url.Values{
+		"error":             {code},
+		"error_description": {desc},
+	})
+}
+
+// tokenClientID returns the client id of a token or revocation request,
+// from the form or, for clients that send it that way, Basic auth.
+func tokenClientID(r *http.Request) string {
+	if id := r.PostForm.Get("client_id"); id != "" {
+		return id
+	}
+	id, _, _ := r.BasicAuth()
+	return id
+}
+
+// writeOAuthError writes an RFC 6749 error response.
+func writeOAuthError(w http.ResponseWriter, status int, code, desc string) {
+	w.Header().Set("Cache-Control", "no-store")
+	WriteJSON(w, status,
+		map[string]string{"error": code, "error_description": desc})
+}
+
+// allowCORS lets browser-based MCP clients call the public OAuth
+// endpoints, answering preflight requests itself.
+func allowCORS(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers",
+				"Authorization, Content-Type, MCP-Protocol-Version")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// publicBaseURL returns the gateway's public base URL, the configured OAuth
+// issuer, or "" when there is none. It is never taken from the Host or
+// X-Forwarded-* headers, which any caller can set; the config refuses to
+// load OAuth, identity tokens or OIDC login without it.
+func publicBaseURL(deps Deps) string {
+	if deps.AppConfig == nil {
+		return ""
+	}
+	return strings.TrimRight(deps.AppConfig.OAuth.Issuer, "/")
+}
+
+// resourceMetadataURL returns the protected resource metadata URL of the
+// MCP endpoint a request was sent to.
+func resourceMetadataURL(deps Deps, r *http.Request) string {
+	return publicBaseURL(deps) + protectedResourcePath + r.URL.Path
+}
@@ -0,0 +1,275 @@
+package server
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+
+	usersvc "github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/user"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/oidc"
+)
+
+const (
+	// oidcFlowCookie holds a sign in in progress between the login
+	// redirect and the callback.
+	oidcFlowCookie = "oidc_flow"
+	oidcFlowTTL    = 10 * time.Minute
+	oidcFlowType   = "oidc_flow"
+)
+
+// oidcFlowClaims is the signed content of the flow cookie.
+type oidcFlowClaims struct {
+	jwt.RegisteredClaims
+	Type     string `json:"typ"`
+	Provider string `json:"provider"`
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	Next     string `json:"next"`
+}
+
+// oidcProviderLink is a provider as login pages list it.
+type oidcProviderLink struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	LoginURL    string `json:"login_url"`
+}
+
+// addOIDCRoutes configures sign in with the OpenID Connect providers.
+func addOIDCRoutes(r *mux.Router, deps Deps, cfg Config) {
+	// list providers for the login page
+	r.HandleFunc(cfg.AdminPrefix+"/auth/oidc/providers",
+		func(w http.ResponseWriter, _ *http.Request) {
+			WriteJSON(w, http.StatusOK,
+				map[string]any{"items": oidcProviderLinks(deps, cfg, "")})
+		}).Methods(http.MethodGet)
+
+	// start a sign in: remember the flow in a cookie and redirect to the
+	// provider
+	r.HandleFunc(cfg.AdminPrefix+"/auth/oidc/{provider}/login",
+		func(w http.ResponseWriter, r *http.Request) {
+			p := oidcProvider(deps, mux.Vars(r)["provider"])
+			if p == nil {
+				WriteJSON(w, http.StatusNotFound,
+					map[string]string{"error": "unknown provider"})
+				return
+			}
+			deps.Logger.Info("AUTH_OIDC_LOGIN_INIT", "provider", p.Name())
+			flow, err := oidc.NewFlow()
+			if err != nil {
+				deps.Logger.Error("AUTH_OIDC_FLOW_ERROR", "error", err)
+				WriteJSON(w, http.StatusInternalServerError,
+					map[string]string{"error": "authorization failed."})
+				return
+			}
+			target, err := p.AuthCodeURL(r.Context(),
+				oidcRedirectURI(deps, cfg, p), flow.State, flow.Nonce,
+				flow.Verifier)
+			if err != nil {
+				deps.Logger.Error("AUTH_OIDC_LOGIN_ERROR",
+					"provider", p.Name(), "error", err)
+				WriteJSON(w, http.StatusBadGateway,
+					map[string]string{"error": "provider unavailable"})
+				return
+			}
+			now := time.Now()
+			cookie := jwt.NewWithClaims(jwt.SigningMethodHS256, oidcFlowClaims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					IssuedAt:  jwt.NewNumericDate(now),
+					ExpiresAt: jwt.NewNumericDate(now.Add(oidcFlowTTL)),
+				},
+				Type:     oidcFlowType,
+				Provider: p.Name(),
+				State:    flow.State,
+				Nonce:    flow.Nonce,
+				Verifier: flow.Verifier,
+				Next:     localPath(r.URL.Query().Get("next")),
+			})
+			signed, err := cookie.SignedString(sessionSecret(deps))
+			if err != nil {
+				deps.Logger.Error("AUTH_OIDC_FLOW_ERROR", "error", err)
+				WriteJSON(w, http.StatusInternalServerError,
+					map[string]string{"error": "authorization failed."})
+				return
+			}
+			http.SetCookie(w, &http.Cookie{
+				Name:     oidcFlowCookie,
+				Value:    signed,
+				Path:     cfg.AdminPrefix + "/auth/oidc",
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+				MaxAge:   int(oidcFlowTTL.Seconds()),
+			})
+			http.Redirect(w, r, target, http.StatusFound)
+		}).Methods(http.MethodGet)
+
+	// finish a sign in: redeem the code, provision the user and set the
+	// session cookie
+	r.HandleFunc(cfg.AdminPrefix+"/auth/oidc/{provider}/callback",
+		func(w http.ResponseWriter, r *http.Request) {
+			p := oidcProvider(deps, mux.Vars(r)["provider"])
+			if p == nil {
+				WriteJSON(w, http.StatusNotFound,
+					map[string]string{"error": "unknown provider"})
+				return
+			}
+			deps.Logger.Info("AUTH_OIDC_CALLBACK_INIT", "provider", p.Name())
+			fail := func(msg string) {
+				http.Redirect(w, r, "/?login_error="+url.QueryEscape(msg),
+					http.StatusFound)
+			}
+			// The flow is single use
+			http.SetCookie(w, &http.Cookie{
+				Name:     oidcFlowCookie,
+				Path:     cfg.AdminPrefix + "/auth/oidc",
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+				MaxAge:   -1,
+			})
+			flow, ok := readOIDCFlow(deps, r, p.Name())
+			if !ok {
+				deps.Logger.Error("AUTH_OIDC_INVALID_FLOW", "provider", p.Name())
+				fail("sign in expired, please try again")
+				return
+			}
+			q := r.URL.Query()
+			if e := q.Get("error"); e != "" {
+				deps.Logger.Error("AUTH_OIDC_PROVIDER_ERROR",
+					"provider", p.Name(), "error", e,
+					"description", q.Get("error_description"))
+				fail("sign in was refused by " + p.DisplayName())
+				return
+			}
+			if subtle.ConstantTimeCompare(
+				[]byte(q.Get("state")), []byte(flow.State)) != 1 {
+				deps.Logger.Error("AUTH_OIDC_STATE_MISMATCH",
+					"provider", p.Name())
+				fail("sign in expired, please try again")
+				return
+			}
+			id, err := p.Exchange(r.Context(), oidcRedirectURI(deps, cfg, p),
+				q.Get("code"), flow.Verifier, flow.Nonce)
+			if errors.Is(err, oidc.ErrEmailNotAllowed) {
+				deps.Logger.Error("AUTH_OIDC_NOT_ALLOWED",
+					"provider", p.Name(), "error", err)
+				fail("this account is not allowed to sign in")
+				return
+			}
+			if err != nil {
+				deps.Logger.Error("AUTH_OIDC_EXCHANGE_ERROR",
+					"provider", p.Name(), "error", err)
+				fail("sign in with " + p.DisplayName() + " failed")
+				return
+			}
+
+			user, err := deps.UserService.FetchOrCreateByOIDC(
+				r.Context(), p.Name(), id.Subject, id.Email)
+			if errors.Is(err, usersvc.ErrAccountExists) {
+				deps.Logger.Error("AUTH_OIDC_ACCOUNT_EXISTS",
+					"provider", p.Name(), "subject", id.Subject)
+				fail("an account with this email already exists")
+				return
+			}
+			if err != nil {
+				deps.Logger.Error("AUTH_OIDC_USER_ERROR", "error", err)
+				fail("sign in failed")
+				return
+			}
+			if id.Role != "" {
+				if err := deps.UserService.SetRole(
+					r.Context(), user, id.Role); err != nil {
+					deps.Logger.Error("AUTH_OIDC_ROLE_ERROR", "error", err)
+					fail("sign in failed")
+					return
+				}
+			}
+			if err := setSessionCookie(w, deps, user, "oidc"); err != nil {
+				deps.Logger.Error("AUTH_OIDC_TOKEN_ERROR", "error", err)
+				fail("sign in failed")
+				return
+			}
+			deps.Logger.Info("AUTH_OIDC_SUCCESS",
+				"provider", p.Name(), "user_id", user.ID)
+			next := flow.Next
+			if next == "" {
+				next = "/"
+			}
+			http.Redirect(w, r, next, http.StatusFound)
+		}).Methods(http.MethodGet)
+}
+
+// oidcProvider returns the provider with the given name, or nil.
+func oidcProvider(deps Deps, name string) *oidc.Provider {
+	for _, p := range deps.OIDC {
+		if p.Name() == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// oidcProviderLinks lists the providers, with login URLs that return to
+// next after signing in.
+func oidcProviderLinks(deps Deps, cfg Config, next string) []oidcProviderLink {
+	links := make([]oidcProviderLink, 0, len(deps.OIDC))
+	for _, p := range deps.OIDC {
+		u := cfg.AdminPrefix + "/auth/oidc/" + p.Name() + "/login"
+		if next != "" {
+			u += "?next=" + url.QueryEscape(next)
+		}
+		links = append(links, oidcProviderLink{
+			Name:        p.Name(),
+			DisplayName: p.DisplayName(),
+			LoginURL:    u,
+		})
+	}
+	return links
+}
+
+// oidcRedirectURI is where a provider sends the user back to; it must be
+// registered with the provider.
+func oidcRedirectURI(deps Deps, cfg Config, p *oidc.Provider) string {
+	return publicBaseURL(deps) + cfg.AdminPrefix + "/auth/oidc/" +
+		p.Name() + "/callback"
+}
+
+// readOIDCFlow verifies the flow cookie for a provider's callback.
+func readOIDCFlow(
+	deps Deps, r *http.Request, provider string) (oidcFlowClaims, bool) {
+	c, err := r.Cookie(oidcFlowCookie)
+	if err != nil {
+		return oidcFlowClaims{}, false
+	}
+	var flow oidcFlowClaims
+	_, err = jwt.ParseWithClaims(c.Value, &flow,
+		func(*jwt.Token) (any, error) { return sessionSecret(deps), nil },
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired())
+	if err != nil || flow.Type != oidcFlowType || flow.Provider != provider {
+		return oidcFlowClaims{}, false
+	}
+	return flow, true
+}
+
+// localPath keeps next only when it is a path on this site, so signing in
+// cannot redirect elsewhere.
+func localPath(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") ||
+		strings.HasPrefix(next, "/\\") {
+		return ""
+	}
+	return next
+}
+
+func sessionSecret(deps Deps) []byte {
+	if deps.AppConfig == nil {
+		return nil
+	}
+	return []byte(deps.AppConfig.Security.JWTSecret)
+}
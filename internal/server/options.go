@@ -5,13 +5,27 @@ import (
 
 	cfgpkg "github.com/ChiragChiranjib/mcp-proxy/internal/config"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/encryptor"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/events"
+	mcpclient "github.com/ChiragChiranjib/mcp-proxy/internal/mcp/client"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/apikey"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/approval"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/audit"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/catalog"
 	catalogOrchestrator "github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/catalog_orchestrator"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/identity"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/mcphub"
 	mcphubOrchestrator "github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/mcphub_orchestrator"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/oauth"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/org"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/prompt"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/ratelimit"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/resource"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/session"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/tool"
 	usersvc "github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/user"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/virtualmcp"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/metrics"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/oidc"
 )
 
 // Option configures dependencies for the server.
@@ -31,6 +45,20 @@ func WithTools(s *tool.Service) Option {
 	}
 }
 
+// WithResources ...
+func WithResources(s *resource.Service) Option {
+	return func(d *Deps) {
+		d.Resources = s
+	}
+}
+
+// WithPrompts ...
+func WithPrompts(s *prompt.Service) Option {
+	return func(d *Deps) {
+		d.Prompts = s
+	}
+}
+
 // WithHubs ...
 func WithHubs(s *mcphub.Service) Option {
 	return func(d *Deps) {
@@ -46,6 +74,90 @@ func WithVirtual(s *virtualmcp.Service) Option {
 	}
 }
 
+// WithKeys ...
+func WithKeys(s *apikey.Service) Option {
+	return func(d *Deps) {
+		d.Keys = s
+	}
+}
+
+// WithAudit ...
+func WithAudit(s *audit.Service) Option {
+	return func(d *Deps) {
+		d.Audit = s
+	}
+}
+
+// WithMetrics ...
+func WithMetrics(mt *metrics.Metrics) Option {
+	return func(d *Deps) {
+		d.Metrics = mt
+	}
+}
+
+// WithSessions ...
+func WithSessions(s *session.Service) Option {
+	return func(d *Deps) {
+		d.Sessions = s
+	}
+}
+
+// WithLimits ...
+func WithLimits(s *ratelimit.Service) Option {
+	return func(d *Deps) {
+		d.Limits = s
+	}
+}
+
+// WithApprovals ...
+func WithApprovals(s *approval.Service) Option {
+	return func(d *Deps) {
+		d.Approvals = s
+	}
+}
+
+// WithOAuth ...
+func WithOAuth(s *oauth.Service) Option {
+	return func(d *Deps) {
+		d.OAuth = s
+	}
+}
+
+// WithIdentity ...
+func WithIdentity(s *identity.Service) Option {
+	return func(d *Deps) {
+		d.Identity = s
+	}
+}
+
+// WithOIDCProviders ...
+func WithOIDCProviders(ps []*oidc.Provider) Option {
+	return func(d *Deps) {
+		d.OIDC = ps
+	}
+}
+
+// WithOrgs ...
+func WithOrgs(s *org.Service) Option {
+	return func(d *Deps) {
+		d.Orgs = s
+	}
+}
+
+// WithEvents ...
+func WithEvents(b *events.Bus) Option {
+	return func(d *Deps) {
+		d.Events = b
+	}
+}
+
+// WithSessionPool ...
+func WithSessionPool(p *mcpclient.Pool) Option {
+	return func(d *Deps) {
+		d.Pool = p
+	}
+}
+
 // WithCatalog ...
 func WithCatalog(s *catalog.Service) Option {
 	return func(d *Deps) {
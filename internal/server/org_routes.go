@@ -0,0 +1,520 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	ck "github.com/ChiragChiranjib/mcp-proxy/internal/contextkey"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/org"
+	m "github.com/ChiragChiranjib/mcp-proxy/internal/models"
+)
+
+// Organization and team routes. Organization owners manage the
+// organization, its members and all of its teams; maintainers may also
+// create teams. Team owners manage a team's members, maintainers its hubs
+// and virtual servers, and members use them. Admins may do everything.
+func addOrgRoutes(r *mux.Router, deps Deps, cfg Config) {
+	if deps.Orgs == nil {
+		return
+	}
+
+	// List the caller's organizations; admins see all of them
+	r.HandleFunc(
+		cfg.AdminPrefix+"/orgs",
+		func(w http.ResponseWriter, r *http.Request) {
+			userID := ck.GetUserIDFromContext(r.Context())
+			deps.Logger.Info("LIST_ORGS_INIT", "user_id", userID)
+			items, err := deps.Orgs.ListOrganizations(
+				r.Context(), userID, isAdminCaller(r))
+			if err != nil {
+				deps.Logger.Error("LIST_ORGS_ERROR", "error", err)
+				WriteJSON(w, http.StatusInternalServerError,
+					map[string]string{"error": err.Error()})
+				return
+			}
+			deps.Logger.Info("LIST_ORGS_SUCCESS", "count", len(items))
+			WriteJSON(w, http.StatusOK, map[string]any{"items": items})
+		},
+	).Methods(http.MethodGet)
+
+	// Create an organization owned by the caller
+	r.HandleFunc(
+		cfg.AdminPrefix+"/orgs",
+		func(w http.ResponseWriter, r *http.Request) {
+			userID := ck.GetUserIDFromContext(r.Context())
+			var body struct {
+				Name string `json:"name"`
+			}
+			if !ReadJSON(w, r, &body) {
+				deps.Logger.Error("CREATE_ORG_READ_BODY_ERROR")
+				return
+			}
+			deps.Logger.Info("CREATE_ORG_INIT", "user_id", userID)
+			o, err := deps.Orgs.CreateOrganization(r.Context(), userID, body.Name)
+			if err != nil {
+				deps.Logger.Error("CREATE_ORG_ERROR", "error", err)
+				WriteJSON(w, orgErrorStatus(err),
+					map[string]string{"error": err.Error()})
+				return
+			}
+			deps.Logger.Info("CREATE_ORG_SUCCESS", "id", o.ID)
+			WriteJSON(w, http.StatusCreated, o)
+		},
+	).Methods(http.MethodPost)
+
+	// Rename / delete an organization
+	r.HandleFunc(
+		cfg.AdminPrefix+"/orgs/{id}",
+		func(w http.ResponseWriter, r *http.Request) {
+			id := mux.Vars(r)["id"]
+			if !requireOrgRole(w, r, deps, id, m.MemberRoleOwner) {
+				return
+			}
+			var err error
+			switch r.Method {
+			case http.MethodPatch:
+				var body struct {
+					Name string `json:"name"`
+				}
+				if !ReadJSON(w, r, &body) {
+					deps.Logger.Error("UPDATE_ORG_READ_BODY_ERROR")
+					return
+				}
+				deps.Logger.Info("UPDATE_ORG_INIT", "id", id)
+				err = deps.Orgs.RenameOrganization(r.Context(), id, body.Name)
+			case http.MethodDelete:
+				deps.Logger.Info("DELETE_ORG_INIT", "id", id)
+				err = deps.Orgs.DeleteOrganization(r.Context(), id)
+			}
+			if err != nil {
+				deps.Logger.Error("UPDATE_ORG_ERROR", "error", err)
+				WriteJSON(w, orgErrorStatus(err),
+					map[string]string{"error": err.Error()})
+				return
+			}
+			deps.Logger.Info("UPDATE_ORG_SUCCESS", "id", id, "method", r.Method)
+			WriteJSON(w, http.StatusOK, map[string]string{"ok": "true"})
+		},
+	).Methods(http.MethodPatch, http.MethodDelete)
+
+	// List organization members
+	r.HandleFunc(
+		cfg.AdminPrefix+"/orgs/{id}/members",
+		func(w http.ResponseWriter, r *http.Request) {
+			id := mux.Vars(r)["id"]
+			if !requireOrgRole(w, r, deps, id, m.MemberRoleMember) {
+				return
+			}
+			items, err := deps.Orgs.ListOrganizationMembers(r.Context(), id)
+			if err != nil {
+				deps.Logger.Error("LIST_ORG_MEMBERS_ERROR", "error", err)
+				WriteJSON(w, http.StatusInternalServerError,
+					map[string]string{"error": err.Error()})
+				return
+			}
+			WriteJSON(w, http.StatusOK, map[string]any{"items": items})
+		},
+	).Methods(http.MethodGet)
+
+	// Add an organization member by username
+	r.HandleFunc(
+		cfg.AdminPrefix+"/orgs/{id}/members",
+		func(w http.ResponseWriter, r *http.Request) {
+			id := mux.Vars(r)["id"]
+			if !requireOrgRole(w, r, deps, id, m.MemberRoleOwner) {
+				return
+			}
+			var body struct {
+				Username string       `json:"username"`
+				Role     m.MemberRole `json:"role"`
+			}
+			if !ReadJSON(w, r, &body) {
+				deps.Logger.Error("ADD_ORG_MEMBER_READ_BODY_ERROR")
+				return
+			}
+			if body.Role == "" {
+				body.Role = m.MemberRoleMember
+			}
+			deps.Logger.Info("ADD_ORG_MEMBER_INIT", "id", id, "role", body.Role)
+			if err := deps.Orgs.AddOrganizationMember(
+				r.Context(), id, body.Username, body.Role,
+			); err != nil {
+				deps.Logger.Error("ADD_ORG_MEMBER_ERROR", "error", err)
+				WriteJSON(w, orgErrorStatus(err),
+					map[string]string{"error": err.Error()})
+				return
+			}
+			deps.Logger.Info("ADD_ORG_MEMBER_SUCCESS", "id", id)
+			WriteJSON(w, http.StatusCreated, map[string]string{"ok": "true"})
+		},
+	).Methods(http.MethodPost)
+
+	// Change a member's role / remove a member. Members may remove
+	// themselves.
+	r.HandleFunc(
+		cfg.AdminPrefix+"/orgs/{id}/members/{user_id}",
+		func(w http.ResponseWriter, r *http.Request) {
+			id := mux.Vars(r)["id"]
+			memberID := mux.Vars(r)["user_id"]
+			self := r.Method == http.MethodDelete &&
+				memberID == ck.GetUserIDFromContext(r.Context())
+			if !self && !requireOrgRole(w, r, deps, id, m.MemberRoleOwner) {
+				return
+			}
+			var err error
+			switch r.Method {
+			case http.MethodPatch:
+				var body struct {
+					Role m.MemberRole `json:"role"`
+				}
+				if !ReadJSON(w, r, &body) {
+					deps.Logger.Error("UPDATE_ORG_MEMBER_READ_BODY_ERROR")
+					return
+				}
+				deps.Logger.Info("UPDATE_ORG_MEMBER_INIT",
+					"id", id, "user_id", memberID, "role", body.Role)
+				err = deps.Orgs.SetOrganizationMemberRole(
+					r.Context(), id, memberID, body.Role)
+			case http.MethodDelete:
+				deps.Logger.Info("REMOVE_ORG_MEMBER_INIT",
+					"id", id, "user_id", memberID)
+				err = deps.Orgs.RemoveOrganizationMember(r.Context(), id, memberID)
+			}
+			if err != nil {
+				deps.Logger.Error("UPDATE_ORG_MEMBER_ERROR", "error", err)
+				WriteJSON(w, orgErrorStatus(err),
+					map[string]string{"error": err.Error()})
+				return
+			}
+			deps.Logger.Info("UPDATE_ORG_MEMBER_SUCCESS",
+				"id", id, "user_id", memberID, "method", r.Method)
+			WriteJSON(w, http.StatusOK, map[string]string{"ok": "true"})
+		},
+	).Methods(http.MethodPatch, http.MethodDelete)
+
+	// Create a team in an organization
+	r.HandleFunc(
+		cfg.AdminPrefix+"/orgs/{id}/teams",
+		func(w http.ResponseWriter, r *http.Request) {
+			id := mux.Vars(r)["id"]
+			if !requireOrgRole(w, r, deps, id, m.MemberRoleMaintainer) {
+				return
+			}
+			var body struct {
+				Name string `json:"name"`
+			}
+			if !ReadJSON(w, r, &body) {
+				deps.Logger.Error("CREATE_TEAM_READ_BODY_ERROR")
+				return
+			}
+			userID := ck.GetUserIDFromContext(r.Context())
+			deps.Logger.Info("CREATE_TEAM_INIT", "org_id", id, "user_id", userID)
+			t, err := deps.Orgs.CreateTeam(r.Context(), id, userID, body.Name)
+			if err != nil {
+				deps.Logger.Error("CREATE_TEAM_ERROR", "error", err)
+				WriteJSON(w, orgErrorStatus(err),
+					map[string]string{"error": err.Error()})
+				return
+			}
+			deps.Logger.Info("CREATE_TEAM_SUCCESS", "id", t.ID)
+			WriteJSON(w, http.StatusCreated, t)
+		},
+	).Methods(http.MethodPost)
+
+	// List the caller's teams with their role, optionally in one
+	// organization; admins see all of them
+	r.HandleFunc(
+		cfg.AdminPrefix+"/teams",
+		func(w http.ResponseWriter, r *http.Request) {
+			userID := ck.GetUserIDFromContext(r.Context())
+			orgID := r.URL.Query().Get("organization_id")
+			deps.Logger.Info("LIST_TEAMS_INIT", "user_id", userID, "org_id", orgID)
+			items, err := deps.Orgs.ListTeams(
+				r.Context(), userID, orgID, isAdminCaller(r))
+			if err != nil {
+				deps.Logger.Error("LIST_TEAMS_ERROR", "error", err)
+				WriteJSON(w, http.StatusInternalServerError,
+					map[string]string{"error": err.Error()})
+				return
+			}
+			deps.Logger.Info("LIST_TEAMS_SUCCESS", "count", len(items))
+			WriteJSON(w, http.StatusOK, map[string]any{"items": items})
+		},
+	).Methods(http.MethodGet)
+
+	// Rename / delete a team
+	r.HandleFunc(
+		cfg.AdminPrefix+"/teams/{id}",
+		func(w http.ResponseWriter, r *http.Request) {
+			id := mux.Vars(r)["id"]
+			if !requireTeamRole(w, r, deps, id, m.MemberRoleOwner) {
+				return
+			}
+			var err error
+			switch r.Method {
+			case http.MethodPatch:
+				var body struct {
+					Name string `json:"name"`
+				}
+				if !ReadJSON(w, r, &body) {
+					deps.Logger.Error("UPDATE_TEAM_READ_BODY_ERROR")
+					return
+				}
+				deps.Logger.Info("UPDATE_TEAM_INIT", "id", id)
+				err = deps.Orgs.RenameTeam(r.Context(), id, body.Name)
+			case http.MethodDelete:
+				deps.Logger.Info("DELETE_TEAM_INIT", "id", id)
+				err = deps.Orgs.DeleteTeam(r.Context(), id)
+			}
+			if err != nil {
+				deps.Logger.Error("UPDATE_TEAM_ERROR", "error", err)
+				WriteJSON(w, orgErrorStatus(err),
+					map[string]string{"error": err.Error()})
+				return
+			}
+			deps.Logger.Info("UPDATE_TEAM_SUCCESS", "id", id, "method", r.Method)
+			WriteJSON(w, http.StatusOK, map[string]string{"ok": "true"})
+		},
+	).Methods(http.MethodPatch, http.MethodDelete)
+
+	// List team members
+	r.HandleFunc(
+		cfg.AdminPrefix+"/teams/{id}/members",
+		func(w http.ResponseWriter, r *http.Request) {
+			id := mux.Vars(r)["id"]
+			if !requireTeamRole(w, r, deps, id, m.MemberRoleMember) {
+				return
+			}
+			items, err := deps.Orgs.ListTeamMembers(r.Context(), id)
+			if err != nil {
+				deps.Logger.Error("LIST_TEAM_MEMBERS_ERROR", "error", err)
+				WriteJSON(w, http.StatusInternalServerError,
+					map[string]string{"error": err.Error()})
+				return
+			}
+			WriteJSON(w, http.StatusOK, map[string]any{"items": items})
+		},
+	).Methods(http.MethodGet)
+
+	// Add a team member by username; they must belong to the organization
+	r.HandleFunc(
+		cfg.AdminPrefix+"/teams/{id}/members",
+		func(w http.ResponseWriter, r *http.Request) {
+			id := mux.Vars(r)["id"]
+			if !requireTeamRole(w, r, deps, id, m.MemberRoleOwner) {
+				return
+			}
+			var body struct {
+				Username string       `json:"username"`
+				Role     m.MemberRole `json:"role"`
+			}
+			if !ReadJSON(w, r, &body) {
+				deps.Logger.Error("ADD_TEAM_MEMBER_READ_BODY_ERROR")
+				return
+			}
+			if body.Role == "" {
+				body.Role = m.MemberRoleMember
+			}
+			deps.Logger.Info("ADD_TEAM_MEMBER_INIT", "id", id, "role", body.Role)
+			if err := deps.Orgs.AddTeamMember(
+				r.Context(), id, body.Username, body.Role,
+			); err != nil {
+				deps.Logger.Error("ADD_TEAM_MEMBER_ERROR", "error", err)
+				WriteJSON(w, orgErrorStatus(err),
+					map[string]string{"error": err.Error()})
+				return
+			}
+			deps.Logger.Info("ADD_TEAM_MEMBER_SUCCESS", "id", id)
+			WriteJSON(w, http.StatusCreated, map[string]string{"ok": "true"})
+		},
+	).Methods(http.MethodPost)
+
+	// Change a member's role / remove a member. Members may remove
+	// themselves.
+	r.HandleFunc(
+		cfg.AdminPrefix+"/teams/{id}/members/{user_id}",
+		func(w http.ResponseWriter, r *http.Request) {
+			id := mux.Vars(r)["id"]
+			memberID := mux.Vars(r)["user_id"]
+			self := r.Method == http.MethodDelete &&
+				memberID == ck.GetUserIDFromContext(r.Context())
+			if !self && !requireTeamRole(w, r, deps, id, m.MemberRoleOwner) {
+				return
+			}
+			var err error
+			switch r.Method {
+			case http.MethodPatch:
+				var body struct {
+					Role m.MemberRole `json:"role"`
+				}
+				if !ReadJSON(w, r, &body) {
+					deps.Logger.Error("UPDATE_TEAM_MEMBER_READ_BODY_ERROR")
+					return
+				}
+				deps.Logger.Info("UPDATE_TEAM_MEMBER_INIT",
+					"id", id, "user_id", memberID, "role", body.Role)
+				err = deps.Orgs.SetTeamMemberRole(
+					r.Context(), id, memberID, body.Role)
+			case http.MethodDelete:
+				deps.Logger.Info("REMOVE_TEAM_MEMBER_INIT",
+					"id", id, "user_id", memberID)
+				err = deps.Orgs.RemoveTeamMember(r.Context(), id, memberID)
+			}
+			if err != nil {
+				deps.Logger.Error("UPDATE_TEAM_MEMBER_ERROR", "error", err)
+				WriteJSON(w, orgErrorStatus(err),
+					map[string]string{"error": err.Error()})
+				return
+			}
+			deps.Logger.Info("UPDATE_TEAM_MEMBER_SUCCESS",
+				"id", id, "user_id", memberID, "method", r.Method)
+			WriteJSON(w, http.StatusOK, map[string]string{"ok": "true"})
+		},
+	).Methods(http.MethodPatch, http.MethodDelete)
+}
+
+// orgErrorStatus maps org service errors to HTTP status codes.
+func orgErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, org.ErrNotFound),
+		errors.Is(err, org.ErrUserNotFound),
+		errors.Is(err, org.ErrNotMember):
+		return http.StatusNotFound
+	case errors.Is(err, org.ErrInvalidName),
+		errors.Is(err, org.ErrInvalidRole),
+		errors.Is(err, org.ErrNotOrganizationMember):
+		return http.StatusBadRequest
+	case errors.Is(err, org.ErrAlreadyMember),
+		errors.Is(err, org.ErrLastOwner),
+		errors.Is(err, org.ErrOrganizationNotEmpty),
+		errors.Is(err, org.ErrTeamNotEmpty):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func isAdminCaller(r *http.Request) bool {
+	return ck.GetUserRoleFromContext(r.Context()) == string(m.RoleAdmin)
+}
+
+// requireOrgRole reports whether the caller has at least min in an
+// organization; admins have every role. It writes the error response when
+// it returns false.
+func requireOrgRole(
+	w http.ResponseWriter, r *http.Request, deps Deps, orgID string,
+	min m.MemberRole,
+) bool {
+	if _, err := deps.Orgs.GetOrganization(r.Context(), orgID); err != nil {
+		WriteJSON(w, orgErrorStatus(err), map[string]string{"error": err.Error()})
+		return false
+	}
+	if isAdminCaller(r) {
+		return true
+	}
+	role, err := deps.Orgs.OrganizationRole(
+		r.Context(), orgID, ck.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		WriteJSON(w, http.StatusInternalServerError,
+			map[string]string{"error": err.Error()})
+		return false
+	}
+	if !role.AtLeast(min) {
+		WriteJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+		return false
+	}
+	return true
+}
+
+// requireTeamRole reports whether the caller has at least min in a team.
+// It writes the error response when it returns false.
+func requireTeamRole(
+	w http.ResponseWriter, r *http.Request, deps Deps, teamID string,
+	min m.MemberRole,
+) bool {
+	if deps.Orgs == nil {
+		WriteJSON(w, http.StatusNotFound,
+			map[string]string{"error": org.ErrNotFound.Error()})
+		return false
+	}
+	if _, err := deps.Orgs.GetTeam(r.Context(), teamID); err != nil {
+		WriteJSON(w, orgErrorStatus(err), map[string]string{"error": err.Error()})
+		return false
+	}
+	ok, err := callerCanAccess(r, deps, "", &teamID, min)
+	if err != nil {
+		WriteJSON(w, http.StatusInternalServerError,
+			map[string]string{"error": err.Error()})
+		return false
+	}
+	if !ok {
+		WriteJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+		return false
+	}
+	return true
+}
+
+// callerCanAccess reports whether the caller has at least min on something
+// owned by the team teamID when set, else by the user userID. Admins have
+// every role.
+func callerCanAccess(
+	r *http.Request, deps Deps, userID string, teamID *string,
+	min m.MemberRole,
+) (bool, error) {
+	if isAdminCaller(r) {
+		return true, nil
+	}
+	callerID := ck.GetUserIDFromContext(r.Context())
+	if teamID == nil {
+		return userID == callerID, nil
+	}
+	if deps.Orgs == nil {
+		return false, nil
+	}
+	role, err := deps.Orgs.TeamRole(r.Context(), *teamID, callerID)
+	return role.AtLeast(min), err
+}
+
+// callerManagesTeamServer reports whether vsID names a team virtual server
+// the caller maintains.
+func callerManagesTeamServer(r *http.Request, deps Deps, vsID string) bool {
+	if vsID == "" {
+		return false
+	}
+	vs, err := deps.Virtual.GetByID(r.Context(), vsID)
+	if err != nil || vs.TeamID == nil {
+		return false
+	}
+	ok, err := callerCanAccess(
+		r, deps, vs.UserID, vs.TeamID, m.MemberRoleMaintainer)
+	return err == nil && ok
+}
+
+// callerTeamIDs returns the teams in which the caller has at least min.
+func callerTeamIDs(
+	r *http.Request, deps Deps, min m.MemberRole) ([]string, error) {
+	if deps.Orgs == nil {
+		return nil, nil
+	}
+	return deps.Orgs.TeamIDsForUser(
+		r.Context(), ck.GetUserIDFromContext(r.Context()), min)
+}
+
+// ownerFromQuery returns whose inventory a list request is for: the team
+// named by the team_id query parameter, in which the caller must have at
+// least min, else the caller. It writes the error response when it returns
+// false.
+func ownerFromQuery(
+	w http.ResponseWriter, r *http.Request, deps Deps, min m.MemberRole,
+) (m.Owner, bool) {
+	teamID := r.URL.Query().Get("team_id")
+	if teamID == "" {
+		return m.Owner{UserID: ck.GetUserIDFromContext(r.Context())}, true
+	}
+	if !requireTeamRole(w, r, deps, teamID, min) {
+		return m.Owner{}, false
+	}
+	return m.Owner{TeamID: teamID}, true
+}
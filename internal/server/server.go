@@ -9,14 +9,28 @@ import (
 
 	cfgpkg "github.com/ChiragChiranjib/mcp-proxy/internal/config"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/encryptor"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/events"
+	mcpclient "github.com/ChiragChiranjib/mcp-proxy/internal/mcp/client"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/apikey"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/approval"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/audit"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/catalog"
 	catalogOrchestrator "github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/catalog_orchestrator"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/identity"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/mcphub"
 	mcphubOrchestrator "github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/mcphub_orchestrator"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/oauth"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/org"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/prompt"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/ratelimit"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/resource"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/session"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/tool"
 	usersvc "github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/user"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/mcp/service/virtualmcp"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/metrics"
 	"github.com/ChiragChiranjib/mcp-proxy/internal/middlewares"
+	"github.com/ChiragChiranjib/mcp-proxy/internal/oidc"
 )
 
 // Server holds the final HTTP handler for the app.
@@ -28,14 +42,28 @@ type Server struct {
 type Deps struct {
 	Logger              *slog.Logger
 	Tools               *tool.Service
+	Resources           *resource.Service
+	Prompts             *prompt.Service
 	Hubs                *mcphub.Service
 	Virtual             *virtualmcp.Service
+	Keys                *apikey.Service
+	Audit               *audit.Service
 	Catalog             *catalog.Service
 	Encrypter           *encryptor.AESEncrypter
+	Pool                *mcpclient.Pool
 	UserService         *usersvc.Service
 	McphubOrchestrator  *mcphubOrchestrator.Orchestrator
 	CatalogOrchestrator *catalogOrchestrator.Orchestrator
 	AppConfig           *cfgpkg.Config
+	Metrics             *metrics.Metrics
+	Sessions            *session.Service
+	Events              *events.Bus
+	Limits              *ratelimit.Service
+	Approvals           *approval.Service
+	OAuth               *oauth.Service
+	Identity            *identity.Service
+	OIDC                []*oidc.Provider
+	Orgs                *org.Service
 }
 
 // Config holds HTTP wiring configuration.
@@ -81,6 +109,9 @@ func New(cfg Config, opts ...Option) *Server {
 
 	// Mount routes
 	addAuthRoutes(r, deps, cfg)
+	addOIDCRoutes(r, deps, cfg)
+	addOAuthRoutes(r, deps, cfg)
+	addIdentityRoutes(r, deps)
 	addMCPRoutes(r, deps, cfg)
 	addAdminRoutes(r, deps, cfg)
 	addHealthRoutes(r, cfg)
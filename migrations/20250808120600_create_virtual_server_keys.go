@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() { goose.AddMigrationContext(upCreateVirtualServerKeys, downCreateVirtualServerKeys) }
+
+func upCreateVirtualServerKeys(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS virtual_server_keys (
+  id CHAR(22) PRIMARY KEY,
+  mcp_virtual_server_id CHAR(22) NOT NULL,
+  user_id CHAR(22) NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  prefix VARCHAR(16) NOT NULL,
+  key_hash CHAR(64) NOT NULL,
+  status VARCHAR(30) NOT NULL,
+  last_used_at TIMESTAMP NULL,
+  revoked_at TIMESTAMP NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  CONSTRAINT fk_vs_keys_vs FOREIGN KEY (mcp_virtual_server_id) REFERENCES mcp_virtual_servers(id) ON DELETE CASCADE,
+  UNIQUE KEY uq_vs_keys_hash (key_hash),
+  INDEX idx_vs_keys_vs (mcp_virtual_server_id),
+  INDEX idx_vs_keys_vs_status (mcp_virtual_server_id, status),
+  INDEX idx_vs_keys_last_used (last_used_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+`)
+	return err
+}
+
+func downCreateVirtualServerKeys(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`DROP TABLE IF EXISTS virtual_server_keys;`)
+	return err
+}
@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() { goose.AddMigrationContext(upCreateMCPResources, downCreateMCPResources) }
+
+func upCreateMCPResources(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS mcp_resources (
+  id CHAR(22) PRIMARY KEY,
+  user_id CHAR(22),
+  mcp_server_id CHAR(22) NOT NULL,
+  mcp_hub_server_id CHAR(22),
+  uri VARCHAR(512) NOT NULL,
+  is_template BOOLEAN NOT NULL DEFAULT FALSE,
+  name VARCHAR(255) NOT NULL,
+  description TEXT,
+  mime_type VARCHAR(255),
+  annotations JSON,
+  status VARCHAR(30) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  CONSTRAINT fk_resources_server FOREIGN KEY (mcp_server_id) REFERENCES mcp_servers(id) ON DELETE CASCADE,
+  CONSTRAINT fk_resources_hub_server FOREIGN KEY (mcp_hub_server_id) REFERENCES mcp_hub_servers(id) ON DELETE CASCADE,
+  UNIQUE KEY uq_server_user_resource (mcp_server_id, user_id, is_template, uri),
+  INDEX idx_resources_user (user_id),
+  INDEX idx_resources_server_user (mcp_server_id, user_id),
+  INDEX idx_resources_hub_server (mcp_hub_server_id),
+  INDEX idx_resources_status (status)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+`)
+	return err
+}
+
+func downCreateMCPResources(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`DROP TABLE IF EXISTS mcp_resources;`)
+	return err
+}
@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() { goose.AddMigrationContext(upCreateMCPPrompts, downCreateMCPPrompts) }
+
+func upCreateMCPPrompts(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS mcp_prompts (
+  id CHAR(22) PRIMARY KEY,
+  user_id CHAR(22),
+  mcp_server_id CHAR(22) NOT NULL,
+  mcp_hub_server_id CHAR(22),
+  name VARCHAR(255) NOT NULL,
+  description TEXT,
+  arguments JSON,
+  status VARCHAR(30) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  CONSTRAINT fk_prompts_server FOREIGN KEY (mcp_server_id) REFERENCES mcp_servers(id) ON DELETE CASCADE,
+  CONSTRAINT fk_prompts_hub_server FOREIGN KEY (mcp_hub_server_id) REFERENCES mcp_hub_servers(id) ON DELETE CASCADE,
+  UNIQUE KEY uq_server_user_prompt (mcp_server_id, user_id, name),
+  INDEX idx_prompts_user (user_id),
+  INDEX idx_prompts_server_user (mcp_server_id, user_id),
+  INDEX idx_prompts_hub_server (mcp_hub_server_id),
+  INDEX idx_prompts_status (status)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+`)
+	return err
+}
+
+func downCreateMCPPrompts(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`DROP TABLE IF EXISTS mcp_prompts;`)
+	return err
+}
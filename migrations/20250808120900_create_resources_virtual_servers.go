@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upCreateResourcesVirtualServers, downCreateResourcesVirtualServers)
+}
+
+func upCreateResourcesVirtualServers(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS resources_virtual_servers (
+  mcp_virtual_server_id CHAR(22) NOT NULL,
+  resource_id CHAR(22) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (mcp_virtual_server_id, resource_id),
+  CONSTRAINT fk_vs_resource_vs FOREIGN KEY (mcp_virtual_server_id) REFERENCES mcp_virtual_servers(id) ON DELETE CASCADE,
+  CONSTRAINT fk_vs_resource_resource FOREIGN KEY (resource_id) REFERENCES mcp_resources(id) ON DELETE CASCADE,
+  INDEX idx_vs_resource_resource (resource_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+`)
+	return err
+}
+
+func downCreateResourcesVirtualServers(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`DROP TABLE IF EXISTS resources_virtual_servers;`)
+	return err
+}
@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upCreatePromptsVirtualServers, downCreatePromptsVirtualServers)
+}
+
+func upCreatePromptsVirtualServers(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS prompts_virtual_servers (
+  mcp_virtual_server_id CHAR(22) NOT NULL,
+  prompt_id CHAR(22) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (mcp_virtual_server_id, prompt_id),
+  CONSTRAINT fk_vs_prompt_vs FOREIGN KEY (mcp_virtual_server_id) REFERENCES mcp_virtual_servers(id) ON DELETE CASCADE,
+  CONSTRAINT fk_vs_prompt_prompt FOREIGN KEY (prompt_id) REFERENCES mcp_prompts(id) ON DELETE CASCADE,
+  INDEX idx_vs_prompt_prompt (prompt_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+`)
+	return err
+}
+
+func downCreatePromptsVirtualServers(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`DROP TABLE IF EXISTS prompts_virtual_servers;`)
+	return err
+}
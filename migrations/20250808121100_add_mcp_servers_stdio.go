@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() { goose.AddMigrationContext(upAddMCPServersStdio, downAddMCPServersStdio) }
+
+func upAddMCPServersStdio(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`
+ALTER TABLE mcp_servers
+  ADD COLUMN command VARCHAR(1024) NOT NULL DEFAULT '' AFTER transport,
+  ADD COLUMN args JSON AFTER command,
+  ADD COLUMN env JSON AFTER args;
+`)
+	return err
+}
+
+func downAddMCPServersStdio(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`
+ALTER TABLE mcp_servers
+  DROP COLUMN env,
+  DROP COLUMN args,
+  DROP COLUMN command;
+`)
+	return err
+}
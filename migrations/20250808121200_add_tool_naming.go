@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() { goose.AddMigrationContext(upAddToolNaming, downAddToolNaming) }
+
+func upAddToolNaming(ctx context.Context, tx *sql.Tx) error {
+	stmts := []string{
+		`ALTER TABLE mcp_virtual_servers
+  ADD COLUMN tool_naming VARCHAR(30) NOT NULL DEFAULT 'original' AFTER status;`,
+		`ALTER TABLE tools_virtual_servers
+  ADD COLUMN alias VARCHAR(255) NULL AFTER tool_id,
+  ADD COLUMN exposed_name VARCHAR(255) NOT NULL DEFAULT '' AFTER alias;`,
+		// Drop links to tools that no longer exist.
+		`DELETE tvs FROM tools_virtual_servers tvs
+  LEFT JOIN mcp_tools t ON t.id = tvs.tool_id
+  WHERE t.id IS NULL;`,
+		`UPDATE tools_virtual_servers tvs
+  JOIN mcp_tools t ON t.id = tvs.tool_id
+  SET tvs.exposed_name = t.original_name;`,
+		// Existing collisions fall back to the server-prefixed name.
+		`UPDATE tools_virtual_servers tvs
+  JOIN (
+    SELECT mcp_virtual_server_id, exposed_name
+    FROM tools_virtual_servers
+    GROUP BY mcp_virtual_server_id, exposed_name
+    HAVING COUNT(*) > 1
+  ) dup ON dup.mcp_virtual_server_id = tvs.mcp_virtual_server_id
+    AND dup.exposed_name = tvs.exposed_name
+  JOIN mcp_tools t ON t.id = tvs.tool_id
+  SET tvs.exposed_name = t.modified_name;`,
+		`ALTER TABLE tools_virtual_servers
+  ADD UNIQUE INDEX uq_vs_tool_exposed_name (mcp_virtual_server_id, exposed_name);`,
+	}
+	for _, q := range stmts {
+		if _, err := tx.Exec(q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func downAddToolNaming(ctx context.Context, tx *sql.Tx) error {
+	stmts := []string{
+		`ALTER TABLE tools_virtual_servers
+  DROP INDEX uq_vs_tool_exposed_name,
+  DROP COLUMN exposed_name,
+  DROP COLUMN alias;`,
+		`ALTER TABLE mcp_virtual_servers DROP COLUMN tool_naming;`,
+	}
+	for _, q := range stmts {
+		if _, err := tx.Exec(q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() { goose.AddMigrationContext(upAddToolTransforms, downAddToolTransforms) }
+
+func upAddToolTransforms(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`
+ALTER TABLE tools_virtual_servers
+  ADD COLUMN transform JSON NULL AFTER exposed_name;
+`)
+	return err
+}
+
+func downAddToolTransforms(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE tools_virtual_servers DROP COLUMN transform;`)
+	return err
+}
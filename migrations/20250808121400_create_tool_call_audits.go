@@ -0,0 +1,42 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() { goose.AddMigrationContext(upCreateToolCallAudits, downCreateToolCallAudits) }
+
+func upCreateToolCallAudits(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS tool_call_audits (
+  id CHAR(22) NOT NULL PRIMARY KEY,
+  virtual_server_id CHAR(22) NOT NULL,
+  user_id CHAR(22) NOT NULL,
+  hub_server_id CHAR(22) NULL,
+  tool_id CHAR(22) NOT NULL,
+  tool_name VARCHAR(255) NOT NULL,
+  api_key_id CHAR(22) NULL,
+  session_id VARCHAR(128) NOT NULL DEFAULT '',
+  request_id VARCHAR(64) NOT NULL DEFAULT '',
+  arguments JSON,
+  result_bytes INT NOT NULL DEFAULT 0,
+  is_error BOOLEAN NOT NULL DEFAULT FALSE,
+  latency_ms BIGINT NOT NULL DEFAULT 0,
+  error TEXT,
+  created_at TIMESTAMP(3) DEFAULT CURRENT_TIMESTAMP(3),
+  INDEX idx_audit_user_created (user_id, created_at),
+  INDEX idx_audit_vs_created (virtual_server_id, created_at),
+  INDEX idx_audit_tool_created (tool_id, created_at),
+  INDEX idx_audit_created (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+`)
+	return err
+}
+
+func downCreateToolCallAudits(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`DROP TABLE IF EXISTS tool_call_audits;`)
+	return err
+}
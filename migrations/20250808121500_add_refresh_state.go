@@ -0,0 +1,54 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() { goose.AddMigrationContext(upAddRefreshState, downAddRefreshState) }
+
+func upAddRefreshState(ctx context.Context, tx *sql.Tx) error {
+	stmts := []string{
+		`ALTER TABLE mcp_servers
+  ADD COLUMN last_refreshed_at TIMESTAMP(3) NULL,
+  ADD COLUMN last_refresh_status VARCHAR(30) NOT NULL DEFAULT '',
+  ADD COLUMN last_refresh_error VARCHAR(2000) NOT NULL DEFAULT '';`,
+		`ALTER TABLE mcp_hub_servers
+  ADD COLUMN last_refreshed_at TIMESTAMP(3) NULL,
+  ADD COLUMN last_refresh_status VARCHAR(30) NOT NULL DEFAULT '',
+  ADD COLUMN last_refresh_error VARCHAR(2000) NOT NULL DEFAULT '';`,
+		`CREATE TABLE IF NOT EXISTS refresh_leases (
+  name VARCHAR(128) NOT NULL PRIMARY KEY,
+  holder VARCHAR(128) NOT NULL,
+  expires_at TIMESTAMP(3) NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`,
+	}
+	for _, s := range stmts {
+		if _, err := tx.Exec(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func downAddRefreshState(ctx context.Context, tx *sql.Tx) error {
+	stmts := []string{
+		`DROP TABLE IF EXISTS refresh_leases;`,
+		`ALTER TABLE mcp_hub_servers
+  DROP COLUMN last_refresh_error,
+  DROP COLUMN last_refresh_status,
+  DROP COLUMN last_refreshed_at;`,
+		`ALTER TABLE mcp_servers
+  DROP COLUMN last_refresh_error,
+  DROP COLUMN last_refresh_status,
+  DROP COLUMN last_refreshed_at;`,
+	}
+	for _, s := range stmts {
+		if _, err := tx.Exec(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
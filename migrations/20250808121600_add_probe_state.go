@@ -0,0 +1,52 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() { goose.AddMigrationContext(upAddProbeState, downAddProbeState) }
+
+func upAddProbeState(ctx context.Context, tx *sql.Tx) error {
+	stmts := []string{
+		`ALTER TABLE mcp_servers
+  ADD COLUMN last_probe_at TIMESTAMP(3) NULL,
+  ADD COLUMN last_probe_latency_ms BIGINT NOT NULL DEFAULT 0,
+  ADD COLUMN last_probe_error VARCHAR(2000) NOT NULL DEFAULT '';`,
+		`ALTER TABLE mcp_hub_servers
+  ADD COLUMN last_probe_at TIMESTAMP(3) NULL,
+  ADD COLUMN last_probe_latency_ms BIGINT NOT NULL DEFAULT 0,
+  ADD COLUMN last_probe_error VARCHAR(2000) NOT NULL DEFAULT '',
+  ADD COLUMN probe_failures INT NOT NULL DEFAULT 0,
+  ADD COLUMN probe_successes INT NOT NULL DEFAULT 0;`,
+	}
+	for _, s := range stmts {
+		if _, err := tx.Exec(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func downAddProbeState(ctx context.Context, tx *sql.Tx) error {
+	stmts := []string{
+		`ALTER TABLE mcp_hub_servers
+  DROP COLUMN probe_successes,
+  DROP COLUMN probe_failures,
+  DROP COLUMN last_probe_error,
+  DROP COLUMN last_probe_latency_ms,
+  DROP COLUMN last_probe_at;`,
+		`ALTER TABLE mcp_servers
+  DROP COLUMN last_probe_error,
+  DROP COLUMN last_probe_latency_ms,
+  DROP COLUMN last_probe_at;`,
+	}
+	for _, s := range stmts {
+		if _, err := tx.Exec(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
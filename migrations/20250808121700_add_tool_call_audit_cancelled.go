@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddToolCallAuditCancelled, downAddToolCallAuditCancelled)
+}
+
+func upAddToolCallAuditCancelled(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`
+ALTER TABLE tool_call_audits
+  ADD COLUMN cancelled BOOLEAN NOT NULL DEFAULT FALSE AFTER is_error;
+`)
+	return err
+}
+
+func downAddToolCallAuditCancelled(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE tool_call_audits DROP COLUMN cancelled;`)
+	return err
+}
@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() { goose.AddMigrationContext(upAddMCPSessions, downAddMCPSessions) }
+
+func upAddMCPSessions(ctx context.Context, tx *sql.Tx) error {
+	stmts := []string{
+		`ALTER TABLE mcp_virtual_servers
+  ADD COLUMN session_mode VARCHAR(30) NOT NULL DEFAULT 'stateless' AFTER tool_naming;`,
+		`CREATE TABLE IF NOT EXISTS mcp_sessions (
+  id VARCHAR(64) NOT NULL PRIMARY KEY,
+  mcp_virtual_server_id CHAR(22) NOT NULL,
+  api_key_id CHAR(22) NOT NULL,
+  protocol_version VARCHAR(32) NOT NULL DEFAULT '',
+  client_name VARCHAR(255) NOT NULL DEFAULT '',
+  client_version VARCHAR(64) NOT NULL DEFAULT '',
+  client_capabilities JSON NULL,
+  log_level VARCHAR(16) NOT NULL DEFAULT '',
+  expires_at TIMESTAMP(3) NOT NULL,
+  created_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  KEY idx_mcp_sessions_vs (mcp_virtual_server_id),
+  KEY idx_mcp_sessions_expires (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`,
+	}
+	for _, s := range stmts {
+		if _, err := tx.Exec(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func downAddMCPSessions(ctx context.Context, tx *sql.Tx) error {
+	stmts := []string{
+		`DROP TABLE IF EXISTS mcp_sessions;`,
+		`ALTER TABLE mcp_virtual_servers DROP COLUMN session_mode;`,
+	}
+	for _, s := range stmts {
+		if _, err := tx.Exec(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddVSClientRequests, downAddVSClientRequests)
+}
+
+func upAddVSClientRequests(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE mcp_virtual_servers
+  ADD COLUMN allow_sampling BOOLEAN NOT NULL DEFAULT FALSE AFTER session_mode,
+  ADD COLUMN allow_elicitation BOOLEAN NOT NULL DEFAULT FALSE AFTER allow_sampling;`)
+	return err
+}
+
+func downAddVSClientRequests(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE mcp_virtual_servers
+  DROP COLUMN allow_elicitation,
+  DROP COLUMN allow_sampling;`)
+	return err
+}
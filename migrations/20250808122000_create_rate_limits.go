@@ -0,0 +1,54 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() { goose.AddMigrationContext(upCreateRateLimits, downCreateRateLimits) }
+
+func upCreateRateLimits(ctx context.Context, tx *sql.Tx) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS rate_limits (
+  id CHAR(22) NOT NULL PRIMARY KEY,
+  scope VARCHAR(30) NOT NULL,
+  scope_id CHAR(22) NOT NULL,
+  rate_per_minute INT NOT NULL DEFAULT 0,
+  burst INT NOT NULL DEFAULT 0,
+  daily_quota BIGINT NOT NULL DEFAULT 0,
+  monthly_quota BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY uq_rate_limits_scope (scope, scope_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`,
+		`CREATE TABLE IF NOT EXISTS call_usage (
+  scope VARCHAR(30) NOT NULL,
+  scope_id CHAR(22) NOT NULL,
+  period VARCHAR(10) NOT NULL,
+  calls BIGINT NOT NULL DEFAULT 0,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (scope, scope_id, period)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`,
+	}
+	for _, s := range stmts {
+		if _, err := tx.Exec(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func downCreateRateLimits(ctx context.Context, tx *sql.Tx) error {
+	stmts := []string{
+		`DROP TABLE IF EXISTS call_usage;`,
+		`DROP TABLE IF EXISTS rate_limits;`,
+	}
+	for _, s := range stmts {
+		if _, err := tx.Exec(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddServerCallPolicy, downAddServerCallPolicy)
+}
+
+func upAddServerCallPolicy(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE mcp_servers
+  ADD COLUMN call_timeout_seconds INT NOT NULL DEFAULT 0,
+  ADD COLUMN connect_timeout_seconds INT NOT NULL DEFAULT 0,
+  ADD COLUMN max_retries INT NOT NULL DEFAULT 0,
+  ADD COLUMN retry_backoff_ms INT NOT NULL DEFAULT 0,
+  ADD COLUMN breaker_threshold INT NOT NULL DEFAULT 0,
+  ADD COLUMN breaker_cooldown_seconds INT NOT NULL DEFAULT 0;`)
+	return err
+}
+
+func downAddServerCallPolicy(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE mcp_servers
+  DROP COLUMN breaker_cooldown_seconds,
+  DROP COLUMN breaker_threshold,
+  DROP COLUMN retry_backoff_ms,
+  DROP COLUMN max_retries,
+  DROP COLUMN connect_timeout_seconds,
+  DROP COLUMN call_timeout_seconds;`)
+	return err
+}
@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddVSArgValidation, downAddVSArgValidation)
+}
+
+func upAddVSArgValidation(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE mcp_virtual_servers
+  ADD COLUMN arg_validation VARCHAR(30) NOT NULL DEFAULT 'off' AFTER allow_elicitation;`)
+	return err
+}
+
+func downAddVSArgValidation(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE mcp_virtual_servers
+  DROP COLUMN arg_validation;`)
+	return err
+}
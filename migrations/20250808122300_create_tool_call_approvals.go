@@ -0,0 +1,66 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upCreateToolCallApprovals, downCreateToolCallApprovals)
+}
+
+func upCreateToolCallApprovals(ctx context.Context, tx *sql.Tx) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS tool_call_approvals (
+  id CHAR(22) NOT NULL PRIMARY KEY,
+  virtual_server_id CHAR(22) NOT NULL,
+  user_id CHAR(22) NOT NULL,
+  tool_id CHAR(22) NOT NULL,
+  tool_name VARCHAR(255) NOT NULL,
+  api_key_id CHAR(22) NULL,
+  session_id VARCHAR(128) NOT NULL DEFAULT '',
+  arguments JSON NULL,
+  status VARCHAR(30) NOT NULL,
+  reason VARCHAR(1000) NOT NULL DEFAULT '',
+  decided_by CHAR(22) NULL,
+  decided_at TIMESTAMP NULL,
+  expires_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  KEY idx_tool_call_approvals_user_status (user_id, status, created_at),
+  KEY idx_tool_call_approvals_vs (virtual_server_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`,
+		`ALTER TABLE mcp_virtual_servers
+  ADD COLUMN approve_destructive BOOLEAN NOT NULL DEFAULT FALSE AFTER arg_validation;`,
+		`ALTER TABLE tools_virtual_servers
+  ADD COLUMN require_approval BOOLEAN NOT NULL DEFAULT FALSE AFTER transform;`,
+		`ALTER TABLE tool_call_audits
+  ADD COLUMN approval_id CHAR(22) NULL,
+  ADD COLUMN approval_status VARCHAR(30) NOT NULL DEFAULT '';`,
+	}
+	for _, s := range stmts {
+		if _, err := tx.Exec(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func downCreateToolCallApprovals(ctx context.Context, tx *sql.Tx) error {
+	stmts := []string{
+		`ALTER TABLE tool_call_audits
+  DROP COLUMN approval_status,
+  DROP COLUMN approval_id;`,
+		`ALTER TABLE tools_virtual_servers DROP COLUMN require_approval;`,
+		`ALTER TABLE mcp_virtual_servers DROP COLUMN approve_destructive;`,
+		`DROP TABLE IF EXISTS tool_call_approvals;`,
+	}
+	for _, s := range stmts {
+		if _, err := tx.Exec(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddVSMode, downAddVSMode)
+}
+
+func upAddVSMode(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE mcp_virtual_servers
+  ADD COLUMN mode VARCHAR(30) NOT NULL DEFAULT 'full' AFTER approve_destructive,
+  ADD COLUMN annotation_filter JSON NULL AFTER mode;`)
+	return err
+}
+
+func downAddVSMode(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE mcp_virtual_servers
+  DROP COLUMN annotation_filter,
+  DROP COLUMN mode;`)
+	return err
+}
@@ -0,0 +1,72 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upCreateOAuthTables, downCreateOAuthTables)
+}
+
+func upCreateOAuthTables(ctx context.Context, tx *sql.Tx) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS oauth_clients (
+  id CHAR(22) NOT NULL PRIMARY KEY,
+  name VARCHAR(255) NOT NULL DEFAULT '',
+  redirect_uris JSON NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`,
+		`CREATE TABLE IF NOT EXISTS oauth_codes (
+  id CHAR(22) NOT NULL PRIMARY KEY,
+  code_hash CHAR(64) NOT NULL,
+  client_id CHAR(22) NOT NULL,
+  user_id CHAR(22) NOT NULL,
+  redirect_uri VARCHAR(2000) NOT NULL,
+  code_challenge VARCHAR(128) NOT NULL,
+  virtual_server_ids JSON NOT NULL,
+  expires_at TIMESTAMP NOT NULL,
+  used_at TIMESTAMP NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY uk_oauth_codes_hash (code_hash),
+  KEY idx_oauth_codes_expires (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`,
+		`CREATE TABLE IF NOT EXISTS oauth_tokens (
+  id CHAR(22) NOT NULL PRIMARY KEY,
+  token_hash CHAR(64) NOT NULL,
+  kind VARCHAR(30) NOT NULL,
+  grant_id CHAR(22) NOT NULL,
+  client_id CHAR(22) NOT NULL,
+  user_id CHAR(22) NOT NULL,
+  virtual_server_ids JSON NOT NULL,
+  expires_at TIMESTAMP NOT NULL,
+  revoked_at TIMESTAMP NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY uk_oauth_tokens_hash (token_hash),
+  KEY idx_oauth_tokens_grant (grant_id),
+  KEY idx_oauth_tokens_expires (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`,
+	}
+	for _, s := range stmts {
+		if _, err := tx.Exec(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func downCreateOAuthTables(ctx context.Context, tx *sql.Tx) error {
+	stmts := []string{
+		`DROP TABLE IF EXISTS oauth_tokens;`,
+		`DROP TABLE IF EXISTS oauth_codes;`,
+		`DROP TABLE IF EXISTS oauth_clients;`,
+	}
+	for _, s := range stmts {
+		if _, err := tx.Exec(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
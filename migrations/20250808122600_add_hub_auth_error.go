@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddHubAuthError, downAddHubAuthError)
+}
+
+func upAddHubAuthError(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE mcp_hub_servers
+  ADD COLUMN auth_error VARCHAR(2000) NOT NULL DEFAULT '' AFTER auth_value;`)
+	return err
+}
+
+func downAddHubAuthError(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE mcp_hub_servers
+  DROP COLUMN auth_error;`)
+	return err
+}
@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddServerIdentityHeader,
+		downAddServerIdentityHeader)
+}
+
+func upAddServerIdentityHeader(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE mcp_servers
+  ADD COLUMN identity_header VARCHAR(100) NOT NULL DEFAULT '';`)
+	return err
+}
+
+func downAddServerIdentityHeader(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE mcp_servers
+  DROP COLUMN identity_header;`)
+	return err
+}
@@ -0,0 +1,126 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() { goose.AddMigrationContext(upCreateTeams, downCreateTeams) }
+
+func upCreateTeams(ctx context.Context, tx *sql.Tx) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS organizations (
+  id CHAR(22) PRIMARY KEY,
+  name VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`,
+		`CREATE TABLE IF NOT EXISTS organization_members (
+  organization_id CHAR(22) NOT NULL,
+  user_id CHAR(22) NOT NULL,
+  role VARCHAR(30) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (organization_id, user_id),
+  CONSTRAINT fk_org_members_org FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+  CONSTRAINT fk_org_members_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  INDEX idx_org_members_user (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`,
+		`CREATE TABLE IF NOT EXISTS teams (
+  id CHAR(22) PRIMARY KEY,
+  organization_id CHAR(22) NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  CONSTRAINT fk_teams_org FOREIGN KEY (organization_id) REFERENCES organizations(id),
+  UNIQUE KEY uq_teams_org_name (organization_id, name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`,
+		`CREATE TABLE IF NOT EXISTS team_members (
+  team_id CHAR(22) NOT NULL,
+  user_id CHAR(22) NOT NULL,
+  role VARCHAR(30) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (team_id, user_id),
+  CONSTRAINT fk_team_members_team FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE CASCADE,
+  CONSTRAINT fk_team_members_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  INDEX idx_team_members_user (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`,
+		// Team hubs keep the user who added them in user_id; owner_id makes
+		// a server unique per team, or per user for personal hubs
+		`ALTER TABLE mcp_hub_servers
+  ADD COLUMN team_id CHAR(22) NULL AFTER user_id,
+  ADD COLUMN owner_id CHAR(22) AS (COALESCE(team_id, user_id)) STORED,
+  ADD CONSTRAINT fk_hub_team FOREIGN KEY (team_id) REFERENCES teams(id),
+  ADD INDEX idx_hub_team (team_id),
+  ADD UNIQUE KEY uq_owner_server (owner_id, mcp_server_id),
+  DROP INDEX uq_user_server;`,
+		`ALTER TABLE mcp_virtual_servers
+  ADD COLUMN team_id CHAR(22) NULL AFTER user_id,
+  ADD CONSTRAINT fk_vs_team FOREIGN KEY (team_id) REFERENCES teams(id),
+  ADD INDEX idx_vs_team (team_id);`,
+		// Tools, resources and prompts of team hubs have a team_id and no
+		// user_id
+		`ALTER TABLE mcp_tools
+  ADD COLUMN team_id CHAR(22) NULL AFTER user_id,
+  ADD UNIQUE KEY uq_server_team_tool (mcp_server_id, team_id, original_name),
+  ADD INDEX idx_tools_team (team_id);`,
+		`ALTER TABLE mcp_resources
+  ADD COLUMN team_id CHAR(22) NULL AFTER user_id,
+  ADD UNIQUE KEY uq_server_team_resource (mcp_server_id, team_id, is_template, uri),
+  ADD INDEX idx_resources_team (team_id);`,
+		`ALTER TABLE mcp_prompts
+  ADD COLUMN team_id CHAR(22) NULL AFTER user_id,
+  ADD UNIQUE KEY uq_server_team_prompt (mcp_server_id, team_id, name),
+  ADD INDEX idx_prompts_team (team_id);`,
+	}
+	for _, s := range stmts {
+		if _, err := tx.Exec(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func downCreateTeams(ctx context.Context, tx *sql.Tx) error {
+	stmts := []string{
+		`DELETE FROM mcp_prompts WHERE team_id IS NOT NULL;`,
+		`ALTER TABLE mcp_prompts
+  DROP INDEX uq_server_team_prompt,
+  DROP INDEX idx_prompts_team,
+  DROP COLUMN team_id;`,
+		`DELETE FROM mcp_resources WHERE team_id IS NOT NULL;`,
+		`ALTER TABLE mcp_resources
+  DROP INDEX uq_server_team_resource,
+  DROP INDEX idx_resources_team,
+  DROP COLUMN team_id;`,
+		`DELETE FROM mcp_tools WHERE team_id IS NOT NULL;`,
+		`ALTER TABLE mcp_tools
+  DROP INDEX uq_server_team_tool,
+  DROP INDEX idx_tools_team,
+  DROP COLUMN team_id;`,
+		`DELETE FROM mcp_virtual_servers WHERE team_id IS NOT NULL;`,
+		`ALTER TABLE mcp_virtual_servers
+  DROP FOREIGN KEY fk_vs_team,
+  DROP INDEX idx_vs_team,
+  DROP COLUMN team_id;`,
+		`DELETE FROM mcp_hub_servers WHERE team_id IS NOT NULL;`,
+		`ALTER TABLE mcp_hub_servers
+  ADD UNIQUE KEY uq_user_server (user_id, mcp_server_id),
+  DROP INDEX uq_owner_server,
+  DROP FOREIGN KEY fk_hub_team,
+  DROP INDEX idx_hub_team,
+  DROP COLUMN owner_id,
+  DROP COLUMN team_id;`,
+		`DROP TABLE IF EXISTS team_members;`,
+		`DROP TABLE IF EXISTS teams;`,
+		`DROP TABLE IF EXISTS organization_members;`,
+		`DROP TABLE IF EXISTS organizations;`,
+	}
+	for _, s := range stmts {
+		if _, err := tx.Exec(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddUserAuthSubject, downAddUserAuthSubject)
+}
+
+// Users created by an OIDC provider keep the provider's name and the
+// account's subject, so a later sign in is matched to the account rather
+// than to whoever holds the email.
+func upAddUserAuthSubject(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE users
+  ADD COLUMN auth_provider VARCHAR(64) NULL,
+  ADD COLUMN auth_subject VARCHAR(255) NULL,
+  ADD UNIQUE KEY uq_users_auth_subject (auth_provider, auth_subject);`)
+	return err
+}
+
+func downAddUserAuthSubject(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE users
+  DROP INDEX uq_users_auth_subject,
+  DROP COLUMN auth_subject,
+  DROP COLUMN auth_provider;`)
+	return err
+}
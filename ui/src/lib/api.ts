@@ -4,17 +4,59 @@ export type CatalogServer = {
   url: string
   description: string
   access_type?: 'public' | 'private'
-  transport?: string
+  transport?: 'streamable-http' | 'sse' | 'stdio'
+  command?: string
+  args?: string[]
   capabilities?: any
+  last_refreshed_at?: string | null
+  last_refresh_status?: '' | 'success' | 'error'
+  last_refresh_error?: string
+  last_probe_at?: string | null
+  last_probe_latency_ms?: number
+  last_probe_error?: string
+  call_timeout_seconds?: number
+  connect_timeout_seconds?: number
+  max_retries?: number
+  retry_backoff_ms?: number
+  breaker_threshold?: number
+  breaker_cooldown_seconds?: number
+  identity_header?: string
+  breaker?: BreakerStatus
+}
+
+export type BreakerStatus = {
+  state: 'closed' | 'open' | 'half_open'
+  consecutive_failures: number
+  opened_at?: string
+  retry_at?: string
+}
+
+export type CallPolicy = {
+  call_timeout_seconds?: number
+  connect_timeout_seconds?: number
+  max_retries?: number
+  retry_backoff_ms?: number
+  breaker_threshold?: number
+  breaker_cooldown_seconds?: number
 }
 
 export type HubServer = {
   id?: string
   user_id: string
+  team_id?: string | null  // Set for hubs shared by a team
   mcp_server_id: string
   status: string
   auth_type?: string
   auth_value?: string
+  auth_error?: string
+  last_refreshed_at?: string | null
+  last_refresh_status?: '' | 'success' | 'error'
+  last_refresh_error?: string
+  last_probe_at?: string | null
+  last_probe_latency_ms?: number
+  last_probe_error?: string
+  probe_failures?: number
+  probe_successes?: number
   // Server details from join
   name?: string
   url?: string
@@ -22,11 +64,13 @@ export type HubServer = {
   capabilities?: any
   transport?: string
   access_type?: string
+  breaker?: BreakerStatus
 }
 
 export type Tool = { 
   id: string
   user_id?: string  // Nullable for global tools
+  team_id?: string | null  // Set for tools of team hubs
   mcp_server_id: string  // Server reference
   mcp_hub_server_id?: string  // Hub server reference (for private tools)
   original_name: string
@@ -37,11 +81,160 @@ export type Tool = {
   annotations?: any
 }
 
+export type ToolNaming = 'original' | 'prefixed'
+
+export type SessionMode = 'stateless' | 'stateful'
+
 export type VirtualServer = { 
   id: string
   user_id: string
+  team_id?: string | null  // Set for virtual servers shared by a team
   name?: string
   status: string
+  tool_naming?: ToolNaming
+  session_mode?: SessionMode
+  allow_sampling?: boolean
+  allow_elicitation?: boolean
+  arg_validation?: 'off' | 'warn' | 'enforce'
+  approve_destructive?: boolean
+  mode?: AccessMode
+  annotation_filter?: AnnotationFilter | null
+}
+
+export type AccessMode = 'full' | 'read_only' | 'annotation_filtered'
+
+export type AnnotationFilter = Partial<Record<'readOnlyHint' | 'destructiveHint' | 'idempotentHint' | 'openWorldHint', boolean>>
+
+export type ToolTransform = {
+  fixed_args?: Record<string, any>
+  default_args?: Record<string, any>
+  hidden?: string[]
+  rename?: Record<string, string>
+  redact?: string[]
+}
+
+export type VirtualServerTool = Tool & {
+  alias?: string | null
+  exposed_name: string
+  transform?: ToolTransform | null
+  require_approval?: boolean
+}
+
+export type ToolCallAudit = {
+  id: string
+  virtual_server_id: string
+  user_id: string
+  hub_server_id?: string | null
+  tool_id: string
+  tool_name: string
+  api_key_id?: string | null
+  session_id?: string
+  request_id?: string
+  arguments?: any
+  result_bytes: number
+  is_error: boolean
+  cancelled: boolean
+  latency_ms: number
+  error?: string
+  approval_id?: string | null
+  approval_status?: '' | ApprovalStatus
+  created_at: string
+}
+
+export type ApprovalStatus = 'pending' | 'approved' | 'rejected' | 'expired' | 'cancelled'
+
+export type ToolCallApproval = {
+  id: string
+  virtual_server_id: string
+  user_id: string
+  tool_id: string
+  tool_name: string
+  api_key_id?: string | null
+  session_id?: string
+  arguments?: any
+  status: ApprovalStatus
+  reason?: string
+  decided_by?: string | null
+  decided_at?: string | null
+  expires_at: string
+  created_at: string
+  updated_at: string
+}
+
+export type Resource = {
+  id: string
+  user_id?: string | null
+  mcp_server_id: string
+  mcp_hub_server_id?: string | null
+  uri: string
+  is_template: boolean
+  name: string
+  description?: string
+  mime_type?: string
+  status: string
+}
+
+export type Prompt = {
+  id: string
+  user_id?: string | null
+  mcp_server_id: string
+  mcp_hub_server_id?: string | null
+  name: string
+  description?: string
+  arguments?: Array<{ name: string, description?: string, required?: boolean }> | null
+  status: string
+}
+
+export type VirtualServerKey = {
+  id: string
+  mcp_virtual_server_id: string
+  user_id: string
+  name: string
+  prefix: string
+  status: string
+  last_used_at?: string | null
+  revoked_at?: string | null
+  created_at: string
+}
+
+export type RateLimit = {
+  rate_per_minute: number
+  burst: number
+  daily_quota: number
+  monthly_quota: number
+}
+
+export type LimitUsage = {
+  day: string
+  calls_today: number
+  month: string
+  calls_this_month: number
+  tokens?: number
+}
+
+export type MemberRole = 'owner' | 'maintainer' | 'member'
+
+export type Organization = {
+  id: string
+  name: string
+  role?: MemberRole | ''  // Caller's role; empty for admins who are not members
+  created_at?: string
+}
+
+export type Team = {
+  id: string
+  organization_id: string
+  organization_name?: string
+  name: string
+  role?: MemberRole | ''
+  created_at?: string
+}
+
+export type Member = {
+  user_id: string
+  username: string
+  role: MemberRole
+  created_at?: string
 }
 
 class ApiError extends Error {
@@ -91,21 +284,23 @@ export const api = {
   loginWithGoogle: (credential: string) => http<{user_id: string; email: string; name?: string}>('/api/auth/google', { method: 'POST', body: JSON.stringify({ credential }) }),
   logout: () => http<void>('/api/auth/logout', { method: 'POST' }),
   loginWithBasic: (username: string, password: string) => http<{user_id: string; email: string}>('/api/auth/basic', { method: 'POST', body: JSON.stringify({ username, password }) }),
+  oidcProviders: () => http<{items: {name: string; display_name: string; login_url: string}[]}>('/api/auth/oidc/providers'),
   me: () => http<{user_id: string; email?: string; name?: string; role?: string}>('/api/auth/me'),
   
   // Catalog endpoints
   listCatalog: () => http<{items: CatalogServer[]}>('/api/catalog/servers'),
-  addCatalog: (body: { name: string; url: string; description?: string; access_type?: string; transport?: string }) => 
+  addCatalog: (body: { name: string; url?: string; description?: string; access_type?: string; transport?: string; command?: string; args?: string[]; env?: Record<string, string>; identity_header?: string }) => 
     http<{id: string}>('/api/catalog/servers', { method: 'POST', body: JSON.stringify(body) }),
-  updateCatalog: (id: string, body: { url?: string; description?: string }) =>
+  updateCatalog: (id: string, body: { url?: string; description?: string; identity_header?: string } & CallPolicy) =>
     http<{ok: boolean}>(`/api/catalog/servers/${id}`, { method: 'PATCH', body: JSON.stringify(body) }),
   refreshCatalog: (id: string) => http<{ok: boolean; added: Tool[]; deleted: Tool[]; total_added: number; total_deleted: number}>(`/api/catalog/servers/${id}/refresh`, { method: 'POST' }),
   getCatalogTools: (id: string) => http<{items: Tool[]}>(`/api/catalog/servers/${id}/tools`),
   
   // Hub endpoints  
-  listHubs: () => http<{items: HubServer[]}>('/api/hub/servers'),
+  listHubs: (team_id?: string) => http<{items: HubServer[]}>(`/api/hub/servers${team_id ? `?team_id=${encodeURIComponent(team_id)}` : ''}`),
   addHub: (body: any) => http<{id: string}>('/api/hub/servers', { method: 'POST', body: JSON.stringify(body) }),
   deleteHub: (id: string) => http<{ok: string}>(`/api/hub/servers/${id}`, { method: 'DELETE' }),
+  startHubOAuth: (id: string) => http<{authorization_url: string}>(`/api/hub/servers/${id}/oauth/start`, { method: 'POST' }),
   refreshHub: (id: string) => http<{ok: boolean; added: Tool[]; deleted: Tool[]; total_added: number; total_deleted: number}>(`/api/hub/servers/${id}/refresh`, { method: 'POST' }),
   
   // Tools endpoints (UPDATED: server_id instead of hub_server_id)
@@ -114,13 +309,60 @@ export const api = {
   deleteTool: (id: string) => http<{ok: string}>(`/api/tools/${id}`, { method: 'DELETE' }),
   
   // Virtual Server endpoints
-  createVS: (name?: string, tool_ids?: string[]) => http<{id: string}>(`/api/virtual-servers`, { method: 'POST', body: JSON.stringify({ name, tool_ids }) }),
-  listVS: () => http<{items: VirtualServer[]}>(`/api/virtual-servers`),
+  createVS: (name?: string, tool_ids?: string[], tool_naming?: ToolNaming, team_id?: string) => http<{id: string}>(`/api/virtual-servers`, { method: 'POST', body: JSON.stringify({ name, tool_ids, tool_naming, team_id }) }),
+  listVS: (team_id?: string) => http<{items: VirtualServer[]}>(`/api/virtual-servers${team_id ? `?team_id=${encodeURIComponent(team_id)}` : ''}`),
   updateVS: (id: string, name: string) => http<{ok: string}>(`/api/virtual-servers/${id}`, { method: 'PATCH', body: JSON.stringify({name}) }),
+  setVSToolNaming: (id: string, tool_naming: ToolNaming) => http<{ok: string}>(`/api/virtual-servers/${id}`, { method: 'PATCH', body: JSON.stringify({tool_naming}) }),
+  setVSSessionMode: (id: string, session_mode: SessionMode) => http<{ok: string}>(`/api/virtual-servers/${id}`, { method: 'PATCH', body: JSON.stringify({session_mode}) }),
+  setVSArgValidation: (id: string, arg_validation: 'off' | 'warn' | 'enforce') => http<{ok: string}>(`/api/virtual-servers/${id}`, { method: 'PATCH', body: JSON.stringify({ arg_validation }) }),
+  setVSMode: (id: string, mode: AccessMode, annotation_filter?: AnnotationFilter) => http<{ok: string}>(`/api/virtual-servers/${id}`, { method: 'PATCH', body: JSON.stringify({ mode, annotation_filter }) }),
+  setVSApproveDestructive: (id: string, approve_destructive: boolean) => http<{ok: string}>(`/api/virtual-servers/${id}`, { method: 'PATCH', body: JSON.stringify({ approve_destructive }) }),
+  setVSClientRequests: (id: string, body: {allow_sampling?: boolean, allow_elicitation?: boolean}) => http<{ok: string}>(`/api/virtual-servers/${id}`, { method: 'PATCH', body: JSON.stringify(body) }),
+  setVSToolAlias: (id: string, tool_id: string, alias: string | null) => http<{ok: string}>(`/api/virtual-servers/${id}/tools/${tool_id}`, { method: 'PATCH', body: JSON.stringify({alias}) }),
+  setVSToolRequireApproval: (id: string, tool_id: string, require_approval: boolean) => http<{ok: string}>(`/api/virtual-servers/${id}/tools/${tool_id}`, { method: 'PATCH', body: JSON.stringify({require_approval}) }),
+  setVSToolTransform: (id: string, tool_id: string, rules: ToolTransform) => http<{ok: string}>(`/api/virtual-servers/${id}/tools/${tool_id}/transform`, { method: 'PUT', body: JSON.stringify(rules) }),
+  clearVSToolTransform: (id: string, tool_id: string) => http<{ok: string}>(`/api/virtual-servers/${id}/tools/${tool_id}/transform`, { method: 'DELETE' }),
   replaceVSTools: (id: string, tool_ids: string[]) => http<{ok: string}>(`/api/virtual-servers/${id}/tools`, { method: 'PUT', body: JSON.stringify({tool_ids}) }),
   removeVSTool: (id: string, tool_id: string) => http<{ok: string}>(`/api/virtual-servers/${id}/tools/${tool_id}`, { method: 'DELETE' }),
   setVSStatus: (id: string, status: string) => http<{ok: string}>(`/api/virtual-servers/${id}/status`, { method: 'PATCH', body: JSON.stringify({status}) }),
   deleteVS: (id: string) => http<{ok: string}>(`/api/virtual-servers/${id}`, { method: 'DELETE' }),
-  listVSTools: (id: string) => http<{items: Tool[]}>(`/api/virtual-servers/${id}/tools`),
+  listVSTools: (id: string) => http<{items: VirtualServerTool[]}>(`/api/virtual-servers/${id}/tools`),
+  listResources: (q: URLSearchParams) => http<{items: Resource[]}>(`/api/resources?${q.toString()}`),
+  listPrompts: (q: URLSearchParams) => http<{items: Prompt[]}>(`/api/prompts?${q.toString()}`),
+  listVSResources: (id: string) => http<{items: Resource[]}>(`/api/virtual-servers/${id}/resources`),
+  replaceVSResources: (id: string, resource_ids: string[]) => http<{ok: string}>(`/api/virtual-servers/${id}/resources`, { method: 'PUT', body: JSON.stringify({resource_ids}) }),
+  removeVSResource: (id: string, resource_id: string) => http<{ok: string}>(`/api/virtual-servers/${id}/resources/${resource_id}`, { method: 'DELETE' }),
+  listVSPrompts: (id: string) => http<{items: Prompt[]}>(`/api/virtual-servers/${id}/prompts`),
+  replaceVSPrompts: (id: string, prompt_ids: string[]) => http<{ok: string}>(`/api/virtual-servers/${id}/prompts`, { method: 'PUT', body: JSON.stringify({prompt_ids}) }),
+  removeVSPrompt: (id: string, prompt_id: string) => http<{ok: string}>(`/api/virtual-servers/${id}/prompts/${prompt_id}`, { method: 'DELETE' }),
+  listAuditCalls: (qp: URLSearchParams) => http<{items: ToolCallAudit[], total: number, limit: number, offset: number}>(`/api/audit/calls?${qp.toString()}`),
+  listApprovals: (qp: URLSearchParams) => http<{items: ToolCallApproval[], total: number, limit: number, offset: number}>(`/api/approvals?${qp.toString()}`),
+  getApproval: (id: string) => http<ToolCallApproval>(`/api/approvals/${id}`),
+  approveCall: (id: string, reason?: string) => http<ToolCallApproval>(`/api/approvals/${id}/approve`, { method: 'POST', body: JSON.stringify({reason}) }),
+  rejectCall: (id: string, reason?: string) => http<ToolCallApproval>(`/api/approvals/${id}/reject`, { method: 'POST', body: JSON.stringify({reason}) }),
+  listVSKeys: (id: string) => http<{items: VirtualServerKey[]}>(`/api/virtual-servers/${id}/keys`),
+  createVSKey: (id: string, name: string) => http<{item: VirtualServerKey, key: string}>(`/api/virtual-servers/${id}/keys`, { method: 'POST', body: JSON.stringify({name}) }),
+  revokeVSKey: (id: string, key_id: string) => http<{ok: string}>(`/api/virtual-servers/${id}/keys/${key_id}`, { method: 'DELETE' }),
+  rotateVSKey: (id: string, key_id: string) => http<{item: VirtualServerKey, key: string}>(`/api/virtual-servers/${id}/keys/${key_id}/rotate`, { method: 'POST' }),
+  // Organizations and teams
+  listOrgs: () => http<{items: Organization[]}>('/api/orgs'),
+  createOrg: (name: string) => http<Organization>('/api/orgs', { method: 'POST', body: JSON.stringify({name}) }),
+  renameOrg: (id: string, name: string) => http<{ok: string}>(`/api/orgs/${id}`, { method: 'PATCH', body: JSON.stringify({name}) }),
+  deleteOrg: (id: string) => http<{ok: string}>(`/api/orgs/${id}`, { method: 'DELETE' }),
+  listOrgMembers: (id: string) => http<{items: Member[]}>(`/api/orgs/${id}/members`),
+  addOrgMember: (id: string, username: string, role: MemberRole) => http<{ok: string}>(`/api/orgs/${id}/members`, { method: 'POST', body: JSON.stringify({username, role}) }),
+  setOrgMemberRole: (id: string, user_id: string, role: MemberRole) => http<{ok: string}>(`/api/orgs/${id}/members/${user_id}`, { method: 'PATCH', body: JSON.stringify({role}) }),
+  removeOrgMember: (id: string, user_id: string) => http<{ok: string}>(`/api/orgs/${id}/members/${user_id}`, { method: 'DELETE' }),
+  createTeam: (org_id: string, name: string) => http<Team>(`/api/orgs/${org_id}/teams`, { method: 'POST', body: JSON.stringify({name}) }),
+  listTeams: (org_id?: string) => http<{items: Team[]}>(`/api/teams${org_id ? `?organization_id=${encodeURIComponent(org_id)}` : ''}`),
+  renameTeam: (id: string, name: string) => http<{ok: string}>(`/api/teams/${id}`, { method: 'PATCH', body: JSON.stringify({name}) }),
+  deleteTeam: (id: string) => http<{ok: string}>(`/api/teams/${id}`, { method: 'DELETE' }),
+  listTeamMembers: (id: string) => http<{items: Member[]}>(`/api/teams/${id}/members`),
+  addTeamMember: (id: string, username: string, role: MemberRole) => http<{ok: string}>(`/api/teams/${id}/members`, { method: 'POST', body: JSON.stringify({username, role}) }),
+  setTeamMemberRole: (id: string, user_id: string, role: MemberRole) => http<{ok: string}>(`/api/teams/${id}/members/${user_id}`, { method: 'PATCH', body: JSON.stringify({role}) }),
+  removeTeamMember: (id: string, user_id: string) => http<{ok: string}>(`/api/teams/${id}/members/${user_id}`, { method: 'DELETE' }),
+  getLimits: (path: string) => http<{limit: RateLimit | null, usage: LimitUsage}>(`/api/${path}/limits`),
+  setLimits: (path: string, limit: RateLimit) => http<{item: RateLimit}>(`/api/${path}/limits`, { method: 'PUT', body: JSON.stringify(limit) }),
+  deleteLimits: (path: string) => http<{ok: string}>(`/api/${path}/limits`, { method: 'DELETE' }),
 }
 
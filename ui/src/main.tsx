@@ -6,6 +6,7 @@ import { AppLayout } from './shell/AppLayout'
 import { Catalogue } from './pages/Catalogue'
 import { Hub } from './pages/Hub'
 import { VirtualServers } from './pages/VirtualServers'
+import { Teams } from './pages/Teams'
 
 const router = createBrowserRouter([
   {
@@ -15,6 +16,7 @@ const router = createBrowserRouter([
       { index: true, element: <Catalogue /> },
       { path: 'hub', element: <Hub /> },
       { path: 'virtual-servers', element: <VirtualServers /> },
+      { path: 'teams', element: <Teams /> },
     ],
   },
 ])
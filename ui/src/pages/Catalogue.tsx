@@ -1,6 +1,6 @@
 import React, { useEffect, useMemo, useState } from 'react'
 import { notifyError, notifySuccess } from '../components/ToastHost'
-import { api, CatalogServer } from '../lib/api'
+import { api, CatalogServer, Team } from '../lib/api'
 import { useNavigate } from 'react-router-dom'
 
 export function Catalogue() {
@@ -18,6 +18,7 @@ export function Catalogue() {
   const [editOpen, setEditOpen] = useState<null | CatalogServer>(null)
   const [editUrl, setEditUrl] = useState('')
   const [editDesc, setEditDesc] = useState('')
+  const [editIdentityHeader, setEditIdentityHeader] = useState('')
   const [refreshingId, setRefreshingId] = useState<string | null>(null)
 
   useEffect(() => {
@@ -27,11 +28,17 @@ export function Catalogue() {
   }, [])
 
   const [hubIds, setHubIds] = useState<Set<string>>(new Set())
+  // Teams the user can add hubs for
+  const [teams, setTeams] = useState<Team[]>([])
   useEffect(() => {
     api.listHubs().then(r => {
-      const ids = new Set<string>((r.items||[]).map((h:any)=>h.mcp_server_id))
+      // Personal hubs only; team hubs can be added alongside them
+      const ids = new Set<string>((r.items||[]).filter(h=>!h.team_id).map(h=>h.mcp_server_id))
       setHubIds(ids)
     })
+    api.listTeams()
+      .then(r => setTeams((r.items||[]).filter(t => t.role === 'owner' || t.role === 'maintainer')))
+      .catch(()=>{})
   }, [])
 
   const filtered = useMemo(() => data.filter(s => s.name.toLowerCase().includes(q.toLowerCase())), [data, q])
@@ -93,7 +100,7 @@ export function Catalogue() {
                 </button>
                 <button
                   disabled={role !== 'ADMIN'}
-                  onClick={()=>{ setEditOpen(s); setEditUrl(s.url); setEditDesc(s.description||'') }}
+                  onClick={()=>{ setEditOpen(s); setEditUrl(s.url); setEditDesc(s.description||''); setEditIdentityHeader(s.identity_header||'') }}
                   className={`text-xs px-2 py-1 rounded border border-white/10 ${role!=='ADMIN' ? 'text-slate-500 cursor-not-allowed' : 'hover:bg-white/10 hover:border-white/20'}`}
                   title={role==='ADMIN' ? 'Edit' : 'Admin only'}
                 >Edit</button>
@@ -107,7 +114,7 @@ export function Catalogue() {
                 <div><span className="text-slate-500">ID:</span> <code className="break-all">{s.id}</code></div>
               </div>
             </details>
-            <AddToHubButton serverId={s.id} serverAccessType={s.access_type} added={hubIds.has(s.id)} onAdded={()=>setHubIds(new Set([...Array.from(hubIds), s.id]))} />
+            <AddToHubButton serverId={s.id} serverAccessType={s.access_type} added={hubIds.has(s.id)} teams={teams} onAdded={()=>setHubIds(new Set([...Array.from(hubIds), s.id]))} />
           </div>
         ))}
         </div>
@@ -192,6 +199,12 @@ export function Catalogue() {
                   <label className="text-xs text-slate-400">Description</label>
                   <input value={editDesc} onChange={e=>setEditDesc(e.target.value)} className="px-3 py-2 rounded-lg border border-white/10 bg-white/5 text-slate-200 focus:outline-none focus:ring-2 focus:ring-blue-500/40" placeholder="Description" />
                 </div>
+                {editOpen.transport !== 'stdio' && (
+                  <div className="grid gap-1">
+                    <label className="text-xs text-slate-400">Identity header</label>
+                    <input value={editIdentityHeader} onChange={e=>setEditIdentityHeader(e.target.value)} className="px-3 py-2 rounded-lg border border-white/10 bg-white/5 text-slate-200 focus:outline-none focus:ring-2 focus:ring-blue-500/40" placeholder="e.g. X-MCP-Caller (empty sends none)" />
+                  </div>
+                )}
               </div>
             </div>
             <div className="p-4 border-t border-white/10 bg-black/20 flex justify-end gap-2">
@@ -200,7 +213,7 @@ export function Catalogue() {
                 onClick={async()=>{
                   if (!editOpen) return
                   try {
-                    await api.updateCatalog(editOpen.id, { url: editUrl.trim() || undefined, description: editDesc })
+                    await api.updateCatalog(editOpen.id, { url: editUrl.trim() || undefined, description: editDesc, identity_header: editIdentityHeader.trim() })
                     notifySuccess('Updated')
                     setEditOpen(null)
                     const r = await api.listCatalog();
@@ -271,9 +284,11 @@ function AddCatalogButton({ disabled, onAdded }: { disabled?: boolean; onAdded?:
   )
 }
 
-function AddToHubButton({ serverId, serverAccessType, added, onAdded }: { serverId: string, serverAccessType?: string, added?: boolean, onAdded?: ()=>void }) {
+function AddToHubButton({ serverId, serverAccessType, added, teams, onAdded }: { serverId: string, serverAccessType?: string, added?: boolean, teams?: Team[], onAdded?: ()=>void }) {
   const [open, setOpen] = useState(false)
-  const [authType, setAuthType] = useState<'none' | 'bearer' | 'custom_headers'>(serverAccessType === 'private' ? 'bearer' : 'none')
+  // Empty adds to the user's own hub, else to the team's
+  const [teamId, setTeamId] = useState('')
+  const [authType, setAuthType] = useState<'none' | 'bearer' | 'custom_headers' | 'oauth2'>(serverAccessType === 'private' ? 'bearer' : 'none')
   const [authValue, setAuthValue] = useState('')
   const [saving, setSaving] = useState(false)
   const [error, setError] = useState<string | null>(null)
@@ -287,22 +302,39 @@ function AddToHubButton({ serverId, serverAccessType, added, onAdded }: { server
       let val: any = null
       if (authType === 'bearer') val = authValue
       if (authType === 'custom_headers') val = JSON.parse(authValue || '{}')
-      await api.addHub({ mcp_server_id: serverId, auth_type: authType, auth_value: val })
+      if (authType === 'oauth2') val = authValue ? JSON.parse(authValue) : null
+      const { id } = await api.addHub({ mcp_server_id: serverId, auth_type: authType, auth_value: val, team_id: teamId || undefined })
+      if (authType === 'oauth2') {
+        // Send the user to the upstream's authorization server; it redirects back to the hub page
+        const { authorization_url } = await api.startHubOAuth(id)
+        window.location.href = authorization_url
+        return
+      }
       setOpen(false)
-      notifySuccess('Added to hub')
+      notifySuccess(teamId ? 'Added to team hub' : 'Added to hub')
     } catch (e:any) {
       setError(e.message || String(e))
       notifyError(e?.message || 'Failed to add to hub')
     } finally { setSaving(false) }
   }
 
-  if (added) {
+  const ownerSelect = (teams || []).length > 0 && (
+    <select value={teamId} onChange={e=>setTeamId(e.target.value)} title="Add for" className="mr-2 border border-white/10 rounded px-2 py-1 text-sm bg-black/30 focus:outline-none focus:ring-2 focus:ring-blue-500/30">
+      <option value="">Personal</option>
+      {(teams || []).map(t => <option key={t.id} value={t.id}>{t.organization_name ? `${t.organization_name} / ${t.name}` : t.name}</option>)}
+    </select>
+  )
+
+  if (added && !teamId && !ownerSelect) {
     return <div className="mt-3"><span className="text-xs px-2 py-1 rounded border border-white/10 bg-emerald-500/20 text-emerald-300">Added</span></div>
   }
 
   return (
     <div className="mt-3">
-      {!open ? (
+      {!open && ownerSelect}
+      {!open && added && !teamId ? (
+        <span className="text-xs px-2 py-1 rounded border border-white/10 bg-emerald-500/20 text-emerald-300">Added</span>
+      ) : !open ? (
         <button
           onClick={async () => {
             if (isPublic) {
@@ -310,9 +342,9 @@ function AddToHubButton({ serverId, serverAccessType, added, onAdded }: { server
               setSaving(true)
               setError(null)
               try {
-                await api.addHub({ mcp_server_id: serverId, auth_type: 'none', auth_value: null })
-                notifySuccess('Added to hub')
-                onAdded && onAdded()
+                await api.addHub({ mcp_server_id: serverId, auth_type: 'none', auth_value: null, team_id: teamId || undefined })
+                notifySuccess(teamId ? 'Added to team hub' : 'Added to hub')
+                if (!teamId) onAdded && onAdded()
               } catch (e: any) {
                 notifyError(e?.message || 'Failed to add to hub')
               } finally {
@@ -335,12 +367,13 @@ function AddToHubButton({ serverId, serverAccessType, added, onAdded }: { server
           <select value={authType} onChange={e=>setAuthType(e.target.value as any)} className="border border-white/10 rounded px-2 py-1 bg-black/30 focus:outline-none focus:ring-2 focus:ring-blue-500/30">
             <option value="bearer">Bearer Token</option>
             <option value="custom_headers">Custom Headers (JSON)</option>
+            <option value="oauth2">OAuth 2.0</option>
           </select>
-          <textarea value={authValue} onChange={e=>setAuthValue(e.target.value)} placeholder={authType==='bearer' ? 'token' : '{"X-Api-Key":"..."}'} className="border border-white/10 rounded p-2 w-full bg-black/30" rows={3} />
+          <textarea value={authValue} onChange={e=>setAuthValue(e.target.value)} placeholder={authType==='bearer' ? 'token' : authType==='oauth2' ? 'optional: {"client_id":"...","scope":"..."}' : '{"X-Api-Key":"..."}'} className="border border-white/10 rounded p-2 w-full bg-black/30" rows={3} />
           {error && <div className="text-xs text-red-500">{error}</div>}
           <div className="flex gap-3">
             <button
-              onClick={async ()=>{ await submit(); onAdded && onAdded(); }}
+              onClick={async ()=>{ await submit(); if (!teamId) onAdded && onAdded(); }}
               disabled={saving}
               className="inline-flex items-center justify-center text-sm font-medium px-4 py-2 rounded-xl bg-gradient-to-r from-emerald-500 to-green-400 text-white shadow-lg shadow-emerald-900/30 hover:from-emerald-400 hover:to-teal-400 hover:shadow-emerald-800/40 active:scale-[0.98] transition-all duration-300 disabled:opacity-50 disabled:cursor-not-allowed cursor-pointer focus:outline-none focus:ring-2 focus:ring-emerald-500/30"
             >
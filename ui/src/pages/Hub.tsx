@@ -1,5 +1,5 @@
 import React, { useEffect, useState } from 'react'
-import { api, HubServer, Tool } from '../lib/api'
+import { api, HubServer, Tool, Team } from '../lib/api'
 import { notifyError, notifySuccess } from '../components/ToastHost'
 import { JSONViewer } from '../components/JSONViewer'
 
@@ -12,6 +12,7 @@ export function Hub() {
   const [loadingHubTools, setLoadingHubTools] = useState<Record<string, boolean>>({})
   const [hubQuery, setHubQuery] = useState<Record<string, string>>({})
   const [role, setRole] = useState<string | undefined>(undefined)
+  const [teams, setTeams] = useState<Team[]>([])
 
   const load = () => {
     setReloading(true)
@@ -20,7 +21,20 @@ export function Hub() {
       .catch((e:any) => notifyError(e?.message || 'Failed to load hubs'))
       .finally(() => setReloading(false))
   }
-  useEffect(() => { load(); api.me().then(m=>setRole((m as any).role)).catch(()=>{}) }, [])
+  useEffect(() => {
+    load()
+    api.me().then(m=>setRole((m as any).role)).catch(()=>{})
+    api.listTeams().then(r=>setTeams(r.items || [])).catch(()=>{})
+  }, [])
+  useEffect(() => {
+    // Set by the redirect back from an upstream's authorization server
+    const params = new URLSearchParams(window.location.search)
+    const result = params.get('oauth')
+    if (!result) return
+    if (result === 'connected') notifySuccess('Hub authorized')
+    else notifyError(params.get('error') || 'Authorization failed')
+    window.history.replaceState(null, '', window.location.pathname)
+  }, [])
 
   const toggleHub = async (hubId: string) => {
     setOpen(s => ({ ...s, [hubId]: !s[hubId] }))
@@ -41,6 +55,7 @@ export function Hub() {
       
       const qp = new URLSearchParams()
       qp.set('server_id', hub.mcp_server_id)  // Changed from hub_server_id to server_id
+      if (hub.team_id) qp.set('team_id', hub.team_id)  // Team hubs have their own tools
       const r = await api.listTools(qp)
       setToolsByHub(s => ({ ...s, [hubId]: r.items || [] }))
     } catch (e: any) {
@@ -115,7 +130,10 @@ export function Hub() {
             }} />
             <div className="flex items-center justify-between">
               <div>
-                <div className="font-medium">{h.name || h.mcp_server_id}</div>
+                <div className="font-medium">
+                  {h.name || h.mcp_server_id}
+                  {h.team_id && <span className="ml-2 text-xs px-1.5 py-0.5 rounded border border-white/10 text-slate-300">{teams.find(t => t.id === h.team_id)?.name || 'Team'}</span>}
+                </div>
                 { h.url && (
                   <a href={h.url} target="_blank" className="text-xs text-blue-500 break-all">{h.url}</a>
                 )}
@@ -143,7 +161,20 @@ export function Hub() {
               </div>
             </div>
             {h.description && <p className="text-xs text-slate-400 mt-2">{h.description}</p>}
+            {h.status === 'NEEDS_AUTH' && h.auth_error && <p className="text-xs text-amber-300 mt-2">{h.auth_error}</p>}
             <div className="mt-3 flex gap-2">
+              {h.auth_type === 'oauth2' && (
+                <button onClick={async ()=>{
+                  const hubId = (h as any).id || (h as any).mcp_hub_server_id || (h as any).mcp_server_id
+                  if (!hubId) { notifyError('Hub id missing'); return }
+                  try {
+                    const { authorization_url } = await api.startHubOAuth(hubId)
+                    window.location.href = authorization_url
+                  } catch (e:any) {
+                    notifyError(e?.message || 'Authorization failed')
+                  }
+                }} className="text-sm px-3 py-1.5 rounded border border-amber-400/30 text-amber-200 hover:border-amber-400/60 transition">{h.status === 'NEEDS_AUTH' ? 'Authorize' : 'Reauthorize'}</button>
+              )}
               <button onClick={async ()=>{
                 const hubId = (h as any).id || (h as any).mcp_hub_server_id || (h as any).mcp_server_id
                 if (!hubId) { notifyError('Hub id missing'); return }
@@ -0,0 +1,213 @@
+import React, { useEffect, useState } from 'react'
+import { api, Organization, Team, Member, MemberRole } from '../lib/api'
+import { notifyError, notifySuccess } from '../components/ToastHost'
+
+const roles: MemberRole[] = ['member', 'maintainer', 'owner']
+
+// Members of an organization or a team, editable by its owners
+function MemberList({ load, add, setRole, remove, canManage }: {
+  load: () => Promise<{items: Member[]}>
+  add: (username: string, role: MemberRole) => Promise<unknown>
+  setRole: (userId: string, role: MemberRole) => Promise<unknown>
+  remove: (userId: string) => Promise<unknown>
+  canManage: boolean
+}) {
+  const [members, setMembers] = useState<Member[]>([])
+  const [username, setUsername] = useState('')
+  const [role, setNewRole] = useState<MemberRole>('member')
+
+  const reload = () => load()
+    .then(r => setMembers(r.items || []))
+    .catch((e:any) => notifyError(e?.message || 'Failed to load members'))
+  useEffect(() => { reload() }, [])
+
+  const run = (p: Promise<unknown>, done: string) => p
+    .then(() => { notifySuccess(done); reload() })
+    .catch((e:any) => notifyError(e?.message || 'Request failed'))
+
+  return (
+    <div className="mt-3 space-y-2">
+      {members.map(mb => (
+        <div key={mb.user_id} className="flex items-center justify-between text-sm">
+          <span>{mb.username}</span>
+          <div className="flex items-center gap-2">
+            {canManage ? (
+              <select
+                value={mb.role}
+                onChange={e=>run(setRole(mb.user_id, e.target.value as MemberRole), 'Role updated')}
+                className="px-2 py-1 rounded border border-white/10 bg-white/5 text-xs"
+              >
+                {roles.map(r => <option key={r} value={r}>{r}</option>)}
+              </select>
+            ) : (
+              <span className="text-xs text-slate-400">{mb.role}</span>
+            )}
+            {canManage && (
+              <button
+                onClick={()=>{ if (confirm(`Remove ${mb.username}?`)) run(remove(mb.user_id), 'Member removed') }}
+                className="text-xs px-2 py-1 rounded border border-white/10 hover:border-white/20 transition"
+              >Remove</button>
+            )}
+          </div>
+        </div>
+      ))}
+      {members.length === 0 && <div className="text-xs text-slate-500">No members.</div>}
+      {canManage && (
+        <div className="flex items-center gap-2 pt-2">
+          <input
+            value={username}
+            onChange={e=>setUsername(e.target.value)}
+            placeholder="Username"
+            className="px-3 py-1.5 rounded border border-white/10 bg-white/5 text-sm placeholder:text-slate-500 focus:outline-none focus:ring-2 focus:ring-blue-500/30"
+          />
+          <select
+            value={role}
+            onChange={e=>setNewRole(e.target.value as MemberRole)}
+            className="px-2 py-1.5 rounded border border-white/10 bg-white/5 text-sm"
+          >
+            {roles.map(r => <option key={r} value={r}>{r}</option>)}
+          </select>
+          <button
+            disabled={!username.trim()}
+            onClick={()=>run(add(username.trim(), role), 'Member added').then(()=>setUsername(''))}
+            className="text-sm px-3 py-1.5 rounded bg-blue-600 text-white disabled:opacity-50 active:scale-95 transition"
+          >Add</button>
+        </div>
+      )}
+    </div>
+  )
+}
+
+export function Teams() {
+  const [orgs, setOrgs] = useState<Organization[]>([])
+  const [teams, setTeams] = useState<Team[]>([])
+  const [newOrg, setNewOrg] = useState('')
+  const [newTeam, setNewTeam] = useState<Record<string, string>>({})
+  const [open, setOpen] = useState<Record<string, boolean>>({})
+  const [role, setRole] = useState<string | undefined>(undefined)
+
+  const load = () => {
+    Promise.all([api.listOrgs(), api.listTeams()])
+      .then(([o, t]) => { setOrgs(o.items || []); setTeams(t.items || []) })
+      .catch((e:any) => notifyError(e?.message || 'Failed to load organizations'))
+  }
+  useEffect(() => { load(); api.me().then(m=>setRole((m as any).role)).catch(()=>{}) }, [])
+
+  const isAdmin = role === 'ADMIN'
+  const run = (p: Promise<unknown>, done: string) => p
+    .then(() => { notifySuccess(done); load() })
+    .catch((e:any) => notifyError(e?.message || 'Request failed'))
+
+  const rename = (current: string, fn: (name: string) => Promise<unknown>) => {
+    const name = prompt('New name', current)
+    if (name && name.trim() && name.trim() !== current) run(fn(name.trim()), 'Renamed')
+  }
+
+  const toggle = (id: string) => setOpen(s => ({ ...s, [id]: !s[id] }))
+
+  return (
+    <div className="space-y-6">
+      <div className="flex items-center justify-between">
+        <h1 className="text-2xl font-semibold">Organizations & Teams</h1>
+        <div className="flex items-center gap-2">
+          <input
+            value={newOrg}
+            onChange={e=>setNewOrg(e.target.value)}
+            placeholder="New organization"
+            className="px-3 py-1.5 rounded border border-white/10 bg-white/5 text-sm placeholder:text-slate-500 focus:outline-none focus:ring-2 focus:ring-blue-500/30"
+          />
+          <button
+            disabled={!newOrg.trim()}
+            onClick={()=>run(api.createOrg(newOrg.trim()), 'Organization created').then(()=>setNewOrg(''))}
+            className="text-sm px-3 py-1.5 rounded bg-blue-600 text-white disabled:opacity-50 active:scale-95 transition"
+          >Create</button>
+        </div>
+      </div>
+      <p className="text-sm text-slate-400">
+        Hubs and virtual servers added for a team are shared by its members.
+        Maintainers manage them; owners also manage membership.
+      </p>
+      {orgs.length === 0 && <div className="text-sm text-slate-500">You are not a member of any organization.</div>}
+      <div className="grid grid-cols-1 gap-4">
+        {orgs.map(o => {
+          const orgOwner = isAdmin || o.role === 'owner'
+          const orgMaintainer = orgOwner || o.role === 'maintainer'
+          const orgTeams = teams.filter(t => t.organization_id === o.id)
+          return (
+            <div key={o.id} className="rounded-2xl border border-white/10 bg-white/[0.04] p-4">
+              <div className="flex items-center justify-between">
+                <div>
+                  <div className="font-medium">{o.name}</div>
+                  <div className="text-xs text-slate-400">{o.role || 'admin'}</div>
+                </div>
+                <div className="flex items-center gap-2">
+                  <button onClick={()=>toggle(o.id)} className="text-xs px-2 py-1 rounded border border-white/10 hover:border-white/20 transition">Members</button>
+                  {orgOwner && <button onClick={()=>rename(o.name, n=>api.renameOrg(o.id, n))} className="text-xs px-2 py-1 rounded border border-white/10 hover:border-white/20 transition">Rename</button>}
+                  {orgOwner && <button onClick={()=>{ if (confirm('Delete this organization?')) run(api.deleteOrg(o.id), 'Organization deleted') }} className="text-xs px-2 py-1 rounded border border-white/10 hover:border-white/20 transition">Delete</button>}
+                </div>
+              </div>
+              {open[o.id] && (
+                <MemberList
+                  load={()=>api.listOrgMembers(o.id)}
+                  add={(u, r)=>api.addOrgMember(o.id, u, r)}
+                  setRole={(u, r)=>api.setOrgMemberRole(o.id, u, r)}
+                  remove={u=>api.removeOrgMember(o.id, u)}
+                  canManage={orgOwner}
+                />
+              )}
+
+              <div className="mt-4 border-t border-white/10 pt-4 space-y-3">
+                <div className="flex items-center justify-between">
+                  <div className="font-medium text-sm">Teams</div>
+                  {orgMaintainer && (
+                    <div className="flex items-center gap-2">
+                      <input
+                        value={newTeam[o.id] || ''}
+                        onChange={e=>setNewTeam(s=>({ ...s, [o.id]: e.target.value }))}
+                        placeholder="New team"
+                        className="px-3 py-1.5 rounded border border-white/10 bg-white/5 text-sm placeholder:text-slate-500 focus:outline-none focus:ring-2 focus:ring-blue-500/30"
+                      />
+                      <button
+                        disabled={!(newTeam[o.id] || '').trim()}
+                        onClick={()=>run(api.createTeam(o.id, (newTeam[o.id] || '').trim()), 'Team created').then(()=>setNewTeam(s=>({ ...s, [o.id]: '' })))}
+                        className="text-sm px-3 py-1.5 rounded bg-blue-600 text-white disabled:opacity-50 active:scale-95 transition"
+                      >Create</button>
+                    </div>
+                  )}
+                </div>
+                {orgTeams.length === 0 && <div className="text-xs text-slate-500">No teams you belong to.</div>}
+                {orgTeams.map(t => {
+                  const teamOwner = isAdmin || t.role === 'owner'
+                  return (
+                    <div key={t.id} className="rounded-xl border border-white/5 bg-white/[0.03] p-3">
+                      <div className="flex items-center justify-between">
+                        <div>
+                          <span className="text-sm">{t.name}</span>
+                          <span className="ml-2 text-xs text-slate-400">{t.role || 'admin'}</span>
+                        </div>
+                        <div className="flex items-center gap-2">
+                          <button onClick={()=>toggle(t.id)} className="text-xs px-2 py-1 rounded border border-white/10 hover:border-white/20 transition">Members</button>
+                          {teamOwner && <button onClick={()=>rename(t.name, n=>api.renameTeam(t.id, n))} className="text-xs px-2 py-1 rounded border border-white/10 hover:border-white/20 transition">Rename</button>}
+                          {teamOwner && <button onClick={()=>{ if (confirm('Delete this team?')) run(api.deleteTeam(t.id), 'Team deleted') }} className="text-xs px-2 py-1 rounded border border-white/10 hover:border-white/20 transition">Delete</button>}
+                        </div>
+                      </div>
+                      {open[t.id] && (
+                        <MemberList
+                          load={()=>api.listTeamMembers(t.id)}
+                          add={(u, r)=>api.addTeamMember(t.id, u, r)}
+                          setRole={(u, r)=>api.setTeamMemberRole(t.id, u, r)}
+                          remove={u=>api.removeTeamMember(t.id, u)}
+                          canManage={teamOwner}
+                        />
+                      )}
+                    </div>
+                  )
+                })}
+              </div>
+            </div>
+          )
+        })}
+      </div>
+    </div>
+  )
+}
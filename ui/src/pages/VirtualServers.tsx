@@ -1,5 +1,5 @@
 import React, { useEffect, useMemo, useState, useEffect as ReactUseEffect } from 'react'
-import { api, VirtualServer, Tool, HubServer, CatalogServer } from '../lib/api'
+import { api, VirtualServer, VirtualServerKey, VirtualServerTool, ToolNaming, Tool, HubServer, CatalogServer, Team } from '../lib/api'
 import { notifyError, notifySuccess } from '../components/ToastHost'
 
 export function VirtualServers() {
@@ -10,13 +10,22 @@ export function VirtualServers() {
   const [selected, setSelected] = useState<string[]>([])
   const [q, setQ] = useState('')
   const [hubFilter, setHubFilter] = useState('')
-  const [toolsByVS, setToolsByVS] = useState<Record<string, Tool[]>>({})
+  const [toolsByVS, setToolsByVS] = useState<Record<string, VirtualServerTool[]>>({})
   const [vsToolsVisible, setVsToolsVisible] = useState<Record<string, boolean>>({})
   const [copied, setCopied] = useState<Record<string, boolean>>({})
   const [role, setRole] = useState<string | undefined>(undefined)
 
+  const [teams, setTeams] = useState<Team[]>([])
   const load = () => { api.listVS().then(r=>setItems(r.items)) }
-  useEffect(() => { load(); api.me().then(m=>setRole((m as any).role)).catch(()=>{}) }, [])
+  useEffect(() => {
+    load()
+    api.me().then(m=>setRole((m as any).role)).catch(()=>{})
+    api.listTeams().then(r=>setTeams(r.items || [])).catch(()=>{})
+  }, [])
+  const teamName = (id?: string | null) => {
+    const t = teams.find(t => t.id === id)
+    return t ? t.name : 'Team'
+  }
 
   const [newName, setNewName] = useState('')
   const [creating, setCreating] = useState(false)
@@ -24,26 +33,38 @@ export function VirtualServers() {
   const [createSelected, setCreateSelected] = useState<string[]>([])
   const [createQ, setCreateQ] = useState('')
   const [createGroups, setCreateGroups] = useState<Array<{server: string; tools: Tool[]}>>([])
+  // Empty creates a personal virtual server, else one shared by the team
+  const [createTeamId, setCreateTeamId] = useState('')
 
   // Edit state
   const [editOpen, setEditOpen] = useState<VirtualServer | null>(null)
   const [editName, setEditName] = useState('')
+  const [editNaming, setEditNaming] = useState<ToolNaming>('original')
   const [saving, setSaving] = useState(false)
 
-  const create = async () => {
+  // API key state
+  const [keysByVS, setKeysByVS] = useState<Record<string, VirtualServerKey[]>>({})
+  const [keyName, setKeyName] = useState('')
+  const [issuedKey, setIssuedKey] = useState('')
+
+  const create = async (teamId = '') => {
     setCreateOpen(true)
     setCreateSelected([])
     setCreateQ('')
-    // Fetch tools from user's hub servers only
+    setCreateTeamId(teamId)
+    // Fetch tools from the owner's hub servers only
     try {
-      // Get user's hub servers first to filter tools
-      const hubsRes = await api.listHubs()
-      const hubServerIds = hubsRes.items.map(h => h.mcp_server_id)
+      // Get the user's or the team's hub servers first to filter tools
+      const hubsRes = await api.listHubs(teamId || undefined)
+      const hubServerIds = hubsRes.items
+        .filter(h => teamId ? h.team_id === teamId : !h.team_id)
+        .map(h => h.mcp_server_id)
       
-      // Load tools only for servers in user's hub
+      // Load tools only for servers in that hub
       const toolsPromises = hubServerIds.map(serverId => {
         const qp = new URLSearchParams()
         qp.set('server_id', serverId)
+        if (teamId) qp.set('team_id', teamId)
         return api.listTools(qp)
       })
       
@@ -86,15 +107,19 @@ export function VirtualServers() {
   const openToolPicker = async (vs: VirtualServer) => {
     try {
       // First get hub servers to filter tools
+      const teamId = vs.team_id || ''
       const [hubsRes, catRes] = await Promise.all([
-        api.listHubs(), api.listCatalog()
+        api.listHubs(teamId || undefined), api.listCatalog()
       ])
       
-      // Load tools only for servers in user's hub
-      const hubServerIds = hubsRes.items.map(h => h.mcp_server_id)
+      // Load tools only for servers in the virtual server owner's hub
+      const hubServerIds = hubsRes.items
+        .filter(h => teamId ? h.team_id === teamId : !h.team_id)
+        .map(h => h.mcp_server_id)
       const toolsPromises = hubServerIds.map(serverId => {
         const qp = new URLSearchParams()
         qp.set('server_id', serverId)
+        if (teamId) qp.set('team_id', teamId)
         return api.listTools(qp)
       })
       
@@ -133,6 +158,53 @@ export function VirtualServers() {
     }
   }
 
+  const loadKeys = async (vsId: string) => {
+    const res = await api.listVSKeys(vsId)
+    setKeysByVS(s => ({ ...s, [vsId]: res.items }))
+  }
+
+  const openKeys = async (vs: VirtualServer) => {
+    setKeyName('')
+    setIssuedKey('')
+    try {
+      await loadKeys(vs.id)
+      ;(document.getElementById('keys-'+vs.id) as HTMLDialogElement).showModal()
+    } catch (e:any) {
+      notifyError(e?.message || 'Failed to load keys')
+    }
+  }
+
+  const createKey = async (vs: VirtualServer) => {
+    try {
+      const res = await api.createVSKey(vs.id, keyName)
+      setIssuedKey(res.key)
+      setKeyName('')
+      await loadKeys(vs.id)
+    } catch (e:any) {
+      notifyError(e?.message || 'Failed to create key')
+    }
+  }
+
+  const rotateKey = async (vs: VirtualServer, k: VirtualServerKey) => {
+    try {
+      const res = await api.rotateVSKey(vs.id, k.id)
+      setIssuedKey(res.key)
+      await loadKeys(vs.id)
+    } catch (e:any) {
+      notifyError(e?.message || 'Failed to rotate key')
+    }
+  }
+
+  const revokeKey = async (vs: VirtualServer, k: VirtualServerKey) => {
+    try {
+      await api.revokeVSKey(vs.id, k.id)
+      await loadKeys(vs.id)
+      notifySuccess('Key revoked')
+    } catch (e:any) {
+      notifyError(e?.message || 'Failed to revoke key')
+    }
+  }
+
   const ensureVSToolsLoaded = async (vsId: string) => {
     if (toolsByVS[vsId]) return
     try {
@@ -156,7 +228,7 @@ export function VirtualServers() {
       <div className="flex items-center justify-between gap-3">
         <h1 className="text-2xl font-semibold">Virtual Servers</h1>
         <div className="flex items-center gap-2">
-          <button onClick={create} className="px-3 py-1.5 rounded-lg bg-gradient-to-r from-blue-500 to-indigo-500 text-white shadow-lg shadow-blue-900/30 hover:from-blue-400 hover:to-indigo-400 active:scale-95 transition">
+          <button onClick={()=>create()} className="px-3 py-1.5 rounded-lg bg-gradient-to-r from-blue-500 to-indigo-500 text-white shadow-lg shadow-blue-900/30 hover:from-blue-400 hover:to-indigo-400 active:scale-95 transition">
             Create
           </button>
         </div>
@@ -168,7 +240,10 @@ export function VirtualServers() {
               background: 'radial-gradient(1000px 300px at 10% -20%, rgba(59,130,246,0.12), transparent 60%), radial-gradient(1000px 300px at 110% 120%, rgba(16,185,129,0.12), transparent 60%)'
             }} />
             <div className="flex items-center justify-between">
-              <div className="font-medium truncate max-w-[60%]">{vs.name || vs.id}</div>
+              <div className="font-medium truncate max-w-[60%]">
+                {vs.name || vs.id}
+                {vs.team_id && <span className="ml-2 text-xs px-1.5 py-0.5 rounded border border-white/10 text-slate-300">{teamName(vs.team_id)}</span>}
+              </div>
               <div className="flex items-center gap-2">
                 <button
                   onClick={()=>toggleShowTools(vs.id)}
@@ -178,7 +253,7 @@ export function VirtualServers() {
                 </button>
                 <button
                   disabled={role !== 'ADMIN'}
-                  onClick={() => { setEditOpen(vs); setEditName(vs.name || '') }}
+                  onClick={() => { setEditOpen(vs); setEditName(vs.name || ''); setEditNaming(vs.tool_naming || 'original') }}
                   title={role==='ADMIN' ? 'Edit virtual server (admin)' : 'Admin only'}
                   className={`text-xs px-2 py-1 rounded border border-white/10 ${role!=='ADMIN' ? 'text-slate-500 cursor-not-allowed' : 'hover:bg-white/10 hover:border-white/20'}`}
                 >Edit</button>
@@ -225,6 +300,7 @@ export function VirtualServers() {
             </div>
             <div className="mt-3 flex gap-2">
               <button onClick={()=>openToolPicker(vs)} className="text-sm px-3 py-1.5 rounded-lg border border-white/10 hover:bg-white/10 hover:border-white/20 focus:outline-none focus:ring-2 focus:ring-blue-500/30 active:scale-95 transition">Manage Tools</button>
+              <button onClick={()=>openKeys(vs)} className="text-sm px-3 py-1.5 rounded-lg border border-white/10 hover:bg-white/10 hover:border-white/20 focus:outline-none focus:ring-2 focus:ring-blue-500/30 active:scale-95 transition">API Keys</button>
               <button onClick={async()=>{ try { await api.setVSStatus(vs.id, vs.status==='ACTIVE'?'DEACTIVATED':'ACTIVE'); load() } catch(e:any){ notifyError(e?.message||'Failed to update status') } }} className="text-sm px-3 py-1.5 rounded-lg border border-white/10 hover:bg-white/10 hover:border-white/20 focus:outline-none focus:ring-2 focus:ring-blue-500/30 active:scale-95 transition">{vs.status==='ACTIVE'?'Deactivate':'Activate'}</button>
               <button onClick={async()=>{ try { await api.deleteVS(vs.id); load() } catch(e:any){ notifyError(e?.message||'Failed to delete') } }} className="text-sm px-3 py-1.5 rounded-lg border border-white/10 hover:bg-white/10 hover:border-white/20 focus:outline-none focus:ring-2 focus:ring-rose-500/30 active:scale-95 transition">Delete</button>
             </div>
@@ -237,10 +313,24 @@ export function VirtualServers() {
                   <span
                     key={t.id}
                     className="inline-flex items-center gap-1.5 pl-3 pr-1 py-1 h-7 max-w-[220px] rounded-full text-xs border border-white/10 bg-white/[0.06] hover:bg-white/[0.12] hover:border-white/20 shadow-[inset_0_1px_0_rgba(255,255,255,0.08)] transition backdrop-blur-sm"
-                    title={t.original_name}
+                    title={`${t.original_name} — click to set alias`}
                   >
-                    <span className="truncate max-w-[170px] font-mono text-[12px] text-slate-200">
-                      {t.modified_name}
+                    <span
+                      onClick={async ()=>{
+                        const alias = window.prompt('Alias (leave empty to clear)', t.alias || '')
+                        if (alias === null) return
+                        try {
+                          await api.setVSToolAlias(vs.id, t.id, alias.trim() || null)
+                          const res = await api.listVSTools(vs.id)
+                          setToolsByVS(s=>({ ...s, [vs.id]: res.items }))
+                          notifySuccess('Alias updated')
+                        } catch(e:any) {
+                          notifyError(e?.message || 'Failed to set alias')
+                        }
+                      }}
+                      className="truncate max-w-[170px] font-mono text-[12px] text-slate-200 cursor-pointer"
+                    >
+                      {t.exposed_name}
                     </span>
                     <button
                       onClick={async ()=>{
@@ -326,6 +416,64 @@ export function VirtualServers() {
                 </button>
               </div>
             </dialog>
+            <dialog
+              id={`keys-${vs.id}`}
+              className="w-[min(720px,90vw)] rounded-2xl p-0 border border-white/10 shadow-2xl bg-gradient-to-b from-blue-950/60 to-slate-900/80 backdrop:backdrop-blur-sm backdrop:bg-black/60"
+            >
+              <div className="p-5 md:p-6 space-y-4">
+                <div className="text-lg font-semibold tracking-tight">API Keys</div>
+                <div className="flex items-center gap-3">
+                  <input
+                    value={keyName}
+                    onChange={e=>setKeyName(e.target.value)}
+                    placeholder="Key name"
+                    className="flex-1 px-3 py-2 rounded-lg border border-white/10 bg-white/5 text-slate-200 placeholder:text-slate-500 focus:outline-none focus:ring-2 focus:ring-blue-500/40"
+                  />
+                  <button
+                    onClick={()=>createKey(vs)}
+                    className="px-4 py-1.5 rounded-lg bg-gradient-to-r from-blue-500 to-indigo-500 text-white shadow-lg shadow-blue-900/30 hover:from-blue-400 hover:to-indigo-400 transition"
+                  >
+                    Generate
+                  </button>
+                </div>
+                {issuedKey && (
+                  <div className="text-xs space-y-1">
+                    <div className="text-amber-300">Copy this key now, it will not be shown again.</div>
+                    <code className="block break-all bg-black/30 px-2 py-1 rounded border border-white/10 font-mono">{issuedKey}</code>
+                  </div>
+                )}
+                <div className="max-h-72 overflow-y-auto pr-1 space-y-1 scroll-panel">
+                  {(keysByVS[vs.id] || []).map(k => (
+                    <div key={k.id} className="flex items-center gap-3 px-2 py-2 rounded-lg hover:bg-white/5 transition text-sm">
+                      <span className="truncate font-medium text-slate-200">{k.name}</span>
+                      <span className="font-mono text-xs text-slate-400">{k.prefix}…</span>
+                      <span className="text-xs text-slate-500 italic flex-1 truncate">
+                        {k.status==='ACTIVE'
+                          ? (k.last_used_at ? `last used ${new Date(k.last_used_at).toLocaleString()}` : 'never used')
+                          : 'revoked'}
+                      </span>
+                      {k.status==='ACTIVE' && (
+                        <>
+                          <button onClick={()=>rotateKey(vs, k)} className="text-xs px-2 py-1 rounded border border-white/10 hover:bg-white/10 hover:border-white/20">Rotate</button>
+                          <button onClick={()=>revokeKey(vs, k)} className="text-xs px-2 py-1 rounded border border-white/10 hover:bg-white/10 hover:border-rose-400/30">Revoke</button>
+                        </>
+                      )}
+                    </div>
+                  ))}
+                  {(keysByVS[vs.id] || []).length === 0 && (
+                    <span className="text-xs text-slate-500">No keys yet</span>
+                  )}
+                </div>
+              </div>
+              <div className="p-4 border-t border-white/10 flex justify-end gap-2 bg-black/20">
+                <button
+                  onClick={()=> { setIssuedKey(''); (document.getElementById('keys-'+vs.id) as HTMLDialogElement).close() }}
+                  className="px-3 py-1.5 rounded-lg border border-white/15 bg-white/5 text-slate-200 hover:bg-white/10 hover:border-white/25 transition"
+                >
+                  Close
+                </button>
+              </div>
+            </dialog>
           </div>
         ))}
       </div>
@@ -340,6 +488,14 @@ export function VirtualServers() {
               </div>
               <div className="grid md:grid-cols-3 gap-3 items-center">
                 <input value={newName} onChange={e=>setNewName(e.target.value)} placeholder="Name" className="px-3 py-2 rounded-lg border border-white/10 bg-white/5 text-slate-200 placeholder:text-slate-500 focus:outline-none focus:ring-2 focus:ring-blue-500/40 md:col-span-1" />
+                {teams.some(t => t.role === 'owner' || t.role === 'maintainer') && (
+                  <select value={createTeamId} onChange={e=>create(e.target.value)} title="Owner" className="px-3 py-2 rounded-lg border border-white/10 bg-white/5 text-slate-200 focus:outline-none focus:ring-2 focus:ring-blue-500/40 md:col-span-3">
+                    <option value="">Personal</option>
+                    {teams.filter(t => t.role === 'owner' || t.role === 'maintainer').map(t => (
+                      <option key={t.id} value={t.id}>{t.organization_name ? `${t.organization_name} / ${t.name}` : t.name}</option>
+                    ))}
+                  </select>
+                )}
                 <input value={createQ} onChange={e=>setCreateQ(e.target.value)} placeholder="Search tools..." className="px-3 py-2 rounded-lg border border-white/10 bg-white/5 text-slate-200 placeholder:text-slate-500 focus:outline-none focus:ring-2 focus:ring-blue-500/40 md:col-span-2" />
               </div>
               <div className="h-[48vh] overflow-y-auto pr-1 scroll-panel space-y-3">
@@ -399,7 +555,7 @@ export function VirtualServers() {
                 onClick={async ()=>{
                   setCreating(true)
                   try {
-                    const res = await api.createVS(newName || undefined, createSelected)
+                    const res = await api.createVS(newName || undefined, createSelected, undefined, createTeamId || undefined)
                     notifySuccess('Virtual server created')
                     setCreateOpen(false)
                     setCreateSelected([])
@@ -441,6 +597,17 @@ export function VirtualServers() {
                     className="w-full px-3 py-2 rounded-lg border border-white/10 bg-black/30 text-slate-200 placeholder:text-slate-500 focus:outline-none focus:ring-2 focus:ring-blue-500/40"
                   />
                 </div>
+                <div>
+                  <label className="block text-sm font-medium mb-1">Tool names</label>
+                  <select
+                    value={editNaming}
+                    onChange={(e) => setEditNaming(e.target.value as ToolNaming)}
+                    className="w-full px-3 py-2 rounded-lg border border-white/10 bg-black/30 text-slate-200 focus:outline-none focus:ring-2 focus:ring-blue-500/40"
+                  >
+                    <option value="original">Original (upstream names)</option>
+                    <option value="prefixed">Prefixed with server name</option>
+                  </select>
+                </div>
               </div>
               <div className="flex gap-2 justify-end">
                 <button 
@@ -456,6 +623,9 @@ export function VirtualServers() {
                     setSaving(true)
                     try {
                       await api.updateVS(editOpen.id, editName.trim())
+                      if (editNaming !== (editOpen.tool_naming || 'original')) {
+                        await api.setVSToolNaming(editOpen.id, editNaming)
+                      }
                       setEditOpen(null)
                       setEditName('')
                       load() // Refresh the list
@@ -15,6 +15,7 @@ export function AppLayout() {
   const [basicU, setBasicU] = useState('')
   const [basicP, setBasicP] = useState('')
   const [err, setErr] = useState('')
+  const [providers, setProviders] = useState<{name: string; display_name: string; login_url: string}[]>([])
 
   useEffect(() => {
     // Check session on load
@@ -30,6 +31,18 @@ export function AppLayout() {
       setUser(null)
     }).finally(() => setAuthChecked(true))
 
+    api.oidcProviders().then((r) => setProviders(r.items || [])).catch(() => {})
+
+    // A failed OIDC sign in comes back with the reason in the URL
+    const params = new URLSearchParams(window.location.search)
+    const loginError = params.get('login_error')
+    if (loginError) {
+      setErr(loginError)
+      params.delete('login_error')
+      const qs = params.toString()
+      window.history.replaceState(null, '', window.location.pathname + (qs ? `?${qs}` : ''))
+    }
+
     // Initialize Google One-tap button if script is available
     // Google button is rendered via GoogleLogin component
   }, [])
@@ -106,6 +119,15 @@ export function AppLayout() {
                     <div className="rounded-lg bg-white/5 p-4 border border-white/10">
                       <GoogleLogin onSuccess={onGoogleSuccess} />
                     </div>
+                    {providers.map((p) => (
+                      <a
+                        key={p.name}
+                        href={p.login_url}
+                        className="block px-4 py-2 rounded-lg border border-white/10 bg-white/10 hover:bg-white/15 transition w-full text-center"
+                      >
+                        Sign in with {p.display_name}
+                      </a>
+                    ))}
                     <div className="text-sm text-slate-400 text-center">Or use your credentials</div>
                     <form onSubmit={submitBasic} className="flex flex-col gap-3">
                       <input
@@ -156,6 +178,7 @@ export function AppLayout() {
               <NavLink to="/" className={({isActive}) => isActive ? 'text-blue-400' : 'text-slate-400 hover:text-slate-200 transition'}>Catalogue</NavLink>
               <NavLink to="/hub" className={({isActive}) => isActive ? 'text-blue-400' : 'text-slate-400 hover:text-slate-200 transition'}>Hub</NavLink>
               <NavLink to="/virtual-servers" className={({isActive}) => isActive ? 'text-blue-400' : 'text-slate-400 hover:text-slate-200 transition'}>Virtual Servers</NavLink>
+              <NavLink to="/teams" className={({isActive}) => isActive ? 'text-blue-400' : 'text-slate-400 hover:text-slate-200 transition'}>Teams</NavLink>
             </nav>
             <UserMenu email={user.email || ''} onLogout={logout} />
           </div>